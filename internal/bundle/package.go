@@ -88,6 +88,9 @@ type NewManagement interface {
 	GetPipe(name string) (<-chan message.Transaction, error)
 	SetPipe(name string, t <-chan message.Transaction)
 	UnsetPipe(name string, t <-chan message.Transaction)
+
+	GetGeneric(key any) (any, bool)
+	GetOrSetGeneric(key, value any) (any, bool)
 }
 
 type componentErr struct {
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/common"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// CliCommand is a cli.Command definition for exporting the topology of a
+// config as a graph.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "graph",
+		Usage: "Export the component topology of a config as a graph",
+		Description: `
+Parse a config file and print a graph of its components, showing how messages
+flow between inputs, processors and outputs, how brokers, switches, workflows
+and branches route messages to their children, and which resources are
+referenced by each component.
+
+  benthos -c ./config.yaml graph
+  benthos -c ./config.yaml -r ./resources.yaml graph --format mermaid
+  benthos -c ./config.yaml graph | dot -Tsvg > config.svg
+
+The supported formats are dot (Graphviz), mermaid and json.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "dot",
+				Usage: "Print the graph in a specific format. Options are " + strings.Join(Formats, ", ") + ".",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if !isFormat(format) {
+				fmt.Fprintf(os.Stderr, "Graph format not recognised: %v, expected one of: %v\n", format, strings.Join(Formats, ", "))
+				os.Exit(1)
+			}
+
+			_, _, confReader := common.ReadConfig(c, false)
+			conf, _, err := confReader.Read()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var node yaml.Node
+			if err = node.Encode(conf); err == nil {
+				sanitConf := docs.NewSanitiseConfig()
+				sanitConf.RemoveTypeField = true
+				sanitConf.RemoveDeprecated = false
+				err = config.Spec().SanitiseYAML(&node, sanitConf)
+			}
+			var g *Graph
+			if err == nil {
+				g, err = FromYAML(config.Spec(), &node, docs.DeprecatedProvider)
+			}
+			if err == nil {
+				err = Write(os.Stdout, format, g)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Graph error: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
+
+func isFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats lists the supported graph output formats.
+var Formats = []string{"dot", "mermaid", "json"}
+
+// Write a graph to a writer in the given format.
+func Write(w io.Writer, format string, g *Graph) error {
+	switch format {
+	case "dot":
+		return writeDOT(w, g)
+	case "mermaid":
+		return writeMermaid(w, g)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	}
+	return fmt.Errorf("graph format not recognised: %v", format)
+}
+
+// nodeLines returns the lines of text used to describe a node.
+func nodeLines(n Node) []string {
+	lines := []string{n.ComponentType + ": " + n.Name}
+	if n.Label != "" {
+		lines = append(lines, "label: "+n.Label)
+	}
+	if n.Resource {
+		lines = append(lines, "(resource)")
+	}
+	return lines
+}
+
+// edgeText returns the text used to annotate an edge, if any.
+func edgeText(e Edge) string {
+	var parts []string
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	if e.Condition != "" {
+		parts = append(parts, "check: "+e.Condition)
+	}
+	return strings.Join(parts, "\n")
+}
+
+//------------------------------------------------------------------------------
+
+func writeDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph benthos {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + strconv.Quote(strings.Join(nodeLines(n), "\n"))}
+		switch {
+		case n.Resource:
+			attrs = append(attrs, "style=dashed")
+		case n.ComponentType == "processor":
+			attrs = append(attrs, "style=rounded")
+		}
+		fmt.Fprintf(&b, "  %v [%v];\n", strconv.Quote(n.ID), strings.Join(attrs, ", "))
+	}
+
+	for _, e := range g.Edges {
+		var attrs []string
+		if text := edgeText(e); text != "" {
+			attrs = append(attrs, "label="+strconv.Quote(text))
+		}
+		switch e.Kind {
+		case EdgeKindChild:
+			attrs = append(attrs, "arrowhead=empty")
+		case EdgeKindResource:
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %v -> %v", strconv.Quote(e.From), strconv.Quote(e.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%v]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// mermaidEscape escapes text for use within a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"\n", "<br/>",
+		"<", "#lt;",
+		">", "#gt;",
+	).Replace(s)
+}
+
+func writeMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+
+		open, closing := "[", "]"
+		switch {
+		case n.Resource:
+			open, closing = "[/", "/]"
+		case n.ComponentType == "processor":
+			open, closing = "(", ")"
+		}
+		var lines []string
+		for _, l := range nodeLines(n) {
+			lines = append(lines, mermaidEscape(l))
+		}
+		fmt.Fprintf(&b, "  %v%v\"%v\"%v\n", id, open, strings.Join(lines, "<br/>"), closing)
+	}
+
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch e.Kind {
+		case EdgeKindChild:
+			arrow = "==>"
+		case EdgeKindResource:
+			arrow = "-.->"
+		}
+		if text := edgeText(e); text != "" {
+			fmt.Fprintf(&b, "  %v %v|\"%v\"| %v\n", ids[e.From], arrow, mermaidEscape(text), ids[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %v %v %v\n", ids[e.From], arrow, ids[e.To])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,417 @@
+package graph
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Edge kinds describe the relationship between two nodes of a graph.
+const (
+	// EdgeKindFlow is an edge along which messages flow from one component to
+	// another.
+	EdgeKindFlow = "flow"
+
+	// EdgeKindChild is an edge from a component to a child component that it
+	// routes messages to or executes, where the child does not necessarily
+	// pass messages back along a flow edge.
+	EdgeKindChild = "child"
+
+	// EdgeKindResource is an edge from a component to a resource that it
+	// references by label.
+	EdgeKindResource = "resource"
+)
+
+// Node is a component within a config graph.
+type Node struct {
+	ID            string `json:"id"`
+	ComponentType string `json:"type"`
+	Name          string `json:"name"`
+	Label         string `json:"label,omitempty"`
+	Resource      bool   `json:"resource,omitempty"`
+}
+
+// Edge is a connection between two components within a config graph.
+type Edge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Kind      string `json:"kind"`
+	Path      string `json:"path,omitempty"`
+	Condition string `json:"condition,omitempty"`
+}
+
+// Graph is a topology of the components within a config.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+var resourceFields = map[string]docs.Type{
+	"input_resources":      docs.TypeInput,
+	"processor_resources":  docs.TypeProcessor,
+	"output_resources":     docs.TypeOutput,
+	"cache_resources":      docs.TypeCache,
+	"rate_limit_resources": docs.TypeRateLimit,
+}
+
+// referenceFields are fields of a component config that, when set to a
+// string, reference a resource of a given type by its label.
+var referenceFields = map[string][]docs.Type{
+	"resource":   {docs.TypeCache, docs.TypeRateLimit},
+	"cache":      {docs.TypeCache},
+	"rate_limit": {docs.TypeRateLimit},
+}
+
+type walkedNode struct {
+	Node
+	path []string
+	conf *yaml.Node
+}
+
+// FromYAML walks a parsed config and returns a graph of its components, where
+// edges represent the flow of messages between components, the routing of
+// messages to child components, and references to resources.
+func FromYAML(spec docs.FieldSpecs, node *yaml.Node, prov docs.Provider) (*Graph, error) {
+	var nodes []*walkedNode
+	if err := spec.WalkYAML(node, prov, func(c docs.WalkedYAMLComponent) error {
+		switch c.ComponentType {
+		case docs.TypeMetrics, docs.TypeTracer:
+			return nil
+		case docs.TypeBuffer:
+			if c.Name == "none" {
+				return nil
+			}
+		}
+		_, isResource := resourceFields[c.Path[0]]
+		nodes = append(nodes, &walkedNode{
+			Node: Node{
+				ID:            strings.Join(c.Path, "."),
+				ComponentType: string(c.ComponentType),
+				Name:          c.Name,
+				Label:         c.Label,
+				Resource:      isResource && len(c.Path) == 2,
+			},
+			path: c.Path,
+			conf: c.Conf,
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	b := builder{
+		byID:     map[string]*walkedNode{},
+		children: map[string][]*walkedNode{},
+	}
+	for _, n := range nodes {
+		b.byID[n.ID] = n
+	}
+	for _, n := range nodes {
+		parent := b.parentOf(n)
+		if parent == nil {
+			b.roots = append(b.roots, n)
+			continue
+		}
+		b.children[parent.ID] = append(b.children[parent.ID], n)
+	}
+
+	g := &Graph{
+		Nodes: []Node{},
+		Edges: []Edge{},
+	}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, n.Node)
+	}
+	for _, n := range nodes {
+		b.addChildEdges(g, n)
+	}
+	b.addStreamEdges(g)
+	b.addResourceEdges(g, nodes)
+	return g, nil
+}
+
+type builder struct {
+	roots    []*walkedNode
+	byID     map[string]*walkedNode
+	children map[string][]*walkedNode
+}
+
+func (b *builder) parentOf(n *walkedNode) *walkedNode {
+	for i := len(n.path) - 1; i > 0; i-- {
+		if p, exists := b.byID[strings.Join(n.path[:i], ".")]; exists {
+			return p
+		}
+	}
+	return nil
+}
+
+// chain is a sequence of processors within the same array of a parent.
+type chain struct {
+	path  []string
+	nodes []*walkedNode
+}
+
+func (c *chain) head() *walkedNode {
+	return c.nodes[0]
+}
+
+func (c *chain) tail() *walkedNode {
+	return c.nodes[len(c.nodes)-1]
+}
+
+// childGroups splits the children of a node into chains of processors, which
+// are executed sequentially, and all other children.
+func (b *builder) childGroups(n *walkedNode) (chains []*chain, others []*walkedNode) {
+	chainsByPath := map[string]*chain{}
+	for _, c := range b.children[n.ID] {
+		rel := c.path[len(n.path):]
+		if c.ComponentType != string(docs.TypeProcessor) || !isIndex(rel[len(rel)-1]) {
+			others = append(others, c)
+			continue
+		}
+		chainPath := rel[:len(rel)-1]
+		key := strings.Join(chainPath, ".")
+		ch, exists := chainsByPath[key]
+		if !exists {
+			ch = &chain{path: chainPath}
+			chainsByPath[key] = ch
+			chains = append(chains, ch)
+		}
+		ch.nodes = append(ch.nodes, c)
+	}
+	for _, ch := range chains {
+		sort.SliceStable(ch.nodes, func(i, j int) bool {
+			return indexOf(ch.nodes[i]) < indexOf(ch.nodes[j])
+		})
+	}
+	return
+}
+
+// reservedProcessors returns the chain of processors configured with the
+// processors field common to all inputs and outputs.
+func (b *builder) reservedProcessors(n *walkedNode) *chain {
+	if n.ComponentType != string(docs.TypeInput) && n.ComponentType != string(docs.TypeOutput) {
+		return nil
+	}
+	chains, _ := b.childGroups(n)
+	for _, ch := range chains {
+		if len(ch.path) == 1 && ch.path[0] == "processors" {
+			return ch
+		}
+	}
+	return nil
+}
+
+// head returns the first node that messages pass through when sent to a
+// component.
+func (b *builder) head(n *walkedNode) *walkedNode {
+	if n.ComponentType == string(docs.TypeOutput) {
+		if ch := b.reservedProcessors(n); ch != nil {
+			return ch.head()
+		}
+	}
+	return n
+}
+
+// tail returns the last node that messages pass through when consumed from a
+// component.
+func (b *builder) tail(n *walkedNode) *walkedNode {
+	if n.ComponentType == string(docs.TypeInput) {
+		if ch := b.reservedProcessors(n); ch != nil {
+			return ch.tail()
+		}
+	}
+	return n
+}
+
+func (b *builder) addChildEdges(g *Graph, n *walkedNode) {
+	chains, others := b.childGroups(n)
+	for _, ch := range chains {
+		for i := 1; i < len(ch.nodes); i++ {
+			g.Edges = append(g.Edges, Edge{
+				From: ch.nodes[i-1].ID,
+				To:   ch.nodes[i].ID,
+				Kind: EdgeKindFlow,
+			})
+		}
+		if len(ch.path) == 1 && ch.path[0] == "processors" {
+			switch n.ComponentType {
+			case string(docs.TypeInput):
+				g.Edges = append(g.Edges, Edge{From: n.ID, To: ch.head().ID, Kind: EdgeKindFlow})
+				continue
+			case string(docs.TypeOutput):
+				g.Edges = append(g.Edges, Edge{From: ch.tail().ID, To: n.ID, Kind: EdgeKindFlow})
+				continue
+			}
+		}
+		g.Edges = append(g.Edges, Edge{
+			From:      n.ID,
+			To:        ch.head().ID,
+			Kind:      EdgeKindChild,
+			Path:      relPath(n, ch.path),
+			Condition: condition(n.conf, ch.path),
+		})
+	}
+
+	for _, c := range others {
+		rel := c.path[len(n.path):]
+		e := Edge{
+			From:      n.ID,
+			To:        c.ID,
+			Kind:      EdgeKindChild,
+			Path:      relPath(n, rel),
+			Condition: condition(n.conf, rel),
+		}
+		switch {
+		case n.ComponentType == string(docs.TypeInput) && c.ComponentType == string(docs.TypeInput):
+			e.From, e.To, e.Kind = b.tail(c).ID, n.ID, EdgeKindFlow
+		case n.ComponentType == string(docs.TypeOutput) && c.ComponentType == string(docs.TypeOutput):
+			e.To, e.Kind = b.head(c).ID, EdgeKindFlow
+		}
+		g.Edges = append(g.Edges, e)
+	}
+}
+
+func (b *builder) addStreamEdges(g *Graph) {
+	var input, buffer, output *walkedNode
+	var pipeline []*walkedNode
+	for _, n := range b.roots {
+		switch n.path[0] {
+		case "input":
+			input = n
+		case "buffer":
+			buffer = n
+		case "output":
+			output = n
+		case "pipeline":
+			pipeline = append(pipeline, n)
+		}
+	}
+	sort.SliceStable(pipeline, func(i, j int) bool {
+		return indexOf(pipeline[i]) < indexOf(pipeline[j])
+	})
+
+	var stages []*walkedNode
+	if input != nil {
+		stages = append(stages, b.tail(input))
+	}
+	if buffer != nil {
+		stages = append(stages, buffer)
+	}
+	stages = append(stages, pipeline...)
+	if output != nil {
+		stages = append(stages, b.head(output))
+	}
+	for i := 1; i < len(stages); i++ {
+		g.Edges = append(g.Edges, Edge{
+			From: stages[i-1].ID,
+			To:   stages[i].ID,
+			Kind: EdgeKindFlow,
+		})
+	}
+}
+
+func (b *builder) addResourceEdges(g *Graph, nodes []*walkedNode) {
+	resources := map[docs.Type]map[string]*walkedNode{}
+	for _, n := range nodes {
+		if !n.Resource || n.Label == "" {
+			continue
+		}
+		cType := docs.Type(n.ComponentType)
+		if resources[cType] == nil {
+			resources[cType] = map[string]*walkedNode{}
+		}
+		resources[cType][n.Label] = n
+	}
+
+	for _, n := range nodes {
+		conf := componentConf(n)
+		if conf == nil {
+			continue
+		}
+		if n.Name == "resource" && conf.Kind == yaml.ScalarNode {
+			if r, exists := resources[docs.Type(n.ComponentType)][conf.Value]; exists {
+				g.Edges = append(g.Edges, Edge{From: n.ID, To: r.ID, Kind: EdgeKindResource})
+			}
+			continue
+		}
+		for i := 0; i < len(conf.Content)-1; i += 2 {
+			key, value := conf.Content[i].Value, conf.Content[i+1]
+			if value.Kind != yaml.ScalarNode || value.Value == "" {
+				continue
+			}
+			for _, cType := range referenceFields[key] {
+				if r, exists := resources[cType][value.Value]; exists {
+					g.Edges = append(g.Edges, Edge{From: n.ID, To: r.ID, Kind: EdgeKindResource, Path: key})
+					break
+				}
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func isIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func indexOf(n *walkedNode) int {
+	i, _ := strconv.Atoi(n.path[len(n.path)-1])
+	return i
+}
+
+// componentConf returns the config node of the implementation of a component.
+func componentConf(n *walkedNode) *yaml.Node {
+	for i := 0; i < len(n.conf.Content)-1; i += 2 {
+		if n.conf.Content[i].Value == n.Name {
+			return n.conf.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// relPath returns a path relative to the implementation config of a component.
+func relPath(n *walkedNode, path []string) string {
+	if len(path) > 0 && path[0] == n.Name {
+		path = path[1:]
+	}
+	return strings.Join(path, ".")
+}
+
+// condition walks a path of a component config and returns the check field of
+// the deepest object that contains the path, this is the routing condition
+// used by components such as the switch output and processor.
+func condition(conf *yaml.Node, path []string) string {
+	var check string
+	current := conf
+	for _, p := range path {
+		if current == nil {
+			break
+		}
+		var next *yaml.Node
+		switch current.Kind {
+		case yaml.MappingNode:
+			check = ""
+			for i := 0; i < len(current.Content)-1; i += 2 {
+				switch current.Content[i].Value {
+				case "check":
+					check = current.Content[i+1].Value
+				case p:
+					next = current.Content[i+1]
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(p); err == nil && i < len(current.Content) {
+				next = current.Content[i]
+			}
+		}
+		current = next
+	}
+	return check
+}
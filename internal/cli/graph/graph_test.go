@@ -0,0 +1,166 @@
+package graph_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/graph"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/io"
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+func graphFromYAML(t *testing.T, confStr string) *graph.Graph {
+	t.Helper()
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &node))
+
+	g, err := graph.FromYAML(config.Spec(), &node, docs.DeprecatedProvider)
+	require.NoError(t, err)
+	return g
+}
+
+func TestGraphFromYAML(t *testing.T) {
+	g := graphFromYAML(t, `
+input:
+  broker:
+    inputs:
+      - generate:
+          mapping: 'root = "a"'
+        processors:
+          - mapping: 'root = content().uppercase()'
+      - resource: foo_in
+pipeline:
+  processors:
+    - label: router
+      switch:
+        - check: 'this.type == "a"'
+          processors:
+            - cache:
+                resource: mycache
+                operator: get
+                key: foo
+            - log:
+                message: hi
+        - processors:
+            - noop: {}
+output:
+  switch:
+    cases:
+      - check: 'meta("x") == "y"'
+        output:
+          stdout: {}
+          processors:
+            - bloblang: 'root = this'
+      - output:
+          drop: {}
+input_resources:
+  - label: foo_in
+    generate:
+      mapping: 'root = "b"'
+cache_resources:
+  - label: mycache
+    memory: {}
+tracer:
+  none: {}
+`)
+
+	assert.Equal(t, []graph.Node{
+		{ID: "input", ComponentType: "input", Name: "broker"},
+		{ID: "input.broker.inputs.0", ComponentType: "input", Name: "generate"},
+		{ID: "input.broker.inputs.0.processors.0", ComponentType: "processor", Name: "mapping"},
+		{ID: "input.broker.inputs.1", ComponentType: "input", Name: "resource"},
+		{ID: "pipeline.processors.0", ComponentType: "processor", Name: "switch", Label: "router"},
+		{ID: "pipeline.processors.0.switch.0.processors.0", ComponentType: "processor", Name: "cache"},
+		{ID: "pipeline.processors.0.switch.0.processors.1", ComponentType: "processor", Name: "log"},
+		{ID: "pipeline.processors.0.switch.1.processors.0", ComponentType: "processor", Name: "noop"},
+		{ID: "output", ComponentType: "output", Name: "switch"},
+		{ID: "output.switch.cases.0.output", ComponentType: "output", Name: "stdout"},
+		{ID: "output.switch.cases.0.output.processors.0", ComponentType: "processor", Name: "bloblang"},
+		{ID: "output.switch.cases.1.output", ComponentType: "output", Name: "drop"},
+		{ID: "input_resources.0", ComponentType: "input", Name: "generate", Label: "foo_in", Resource: true},
+		{ID: "cache_resources.0", ComponentType: "cache", Name: "memory", Label: "mycache", Resource: true},
+	}, g.Nodes)
+
+	assert.ElementsMatch(t, []graph.Edge{
+		{From: "input.broker.inputs.0", To: "input.broker.inputs.0.processors.0", Kind: graph.EdgeKindFlow},
+		{From: "input.broker.inputs.0.processors.0", To: "input", Kind: graph.EdgeKindFlow, Path: "inputs.0"},
+		{From: "input.broker.inputs.1", To: "input", Kind: graph.EdgeKindFlow, Path: "inputs.1"},
+		{From: "input", To: "pipeline.processors.0", Kind: graph.EdgeKindFlow},
+		{
+			From: "pipeline.processors.0", To: "pipeline.processors.0.switch.0.processors.0",
+			Kind: graph.EdgeKindChild, Path: "0.processors", Condition: `this.type == "a"`,
+		},
+		{From: "pipeline.processors.0.switch.0.processors.0", To: "pipeline.processors.0.switch.0.processors.1", Kind: graph.EdgeKindFlow},
+		{From: "pipeline.processors.0", To: "pipeline.processors.0.switch.1.processors.0", Kind: graph.EdgeKindChild, Path: "1.processors"},
+		{From: "pipeline.processors.0", To: "output", Kind: graph.EdgeKindFlow},
+		{
+			From: "output", To: "output.switch.cases.0.output.processors.0",
+			Kind: graph.EdgeKindFlow, Path: "cases.0.output", Condition: `meta("x") == "y"`,
+		},
+		{From: "output.switch.cases.0.output.processors.0", To: "output.switch.cases.0.output", Kind: graph.EdgeKindFlow},
+		{From: "output", To: "output.switch.cases.1.output", Kind: graph.EdgeKindFlow, Path: "cases.1.output"},
+		{From: "input.broker.inputs.1", To: "input_resources.0", Kind: graph.EdgeKindResource},
+		{From: "pipeline.processors.0.switch.0.processors.0", To: "cache_resources.0", Kind: graph.EdgeKindResource, Path: "resource"},
+	}, g.Edges)
+}
+
+func TestGraphFormats(t *testing.T) {
+	g := graphFromYAML(t, `
+input:
+  generate:
+    mapping: 'root = "a"'
+pipeline:
+  processors:
+    - label: meow
+      mapping: 'root = content().uppercase()'
+output:
+  drop: {}
+`)
+
+	var buf bytes.Buffer
+	require.NoError(t, graph.Write(&buf, "dot", g))
+	assert.Equal(t, `digraph benthos {
+  rankdir=LR;
+  node [shape=box];
+  "input" [label="input: generate"];
+  "pipeline.processors.0" [label="processor: mapping\nlabel: meow", style=rounded];
+  "output" [label="output: drop"];
+  "input" -> "pipeline.processors.0";
+  "pipeline.processors.0" -> "output";
+}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, graph.Write(&buf, "mermaid", g))
+	assert.Equal(t, `flowchart LR
+  n0["input: generate"]
+  n1("processor: mapping<br/>label: meow")
+  n2["output: drop"]
+  n0 --> n1
+  n1 --> n2
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, graph.Write(&buf, "json", g))
+	assert.JSONEq(t, `{
+  "nodes": [
+    {"id": "input", "type": "input", "name": "generate"},
+    {"id": "pipeline.processors.0", "type": "processor", "name": "mapping", "label": "meow"},
+    {"id": "output", "type": "output", "name": "drop"}
+  ],
+  "edges": [
+    {"from": "input", "to": "pipeline.processors.0", "kind": "flow"},
+    {"from": "pipeline.processors.0", "to": "output", "kind": "flow"}
+  ]
+}`, buf.String())
+
+	require.Error(t, graph.Write(&buf, "nope", g))
+}
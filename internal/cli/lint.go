@@ -125,6 +125,54 @@ func lintMDSnippets(path string, opts config.LintOptions) (pathLints []pathLint)
 	return
 }
 
+func fixFile(path string) (pathLints []pathLint) {
+	rawBytes, err := ifs.ReadFile(ifs.OS(), path)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			lint:   docs.NewLintError(1, docs.LintFailedRead, err.Error()),
+		})
+		return
+	}
+
+	newBytes, migrated, err := config.MigrateBytes(rawBytes)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			lint:   docs.NewLintError(1, docs.LintFailedRead, err.Error()),
+		})
+		return
+	}
+
+	for _, m := range migrated {
+		if m.Err != nil {
+			pathLints = append(pathLints, pathLint{
+				source: path,
+				lint: docs.NewLintError(m.Line, docs.LintDeprecated, fmt.Sprintf(
+					"unable to migrate %v %v to %v automatically: %v", m.ComponentType, m.From, m.To, m.Err,
+				)),
+			})
+		}
+	}
+
+	if newBytes == nil {
+		return
+	}
+	if err := ifs.WriteFile(ifs.OS(), path, newBytes, 0o644); err != nil {
+		pathLints = append(pathLints, pathLint{
+			source: path,
+			lint:   docs.NewLintError(1, docs.LintFailedRead, fmt.Sprintf("failed to write fixed config: %v", err)),
+		})
+		return
+	}
+	for _, m := range migrated {
+		if m.Err == nil {
+			fmt.Printf("%v(%v,1) migrated %v %v to %v\n", path, m.Line, m.ComponentType, m.From, m.To)
+		}
+	}
+	return
+}
+
 func lintCliCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "lint",
@@ -138,7 +186,12 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+When the --fix flag is set any deprecated components that have a known
+replacement are migrated, and the config files are rewritten in place:
+
+  benthos lint --fix --deprecated ./configs/...`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
@@ -150,6 +203,11 @@ files with the .yaml or .yml extension.`[1:],
 				Value: false,
 				Usage: "Print linting errors when components do not have labels.",
 			},
+			&cli.BoolFlag{
+				Name:  "fix",
+				Value: false,
+				Usage: "Rewrite config files in place in order to migrate deprecated components to their replacements where possible.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			targets, err := ifilepath.GlobsAndSuperPaths(ifs.OS(), c.Args().Slice(), "yaml", "yml")
@@ -165,6 +223,7 @@ files with the .yaml or .yml extension.`[1:],
 				RejectDeprecated: c.Bool("deprecated"),
 				RequireLabels:    c.Bool("labels"),
 			}
+			fix := c.Bool("fix")
 
 			var pathLintMut sync.Mutex
 			var pathLints []pathLint
@@ -185,7 +244,10 @@ files with the .yaml or .yml extension.`[1:],
 						if path.Ext(target) == ".md" {
 							lints = lintMDSnippets(target, lintOpts)
 						} else {
-							lints = lintFile(target, lintOpts)
+							if fix {
+								lints = fixFile(target)
+							}
+							lints = append(lints, lintFile(target, lintOpts)...)
 						}
 						if len(lints) > 0 {
 							pathLintMut.Lock()
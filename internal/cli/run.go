@@ -12,6 +12,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
 	"github.com/benthosdev/benthos/v4/internal/cli/common"
+	"github.com/benthosdev/benthos/v4/internal/cli/graph"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
@@ -256,6 +257,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 			},
 			listCliCommand(),
 			createCliCommand(),
+			graph.CliCommand(),
 			test.CliCommand(),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
@@ -2,8 +2,10 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 
@@ -72,8 +74,10 @@ type Case struct {
 	InputBatch       []InputPart          `yaml:"input_batch"`
 	InputBatches     [][]InputPart        `yaml:"input_batches"`
 	OutputBatches    [][]ConditionsMap    `yaml:"output_batches"`
+	Generate         *Generator           `yaml:"generate"`
 
-	line int
+	line           int
+	conditionLines [][]map[string]int
 }
 
 // AtLine returns a test case at a given line.
@@ -107,9 +111,42 @@ func (c *Case) UnmarshalYAML(value *yaml.Node) error {
 
 	*c = Case(aliased)
 	c.line = value.Line
+	c.conditionLines = extractConditionLines(value)
 	return nil
 }
 
+// extractConditionLines walks the output batches of a test case YAML node and
+// returns the line numbers of each condition, indexed by batch, message and
+// condition type.
+func extractConditionLines(value *yaml.Node) (lines [][]map[string]int) {
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(value.Content)-1; i += 2 {
+		if value.Content[i].Value != "output_batches" {
+			continue
+		}
+		for _, batchNode := range value.Content[i+1].Content {
+			batchLines := make([]map[string]int, len(batchNode.Content))
+			for j, condsNode := range batchNode.Content {
+				batchLines[j] = map[string]int{}
+				for k := 0; k < len(condsNode.Content)-1; k += 2 {
+					batchLines[j][condsNode.Content[k].Value] = condsNode.Content[k].Line
+				}
+			}
+			lines = append(lines, batchLines)
+		}
+	}
+	return
+}
+
+func (c *Case) conditionLine(batch, index int, condType string) int {
+	if batch >= len(c.conditionLines) || index >= len(c.conditionLines[batch]) {
+		return 0
+	}
+	return c.conditionLines[batch][index][condType]
+}
+
 //------------------------------------------------------------------------------
 
 // CaseFailure encapsulates information about a failed test case.
@@ -117,6 +154,10 @@ type CaseFailure struct {
 	Name     string
 	TestLine int
 	Reason   string
+
+	// ConditionLine is the line of the output condition that failed, or zero
+	// when the failure is not specific to a condition.
+	ConditionLine int
 }
 
 // String returns a string representation of the case failure.
@@ -132,8 +173,10 @@ type ProcProvider interface {
 }
 
 // ExecuteFrom executes a test case from the perspective of a given directory,
-// which is used for obtaining relative condition file imports.
-func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
+// which is used for obtaining relative condition file imports. When
+// updateSnapshots is true the golden files of snapshot conditions are
+// rewritten with the output of the test.
+func (c *Case) ExecuteFrom(dir string, updateSnapshots bool, provider ProcProvider) (failures []CaseFailure, err error) {
 	var procSet []iprocessor.V1
 	if c.TargetMapping != "" {
 		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
@@ -202,9 +245,19 @@ func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFa
 				reportFailure(fmt.Sprintf("unexpected message from batch %v: %s", i, part.AsBytes()))
 				return nil
 			}
-			condErrs := expectedBatch[i2].CheckAll(dir, part)
+			condErrs := expectedBatch[i2].CheckAll(dir, updateSnapshots, part)
 			for _, condErr := range condErrs {
-				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
+				var condLine int
+				var cErr *conditionError
+				if errors.As(condErr, &cErr) {
+					condLine = c.conditionLine(i, i2, cErr.condType)
+				}
+				failures = append(failures, CaseFailure{
+					Name:          c.Name,
+					TestLine:      c.line,
+					Reason:        fmt.Sprintf("batch %v message %v: %v", i, i2, condErr),
+					ConditionLine: condLine,
+				})
 			}
 			if procErr := part.ErrorGet(); procErr != nil && len(condErrs) > 0 {
 				reportFailure(fmt.Sprintf("batch %v message %v: %v", i, i2, red(procErr)))
@@ -212,5 +265,46 @@ func (c *Case) ExecuteFrom(dir string, provider ProcProvider) (failures []CaseFa
 			return nil
 		})
 	}
+
+	if c.Generate != nil {
+		var genFailures []string
+		if genFailures, err = c.Generate.Execute(func(input []byte) string {
+			return checkGenerated(dir, procSet, c.Generate.Invariants, input)
+		}); err != nil {
+			return
+		}
+		for _, f := range genFailures {
+			reportFailure(f)
+		}
+	}
 	return
 }
+
+// checkGenerated executes processors against a single generated input and
+// returns a description of any panics, errors or invariant failures.
+func checkGenerated(dir string, procSet []iprocessor.V1, invariants ConditionsMap, input []byte) (reason string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reason = fmt.Sprintf("processors panicked: %v", r)
+		}
+	}()
+
+	outputBatches, err := iprocessor.ExecuteAll(context.Background(), procSet, message.Batch{message.NewPart(input)})
+	if err != nil {
+		return fmt.Sprintf("processors resulted in error: %v", err)
+	}
+
+	var reasons []string
+	for i, v := range outputBatches {
+		_ = v.Iter(func(i2 int, part *message.Part) error {
+			if procErr := part.ErrorGet(); procErr != nil {
+				reasons = append(reasons, fmt.Sprintf("batch %v message %v: processor error: %v", i, i2, procErr))
+			}
+			for _, condErr := range invariants.CheckAll(dir, false, part) {
+				reasons = append(reasons, fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
+			}
+			return nil
+		})
+	}
+	return strings.Join(reasons, "\n")
+}
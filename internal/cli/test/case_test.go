@@ -171,9 +171,10 @@ output_batches:
 `,
 			expected: []test.CaseFailure{
 				{
-					Name:     "negative 1",
-					TestLine: 2,
-					Reason:   "batch 0 message 0: content_equals: content mismatch\n  expected: foo baz\n  received: foo bar",
+					Name:          "negative 1",
+					TestLine:      2,
+					Reason:        "batch 0 message 0: content_equals: content mismatch\n  expected: foo baz\n  received: foo bar",
+					ConditionLine: 7,
 				},
 			},
 		},
@@ -195,14 +196,16 @@ output_batches:
 `,
 			expected: []test.CaseFailure{
 				{
-					Name:     "negative 2",
-					TestLine: 2,
-					Reason:   "batch 0 message 1: content_equals: content mismatch\n  expected: bar baz\n  received: foo baz",
+					Name:          "negative 2",
+					TestLine:      2,
+					Reason:        "batch 0 message 1: content_equals: content mismatch\n  expected: bar baz\n  received: foo baz",
+					ConditionLine: 11,
 				},
 				{
-					Name:     "negative 2",
-					TestLine: 2,
-					Reason:   "batch 0 message 1: metadata_equals: metadata key 'foo' mismatch\n  expected: bar\n  received: baz",
+					Name:          "negative 2",
+					TestLine:      2,
+					Reason:        "batch 0 message 1: metadata_equals: metadata key 'foo' mismatch\n  expected: bar\n  received: baz",
+					ConditionLine: 12,
 				},
 			},
 		},
@@ -259,7 +262,7 @@ output_batches:
 			if err = yaml.Unmarshal([]byte(testCase.conf), &c); err != nil {
 				tt.Fatal(err)
 			}
-			fails, err := c.ExecuteFrom("", provider)
+			fails, err := c.ExecuteFrom("", false, provider)
 			if err != nil {
 				tt.Fatal(err)
 			}
@@ -302,7 +305,7 @@ output_batches:
   - content_equals: hello world FOO BAR BAZ
 `), &c))
 
-	fails, err := c.ExecuteFrom(tmpDir, provider)
+	fails, err := c.ExecuteFrom(tmpDir, false, provider)
 	require.NoError(t, err)
 
 	assert.Equal(t, []test.CaseFailure(nil), fails)
@@ -317,14 +320,15 @@ output_batches:
   - content_equals: hello world FOO BAR BAZ
 `), &c))
 
-	fails, err = c.ExecuteFrom(tmpDir, provider)
+	fails, err = c.ExecuteFrom(tmpDir, false, provider)
 	require.NoError(t, err)
 
 	assert.Equal(t, []test.CaseFailure{
 		{
-			Name:     "not uppercased",
-			TestLine: 2,
-			Reason:   "batch 0 message 0: content_equals: content mismatch\n  expected: hello world FOO BAR BAZ\n  received: hello world foo bar baz",
+			Name:          "not uppercased",
+			TestLine:      2,
+			Reason:        "batch 0 message 0: content_equals: content mismatch\n  expected: hello world FOO BAR BAZ\n  received: hello world foo bar baz",
+			ConditionLine: 7,
 		},
 	}, fails)
 }
@@ -361,7 +365,7 @@ output_batches:
   - file_equals: "./inner/uppercased.txt"
 `), &c))
 
-	fails, err := c.ExecuteFrom(tmpDir, provider)
+	fails, err := c.ExecuteFrom(tmpDir, false, provider)
 	require.NoError(t, err)
 
 	assert.Equal(t, []test.CaseFailure(nil), fails)
@@ -376,14 +380,204 @@ output_batches:
   - file_equals: "./not_uppercased.txt"
 `), &c))
 
-	fails, err = c.ExecuteFrom(tmpDir, provider)
+	fails, err = c.ExecuteFrom(tmpDir, false, provider)
 	require.NoError(t, err)
 
 	assert.Equal(t, []test.CaseFailure{
 		{
-			Name:     "not uppercased",
-			TestLine: 2,
-			Reason:   "batch 0 message 0: file_equals: content mismatch\n  expected: foo bar baz\n  received: FOO BAR BAZ",
+			Name:          "not uppercased",
+			TestLine:      2,
+			Reason:        "batch 0 message 0: file_equals: content mismatch\n  expected: foo bar baz\n  received: FOO BAR BAZ",
+			ConditionLine: 7,
 		},
 	}, fails)
 }
+
+func TestGenerateCase(t *testing.T) {
+	color.NoColor = true
+
+	provider := mockProvider{}
+	procConf := processor.NewConfig()
+
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root.name = this.name.uppercase()
+root.tags = this.tags.or([]).map_each(t -> t.uppercase())`
+	proc, err := mock.NewManager().NewProcessor(procConf)
+	require.NoError(t, err)
+
+	provider["/pipeline/processors"] = []processor.V1{proc}
+
+	c := test.NewCase()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: generated names
+generate:
+  count: 50
+  seed: 10
+  json_schema:
+    type: object
+    properties:
+      name: { type: string }
+      tags: { type: array, items: { type: string } }
+    required: [ name ]
+  invariants:
+    json_schema:
+      type: object
+      properties:
+        name: { type: string }
+      required: [ name ]
+`), &c))
+
+	fails, err := c.ExecuteFrom("", false, provider)
+	require.NoError(t, err)
+	assert.Empty(t, fails)
+
+	c = test.NewCase()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: generated names
+generate:
+  count: 50
+  seed: 10
+  json_schema:
+    type: object
+    properties:
+      name: { type: string }
+      tags: { type: array, items: { type: [ string, integer ] }, minItems: 3 }
+    required: [ name, tags ]
+`), &c))
+
+	fails, err = c.ExecuteFrom("", false, provider)
+	require.NoError(t, err)
+	require.Len(t, fails, 1)
+	assert.Contains(t, fails[0].Reason, "generated input")
+	assert.Contains(t, fails[0].Reason, `minimal input: {"name":"","tags":["","",0]}`)
+}
+
+func TestGenerateCaseSchemaErrors(t *testing.T) {
+	provider := mockProvider{}
+	procConf := processor.NewConfig()
+
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = this`
+	proc, err := mock.NewManager().NewProcessor(procConf)
+	require.NoError(t, err)
+
+	provider["/pipeline/processors"] = []processor.V1{proc}
+
+	for _, tc := range []struct {
+		name        string
+		schema      string
+		errContains string
+	}{
+		{
+			name:        "no integers in range",
+			schema:      `{ type: integer, minimum: 1.2, maximum: 1.8 }`,
+			errContains: "no integers exist between minimum 1.2 and maximum 1.8",
+		},
+		{
+			name:        "maximum below minimum",
+			schema:      `{ type: object, properties: { n: { type: number, minimum: 10, maximum: 5 } }, required: [ n ] }`,
+			errContains: "property n: maximum 5 is less than minimum 10",
+		},
+		{
+			name:        "unsupported keyword",
+			schema:      `{ type: string, pattern: "^[0-9]+$", minLength: 5 }`,
+			errContains: "does not conform to json_schema",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := test.NewCase()
+			require.NoError(t, yaml.Unmarshal([]byte(`
+name: bad schema
+generate:
+  count: 10
+  json_schema: `+tc.schema+`
+`), &c))
+
+			_, err := c.ExecuteFrom("", false, provider)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.errContains)
+		})
+	}
+}
+
+func TestGenerateCaseSchemaBounds(t *testing.T) {
+	provider := mockProvider{}
+	procConf := processor.NewConfig()
+
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = this`
+	proc, err := mock.NewManager().NewProcessor(procConf)
+	require.NoError(t, err)
+
+	provider["/pipeline/processors"] = []processor.V1{proc}
+
+	c := test.NewCase()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: generated bounds
+generate:
+  count: 100
+  json_schema:
+    type: object
+    properties:
+      a: { type: integer, minimum: 1.5, maximum: 2.5 }
+      b: { type: integer, minimum: 5000 }
+      c: { type: number, maximum: -5000 }
+      d: { type: string, minLength: 30 }
+      e: { type: array, items: { type: boolean }, minItems: 10 }
+    required: [ a, b, c, d, e ]
+  invariants:
+    bloblang: 'this.a == 2 && this.b >= 5000 && this.c <= -5000 && this.d.length() >= 30 && this.e.length() >= 10'
+`), &c))
+
+	fails, err := c.ExecuteFrom("", false, provider)
+	require.NoError(t, err)
+	assert.Empty(t, fails)
+}
+
+func TestGenerateCaseBloblang(t *testing.T) {
+	color.NoColor = true
+
+	provider := mockProvider{}
+	procConf := processor.NewConfig()
+
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = this`
+	proc, err := mock.NewManager().NewProcessor(procConf)
+	require.NoError(t, err)
+
+	provider["/pipeline/processors"] = []processor.V1{proc}
+
+	c := test.NewCase()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: generated numbers
+generate:
+  count: 20
+  bloblang: 'root.id = random_int(max: 100)'
+  invariants:
+    bloblang: 'this.id < 200'
+`), &c))
+
+	fails, err := c.ExecuteFrom("", false, provider)
+	require.NoError(t, err)
+	assert.Empty(t, fails)
+
+	c = test.NewCase()
+	err = yaml.Unmarshal([]byte(`
+name: no generator
+generate:
+  count: 20
+`), &c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "either a bloblang or json_schema generator must be specified")
+
+	c = test.NewCase()
+	err = yaml.Unmarshal([]byte(`
+name: seeded bloblang
+generate:
+  seed: 10
+  bloblang: 'root.name = uuid_v4()'
+`), &c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 4: a seed cannot be specified with a bloblang generator")
+}
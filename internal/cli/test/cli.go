@@ -2,7 +2,9 @@ package test
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
@@ -30,7 +32,22 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 			&cli.StringFlag{
 				Name:  "log",
 				Value: "",
-				Usage: "allow components to write logs at a provided level to stdout.",
+				Usage: "allow components to write logs at a provided level to stdout, or to stderr when a report produced with --format is written to stdout.",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "",
+				Usage: "write a machine readable report of test results in a given format (" + strings.Join(ReportFormats, ", ") + ").",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Value: "",
+				Usage: "write the report produced with --format to a file path rather than stdout.",
+			},
+			&cli.BoolFlag{
+				Name:  "update-snapshots",
+				Value: false,
+				Usage: "rewrite the golden files of snapshot conditions with the output of each test.",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -44,18 +61,49 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			updateSnapshots := c.Bool("update-snapshots")
+			format := c.String("format")
+			if len(format) > 0 && !isReportFormat(format) {
+				fmt.Printf("Report format not recognised: %v, expected one of: %v\n", format, strings.Join(ReportFormats, ", "))
+				os.Exit(1)
+			}
+			var logger log.Modular = log.Noop()
 			if logLevel := c.String("log"); len(logLevel) > 0 {
+				// Logs would corrupt a report written to stdout.
+				var logOut io.Writer = os.Stdout
+				if len(format) > 0 && len(c.String("output")) == 0 {
+					logOut = os.Stderr
+				}
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
-				logger, err := log.New(os.Stdout, logConf)
-				if err != nil {
+				if logger, err = log.New(logOut, logConf); err != nil {
 					fmt.Printf("Failed to init logger: %v\n", err)
 					os.Exit(1)
 				}
-				if RunAll(c.Args().Slice(), "_benthos_test", true, logger, resourcesPaths) {
+			}
+			if len(format) > 0 {
+				var humanOut, reportOut io.Writer = io.Discard, os.Stdout
+				var reportFile *os.File
+				if outPath := c.String("output"); len(outPath) > 0 {
+					if reportFile, err = os.Create(outPath); err != nil {
+						fmt.Printf("Failed to create report file: %v\n", err)
+						os.Exit(1)
+					}
+					humanOut, reportOut = os.Stdout, reportFile
+				}
+				passed := RunAllWithReport(c.Args().Slice(), "_benthos_test", true, updateSnapshots, logger, resourcesPaths, humanOut, format, reportOut)
+				if reportFile != nil {
+					if err := reportFile.Close(); err != nil {
+						fmt.Printf("Failed to write report file: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				if passed {
 					os.Exit(0)
 				}
-			} else if RunAll(c.Args().Slice(), "_benthos_test", true, log.Noop(), resourcesPaths) {
+				os.Exit(1)
+			}
+			if RunAll(c.Args().Slice(), "_benthos_test", true, updateSnapshots, logger, resourcesPaths) {
 				os.Exit(0)
 			}
 			os.Exit(1)
@@ -63,3 +111,12 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 		},
 	}
 }
+
+func isReportFormat(format string) bool {
+	for _, f := range ReportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -3,11 +3,13 @@ package test
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	yaml "gopkg.in/yaml.v3"
@@ -65,6 +67,7 @@ func getDefinition(targetPath, definitionPath string) (*Definition, error) {
 	if err := yaml.Unmarshal(defBytes, &definition); err != nil {
 		return nil, fmt.Errorf("failed to parse test definition from '%v': %v", definitionPath, err)
 	}
+	definition.path = definitionPath
 	return &definition, nil
 }
 
@@ -105,26 +108,63 @@ func lintTarget(path, testSuffix string) ([]docs.Lint, error) {
 
 //------------------------------------------------------------------------------
 
+// TargetResult describes the outcome of executing the tests of a single config
+// target.
+type TargetResult struct {
+	Target         string
+	DefinitionPath string
+	Lints          []docs.Lint
+	Cases          []CaseResult
+	Duration       time.Duration
+}
+
+// Failed returns true if the target has lint errors or any failed test cases.
+func (t TargetResult) Failed() bool {
+	if len(t.Lints) > 0 {
+		return true
+	}
+	for _, c := range t.Cases {
+		if len(c.Failures) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // RunAll executes the test command for a slice of paths. The path can either be
 // a config file, a config files test definition file, a directory, or the
-// wildcard pattern './...'.
-func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
-	targets, err := GetTestTargets(paths, testSuffix)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
+// wildcard pattern './...'. When updateSnapshots is true the golden files of
+// snapshot conditions are rewritten rather than compared.
+func RunAll(paths []string, testSuffix string, lint, updateSnapshots bool, logger log.Modular, resourcesPaths []string) bool {
+	_, passed := runAll(paths, testSuffix, lint, updateSnapshots, logger, resourcesPaths, os.Stdout)
+	return passed
+}
+
+// RunAllWithReport executes the test command in the same way as RunAll, writing
+// human readable results to humanOut and a machine readable report of the
+// results in the given format to reportOut.
+func RunAllWithReport(paths []string, testSuffix string, lint, updateSnapshots bool, logger log.Modular, resourcesPaths []string, humanOut io.Writer, format string, reportOut io.Writer) bool {
+	results, passed := runAll(paths, testSuffix, lint, updateSnapshots, logger, resourcesPaths, humanOut)
+	if results == nil {
 		return false
 	}
-	if len(targets) == 0 {
-		fmt.Printf("%v\n", yellow("No tests were found"))
+	if err := WriteReport(reportOut, format, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write test report: %v\n", err)
 		return false
 	}
+	return passed
+}
 
-	type failedTarget struct {
-		target string
-		lints  []docs.Lint
-		cases  []CaseFailure
+func runAll(paths []string, testSuffix string, lint, updateSnapshots bool, logger log.Modular, resourcesPaths []string, out io.Writer) ([]TargetResult, bool) {
+	targets, err := GetTestTargets(paths, testSuffix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
+		return nil, false
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(out, "%v\n", yellow("No tests were found"))
+		return []TargetResult{}, false
 	}
-	fails := []failedTarget{}
 
 	targetPaths := make([]string, 0, len(targets))
 	for k := range targets {
@@ -132,58 +172,70 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 	}
 	sort.Strings(targetPaths)
 
+	results := make([]TargetResult, 0, len(targetPaths))
+	fails := []TargetResult{}
 	for _, target := range targetPaths {
-		var lints []docs.Lint
-		var failCases []CaseFailure
+		res := TargetResult{
+			Target:         target,
+			DefinitionPath: targets[target].path,
+		}
+		if res.DefinitionPath == "" {
+			res.DefinitionPath = target
+		}
+
+		started := time.Now()
 		if lint {
-			if lints, err = lintTarget(target, testSuffix); err != nil {
+			if res.Lints, err = lintTarget(target, testSuffix); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
-				return false
+				return nil, false
 			}
 		}
-		if failCases, err = targets[target].Execute(target, resourcesPaths, logger); err != nil {
+		if res.Cases, err = targets[target].ExecuteCases(target, resourcesPaths, updateSnapshots, logger); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
-			return false
+			return nil, false
 		}
-		if len(lints) > 0 || len(failCases) > 0 {
-			fails = append(fails, failedTarget{
-				target: target,
-				lints:  lints,
-				cases:  failCases,
-			})
-			fmt.Printf("Test '%v' %v\n", target, red("failed"))
+		res.Duration = time.Since(started)
+
+		results = append(results, res)
+		if res.Failed() {
+			fails = append(fails, res)
+			fmt.Fprintf(out, "Test '%v' %v\n", target, red("failed"))
 		} else {
-			fmt.Printf("Test '%v' %v\n", target, green("succeeded"))
+			fmt.Fprintf(out, "Test '%v' %v\n", target, green("succeeded"))
 		}
 	}
 	if len(fails) > 0 {
-		fmt.Printf("\nFailures:\n\n")
+		fmt.Fprintf(out, "\nFailures:\n\n")
 		for i, fail := range fails {
 			if i > 0 {
-				fmt.Println("")
+				fmt.Fprintln(out, "")
+			}
+			fmt.Fprintf(out, "--- %v ---\n\n", fail.Target)
+			for _, lint := range fail.Lints {
+				fmt.Fprintf(out, "Lint: %v\n", lint)
 			}
-			fmt.Printf("--- %v ---\n\n", fail.target)
-			for _, lint := range fail.lints {
-				fmt.Printf("Lint: %v\n", lint)
+			var failCases []CaseFailure
+			for _, c := range fail.Cases {
+				failCases = append(failCases, c.Failures...)
 			}
-			if len(fail.cases) > 0 {
-				if len(fail.lints) > 0 {
-					fmt.Println("")
+			if len(failCases) > 0 {
+				if len(fail.Lints) > 0 {
+					fmt.Fprintln(out, "")
 				}
 				var namePrev string
-				for i, fail := range fail.cases {
+				for i, fail := range failCases {
 					if namePrev != fail.Name {
 						if i > 0 {
-							fmt.Println("")
+							fmt.Fprintln(out, "")
 						}
-						fmt.Printf("%v [line %v]:\n", fail.Name, fail.TestLine)
+						fmt.Fprintf(out, "%v [line %v]:\n", fail.Name, fail.TestLine)
 						namePrev = fail.Name
 					}
-					fmt.Println(fail.Reason)
+					fmt.Fprintln(out, fail.Reason)
 				}
 			}
 		}
-		return false
+		return results, false
 	}
-	return true
+	return results, true
 }
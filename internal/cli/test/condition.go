@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/nsf/jsondiff"
+	jsonschema "github.com/xeipuuv/gojsonschema"
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
@@ -87,6 +90,22 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 				return fmt.Errorf("line %v: %v", v.Line, err)
 			}
 			cond = val
+		case "json_schema":
+			var schemaStr string
+			if err := yamlNodeToTestString(&v, &schemaStr); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			val, err := NewJSONSchemaCondition(schemaStr)
+			if err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
+		case "snapshot":
+			val := SnapshotCondition("")
+			if err := v.Decode(&val); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			cond = val
 		case "metadata_equals":
 			root := map[string]any{}
 			if err := v.Decode(&root); err != nil {
@@ -102,27 +121,54 @@ func (c *ConditionsMap) UnmarshalYAML(value *yaml.Node) error {
 }
 
 // CheckAll checks all conditions against a message part. Conditions are
-// executed in alphabetical order.
-func (c ConditionsMap) CheckAll(dir string, part *message.Part) (errs []error) {
+// executed in alphabetical order. When updateSnapshots is true any snapshot
+// conditions are rewritten with the contents of the message part before being
+// checked.
+func (c ConditionsMap) CheckAll(dir string, updateSnapshots bool, part *message.Part) (errs []error) {
 	condTypes := []string{}
 	for k := range c {
 		condTypes = append(condTypes, k)
 	}
 	sort.Strings(condTypes)
 	for _, k := range condTypes {
+		if updateSnapshots {
+			if upd, ok := c[k].(interface {
+				updateFrom(string, *message.Part) error
+			}); ok {
+				if err := upd.updateFrom(dir, part); err != nil {
+					errs = append(errs, &conditionError{condType: k, err: err})
+					continue
+				}
+			}
+		}
 		if relCheck, ok := c[k].(interface {
 			checkFrom(string, *message.Part) error
 		}); ok {
 			if err := relCheck.checkFrom(dir, part); err != nil {
-				errs = append(errs, fmt.Errorf("%v: %v", k, err))
+				errs = append(errs, &conditionError{condType: k, err: err})
 			}
 		} else if err := c[k].Check(part); err != nil {
-			errs = append(errs, fmt.Errorf("%v: %v", k, err))
+			errs = append(errs, &conditionError{condType: k, err: err})
 		}
 	}
 	return
 }
 
+// conditionError is returned by CheckAll and describes the failure of a single
+// condition type.
+type conditionError struct {
+	condType string
+	err      error
+}
+
+func (c *conditionError) Error() string {
+	return fmt.Sprintf("%v: %v", c.condType, c.err)
+}
+
+func (c *conditionError) Unwrap() error {
+	return c.err
+}
+
 //------------------------------------------------------------------------------
 
 type bloblangCondition struct {
@@ -295,6 +341,101 @@ func (c FileJSONContainsCondition) checkFrom(dir string, p *message.Part) error
 
 //------------------------------------------------------------------------------
 
+// JSONSchemaCondition checks that the contents of a message is a valid JSON
+// document that conforms to a JSON schema.
+type JSONSchemaCondition struct {
+	schema *jsonschema.Schema
+}
+
+// NewJSONSchemaCondition attempts to parse a JSON schema and returns a
+// condition that validates messages against it.
+func NewJSONSchemaCondition(schemaStr string) (*JSONSchemaCondition, error) {
+	schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(schemaStr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	return &JSONSchemaCondition{schema: schema}, nil
+}
+
+// Check this condition against a message part.
+func (j *JSONSchemaCondition) Check(p *message.Part) error {
+	result, err := j.schema.Validate(jsonschema.NewBytesLoader(p.AsBytes()))
+	if err != nil {
+		return fmt.Errorf("failed to validate JSON schema: %w", err)
+	}
+	if !result.Valid() {
+		var errStrs []string
+		for _, desc := range result.Errors() {
+			errStrs = append(errStrs, desc.String())
+		}
+		return fmt.Errorf("JSON schema mismatch\n  received: %v\n  errors: %v", red(string(p.AsBytes())), strings.Join(errStrs, ", "))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// SnapshotCondition is a string condition that compares the contents of a
+// message against a golden file at the string path. When both the file and
+// the message are valid JSON documents they are compared structurally,
+// otherwise the raw bytes are compared. Snapshot files can be created or
+// rewritten by running tests with snapshot updates enabled.
+type SnapshotCondition string
+
+// Check this condition against a message part.
+func (c SnapshotCondition) Check(p *message.Part) error {
+	return c.checkFrom("", p)
+}
+
+func (c SnapshotCondition) checkFrom(dir string, p *message.Part) error {
+	relPath := filepath.Join(dir, string(c))
+
+	fileContent, err := ifs.ReadFile(ifs.OS(), relPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("snapshot file '%v' does not exist, run with --update-snapshots to create it", relPath)
+		}
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+
+	if json.Valid(fileContent) && json.Valid(p.AsBytes()) {
+		jdopts := jsondiff.DefaultConsoleOptions()
+		diff, explanation := jsondiff.Compare(p.AsBytes(), fileContent, &jdopts)
+		if diff != jsondiff.FullMatch {
+			return fmt.Errorf("JSON snapshot mismatch\n%v", explanation)
+		}
+		return nil
+	}
+
+	if exp, act := string(fileContent), string(p.AsBytes()); exp != act {
+		return fmt.Errorf("snapshot mismatch\n  expected: %v\n  received: %v", blue(exp), red(act))
+	}
+	return nil
+}
+
+func (c SnapshotCondition) updateFrom(dir string, p *message.Part) error {
+	relPath := filepath.Join(dir, string(c))
+
+	content := p.AsBytes()
+	if json.Valid(content) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, content, "", "  "); err == nil {
+			buf.WriteByte('\n')
+			content = buf.Bytes()
+		}
+	}
+
+	if err := ifs.OS().MkdirAll(filepath.Dir(relPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := ifs.WriteFile(ifs.OS(), relPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
 // MetadataEqualsCondition checks whether a metadata keys contents matches a
 // value.
 type MetadataEqualsCondition map[string]any
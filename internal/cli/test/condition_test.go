@@ -59,8 +59,8 @@ tests:
 
 	require.NoError(t, yaml.Unmarshal([]byte(conf), &tests))
 
-	assert.Empty(t, tests.Tests.CheckAll("", message.NewPart([]byte("foo bar"))))
-	assert.NotEmpty(t, tests.Tests.CheckAll("", message.NewPart([]byte("bar baz"))))
+	assert.Empty(t, tests.Tests.CheckAll("", false, message.NewPart([]byte("foo bar"))))
+	assert.NotEmpty(t, tests.Tests.CheckAll("", false, message.NewPart([]byte("bar baz"))))
 }
 
 func TestBloblangConditionSad(t *testing.T) {
@@ -113,18 +113,18 @@ func TestConditionCheckAll(t *testing.T) {
 
 	part := message.NewPart([]byte("foo bar"))
 	part.MetaSetMut("foo", "bar")
-	errs := conds.CheckAll("", part)
+	errs := conds.CheckAll("", false, part)
 	require.Len(t, errs, 0)
 
 	part = message.NewPart([]byte("nope"))
-	errs = conds.CheckAll("", part)
+	errs = conds.CheckAll("", false, part)
 	require.Len(t, errs, 2)
 	assert.Contains(t, "content_equals: content mismatch\n  expected: foo bar\n  received: nope", errs[0].Error())
 	assert.Contains(t, "metadata_equals: metadata key 'foo' expected but not found", errs[1].Error())
 
 	part = message.NewPart([]byte("foo bar"))
 	part.MetaSetMut("foo", "wrong")
-	errs = conds.CheckAll("", part)
+	errs = conds.CheckAll("", false, part)
 	if exp, act := 1, len(errs); exp != act {
 		t.Fatalf("Wrong count of errors: %v != %v", act, exp)
 	}
@@ -134,7 +134,7 @@ func TestConditionCheckAll(t *testing.T) {
 
 	part = message.NewPart([]byte("wrong"))
 	part.MetaSetMut("foo", "bar")
-	errs = conds.CheckAll("", part)
+	errs = conds.CheckAll("", false, part)
 	if exp, act := 1, len(errs); exp != act {
 		t.Fatalf("Wrong count of errors: %v != %v", act, exp)
 	}
@@ -597,3 +597,89 @@ func TestFileJSONContainsCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshotCondition(t *testing.T) {
+	color.NoColor = true
+
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "doc.json"), []byte(`{
+  "id": 123456,
+  "name": "Benthos"
+}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "raw.txt"), []byte(`hello world`), 0o644))
+
+	type testCase struct {
+		name        string
+		path        string
+		input       string
+		errContains string
+	}
+
+	tests := []testCase{
+		{
+			name:  "json match ignores formatting",
+			path:  `./doc.json`,
+			input: `{"name":"Benthos","id":123456}`,
+		},
+		{
+			name:        "json mismatch",
+			path:        `./doc.json`,
+			input:       `{"name":"Benthos","id":654321}`,
+			errContains: "JSON snapshot mismatch",
+		},
+		{
+			name:  "raw match",
+			path:  `./raw.txt`,
+			input: `hello world`,
+		},
+		{
+			name:        "raw mismatch",
+			path:        `./raw.txt`,
+			input:       `hello there`,
+			errContains: "snapshot mismatch",
+		},
+		{
+			name:        "missing file",
+			path:        `./nope.json`,
+			input:       `{}`,
+			errContains: "does not exist, run with --update-snapshots",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			actErr := SnapshotCondition(test.path).checkFrom(tmpDir, message.NewPart([]byte(test.input)))
+			if test.errContains == "" {
+				assert.NoError(t, actErr)
+			} else {
+				require.Error(t, actErr)
+				assert.Contains(t, actErr.Error(), test.errContains)
+			}
+		})
+	}
+}
+
+func TestSnapshotConditionUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	conds := ConditionsMap{
+		"snapshot": SnapshotCondition("./snapshots/out.json"),
+	}
+
+	part := message.NewPart([]byte(`{"name":"Benthos","id":123456}`))
+	assert.NotEmpty(t, conds.CheckAll(tmpDir, false, part))
+	assert.Empty(t, conds.CheckAll(tmpDir, true, part))
+	assert.Empty(t, conds.CheckAll(tmpDir, false, part))
+
+	fileBytes, err := os.ReadFile(filepath.Join(tmpDir, "snapshots", "out.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "name": "Benthos",
+  "id": 123456
+}
+`, string(fileBytes))
+
+	assert.NotEmpty(t, conds.CheckAll(tmpDir, false, message.NewPart([]byte(`{"name":"Benthos"}`))))
+}
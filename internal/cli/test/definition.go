@@ -3,6 +3,7 @@ package test
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/log"
 )
@@ -10,10 +11,36 @@ import (
 // Definition of a group of tests for a Benthos config file.
 type Definition struct {
 	Cases []Case `yaml:"tests"`
+
+	path string
+}
+
+// CaseResult describes the outcome of executing a single test case.
+type CaseResult struct {
+	Name     string
+	TestLine int
+	Duration time.Duration
+	Failures []CaseFailure
+}
+
+// Execute the test definition. When updateSnapshots is true the golden files
+// of snapshot conditions are rewritten with the output of each test case.
+func (d Definition) Execute(testFilePath string, resourcesPaths []string, updateSnapshots bool, logger log.Modular) ([]CaseFailure, error) {
+	results, err := d.ExecuteCases(testFilePath, resourcesPaths, updateSnapshots, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalFailures []CaseFailure
+	for _, r := range results {
+		totalFailures = append(totalFailures, r.Failures...)
+	}
+	return totalFailures, nil
 }
 
-// Execute the test definition.
-func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
+// ExecuteCases executes the test definition and returns the result of each
+// individual test case.
+func (d Definition) ExecuteCases(testFilePath string, resourcesPaths []string, updateSnapshots bool, logger log.Modular) ([]CaseResult, error) {
 	procsProvider := NewProcessorsProvider(
 		testFilePath,
 		OptAddResourcesPaths(resourcesPaths),
@@ -22,17 +49,23 @@ func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger
 
 	dir := filepath.Dir(testFilePath)
 
-	var totalFailures []CaseFailure
+	results := make([]CaseResult, 0, len(d.Cases))
 	for i, c := range d.Cases {
 		cleanupEnv := setEnvironment(c.Environment)
-		failures, err := c.ExecuteFrom(dir, procsProvider)
+		started := time.Now()
+		failures, err := c.ExecuteFrom(dir, updateSnapshots, procsProvider)
 		if err != nil {
 			cleanupEnv()
 			return nil, fmt.Errorf("test case %v failed: %v", i, err)
 		}
-		totalFailures = append(totalFailures, failures...)
+		results = append(results, CaseResult{
+			Name:     c.Name,
+			TestLine: c.line,
+			Duration: time.Since(started),
+			Failures: failures,
+		})
 		cleanupEnv()
 	}
 
-	return totalFailures, nil
+	return results, nil
 }
@@ -66,7 +66,7 @@ pipeline:
 		},
 	}
 
-	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"), nil, false, log.Noop())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -148,7 +148,7 @@ pipeline:
 		},
 	}
 
-	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"), nil, log.Noop())
+	failures, err := def.Execute(filepath.Join(testDir, "config1.yaml"), nil, false, log.Noop())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,45 +102,89 @@ It is also possible to target processors in a separate file by prefixing the tar
 		).ArrayOfArrays().Optional().WithChildren(
 			docs.FieldString("content", "The raw content of the input message.").HasDefault(""),
 			docs.FieldAnything("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
+		).WithChildren(conditionFields()...),
+		docs.FieldObject(
+			"generate",
+			"Generate a number of random input messages, each of which is fed into the target processors individually, and check that every resulting message satisfies a set of invariants. A processor panic or error flagged on an output message is also considered a failure. When an input fails the test it is shrunk to a minimal input that reproduces the failure, which is reported along with the original.",
+		).Optional().WithChildren(
+			docs.FieldInt("count", "The number of inputs to generate.").HasDefault(100),
+			docs.FieldInt("seed", "A seed for the random number generator used when generating inputs from a `json_schema`, this allows failures to be reproduced. The seed cannot be specified along with `bloblang`, where functions such as `random_int` accept their own `seed` argument and others such as `fake` and `uuid_v4` cannot be reproduced.").HasDefault(0),
 			docs.FieldString(
-				`bloblang`,
-				"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
-				"this.age > 10 && @foo.length() > 0",
+				"bloblang",
+				"A Bloblang mapping executed once per input in order to generate its contents. Functions such as `fake` and `random_int` can be used in order to create random values.",
+				`root.name = fake("name")
+root.age = random_int(max: 120)`,
 			).Optional(),
-			docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
-			docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
 			docs.FieldAnything(
-				`metadata_equals`,
-				"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
+				"json_schema",
+				"A JSON schema from which inputs are generated. Only a subset of the specification is supported: `type`, `properties`, `required`, `items`, `enum`, `const`, `oneOf`, `anyOf`, `minimum`, `maximum`, `minLength`, `maxLength`, `minItems` and `maxItems`.",
 				map[string]any{
-					"example_key": "example metadata value",
+					"type": "object",
+					"properties": map[string]any{
+						"name": map[string]any{"type": "string"},
+						"age":  map[string]any{"type": "integer", "minimum": 0},
+					},
+					"required": []any{"name"},
 				},
-			).Map().Optional(),
-			docs.FieldString(
-				`file_equals`,
-				"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.txt",
-			).Optional(),
-			docs.FieldString(
-				`file_json_equals`,
-				"Checks that both the message and the file contents are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.json",
-			).Optional(),
-			docs.FieldAnything(
-				`json_equals`,
-				"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
-				map[string]any{"key": "value"},
-			).Optional(),
-			docs.FieldAnything(
-				`json_contains`,
-				"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
-				map[string]any{"key": "value"},
-			).Optional(),
-			docs.FieldString(
-				`file_json_contains`,
-				"Checks that both the message and the file contents are valid JSON documents, and that the message is a superset of the condition. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.json",
 			).Optional(),
+			docs.FieldObject(
+				"invariants",
+				"A map of conditions, of the same types as those of `output_batches`, that each output message must satisfy.",
+			).Optional().WithChildren(conditionFields()...),
 		),
 	)
 }
+
+func conditionFields() []docs.FieldSpec {
+	return []docs.FieldSpec{
+		docs.FieldString(
+			`bloblang`,
+			"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
+			"this.age > 10 && @foo.length() > 0",
+		).Optional(),
+		docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
+		docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
+		docs.FieldAnything(
+			`metadata_equals`,
+			"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
+			map[string]any{
+				"example_key": "example metadata value",
+			},
+		).Map().Optional(),
+		docs.FieldString(
+			`file_equals`,
+			"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.txt",
+		).Optional(),
+		docs.FieldString(
+			`file_json_equals`,
+			"Checks that both the message and the file contents are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.json",
+		).Optional(),
+		docs.FieldAnything(
+			`json_equals`,
+			"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
+			map[string]any{"key": "value"},
+		).Optional(),
+		docs.FieldAnything(
+			`json_contains`,
+			"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
+			map[string]any{"key": "value"},
+		).Optional(),
+		docs.FieldString(
+			`file_json_contains`,
+			"Checks that both the message and the file contents are valid JSON documents, and that the message is a superset of the condition. Will ignore formatting and ordering differences. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.json",
+		).Optional(),
+		docs.FieldString(
+			`snapshot`,
+			"Checks that the contents of a message matches the contents of a golden file. When both the message and the file contents are valid JSON documents they are compared structurally and a diff of any mismatches is printed, otherwise the raw contents are compared. Running `benthos test` with the flag `--update-snapshots` rewrites the file with the contents of the message. The path of the file should be relative to the path of the test file.",
+			"./snapshots/bar.json",
+		).Optional(),
+		docs.FieldAnything(
+			`json_schema`,
+			"Checks that the message is a valid JSON document that conforms to a JSON schema.",
+			map[string]any{"type": "object", "required": []any{"id"}},
+		).Optional(),
+	}
+}
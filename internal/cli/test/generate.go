@@ -0,0 +1,500 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+const (
+	defaultGenerateCount = 100
+	maxShrinkAttempts    = 1000
+)
+
+// Generator defines a property based test where a number of input messages are
+// generated and fed through the target processors, with each output message
+// checked against a set of invariants.
+type Generator struct {
+	Count      int
+	Seed       int64
+	Invariants ConditionsMap
+
+	bloblang        *mapping.Executor
+	jsonSchema      map[string]any
+	jsonSchemaCheck *JSONSchemaCondition
+}
+
+// UnmarshalYAML extracts a Generator from a YAML node.
+func (g *Generator) UnmarshalYAML(value *yaml.Node) error {
+	g.Count = defaultGenerateCount
+
+	rawMap := map[string]yaml.Node{}
+	if err := value.Decode(&rawMap); err != nil {
+		return fmt.Errorf("line %v: %v", value.Line, err)
+	}
+	var seedLine int
+	for k, v := range rawMap {
+		switch k {
+		case "count":
+			if err := v.Decode(&g.Count); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			if g.Count <= 0 {
+				return fmt.Errorf("line %v: count must be greater than zero", v.Line)
+			}
+		case "seed":
+			if err := v.Decode(&g.Seed); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			seedLine = v.Line
+		case "bloblang":
+			var expr string
+			if err := v.Decode(&expr); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			exec, err := bloblang.GlobalEnvironment().NewMapping(expr)
+			if err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			g.bloblang = exec
+		case "json_schema":
+			var schemaStr string
+			if err := yamlNodeToTestString(&v, &schemaStr); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+			if err := json.Unmarshal([]byte(schemaStr), &g.jsonSchema); err != nil {
+				return fmt.Errorf("line %v: failed to parse JSON schema: %v", v.Line, err)
+			}
+			var err error
+			if g.jsonSchemaCheck, err = NewJSONSchemaCondition(schemaStr); err != nil {
+				return fmt.Errorf("line %v: %v", v.Line, err)
+			}
+		case "invariants":
+			if err := v.Decode(&g.Invariants); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("line %v: generate field not recognised: %v", v.Line, k)
+		}
+	}
+
+	if g.bloblang == nil && g.jsonSchema == nil {
+		return fmt.Errorf("line %v: either a bloblang or json_schema generator must be specified", value.Line)
+	}
+	if g.bloblang != nil && g.jsonSchema != nil {
+		return fmt.Errorf("line %v: cannot specify both a bloblang and json_schema generator", value.Line)
+	}
+	// Bloblang functions such as fake and uuid_v4 draw from sources that cannot
+	// be seeded, and therefore a seed would not reproduce the inputs.
+	if g.bloblang != nil && seedLine > 0 {
+		return fmt.Errorf("line %v: a seed cannot be specified with a bloblang generator, use functions that accept their own seed such as random_int instead", seedLine)
+	}
+	return nil
+}
+
+// generate creates the raw contents of an input message for a given index.
+func (g *Generator) generate(rng *rand.Rand, index int) ([]byte, error) {
+	if g.jsonSchema != nil {
+		v, err := genFromSchema(rng, g.jsonSchema, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate input %v from json_schema: %w", index, err)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		// Only a subset of JSON schema is understood by the generator and so we
+		// make sure that each input actually conforms to it.
+		if err := g.jsonSchemaCheck.Check(message.NewPart(b)); err != nil {
+			return nil, fmt.Errorf("generated input %v does not conform to json_schema, it may use keywords that are not supported by the generator: %w", index, err)
+		}
+		return b, nil
+	}
+	part, err := g.bloblang.MapPart(0, message.Batch{message.NewPart(nil)})
+	if err != nil {
+		return nil, fmt.Errorf("generator mapping failed for input %v: %w", index, err)
+	}
+	if part == nil {
+		return nil, fmt.Errorf("generator mapping deleted input %v", index)
+	}
+	return part.AsBytes(), nil
+}
+
+// checkFunc executes a generated input and returns a non-empty failure reason
+// if the outputs do not satisfy the invariants.
+type checkFunc func(input []byte) string
+
+// Execute the generator, feeding each input into the check function, and
+// returns a list of failure reasons each accompanied by a minimal reproducer.
+func (g *Generator) Execute(check checkFunc) ([]string, error) {
+	rng := rand.New(rand.NewSource(g.Seed))
+
+	var failures []string
+	for i := 0; i < g.Count; i++ {
+		input, err := g.generate(rng, i)
+		if err != nil {
+			return nil, err
+		}
+		reason := check(input)
+		if reason == "" {
+			continue
+		}
+		minInput, minReason := shrinkInput(input, reason, g.shrinkCheck(check))
+		failures = append(failures, fmt.Sprintf(
+			"generated input %v failed: %v\n  input: %s\n  minimal input: %s",
+			i, minReason, red(string(input)), blue(string(minInput)),
+		))
+		// Once one input has failed we usually get a flood of similar failures,
+		// so we stop here.
+		break
+	}
+	return failures, nil
+}
+
+// shrinkCheck wraps a check function so that, when inputs are generated from a
+// JSON schema, shrunk inputs that no longer conform to the schema are not
+// considered failures.
+func (g *Generator) shrinkCheck(check checkFunc) checkFunc {
+	if g.jsonSchemaCheck == nil {
+		return check
+	}
+	return func(input []byte) string {
+		if err := g.jsonSchemaCheck.Check(message.NewPart(input)); err != nil {
+			return ""
+		}
+		return check(input)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// shrinkInput attempts to reduce a failing input to a smaller one that still
+// fails, and returns the smallest failing input found along with its failure
+// reason.
+func shrinkInput(input []byte, reason string, check checkFunc) ([]byte, string) {
+	var structured any
+	if err := json.Unmarshal(input, &structured); err != nil {
+		return shrinkRaw(input, reason, check)
+	}
+
+	attempts := 0
+	current := structured
+	for attempts < maxShrinkAttempts {
+		progressed := false
+		for _, candidate := range shrinkCandidates(current) {
+			if attempts++; attempts >= maxShrinkAttempts {
+				break
+			}
+			candidateBytes, err := json.Marshal(candidate)
+			if err != nil {
+				continue
+			}
+			if r := check(candidateBytes); r != "" {
+				current, reason, progressed = candidate, r, true
+				break
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	minBytes, err := json.Marshal(current)
+	if err != nil {
+		return input, reason
+	}
+	return minBytes, reason
+}
+
+func shrinkRaw(input []byte, reason string, check checkFunc) ([]byte, string) {
+	current := input
+	for attempts := 0; attempts < maxShrinkAttempts && len(current) > 0; attempts++ {
+		progressed := false
+		for _, candidate := range [][]byte{
+			current[:len(current)/2],
+			current[len(current)/2:],
+			current[:len(current)-1],
+			current[1:],
+		} {
+			if len(candidate) >= len(current) {
+				continue
+			}
+			if r := check(candidate); r != "" {
+				current, reason, progressed = candidate, r, true
+				break
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return current, reason
+}
+
+// shrinkCandidates returns a list of values that are simpler than the provided
+// value, ordered roughly from most to least aggressive.
+func shrinkCandidates(v any) []any {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var candidates []any
+		if len(t) > 0 {
+			candidates = append(candidates, map[string]any{})
+		}
+		for _, k := range keys {
+			without := make(map[string]any, len(t)-1)
+			for k2, v2 := range t {
+				if k2 != k {
+					without[k2] = v2
+				}
+			}
+			candidates = append(candidates, without)
+		}
+		for _, k := range keys {
+			for _, c := range shrinkCandidates(t[k]) {
+				replaced := make(map[string]any, len(t))
+				for k2, v2 := range t {
+					replaced[k2] = v2
+				}
+				replaced[k] = c
+				candidates = append(candidates, replaced)
+			}
+		}
+		return candidates
+	case []any:
+		var candidates []any
+		if len(t) > 0 {
+			candidates = append(candidates, []any{})
+		}
+		if len(t) > 1 {
+			candidates = append(candidates, append([]any{}, t[:len(t)/2]...), append([]any{}, t[len(t)/2:]...))
+		}
+		for i := range t {
+			without := make([]any, 0, len(t)-1)
+			without = append(without, t[:i]...)
+			without = append(without, t[i+1:]...)
+			candidates = append(candidates, without)
+		}
+		for i := range t {
+			for _, c := range shrinkCandidates(t[i]) {
+				replaced := append([]any{}, t...)
+				replaced[i] = c
+				candidates = append(candidates, replaced)
+			}
+		}
+		return candidates
+	case string:
+		if t == "" {
+			return nil
+		}
+		candidates := []any{""}
+		if r := []rune(t); len(r) > 1 {
+			candidates = append(candidates, string(r[:len(r)/2]), string(r[:len(r)-1]))
+		}
+		return candidates
+	case float64:
+		if t == 0 {
+			return nil
+		}
+		candidates := []any{float64(0)}
+		if half := math.Trunc(t / 2); half != t && half != 0 {
+			candidates = append(candidates, half)
+		}
+		if t != math.Trunc(t) {
+			candidates = append(candidates, math.Trunc(t))
+		}
+		return candidates
+	case bool:
+		if t {
+			return []any{false}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+const maxGenerateDepth = 8
+
+// genFromSchema generates a random value that conforms to a subset of the JSON
+// Schema specification.
+func genFromSchema(rng *rand.Rand, schema map[string]any, depth int) (any, error) {
+	if c, exists := schema["const"]; exists {
+		return c, nil
+	}
+	if e, ok := schema["enum"].([]any); ok && len(e) > 0 {
+		return e[rng.Intn(len(e))], nil
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if opts, ok := schema[k].([]any); ok && len(opts) > 0 {
+			if sub, ok := opts[rng.Intn(len(opts))].(map[string]any); ok {
+				return genFromSchema(rng, sub, depth+1)
+			}
+		}
+	}
+
+	var typeStr string
+	switch t := schema["type"].(type) {
+	case string:
+		typeStr = t
+	case []any:
+		if len(t) > 0 {
+			typeStr, _ = t[rng.Intn(len(t))].(string)
+		}
+	default:
+		if _, exists := schema["properties"]; exists {
+			typeStr = "object"
+		} else if _, exists := schema["items"]; exists {
+			typeStr = "array"
+		}
+	}
+
+	switch typeStr {
+	case "object":
+		obj := map[string]any{}
+		required := map[string]struct{}{}
+		if r, ok := schema["required"].([]any); ok {
+			for _, k := range r {
+				if kStr, ok := k.(string); ok {
+					required[kStr] = struct{}{}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_, isRequired := required[k]
+			if !isRequired && (depth >= maxGenerateDepth || rng.Intn(2) == 0) {
+				continue
+			}
+			sub, _ := props[k].(map[string]any)
+			var err error
+			if obj[k], err = genFromSchema(rng, sub, depth+1); err != nil {
+				return nil, fmt.Errorf("property %v: %w", k, err)
+			}
+		}
+		return obj, nil
+	case "array":
+		minItems, maxItems := schemaInt(schema, "minItems", 0), schemaInt(schema, "maxItems", -1)
+		if maxItems == -1 {
+			if maxItems = 5; maxItems < minItems {
+				maxItems = minItems
+			}
+		}
+		if maxItems < minItems {
+			return nil, fmt.Errorf("maxItems %v is less than minItems %v", maxItems, minItems)
+		}
+		if depth >= maxGenerateDepth {
+			maxItems = minItems
+		}
+		items, _ := schema["items"].(map[string]any)
+		arr := make([]any, minItems+rng.Intn(maxItems-minItems+1))
+		for i := range arr {
+			var err error
+			if arr[i], err = genFromSchema(rng, items, depth+1); err != nil {
+				return nil, fmt.Errorf("items: %w", err)
+			}
+		}
+		return arr, nil
+	case "string":
+		minLen, maxLen := schemaInt(schema, "minLength", 0), schemaInt(schema, "maxLength", -1)
+		if maxLen == -1 {
+			if maxLen = 20; maxLen < minLen {
+				maxLen = minLen
+			}
+		}
+		if maxLen < minLen {
+			return nil, fmt.Errorf("maxLength %v is less than minLength %v", maxLen, minLen)
+		}
+		return randomString(rng, minLen+rng.Intn(maxLen-minLen+1)), nil
+	case "integer":
+		minV, maxV, err := schemaRange(schema)
+		if err != nil {
+			return nil, err
+		}
+		minI, maxI := math.Ceil(minV), math.Floor(maxV)
+		if minI > maxI {
+			return nil, fmt.Errorf("no integers exist between minimum %v and maximum %v", minV, maxV)
+		}
+		if maxI-minI >= math.MaxInt64 {
+			return nil, fmt.Errorf("range between minimum %v and maximum %v is too large", minV, maxV)
+		}
+		return minI + float64(rng.Int63n(int64(maxI-minI)+1)), nil
+	case "number":
+		minV, maxV, err := schemaRange(schema)
+		if err != nil {
+			return nil, err
+		}
+		return minV + rng.Float64()*(maxV-minV), nil
+	case "boolean":
+		return rng.Intn(2) == 0, nil
+	case "null":
+		return nil, nil
+	}
+
+	// With no type constraints we pick a random scalar.
+	switch rng.Intn(4) {
+	case 0:
+		return randomString(rng, rng.Intn(20)), nil
+	case 1:
+		return float64(rng.Intn(2000) - 1000), nil
+	case 2:
+		return rng.Intn(2) == 0, nil
+	}
+	return nil, nil
+}
+
+func schemaInt(schema map[string]any, key string, def int) int {
+	if f, ok := schema[key].(float64); ok && f >= 0 {
+		return int(f)
+	}
+	return def
+}
+
+// schemaRange returns the minimum and maximum of a numeric schema, where a
+// missing bound defaults to a distance of 1000 from the other.
+func schemaRange(schema map[string]any) (minV, maxV float64, err error) {
+	minV, hasMin := schema["minimum"].(float64)
+	maxV, hasMax := schema["maximum"].(float64)
+	switch {
+	case !hasMin && !hasMax:
+		minV, maxV = -1000, 1000
+	case !hasMin:
+		minV = math.Min(-1000, maxV-1000)
+	case !hasMax:
+		maxV = math.Max(1000, minV+1000)
+	}
+	if maxV < minV {
+		return 0, 0, fmt.Errorf("maximum %v is less than minimum %v", maxV, minV)
+	}
+	return minV, maxV, nil
+}
+
+const randomStringChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-.\"\\\né世"
+
+func randomString(rng *rand.Rand, length int) string {
+	chars := []rune(randomStringChars)
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		b.WriteRune(chars[rng.Intn(len(chars))])
+	}
+	return b.String()
+}
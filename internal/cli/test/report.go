@@ -0,0 +1,316 @@
+package test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportFormats lists the supported machine readable test report formats.
+var ReportFormats = []string{"junit", "json", "tap"}
+
+// WriteReport writes a machine readable report of test results in the given
+// format to a writer.
+func WriteReport(w io.Writer, format string, results []TargetResult) error {
+	switch format {
+	case "junit":
+		return writeJUnitReport(w, results)
+	case "json":
+		return writeJSONReport(w, results)
+	case "tap":
+		return writeTAPReport(w, results)
+	}
+	return fmt.Errorf("report format not recognised: %v", format)
+}
+
+var ansiColorRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripColors removes terminal color codes from failure reasons.
+func stripColors(s string) string {
+	return ansiColorRe.ReplaceAllString(s, "")
+}
+
+// failureLine returns the most specific line of a case failure.
+func failureLine(f CaseFailure) int {
+	if f.ConditionLine > 0 {
+		return f.ConditionLine
+	}
+	return f.TestLine
+}
+
+func failureLocation(file string, f CaseFailure) string {
+	return fmt.Sprintf("%v:%v", file, failureLine(f))
+}
+
+//------------------------------------------------------------------------------
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	File     string          `xml:"file,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func writeJUnitReport(w io.Writer, results []TargetResult) error {
+	suites := junitTestSuites{}
+
+	var total time.Duration
+	for _, res := range results {
+		suite := junitTestSuite{
+			Name: res.Target,
+			Time: junitSeconds(res.Duration),
+			File: res.DefinitionPath,
+		}
+		if len(res.Lints) > 0 {
+			var lintStrs []string
+			for _, l := range res.Lints {
+				lintStrs = append(lintStrs, fmt.Sprintf("%v:%v: %v", res.Target, l.Line, l.What))
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "lint",
+				Classname: res.Target,
+				File:      res.Target,
+				Line:      res.Lints[0].Line,
+				Time:      junitSeconds(0),
+				Failure: &junitFailure{
+					Message: res.Lints[0].What,
+					Type:    "lint",
+					Content: strings.Join(lintStrs, "\n"),
+				},
+			})
+		}
+		for _, c := range res.Cases {
+			tc := junitTestCase{
+				Name:      c.Name,
+				Classname: res.Target,
+				File:      res.DefinitionPath,
+				Line:      c.TestLine,
+				Time:      junitSeconds(c.Duration),
+			}
+			if len(c.Failures) > 0 {
+				var reasons []string
+				for _, f := range c.Failures {
+					reasons = append(reasons, fmt.Sprintf("%v: %v", failureLocation(res.DefinitionPath, f), stripColors(f.Reason)))
+				}
+				tc.Line = failureLine(c.Failures[0])
+				tc.Failure = &junitFailure{
+					Message: strings.SplitN(stripColors(c.Failures[0].Reason), "\n", 2)[0],
+					Type:    "failure",
+					Content: strings.Join(reasons, "\n\n"),
+				}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		for _, tc := range suite.Cases {
+			suite.Tests++
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+		total += res.Duration
+	}
+	suites.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type jsonReport struct {
+	Passed  bool               `json:"passed"`
+	Targets []jsonReportTarget `json:"targets"`
+}
+
+type jsonReportTarget struct {
+	Target     string           `json:"target"`
+	Definition string           `json:"definition"`
+	Passed     bool             `json:"passed"`
+	DurationMS int64            `json:"duration_ms"`
+	Lints      []jsonReportLint `json:"lints"`
+	Cases      []jsonReportCase `json:"cases"`
+}
+
+type jsonReportLint struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	What   string `json:"what"`
+}
+
+type jsonReportCase struct {
+	Name       string             `json:"name"`
+	Line       int                `json:"line"`
+	Passed     bool               `json:"passed"`
+	DurationMS int64              `json:"duration_ms"`
+	Failures   []jsonReportFailed `json:"failures"`
+}
+
+type jsonReportFailed struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+func writeJSONReport(w io.Writer, results []TargetResult) error {
+	report := jsonReport{
+		Passed:  len(results) > 0,
+		Targets: []jsonReportTarget{},
+	}
+	for _, res := range results {
+		t := jsonReportTarget{
+			Target:     res.Target,
+			Definition: res.DefinitionPath,
+			Passed:     !res.Failed(),
+			DurationMS: res.Duration.Milliseconds(),
+			Lints:      []jsonReportLint{},
+			Cases:      []jsonReportCase{},
+		}
+		for _, l := range res.Lints {
+			t.Lints = append(t.Lints, jsonReportLint{
+				Line:   l.Line,
+				Column: l.Column,
+				What:   l.What,
+			})
+		}
+		for _, c := range res.Cases {
+			jc := jsonReportCase{
+				Name:       c.Name,
+				Line:       c.TestLine,
+				Passed:     len(c.Failures) == 0,
+				DurationMS: c.Duration.Milliseconds(),
+				Failures:   []jsonReportFailed{},
+			}
+			for _, f := range c.Failures {
+				jc.Failures = append(jc.Failures, jsonReportFailed{
+					File:   res.DefinitionPath,
+					Line:   failureLine(f),
+					Reason: stripColors(f.Reason),
+				})
+			}
+			t.Cases = append(t.Cases, jc)
+		}
+		if !t.Passed {
+			report.Passed = false
+		}
+		report.Targets = append(report.Targets, t)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+//------------------------------------------------------------------------------
+
+func writeTAPReport(w io.Writer, results []TargetResult) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+
+	total := 0
+	for _, res := range results {
+		if len(res.Lints) > 0 {
+			total++
+		}
+		total += len(res.Cases)
+	}
+	fmt.Fprintf(&b, "1..%v\n", total)
+
+	writeDiagnostics := func(fields [][2]string) {
+		b.WriteString("  ---\n")
+		for _, f := range fields {
+			if strings.Contains(f[1], "\n") {
+				fmt.Fprintf(&b, "  %v: |\n", f[0])
+				for _, l := range strings.Split(f[1], "\n") {
+					fmt.Fprintf(&b, "    %v\n", l)
+				}
+			} else if _, err := strconv.Atoi(f[1]); err == nil {
+				fmt.Fprintf(&b, "  %v: %v\n", f[0], f[1])
+			} else {
+				fmt.Fprintf(&b, "  %v: %q\n", f[0], f[1])
+			}
+		}
+		b.WriteString("  ...\n")
+	}
+
+	n := 0
+	for _, res := range results {
+		if len(res.Lints) > 0 {
+			n++
+			fmt.Fprintf(&b, "not ok %v - %v lint\n", n, res.Target)
+			var lintStrs []string
+			for _, l := range res.Lints {
+				lintStrs = append(lintStrs, fmt.Sprintf("%v:%v: %v", res.Target, l.Line, l.What))
+			}
+			writeDiagnostics([][2]string{
+				{"message", strings.Join(lintStrs, "\n")},
+				{"file", res.Target},
+				{"line", fmt.Sprintf("%v", res.Lints[0].Line)},
+			})
+		}
+		for _, c := range res.Cases {
+			n++
+			if len(c.Failures) == 0 {
+				fmt.Fprintf(&b, "ok %v - %v %v # time=%vms\n", n, res.Target, c.Name, c.Duration.Milliseconds())
+				continue
+			}
+			fmt.Fprintf(&b, "not ok %v - %v %v # time=%vms\n", n, res.Target, c.Name, c.Duration.Milliseconds())
+			var reasons []string
+			for _, f := range c.Failures {
+				reasons = append(reasons, fmt.Sprintf("%v: %v", failureLocation(res.DefinitionPath, f), stripColors(f.Reason)))
+			}
+			writeDiagnostics([][2]string{
+				{"message", strings.Join(reasons, "\n")},
+				{"file", res.DefinitionPath},
+				{"line", fmt.Sprintf("%v", failureLine(c.Failures[0]))},
+			})
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
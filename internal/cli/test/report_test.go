@@ -0,0 +1,139 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func testReportResults() []TargetResult {
+	return []TargetResult{
+		{
+			Target:         "foo.yaml",
+			DefinitionPath: "foo_benthos_test.yaml",
+			Duration:       time.Millisecond * 30,
+			Cases: []CaseResult{
+				{
+					Name:     "passes",
+					TestLine: 2,
+					Duration: time.Millisecond * 10,
+				},
+				{
+					Name:     "fails",
+					TestLine: 10,
+					Duration: time.Millisecond * 20,
+					Failures: []CaseFailure{
+						{
+							Name:          "fails",
+							TestLine:      10,
+							Reason:        "batch 0 message 0: content_equals: content mismatch\n  expected: \x1b[34mfoo\x1b[0m\n  received: \x1b[31mbar\x1b[0m",
+							ConditionLine: 16,
+						},
+					},
+				},
+			},
+		},
+		{
+			Target:         "bar.yaml",
+			DefinitionPath: "bar.yaml",
+			Lints: []docs.Lint{
+				docs.NewLintError(5, docs.LintUnknown, "field nope not recognised"),
+			},
+		},
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, "json", testReportResults()))
+
+	assert.JSONEq(t, `{
+  "passed": false,
+  "targets": [
+    {
+      "target": "foo.yaml",
+      "definition": "foo_benthos_test.yaml",
+      "passed": false,
+      "duration_ms": 30,
+      "lints": [],
+      "cases": [
+        { "name": "passes", "line": 2, "passed": true, "duration_ms": 10, "failures": [] },
+        {
+          "name": "fails", "line": 10, "passed": false, "duration_ms": 20,
+          "failures": [
+            {
+              "file": "foo_benthos_test.yaml",
+              "line": 16,
+              "reason": "batch 0 message 0: content_equals: content mismatch\n  expected: foo\n  received: bar"
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "target": "bar.yaml",
+      "definition": "bar.yaml",
+      "passed": false,
+      "duration_ms": 0,
+      "lints": [ { "line": 5, "column": 1, "what": "field nope not recognised" } ],
+      "cases": []
+    }
+  ]
+}`, buf.String())
+}
+
+func TestReportJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, "junit", testReportResults()))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2" time="0.030">
+  <testsuite name="foo.yaml" tests="2" failures="1" time="0.030" file="foo_benthos_test.yaml">
+    <testcase name="passes" classname="foo.yaml" file="foo_benthos_test.yaml" line="2" time="0.010"></testcase>
+    <testcase name="fails" classname="foo.yaml" file="foo_benthos_test.yaml" line="16" time="0.020">
+      <failure message="batch 0 message 0: content_equals: content mismatch" type="failure">foo_benthos_test.yaml:16: batch 0 message 0: content_equals: content mismatch&#xA;  expected: foo&#xA;  received: bar</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="bar.yaml" tests="1" failures="1" time="0.000" file="bar.yaml">
+    <testcase name="lint" classname="bar.yaml" file="bar.yaml" line="5" time="0.000">
+      <failure message="field nope not recognised" type="lint">bar.yaml:5: field nope not recognised</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestReportTAP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, "tap", testReportResults()))
+
+	assert.Equal(t, `TAP version 13
+1..3
+ok 1 - foo.yaml passes # time=10ms
+not ok 2 - foo.yaml fails # time=20ms
+  ---
+  message: |
+    foo_benthos_test.yaml:16: batch 0 message 0: content_equals: content mismatch
+      expected: foo
+      received: bar
+  file: "foo_benthos_test.yaml"
+  line: 16
+  ...
+not ok 3 - bar.yaml lint
+  ---
+  message: "bar.yaml:5: field nope not recognised"
+  file: "bar.yaml"
+  line: 5
+  ...
+`, buf.String())
+}
+
+func TestReportUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	require.EqualError(t, WriteReport(&buf, "nope", nil), "report format not recognised: nope")
+}
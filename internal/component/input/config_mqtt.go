@@ -8,6 +8,7 @@ import (
 // MQTTConfig contains configuration fields for the MQTT input type.
 type MQTTConfig struct {
 	URLs                  []string      `json:"urls" yaml:"urls"`
+	ProtocolVersion       string        `json:"protocol_version" yaml:"protocol_version"`
 	QoS                   uint8         `json:"qos" yaml:"qos"`
 	Topics                []string      `json:"topics" yaml:"topics"`
 	ClientID              string        `json:"client_id" yaml:"client_id"`
@@ -18,22 +19,24 @@ type MQTTConfig struct {
 	Password              string        `json:"password" yaml:"password"`
 	ConnectTimeout        string        `json:"connect_timeout" yaml:"connect_timeout"`
 	KeepAlive             int64         `json:"keepalive" yaml:"keepalive"`
+	TopicAliasMaximum     uint16        `json:"topic_alias_maximum" yaml:"topic_alias_maximum"`
 	TLS                   tls.Config    `json:"tls" yaml:"tls"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
 func NewMQTTConfig() MQTTConfig {
 	return MQTTConfig{
-		URLs:           []string{},
-		QoS:            1,
-		Topics:         []string{},
-		ClientID:       "",
-		Will:           mqttconf.EmptyWill(),
-		CleanSession:   true,
-		User:           "",
-		Password:       "",
-		ConnectTimeout: "30s",
-		KeepAlive:      30,
-		TLS:            tls.NewConfig(),
+		URLs:            []string{},
+		ProtocolVersion: "3.1.1",
+		QoS:             1,
+		Topics:          []string{},
+		ClientID:        "",
+		Will:            mqttconf.EmptyWill(),
+		CleanSession:    true,
+		User:            "",
+		Password:        "",
+		ConnectTimeout:  "30s",
+		KeepAlive:       30,
+		TLS:             tls.NewConfig(),
 	}
 }
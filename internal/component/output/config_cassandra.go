@@ -25,6 +25,10 @@ type CassandraConfig struct {
 	Consistency              string                `json:"consistency" yaml:"consistency"`
 	Timeout                  string                `json:"timeout" yaml:"timeout"`
 	LoggedBatch              bool                  `json:"logged_batch" yaml:"logged_batch"`
+	IfNotExists              bool                  `json:"if_not_exists" yaml:"if_not_exists"`
+	FailNotApplied           bool                  `json:"fail_not_applied" yaml:"fail_not_applied"`
+	TTLMapping               string                `json:"ttl_mapping" yaml:"ttl_mapping"`
+	TimestampMapping         string                `json:"timestamp_mapping" yaml:"timestamp_mapping"`
 	// TODO: V4 Remove this and replace with explicit values.
 	retries.Config `json:",inline" yaml:",inline"`
 	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
@@ -56,5 +60,9 @@ func NewCassandraConfig() CassandraConfig {
 		MaxInFlight:              64,
 		Batching:                 batchconfig.NewConfig(),
 		LoggedBatch:              true,
+		IfNotExists:              false,
+		FailNotApplied:           false,
+		TTLMapping:               "",
+		TimestampMapping:         "",
 	}
 }
@@ -58,6 +58,40 @@ func LintBytes(opts LintOptions, rawBytes []byte) ([]docs.Lint, error) {
 	return Spec().LintYAML(lintCtx, &rawNode), nil
 }
 
+// MigrateBytes attempts to rewrite any components within a user config that
+// have migration rules, usually because they are deprecated. Returns the
+// rewritten config, or nil if no components were migrated, along with a list of
+// the attempted migrations.
+func MigrateBytes(rawBytes []byte) ([]byte, []docs.MigratedComponent, error) {
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return nil, nil, nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return nil, nil, err
+	}
+
+	migrated, err := Spec().MigrateYAML(&rawNode, docs.DeprecatedProvider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var applied bool
+	for _, m := range migrated {
+		applied = applied || m.Err == nil
+	}
+	if !applied || len(rawNode.Content) == 0 {
+		return nil, migrated, nil
+	}
+
+	newBytes, err := MarshalYAML(*rawNode.Content[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return newBytes, migrated, nil
+}
+
 // ReadFileEnvSwap reads a file and replaces any environment variable
 // interpolations before returning the contents. Linting errors are returned if
 // the file has an unexpected higher level format, such as invalid utf-8
@@ -103,4 +103,9 @@ type ComponentSpec struct {
 
 	// Version is the Benthos version this component was introduced.
 	Version string `json:"version,omitempty"`
+
+	// Migrations are optional rules for automatically rewriting configs of
+	// this component into configs of alternative components, which are
+	// attempted in order until one of them succeeds.
+	Migrations []ComponentMigration `json:"migrations,omitempty"`
 }
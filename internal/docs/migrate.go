@@ -0,0 +1,255 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+// ComponentMigration describes a rule for automatically rewriting the config of
+// a component into the config of an alternative component, which is usually
+// done in order to move away from deprecated components.
+type ComponentMigration struct {
+	// To is the name of the component that configs are migrated to.
+	To string `json:"to"`
+
+	// Mapping is a Bloblang mapping that is provided the config of the
+	// component as `this`, and should assign the equivalent config of the
+	// component being migrated to as `root`. The mapping may throw an error in
+	// order to indicate that a config cannot be migrated automatically.
+	Mapping string `json:"mapping"`
+}
+
+func (m *ComponentMigration) apply(conf any) (any, error) {
+	exec, err := bloblang.NewEnvironment().OnlyPure().Parse(m.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("migration mapping failed to parse: %w", err)
+	}
+	res, err := exec.Query(conf)
+	if err != nil {
+		if errors.Is(err, bloblang.ErrRootDeleted) {
+			return nil, errors.New("migration mapping deleted the config")
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// MigratedComponent describes a component within a config that was rewritten
+// by a migration rule, or failed to be.
+type MigratedComponent struct {
+	Line          int
+	ComponentType Type
+	From          string
+	To            string
+	Err           error
+}
+
+// maxMigrationPasses is the maximum number of times a config is walked when
+// applying migrations, migrated components are not walked until the next pass
+// and therefore this also limits the depth of nested migrations.
+const maxMigrationPasses = 10
+
+// MigrateYAML walks a YAML config tree and rewrites, in place, any components
+// that have a migration rule. Comments and the ordering of fields that are
+// carried over to the migrated config are preserved. Returns a list of the
+// components that were migrated, or that had a migration rule that failed.
+func (f FieldSpecs) MigrateYAML(node *yaml.Node, prov Provider) ([]MigratedComponent, error) {
+	var migrated []MigratedComponent
+	failed := map[*yaml.Node]struct{}{}
+	for i := 0; i < maxMigrationPasses; i++ {
+		var passMigrated []MigratedComponent
+		if err := f.WalkYAML(node, prov, func(c WalkedYAMLComponent) error {
+			if _, exists := failed[c.Conf]; exists {
+				return nil
+			}
+			spec, exists := prov.GetDocs(c.Name, c.ComponentType)
+			if !exists || len(spec.Migrations) == 0 {
+				return nil
+			}
+			res := MigratedComponent{
+				Line:          c.Conf.Line,
+				ComponentType: c.ComponentType,
+				From:          c.Name,
+			}
+			if res.To, res.Err = migrateComponentYAML(c.Conf, c.Name, spec.Migrations); res.Err != nil {
+				failed[c.Conf] = struct{}{}
+			}
+			passMigrated = append(passMigrated, res)
+			return nil
+		}); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, passMigrated...)
+
+		var applied bool
+		for _, m := range passMigrated {
+			applied = applied || m.Err == nil
+		}
+		if !applied {
+			break
+		}
+	}
+	return migrated, nil
+}
+
+// migrateComponentYAML applies the first of a list of migrations that succeeds
+// to a component, and returns the name of the component it was migrated to. If
+// all migrations fail the names of all targets and their errors are returned.
+func migrateComponentYAML(node *yaml.Node, name string, migrations []ComponentMigration) (string, error) {
+	var keyNode, confNode, typeNode *yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case name:
+			keyNode, confNode = node.Content[i], node.Content[i+1]
+		case "type":
+			typeNode = node.Content[i+1]
+		}
+	}
+
+	var conf any = map[string]any{}
+	if confNode != nil {
+		if err := confNode.Decode(&conf); err != nil {
+			return "", err
+		}
+	}
+
+	var migration *ComponentMigration
+	var newConf any
+	targets := make([]string, 0, len(migrations))
+	errs := make([]string, 0, len(migrations))
+	for i := range migrations {
+		res, err := migrations[i].apply(conf)
+		if err != nil {
+			if len(migrations) == 1 {
+				return migrations[i].To, err
+			}
+			targets = append(targets, migrations[i].To)
+			errs = append(errs, fmt.Sprintf("%v: %v", migrations[i].To, err))
+			continue
+		}
+		migration, newConf = &migrations[i], res
+		break
+	}
+	if migration == nil {
+		return strings.Join(targets, " or "), errors.New(strings.Join(errs, "; "))
+	}
+
+	var newConfNode yaml.Node
+	if err := newConfNode.Encode(newConf); err != nil {
+		return migration.To, err
+	}
+	if confNode != nil {
+		preserveYAMLFormatting(confNode, &newConfNode)
+	}
+
+	if typeNode != nil {
+		typeNode.Value = migration.To
+	}
+	if keyNode != nil {
+		keyNode.Value = migration.To
+		*confNode = newConfNode
+	} else {
+		node.Content = append(node.Content, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: migration.To,
+		}, &newConfNode)
+	}
+	return migration.To, nil
+}
+
+// preserveYAMLFormatting carries comments, scalar styles and the ordering of
+// mapping keys from an original node into a replacement node wherever they
+// share the same structure.
+func preserveYAMLFormatting(from, to *yaml.Node) {
+	if from.Kind != to.Kind {
+		return
+	}
+
+	to.HeadComment = from.HeadComment
+	to.LineComment = from.LineComment
+	to.FootComment = from.FootComment
+
+	switch to.Kind {
+	case yaml.ScalarNode:
+		if from.Value == to.Value {
+			to.Style = from.Style
+			to.Tag = from.Tag
+		}
+	case yaml.SequenceNode:
+		to.Style = from.Style
+		for i := 0; i < len(to.Content) && i < len(from.Content); i++ {
+			preserveYAMLFormatting(from.Content[i], to.Content[i])
+		}
+	case yaml.MappingNode:
+		to.Style = from.Style
+
+		fromIndex := map[string]int{}
+		for i := 0; i < len(from.Content)-1; i += 2 {
+			fromIndex[from.Content[i].Value] = i
+		}
+
+		type kvPair struct {
+			key, value *yaml.Node
+			order      int
+		}
+		toKeys := map[string]struct{}{}
+		for i := 0; i < len(to.Content)-1; i += 2 {
+			toKeys[to.Content[i].Value] = struct{}{}
+		}
+
+		// Fields that were renamed by the migration are matched against
+		// removed fields that have an identical value.
+		renamedFrom := func(value *yaml.Node) (int, bool) {
+			var toValue any
+			if err := value.Decode(&toValue); err != nil {
+				return 0, false
+			}
+			for j := 0; j < len(from.Content)-1; j += 2 {
+				if _, exists := toKeys[from.Content[j].Value]; exists {
+					continue
+				}
+				var fromValue any
+				if err := from.Content[j+1].Decode(&fromValue); err != nil {
+					continue
+				}
+				if reflect.DeepEqual(fromValue, toValue) {
+					return j, true
+				}
+			}
+			return 0, false
+		}
+
+		pairs := make([]kvPair, 0, len(to.Content)/2)
+		for i := 0; i < len(to.Content)-1; i += 2 {
+			p := kvPair{key: to.Content[i], value: to.Content[i+1], order: len(from.Content) + i}
+			j, exists := fromIndex[p.key.Value]
+			if !exists {
+				j, exists = renamedFrom(p.value)
+			}
+			if exists {
+				p.order = j
+				p.key.HeadComment = from.Content[j].HeadComment
+				p.key.LineComment = from.Content[j].LineComment
+				p.key.FootComment = from.Content[j].FootComment
+				preserveYAMLFormatting(from.Content[j+1], p.value)
+			}
+			pairs = append(pairs, p)
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i].order < pairs[j].order
+		})
+
+		to.Content = to.Content[:0]
+		for _, p := range pairs {
+			to.Content = append(to.Content, p.key, p.value)
+		}
+	}
+}
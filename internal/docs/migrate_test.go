@@ -0,0 +1,209 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestMigrateYAML(t *testing.T) {
+	docsProv := docs.NewMappedDocsProvider()
+	docsProv.RegisterDocs(docs.ComponentSpec{
+		Name: "oldfoo",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("addresses", "").Array(),
+			docs.FieldString("topic", ""),
+			docs.FieldBool("strict", ""),
+		),
+		Status: docs.StatusDeprecated,
+		Migrations: []docs.ComponentMigration{
+			{
+				To: "newfoo",
+				Mapping: `
+let _ = if this.strict.or(false) { throw("strict mode is not supported") }
+root.topic = this.topic
+root.seed_brokers = this.addresses
+`,
+			},
+		},
+	})
+	docsProv.RegisterDocs(docs.ComponentSpec{
+		Name: "newfoo",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("seed_brokers", "").Array(),
+			docs.FieldString("topic", ""),
+		),
+	})
+	docsProv.RegisterDocs(docs.ComponentSpec{
+		Name: "oldbar",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("topic", ""),
+			docs.FieldBool("strict", ""),
+		),
+		Status: docs.StatusDeprecated,
+		Migrations: []docs.ComponentMigration{
+			{
+				To: "newfoo",
+				Mapping: `
+let _ = if this.strict.or(false) { throw("strict mode is not supported") }
+root.topic = this.topic
+`,
+			},
+			{
+				To: "newbar",
+				Mapping: `
+let _ = if this.topic.or("") == "" { throw("a topic is required") }
+root.strict_topic = this.topic
+`,
+			},
+		},
+	})
+	docsProv.RegisterDocs(docs.ComponentSpec{
+		Name: "newbar",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("strict_topic", ""),
+		),
+	})
+	docsProv.RegisterDocs(docs.ComponentSpec{
+		Name: "broker",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldInput("inputs", "").Array(),
+		),
+	})
+
+	fields := docs.FieldSpecs{
+		docs.FieldInput("input", ""),
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		output   string
+		migrated []docs.MigratedComponent
+	}{
+		{
+			name: "no migrations",
+			input: `input:
+  newfoo:
+    topic: meow
+`,
+			output: `input:
+    newfoo:
+        topic: meow
+`,
+		},
+		{
+			name: "simple migration with comments",
+			input: `input:
+  # The old one
+  oldfoo:
+    topic: meow # the topic
+    addresses: [ "localhost:9092" ] # the brokers
+`,
+			output: `input:
+    # The old one
+    newfoo:
+        topic: meow # the topic
+        seed_brokers: ["localhost:9092"] # the brokers
+`,
+			migrated: []docs.MigratedComponent{
+				{Line: 3, ComponentType: docs.TypeInput, From: "oldfoo", To: "newfoo"},
+			},
+		},
+		{
+			name: "nested migration with failure",
+			input: `input:
+  broker:
+    inputs:
+      - oldfoo:
+          topic: first
+          addresses: [ a ]
+      - type: oldfoo
+        oldfoo:
+          topic: second
+          strict: true
+`,
+			output: `input:
+    broker:
+        inputs:
+            - newfoo:
+                topic: first
+                seed_brokers: [a]
+            - type: oldfoo
+              oldfoo:
+                topic: second
+                strict: true
+`,
+			migrated: []docs.MigratedComponent{
+				{Line: 4, ComponentType: docs.TypeInput, From: "oldfoo", To: "newfoo"},
+				{Line: 7, ComponentType: docs.TypeInput, From: "oldfoo", To: "newfoo", Err: assert.AnError},
+			},
+		},
+		{
+			name: "multiple migration rules",
+			input: `input:
+  broker:
+    inputs:
+      - oldbar:
+          topic: first
+      - oldbar:
+          topic: second
+          strict: true
+      - oldbar:
+          strict: true
+`,
+			output: `input:
+    broker:
+        inputs:
+            - newfoo:
+                topic: first
+            - newbar:
+                strict_topic: second
+            - oldbar:
+                strict: true
+`,
+			migrated: []docs.MigratedComponent{
+				{Line: 4, ComponentType: docs.TypeInput, From: "oldbar", To: "newfoo"},
+				{Line: 6, ComponentType: docs.TypeInput, From: "oldbar", To: "newbar"},
+				{Line: 9, ComponentType: docs.TypeInput, From: "oldbar", To: "newfoo or newbar", Err: assert.AnError},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(test.input), &node))
+
+			migrated, err := fields.MigrateYAML(&node, docsProv)
+			require.NoError(t, err)
+
+			require.Len(t, migrated, len(test.migrated))
+			for i, exp := range test.migrated {
+				assert.Equal(t, exp.Line, migrated[i].Line, i)
+				assert.Equal(t, exp.ComponentType, migrated[i].ComponentType, i)
+				assert.Equal(t, exp.From, migrated[i].From, i)
+				assert.Equal(t, exp.To, migrated[i].To, i)
+				if exp.Err != nil {
+					assert.Error(t, migrated[i].Err, i)
+				} else {
+					assert.NoError(t, migrated[i].Err, i)
+				}
+			}
+
+			resBytes, err := yaml.Marshal(&node)
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(resBytes))
+		})
+	}
+}
@@ -2,6 +2,8 @@ package docs
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -484,7 +486,15 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 	}
 
 	if ctx.RejectDeprecated && cSpec.Status == StatusDeprecated {
-		lints = append(lints, NewLintError(node.Line, LintDeprecated, fmt.Sprintf("component %v is deprecated", cSpec.Name)))
+		if len(cSpec.Migrations) > 0 {
+			targets := make([]string, 0, len(cSpec.Migrations))
+			for _, m := range cSpec.Migrations {
+				targets = append(targets, m.To)
+			}
+			lints = append(lints, NewLintError(node.Line, LintDeprecated, fmt.Sprintf("component %v is deprecated, it can be migrated to %v automatically with --fix", cSpec.Name, strings.Join(targets, " or "))))
+		} else {
+			lints = append(lints, NewLintError(node.Line, LintDeprecated, fmt.Sprintf("component %v is deprecated", cSpec.Name)))
+		}
 	}
 
 	nameFound := false
@@ -884,7 +894,7 @@ func (f FieldSpecs) YAMLToMap(node *yaml.Node, conf ToValueConfig) (map[string]a
 
 //------------------------------------------------------------------------------
 
-func walkComponentsYAML(cType Type, node *yaml.Node, prov Provider, fn ComponentWalkYAMLFunc) error {
+func walkComponentsYAML(cType Type, node *yaml.Node, prov Provider, path []string, fn ComponentWalkYAMLFunc) error {
 	node = unwrapDocumentNode(node)
 
 	name, spec, err := GetInferenceCandidateFromYAML(prov, cType, node)
@@ -904,6 +914,7 @@ func walkComponentsYAML(cType Type, node *yaml.Node, prov Provider, fn Component
 		ComponentType: cType,
 		Name:          name,
 		Label:         label,
+		Path:          path,
 		Conf:          node,
 	}); err != nil {
 		return err
@@ -911,17 +922,18 @@ func walkComponentsYAML(cType Type, node *yaml.Node, prov Provider, fn Component
 
 	reservedFields := ReservedFieldsByType(cType)
 	for i := 0; i < len(node.Content)-1; i += 2 {
-		if node.Content[i].Value == name {
-			if err := spec.Config.WalkYAML(node.Content[i+1], prov, fn); err != nil {
+		key := node.Content[i].Value
+		if key == name {
+			if err := spec.Config.walkYAML(node.Content[i+1], prov, appendPath(path, key), fn); err != nil {
 				return err
 			}
 			continue
 		}
-		if node.Content[i].Value == "type" || node.Content[i].Value == "label" {
+		if key == "type" || key == "label" {
 			continue
 		}
-		if spec, exists := reservedFields[node.Content[i].Value]; exists {
-			if err := spec.WalkYAML(node.Content[i+1], prov, fn); err != nil {
+		if spec, exists := reservedFields[key]; exists {
+			if err := spec.walkYAML(node.Content[i+1], prov, appendPath(path, key), fn); err != nil {
 				return err
 			}
 		}
@@ -929,65 +941,56 @@ func walkComponentsYAML(cType Type, node *yaml.Node, prov Provider, fn Component
 	return nil
 }
 
+func appendPath(path []string, elements ...string) []string {
+	newPath := make([]string, 0, len(path)+len(elements))
+	newPath = append(newPath, path...)
+	return append(newPath, elements...)
+}
+
 // WalkYAML walks each node of a YAML tree and for any component types within
 // the config a provided func is called.
 func (f FieldSpec) WalkYAML(node *yaml.Node, prov Provider, fn ComponentWalkYAMLFunc) error {
+	return f.walkYAML(node, prov, nil, fn)
+}
+
+func (f FieldSpec) walkYAML(node *yaml.Node, prov Provider, path []string, fn ComponentWalkYAMLFunc) error {
 	node = unwrapDocumentNode(node)
 
-	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
-		switch f.Kind {
-		case Kind2DArray:
-			for i := 0; i < len(node.Content); i++ {
-				for j := 0; j < len(node.Content[i].Content); j++ {
-					if err := walkComponentsYAML(coreType, node.Content[i].Content[j], prov, fn); err != nil {
-						return err
-					}
-				}
-			}
-		case KindArray:
-			for i := 0; i < len(node.Content); i++ {
-				if err := walkComponentsYAML(coreType, node.Content[i], prov, fn); err != nil {
-					return err
-				}
-			}
-		case KindMap:
-			for i := 0; i < len(node.Content)-1; i += 2 {
-				if err := walkComponentsYAML(coreType, node.Content[i+1], prov, fn); err != nil {
+	walkFn := func(node *yaml.Node, path []string) error {
+		if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+			return walkComponentsYAML(coreType, node, prov, path, fn)
+		}
+		return f.Children.walkYAML(node, prov, path, fn)
+	}
+	if _, isCore := f.Type.IsCoreComponent(); !isCore && len(f.Children) == 0 {
+		return nil
+	}
+
+	switch f.Kind {
+	case Kind2DArray:
+		for i := 0; i < len(node.Content); i++ {
+			for j := 0; j < len(node.Content[i].Content); j++ {
+				if err := walkFn(node.Content[i].Content[j], appendPath(path, strconv.Itoa(i), strconv.Itoa(j))); err != nil {
 					return err
 				}
 			}
-		default:
-			if err := walkComponentsYAML(coreType, node, prov, fn); err != nil {
+		}
+	case KindArray:
+		for i := 0; i < len(node.Content); i++ {
+			if err := walkFn(node.Content[i], appendPath(path, strconv.Itoa(i))); err != nil {
 				return err
 			}
 		}
-	} else if len(f.Children) > 0 {
-		switch f.Kind {
-		case Kind2DArray:
-			for i := 0; i < len(node.Content); i++ {
-				for j := 0; j < len(node.Content[i].Content); j++ {
-					if err := f.Children.WalkYAML(node.Content[i].Content[j], prov, fn); err != nil {
-						return err
-					}
-				}
-			}
-		case KindArray:
-			for i := 0; i < len(node.Content); i++ {
-				if err := f.Children.WalkYAML(node.Content[i], prov, fn); err != nil {
-					return err
-				}
-			}
-		case KindMap:
-			for i := 0; i < len(node.Content)-1; i += 2 {
-				if err := f.Children.WalkYAML(node.Content[i+1], prov, fn); err != nil {
-					return err
-				}
-			}
-		default:
-			if err := f.Children.WalkYAML(node, prov, fn); err != nil {
+	case KindMap:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if err := walkFn(node.Content[i+1], appendPath(path, node.Content[i].Value)); err != nil {
 				return err
 			}
 		}
+	default:
+		if err := walkFn(node, path); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1003,12 +1006,20 @@ type WalkedYAMLComponent struct {
 	ComponentType Type
 	Name          string
 	Label         string
-	Conf          *yaml.Node
+
+	// Path is the sequence of field names and array indexes that lead to the
+	// component from the root of the walked config.
+	Path []string
+	Conf *yaml.Node
 }
 
 // WalkYAML walks each node of a YAML tree and for any component types within
 // the config a provided func is called.
 func (f FieldSpecs) WalkYAML(node *yaml.Node, prov Provider, fn ComponentWalkYAMLFunc) error {
+	return f.walkYAML(node, prov, nil, fn)
+}
+
+func (f FieldSpecs) walkYAML(node *yaml.Node, prov Provider, path []string, fn ComponentWalkYAMLFunc) error {
 	node = unwrapDocumentNode(node)
 
 	nodeKeys := map[string]*yaml.Node{}
@@ -1022,7 +1033,7 @@ func (f FieldSpecs) WalkYAML(node *yaml.Node, prov Provider, fn ComponentWalkYAM
 		if !exists {
 			continue
 		}
-		if err := field.WalkYAML(value, prov, fn); err != nil {
+		if err := field.walkYAML(value, prov, appendPath(path, field.Name), fn); err != nil {
 			return err
 		}
 	}
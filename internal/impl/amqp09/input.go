@@ -22,6 +22,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	btls "github.com/benthosdev/benthos/v4/internal/tls"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
 )
 
 func init() {
@@ -67,7 +68,31 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#bloblang-queries).`,
+[function interpolation](/docs/configuration/interpolation#bloblang-queries).
+
+### Replies
+
+Messages that have a ` + "`reply_to`" + ` property can be replied to with a
+` + "[`sync_response`](/docs/components/outputs/sync_response)" + ` output, which
+allows Benthos to act as an RPC worker. Responses are published to the default
+exchange with the routing key of the ` + "`reply_to`" + ` property and the
+` + "`correlation_id`" + ` of the request, before the request is acknowledged.
+Metadata of a response is sent as headers, with underscores replaced by hyphens,
+except for the ` + "`amqp_`" + ` prefixed fields added by this input, of which
+` + "`amqp_content_type`" + ` and ` + "`amqp_content_encoding`" + ` set the
+properties of the response.
+
+### Dead Letter Routing
+
+Messages that fail with an error matching one of the ` + "`nack_reject_patterns`" + `
+are rejected without being requeued, and the broker then routes them to the
+dead letter exchange of the queue, if one is configured. Dead lettering is
+configured within RabbitMQ rather than Benthos, and since ` + "`queue_declare`" + `
+declares queues without arguments it is best done with a policy, for example:
+
+` + "```sh" + `
+rabbitmqctl set_policy DLX "^my-queue$" '{"dead-letter-exchange":"my-dlx"}' --apply-to queues
+` + "```",
 		Categories: []string{
 			"Services",
 		},
@@ -334,6 +359,10 @@ func (a *amqp09Reader) ReadBatch(ctx context.Context) (message.Batch, input.Asyn
 		part := message.NewPart(data.Body)
 
 		for k, v := range data.Headers {
+			if k == returnCorrelationHeader {
+				// Only used by the amqp_0_9 output for correlating returns.
+				continue
+			}
 			amqpSetMetadata(part, k, v)
 		}
 
@@ -373,7 +402,28 @@ func (a *amqp09Reader) ReadBatch(ctx context.Context) (message.Batch, input.Asyn
 			return nil, nil, component.ErrNotConnected
 		}
 		addPart(data)
+
+		var store transaction.ResultStore
+		if data.ReplyTo != "" {
+			store = transaction.NewResultStore()
+			transaction.AddResultStore(msg, store)
+		}
+
 		return msg, func(actx context.Context, res error) error {
+			if res == nil && store != nil {
+				if err := a.sendReplies(actx, data, store); err != nil {
+					err = fmt.Errorf("failed to send reply to '%v': %w", data.ReplyTo, err)
+					if a.conf.AutoAck {
+						return err
+					}
+					// The request is redelivered in order to reattempt the
+					// reply.
+					if nackErr := data.Nack(false, true); nackErr != nil {
+						a.log.Errorf("Failed to nack message: %v\n", nackErr)
+					}
+					return err
+				}
+			}
 			if a.conf.AutoAck {
 				return nil
 			}
@@ -393,6 +443,58 @@ func (a *amqp09Reader) ReadBatch(ctx context.Context) (message.Batch, input.Asyn
 	return nil, nil, component.ErrTimeout
 }
 
+// sendReplies publishes messages stored by a sync_response output to the reply
+// queue of a request, along with the correlation ID of the request.
+func (a *amqp09Reader) sendReplies(ctx context.Context, req amqp.Delivery, store transaction.ResultStore) error {
+	a.m.RLock()
+	amqpChan := a.amqpChan
+	a.m.RUnlock()
+
+	if amqpChan == nil {
+		return component.ErrNotConnected
+	}
+
+	for _, batch := range store.Get() {
+		for _, part := range batch {
+			if err := amqpChan.PublishWithContext(
+				ctx,
+				"",          // default exchange
+				req.ReplyTo, // routing key
+				false,       // mandatory
+				false,       // immediate
+				replyPublishing(req, part),
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replyPublishing maps a reply part to a publishing in the same way as the
+// output, where metadata becomes headers, except for the amqp_ prefixed
+// metadata added by this input, which sets the content type and encoding.
+func replyPublishing(req amqp.Delivery, part *message.Part) amqp.Publishing {
+	headers := amqp.Table{}
+	_ = part.MetaIterMut(func(k string, v any) error {
+		if strings.HasPrefix(k, "amqp_") {
+			return nil
+		}
+		if k = strings.ReplaceAll(k, "_", "-"); k == returnCorrelationHeader {
+			return nil
+		}
+		headers[k] = v
+		return nil
+	})
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     part.MetaGetStr("amqp_content_type"),
+		ContentEncoding: part.MetaGetStr("amqp_content_encoding"),
+		CorrelationId:   req.CorrelationId,
+		Body:            part.AsBytes(),
+	}
+}
+
 func (a *amqp09Reader) Close(ctx context.Context) error {
 	return a.disconnect()
 }
@@ -0,0 +1,28 @@
+package amqp09
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestAMQP09ReplyPublishing(t *testing.T) {
+	part := message.NewPart([]byte("hello"))
+	part.MetaSetMut("amqp_content_type", "application/json")
+	part.MetaSetMut("amqp_content_encoding", "gzip")
+	part.MetaSetMut("amqp_routing_key", "requests")
+	part.MetaSetMut("trace_id", "abc")
+	part.MetaSetMut("benthos_return_correlation_id", "nope")
+
+	pub := replyPublishing(amqp.Delivery{CorrelationId: "foo"}, part)
+	assert.Equal(t, amqp.Publishing{
+		Headers:         amqp.Table{"trace-id": "abc"},
+		ContentType:     "application/json",
+		ContentEncoding: "gzip",
+		CorrelationId:   "foo",
+		Body:            []byte("hello"),
+	}, pub)
+}
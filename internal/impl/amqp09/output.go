@@ -44,6 +44,15 @@ various message brokers, including RabbitMQ.`,
 		Description: output.Description(true, false, `
 The metadata from each message are delivered as headers.
 
+Messages are published with publisher confirms enabled, and a message is only
+acknowledged once the server has confirmed that it has taken responsibility for
+it. When the `+"`mandatory`"+` or `+"`immediate`"+` flags are set messages
+that are returned by the server are treated as having failed to be delivered,
+in which case they are reattempted. In order to identify which message was
+returned each message is published with a unique `+"`benthos-return-correlation-id`"+`
+header, which is only added when either flag is set and is not added to the
+metadata of messages consumed with the `+"`amqp_0_9`"+` input.
+
 It's possible for this output type to create the target exchange by setting
 `+"`exchange_declare.enabled` to `true`"+`, if the exchange already exists
 then the declaration passively verifies that the settings match.
@@ -78,7 +87,7 @@ The fields 'key' and 'type' can be dynamically set using function interpolations
 			docs.FieldBool("persistent", "Whether message delivery should be persistent (transient by default).").Advanced().HasDefault(false),
 			docs.FieldBool("mandatory", "Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned.").Advanced().HasDefault(false),
 			docs.FieldBool("immediate", "Whether to set the immediate flag on published messages. When set if there are no ready consumers of a queue then the message is dropped instead of waiting.").Advanced().HasDefault(false),
-			docs.FieldString("timeout", "The maximum period to wait before abandoning it and reattempting. If not set, wait indefinitely.").Advanced().HasDefault(""),
+			docs.FieldString("timeout", "The maximum period to wait for a message to be published and confirmed by the server before abandoning it and reattempting. If not set, wait indefinitely.").Advanced().HasDefault(""),
 			btls.FieldSpec(),
 		),
 		Categories: []string{
@@ -90,6 +99,106 @@ The fields 'key' and 'type' can be dynamically set using function interpolations
 	}
 }
 
+// returnCorrelationHeader is a header added to messages published with the
+// mandatory or immediate flags in order to correlate returned messages with
+// their publishings.
+const returnCorrelationHeader = "benthos-return-correlation-id"
+
+// amqp09Returns collects messages returned by the server on a channel, which
+// are claimed by the publisher of each message once its confirmation arrives.
+type amqp09Returns struct {
+	returnChan <-chan amqp.Return
+	nextID     uint64
+
+	mut      sync.Mutex
+	waiting  map[string]struct{}
+	returned map[string]amqp.Return
+}
+
+func newAMQP09Returns(returnChan <-chan amqp.Return) *amqp09Returns {
+	return &amqp09Returns{
+		returnChan: returnChan,
+		waiting:    map[string]struct{}{},
+		returned:   map[string]amqp.Return{},
+	}
+}
+
+// newID creates a correlation ID for a publishing, which must be released
+// once the publisher is no longer waiting for it.
+func (r *amqp09Returns) newID() string {
+	r.mut.Lock()
+	r.nextID++
+	id := strconv.FormatUint(r.nextID, 10)
+	r.waiting[id] = struct{}{}
+	r.mut.Unlock()
+	return id
+}
+
+// release forgets a correlation ID, and any returned message for it. Messages
+// returned after their publisher stopped waiting, for example due to a timeout,
+// are dropped.
+func (r *amqp09Returns) release(id string) {
+	r.mut.Lock()
+	delete(r.waiting, id)
+	delete(r.returned, id)
+	r.mut.Unlock()
+}
+
+func (r *amqp09Returns) add(ret amqp.Return) {
+	id, _ := ret.Headers[returnCorrelationHeader].(string)
+	r.mut.Lock()
+	if _, exists := r.waiting[id]; exists {
+		r.returned[id] = ret
+	}
+	r.mut.Unlock()
+}
+
+// wait blocks until a publishing has been confirmed, collecting any returned
+// messages in the meantime. This is necessary as the server sends returns
+// before confirmations, and therefore a blocked return channel would prevent
+// confirmations from being received.
+func (r *amqp09Returns) wait(ctx context.Context, conf *amqp.DeferredConfirmation) error {
+	for {
+		select {
+		case <-conf.Done():
+			return nil
+		case ret, open := <-r.returnChan:
+			if !open {
+				return component.ErrNotConnected
+			}
+			r.add(ret)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// claim returns a message returned by the server for a publishing, if any. As
+// returns are sent before the confirmation of a publishing this must only be
+// called once the publishing has been confirmed.
+func (r *amqp09Returns) claim(id string) (amqp.Return, bool) {
+drain:
+	for {
+		select {
+		case ret, open := <-r.returnChan:
+			if !open {
+				break drain
+			}
+			r.add(ret)
+		default:
+			break drain
+		}
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	ret, exists := r.returned[id]
+	delete(r.waiting, id)
+	delete(r.returned, id)
+	return ret, exists
+}
+
 type amqp09Writer struct {
 	key             *field.Expression
 	msgType         *field.Expression
@@ -104,10 +213,10 @@ type amqp09Writer struct {
 	urls    []string
 	tlsConf *tls.Config
 
-	conn       *amqp.Connection
-	amqpChan   *amqp.Channel
-	returnChan <-chan amqp.Return
-	timeout    time.Duration
+	conn     *amqp.Connection
+	amqpChan *amqp.Channel
+	returns  *amqp09Returns
+	timeout  time.Duration
 
 	deliveryMode uint8
 
@@ -207,8 +316,9 @@ func (a *amqp09Writer) Connect(ctx context.Context) error {
 
 	a.conn = conn
 	a.amqpChan = amqpChan
+	a.returns = nil
 	if a.conf.Mandatory || a.conf.Immediate {
-		a.returnChan = amqpChan.NotifyReturn(make(chan amqp.Return, 1))
+		a.returns = newAMQP09Returns(amqpChan.NotifyReturn(make(chan amqp.Return, a.conf.MaxInFlight)))
 	}
 
 	a.log.Infof("Sending AMQP messages to exchange: %v\n", a.conf.Exchange)
@@ -236,7 +346,7 @@ func (a *amqp09Writer) WriteBatch(wctx context.Context, msg message.Batch) error
 	a.connLock.RLock()
 	conn := a.conn
 	amqpChan := a.amqpChan
-	returnChan := a.returnChan
+	returns := a.returns
 	a.connLock.RUnlock()
 
 	if conn == nil {
@@ -295,10 +405,19 @@ func (a *amqp09Writer) WriteBatch(wctx context.Context, msg message.Batch) error
 
 		headers := amqp.Table{}
 		_ = a.metaFilter.Iter(p, func(k string, v any) error {
-			headers[strings.ReplaceAll(k, "_", "-")] = v
+			if k = strings.ReplaceAll(k, "_", "-"); k != returnCorrelationHeader {
+				headers[k] = v
+			}
 			return nil
 		})
 
+		var returnID string
+		if returns != nil {
+			returnID = returns.newID()
+			defer returns.release(returnID)
+			headers[returnCorrelationHeader] = returnID
+		}
+
 		conf, err := amqpChan.PublishWithDeferredConfirmWithContext(
 			ctx,
 			a.conf.Exchange,  // publish to an exchange
@@ -321,18 +440,20 @@ func (a *amqp09Writer) WriteBatch(wctx context.Context, msg message.Batch) error
 			a.log.Errorf("Failed to send message: %v\n", err)
 			return component.ErrNotConnected
 		}
-		if !conf.Wait() {
+		if returns != nil {
+			if err := returns.wait(ctx, conf); err != nil {
+				return err
+			}
+		} else if _, err := conf.WaitContext(ctx); err != nil {
+			return err
+		}
+		if !conf.Acked() {
 			a.log.Errorln("Failed to acknowledge message.")
 			return component.ErrNoAck
 		}
-		if returnChan != nil {
-			select {
-			case _, open := <-returnChan:
-				if !open {
-					return fmt.Errorf("acknowledgement not supported, ensure server supports immediate and mandatory flags")
-				}
-				return component.ErrNoAck
-			default:
+		if returns != nil {
+			if ret, returned := returns.claim(returnID); returned {
+				return fmt.Errorf("message returned by server: %v (%v)", ret.ReplyText, ret.ReplyCode)
 			}
 		}
 		return nil
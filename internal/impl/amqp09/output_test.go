@@ -0,0 +1,44 @@
+package amqp09
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAMQP09ReturnsRelease(t *testing.T) {
+	returnChan := make(chan amqp.Return, 10)
+	r := newAMQP09Returns(returnChan)
+
+	returnedID, abandonedID := r.newID(), r.newID()
+	assert.NotEqual(t, returnedID, abandonedID)
+
+	returnChan <- amqp.Return{
+		ReplyText: "NO_ROUTE",
+		Headers:   amqp.Table{returnCorrelationHeader: returnedID},
+	}
+
+	ret, returned := r.claim(returnedID)
+	assert.True(t, returned)
+	assert.Equal(t, "NO_ROUTE", ret.ReplyText)
+
+	// A publisher that stops waiting, e.g. due to a timeout, releases its ID
+	// and any later return for it is dropped.
+	r.release(abandonedID)
+	returnChan <- amqp.Return{
+		Headers: amqp.Table{returnCorrelationHeader: abandonedID},
+	}
+	_, returned = r.claim(abandonedID)
+	assert.False(t, returned)
+
+	// Returns for unknown IDs are also dropped.
+	returnChan <- amqp.Return{
+		Headers: amqp.Table{returnCorrelationHeader: "nope"},
+	}
+	_, returned = r.claim(r.newID())
+	assert.False(t, returned)
+
+	assert.Empty(t, r.waiting)
+	assert.Empty(t, r.returned)
+}
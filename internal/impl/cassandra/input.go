@@ -32,7 +32,8 @@ func cassandraConfigSpec() *service.ConfigSpec {
 		Field(service.NewStringField("timeout").
 			Description("").
 			Default("600ms").
-			Example("600ms"))
+			Example("600ms")).
+		Field(tokenRangesField())
 	spec = spec.
 		Example("Minimal Select (Cassandra/Scylla)",
 			`
@@ -54,6 +55,29 @@ input:
       - 172.17.0.2
     query:
       'SELECT * FROM learn_cassandra.users_by_country'
+`,
+		).
+		Example("Parallel Table Scan",
+			`
+Here we read a large table in parallel across 256 ranges of the token ring, storing the paging state of each range in a Redis cache so that the scan resumes where it left off when restarted:
+`,
+			`
+input:
+  cassandra:
+    addresses:
+      - 172.17.0.2
+    query: 'SELECT * FROM learn_cassandra.users_by_country'
+    token_ranges:
+      enabled: true
+      partition_keys: [ country ]
+      splits: 256
+      parallelism: 8
+      checkpoint_cache: checkpoints
+
+cache_resources:
+  - label: checkpoints
+    redis:
+      url: redis://localhost:6379
 `,
 		)
 	return spec
@@ -78,14 +102,14 @@ func init() {
 	err := service.RegisterInput(
 		"cassandra", cassandraConfigSpec(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			return newCassandraInput(conf)
+			return newCassandraInput(conf, mgr)
 		})
 	if err != nil {
 		panic(err)
 	}
 }
 
-func newCassandraInput(conf *service.ParsedConfig) (service.Input, error) {
+func newCassandraInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
 	addrs, err := conf.FieldStringList("addresses")
 	if err != nil {
 		return nil, err
@@ -131,14 +155,29 @@ func newCassandraInput(conf *service.ParsedConfig) (service.Input, error) {
 		return nil, err
 	}
 
+	tokenRanges, err := tokenRangesFromParsed(conf.Namespace("token_ranges"))
+	if err != nil {
+		return nil, fmt.Errorf("token_ranges: %w", err)
+	}
+	if tokenRanges != nil {
+		if tokenRanges.cacheName != "" && !mgr.HasCache(tokenRanges.cacheName) {
+			return nil, fmt.Errorf("cache resource %v was not found", tokenRanges.cacheName)
+		}
+		if query, err = tokenRangeQuery(query, tokenRanges.partitionKeys); err != nil {
+			return nil, fmt.Errorf("token_ranges: %w", err)
+		}
+	}
+
 	return service.AutoRetryNacks(&cassandraInput{
-		addresses:  addrs,
-		auth:       pAuth,
-		disableIHL: disable,
-		query:      query,
-		maxRetries: retries,
-		backoff:    backoff,
-		timeout:    timeout,
+		addresses:   addrs,
+		auth:        pAuth,
+		disableIHL:  disable,
+		query:       query,
+		maxRetries:  retries,
+		backoff:     backoff,
+		timeout:     timeout,
+		tokenRanges: tokenRanges,
+		mgr:         mgr,
 	}), nil
 }
 
@@ -219,8 +258,12 @@ type cassandraInput struct {
 	backoff    backOff
 	timeout    time.Duration
 
+	tokenRanges *tokenRangesConfig
+	mgr         *service.Resources
+
 	session *gocql.Session
 	iter    *gocql.Iter
+	reader  *tokenRangeReader
 }
 
 func (c *cassandraInput) Connect(ctx context.Context) error {
@@ -252,12 +295,27 @@ func (c *cassandraInput) Connect(ctx context.Context) error {
 		return fmt.Errorf("creating Cassandra session: %w", err)
 	}
 
+	if c.tokenRanges != nil {
+		reader := newTokenRangeReader(c.tokenRanges, c.query, session, c.mgr)
+		if err := reader.start(ctx); err != nil {
+			session.Close()
+			return err
+		}
+		c.session = session
+		c.reader = reader
+		return nil
+	}
+
 	c.session = session
 	c.iter = session.Query(c.query).Iter()
 	return nil
 }
 
 func (c *cassandraInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if c.reader != nil {
+		return c.reader.read(ctx)
+	}
+
 	mp := make(map[string]interface{})
 	if !c.iter.MapScan(mp) {
 		return nil, nil, service.ErrEndOfInput
@@ -271,6 +329,12 @@ func (c *cassandraInput) Read(ctx context.Context) (*service.Message, service.Ac
 }
 
 func (c *cassandraInput) Close(ctx context.Context) error {
+	if c.reader != nil {
+		if err := c.reader.close(ctx); err != nil {
+			return err
+		}
+		c.reader = nil
+	}
 	if c.session != nil {
 		c.session.Close()
 		c.session = nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
 )
 
 func TestIntegrationCassandra(t *testing.T) {
@@ -141,4 +145,100 @@ output:
 			}),
 		)
 	})
+
+	t.Run("with conditional writes and ttl", func(t *testing.T) {
+		template := `
+output:
+  cassandra:
+    addresses:
+      - localhost:$PORT
+    query: 'INSERT INTO testspace.table$ID (id, content) VALUES (?, ?)'
+    args_mapping: 'root = [ this.id, this.content ]'
+    if_not_exists: true
+    ttl_mapping: 'root = "1h"'
+`
+		queryGetFn := func(ctx context.Context, testID, messageID string) (string, []string, error) {
+			var resID, ttl int
+			var resContent string
+			if err := session.Query(
+				fmt.Sprintf("select id, content, ttl(content) from testspace.table%v where id = ?;", testID), messageID,
+			).Scan(&resID, &resContent, &ttl); err != nil {
+				return "", nil, err
+			}
+			if ttl <= 0 || ttl > 3600 {
+				return "", nil, fmt.Errorf("received bad ttl: %v", ttl)
+			}
+			return fmt.Sprintf(`{"content":"%v","id":%v}`, resContent, resID), nil, err
+		}
+		suite := integration.StreamTests(
+			integration.StreamTestOutputOnlySendSequential(10, queryGetFn),
+			integration.StreamTestOutputOnlySendBatch(10, queryGetFn),
+		)
+		suite.Run(
+			t, template,
+			integration.StreamTestOptPort(resource.GetPort("9042/tcp")),
+			integration.StreamTestOptSleepAfterInput(time.Second*10),
+			integration.StreamTestOptSleepAfterOutput(time.Second*10),
+			integration.StreamTestOptPreTest(func(t testing.TB, ctx context.Context, testID string, vars *integration.StreamTestConfigVars) {
+				vars.ID = strings.ReplaceAll(testID, "-", "")
+				require.NoError(t, session.Query(
+					fmt.Sprintf(
+						"CREATE TABLE testspace.table%v (id int primary key, content text);",
+						vars.ID,
+					),
+				).Exec())
+			}),
+		)
+	})
+
+	t.Run("with token ranges", func(t *testing.T) {
+		require.NoError(t, session.Query(
+			"CREATE TABLE testspace.tokenranges (id int primary key, content text);",
+		).Exec())
+		for i := 0; i < 100; i++ {
+			require.NoError(t, session.Query(
+				"INSERT INTO testspace.tokenranges (id, content) VALUES (?, ?);", i, fmt.Sprintf("hello world %v", i),
+			).Exec())
+		}
+
+		streamBuilder := service.NewStreamBuilder()
+		require.NoError(t, streamBuilder.SetLoggerYAML(`level: OFF`))
+		require.NoError(t, streamBuilder.AddCacheYAML(`
+label: checkpoints
+memory: {}
+`))
+		require.NoError(t, streamBuilder.AddInputYAML(fmt.Sprintf(`
+cassandra:
+  addresses: [ localhost:%v ]
+  query: 'SELECT id, content FROM testspace.tokenranges'
+  token_ranges:
+    enabled: true
+    partition_keys: [ id ]
+    splits: 8
+    page_size: 10
+    checkpoint_cache: checkpoints
+`, resource.GetPort("9042/tcp"))))
+
+		var idsMut sync.Mutex
+		ids := map[string]struct{}{}
+		require.NoError(t, streamBuilder.AddConsumerFunc(func(ctx context.Context, msg *service.Message) error {
+			v, err := msg.AsStructured()
+			require.NoError(t, err)
+			idsMut.Lock()
+			ids[fmt.Sprintf("%v", v.(map[string]any)["id"])] = struct{}{}
+			idsMut.Unlock()
+			return nil
+		}))
+
+		stream, err := streamBuilder.Build()
+		require.NoError(t, err)
+
+		ctx, done := context.WithTimeout(context.Background(), time.Minute)
+		defer done()
+		require.NoError(t, stream.Run(ctx))
+
+		idsMut.Lock()
+		assert.Len(t, ids, 100)
+		idsMut.Unlock()
+	})
 }
@@ -4,14 +4,18 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gocql/gocql"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
@@ -46,7 +50,15 @@ Runs a query against a Cassandra database for each message in order to insert da
 		Description: output.Description(true, true, `
 Query arguments can be set using a bloblang array for the fields using the `+"`args_mapping`"+` field.
 
-When populating timestamp columns the value must either be a string in ISO 8601 format (2006-01-02T15:04:05Z07:00), or an integer representing unix time in seconds.`),
+When populating timestamp columns the value must either be a string in ISO 8601 format (2006-01-02T15:04:05Z07:00), or an integer representing unix time in seconds.
+
+### Conditional Writes
+
+When `+"`if_not_exists`"+` is enabled the query, which must be an `+"`INSERT`"+` statement, is executed as a [lightweight transaction](https://cassandra.apache.org/doc/latest/cassandra/cql/dml.html#insert-statement) that only inserts rows that do not already exist. Lightweight transactions cannot be batched and therefore each message is written individually. The number of rows that were and were not applied is tracked with the metrics `+"`output_cassandra_lwt_applied` and `output_cassandra_lwt_not_applied`"+`, and when `+"`fail_not_applied`"+` is enabled the messages of rows that were not applied are failed.
+
+### TTL and Timestamps
+
+The fields `+"`ttl_mapping` and `timestamp_mapping`"+` set the time to live and write timestamp of each row by adding a `+"`USING TTL ? AND TIMESTAMP ?`"+` clause to the query, which must be an `+"`INSERT`"+` statement.`),
 		Examples: []docs.AnnotatedExample{
 			{
 				Title:   "Basic Inserts",
@@ -81,6 +93,20 @@ output:
     batching:
       count: 500
       period: 1s
+`,
+			},
+			{
+				Title:   "Expiring Inserts",
+				Summary: "The following example only inserts rows that do not already exist, and expires them after the number of seconds within the field `ttl` of each document:",
+				Config: `
+output:
+  cassandra:
+    addresses:
+      - localhost:9042
+    query: 'INSERT INTO foo.bar (id, content) VALUES (?, ?)'
+    args_mapping: 'root = [ this.id, this.content ]'
+    if_not_exists: true
+    ttl_mapping: 'root = this.ttl'
 `,
 			},
 		},
@@ -119,6 +145,24 @@ output:
 				"logged_batch",
 				"If enabled the driver will perform a logged batch. Disabling this prompts unlogged batches to be used instead, which are less efficient but necessary for alternative storages that do not support logged batches.",
 			).Advanced(),
+			docs.FieldBool(
+				"if_not_exists",
+				"Whether to only insert rows that do not already exist by adding `IF NOT EXISTS` to the query, which must be an `INSERT` statement. Messages are written individually when enabled.",
+			).Advanced().AtVersion("4.14.0"),
+			docs.FieldBool(
+				"fail_not_applied",
+				"Whether to fail messages that were not applied because their row already exists. Only applies when `if_not_exists` is enabled.",
+			).Advanced().AtVersion("4.14.0"),
+			docs.FieldBloblang(
+				"ttl_mapping",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in the time to live of each row, either as an integer number of seconds or as a duration string.",
+				`root = 3600`, `root = this.expires_in`, `root = "24h"`,
+			).Advanced().AtVersion("4.14.0"),
+			docs.FieldBloblang(
+				"timestamp_mapping",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in the write timestamp of each row, either as a string in ISO 8601 format or as an integer representing unix time in seconds. Custom timestamps cannot be used along with `if_not_exists`.",
+				`root = this.updated_at`, `root = now()`,
+			).Advanced().AtVersion("4.14.0"),
 			docs.FieldInt("max_retries", "The maximum number of retries before giving up on a request.").Advanced(),
 			docs.FieldObject("backoff", "Control time intervals between retry attempts.").WithChildren(
 				docs.FieldString("initial_interval", "The initial period to wait between retry attempts."),
@@ -148,8 +192,14 @@ type cassandraWriter struct {
 	session  *gocql.Session
 	connLock sync.RWMutex
 
-	argsMapping *mapping.Executor
-	batchType   gocql.BatchType
+	query            string
+	argsMapping      *mapping.Executor
+	ttlMapping       *mapping.Executor
+	timestampMapping *mapping.Executor
+	batchType        gocql.BatchType
+
+	mLWTApplied    metrics.StatCounter
+	mLWTNotApplied metrics.StatCounter
 }
 
 func newCassandraWriter(conf output.CassandraConfig, mgr bundle.NewManagement) (*cassandraWriter, error) {
@@ -174,6 +224,11 @@ func newCassandraWriter(conf output.CassandraConfig, mgr bundle.NewManagement) (
 	if err = c.parseArgs(mgr); err != nil {
 		return nil, fmt.Errorf("parsing args: %w", err)
 	}
+	if c.query, err = writeQuery(conf); err != nil {
+		return nil, err
+	}
+	c.mLWTApplied = c.stats.GetCounter("output_cassandra_lwt_applied")
+	c.mLWTNotApplied = c.stats.GetCounter("output_cassandra_lwt_not_applied")
 	c.batchType = gocql.UnloggedBatch
 	if c.conf.LoggedBatch {
 		c.batchType = gocql.LoggedBatch
@@ -189,9 +244,53 @@ func (c *cassandraWriter) parseArgs(mgr bundle.NewManagement) error {
 			return fmt.Errorf("parsing args_mapping: %w", err)
 		}
 	}
+	if c.conf.TTLMapping != "" {
+		var err error
+		if c.ttlMapping, err = mgr.BloblEnvironment().NewMapping(c.conf.TTLMapping); err != nil {
+			return fmt.Errorf("parsing ttl_mapping: %w", err)
+		}
+	}
+	if c.conf.TimestampMapping != "" {
+		var err error
+		if c.timestampMapping, err = mgr.BloblEnvironment().NewMapping(c.conf.TimestampMapping); err != nil {
+			return fmt.Errorf("parsing timestamp_mapping: %w", err)
+		}
+	}
 	return nil
 }
 
+var insertQueryRegexp = regexp.MustCompile(`(?i)^\s*INSERT\s`)
+
+// writeQuery returns the query to execute for each message, which is extended
+// with clauses for conditional writes, TTLs and timestamps when configured.
+func writeQuery(conf output.CassandraConfig) (string, error) {
+	if !conf.IfNotExists && conf.TTLMapping == "" && conf.TimestampMapping == "" {
+		return conf.Query, nil
+	}
+	if !insertQueryRegexp.MatchString(conf.Query) {
+		return "", errors.New("if_not_exists, ttl_mapping and timestamp_mapping can only be used with INSERT queries")
+	}
+	if conf.IfNotExists && conf.TimestampMapping != "" {
+		return "", errors.New("timestamp_mapping cannot be used with if_not_exists as conditional writes do not support custom timestamps")
+	}
+
+	query := strings.TrimRight(strings.TrimSpace(conf.Query), "; ")
+	if conf.IfNotExists {
+		query += " IF NOT EXISTS"
+	}
+	var using []string
+	if conf.TTLMapping != "" {
+		using = append(using, "TTL ?")
+	}
+	if conf.TimestampMapping != "" {
+		using = append(using, "TIMESTAMP ?")
+	}
+	if len(using) > 0 {
+		query += " USING " + strings.Join(using, " AND ")
+	}
+	return query, nil
+}
+
 func (c *cassandraWriter) Connect(ctx context.Context) error {
 	c.connLock.Lock()
 	defer c.connLock.Unlock()
@@ -249,6 +348,9 @@ func (c *cassandraWriter) WriteBatch(ctx context.Context, msg message.Batch) err
 		return component.ErrNotConnected
 	}
 
+	if c.conf.IfNotExists {
+		return c.writeConditional(session, msg)
+	}
 	if msg.Len() == 1 {
 		return c.writeRow(session, msg)
 	}
@@ -260,7 +362,54 @@ func (c *cassandraWriter) writeRow(session *gocql.Session, msg message.Batch) er
 	if err != nil {
 		return fmt.Errorf("parsing args: %w", err)
 	}
-	return session.Query(c.conf.Query, values...).Exec()
+	return session.Query(c.query, values...).Exec()
+}
+
+// writeConditional executes a lightweight transaction for each message, as
+// they cannot be batched.
+func (c *cassandraWriter) writeConditional(session *gocql.Session, msg message.Batch) error {
+	if msg.Len() == 1 {
+		return c.writeConditionalRow(session, msg, 0)
+	}
+
+	var batchErr *batch.Error
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if err := c.writeConditionalRow(session, msg, i); err != nil {
+			if batchErr == nil {
+				batchErr = batch.NewError(msg, err)
+			}
+			batchErr.Failed(i, err)
+		}
+		return nil
+	})
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (c *cassandraWriter) writeConditionalRow(session *gocql.Session, msg message.Batch, index int) error {
+	values, err := c.mapArgs(msg, index)
+	if err != nil {
+		return fmt.Errorf("parsing args: %w", err)
+	}
+
+	existing := map[string]any{}
+	applied, err := session.Query(c.query, values...).MapScanCAS(existing)
+	if err != nil {
+		return err
+	}
+	if applied {
+		c.mLWTApplied.Incr(1)
+		return nil
+	}
+
+	c.mLWTNotApplied.Incr(1)
+	if !c.conf.FailNotApplied {
+		c.log.Debugf("Row of message %v was not applied as it already exists: %v", index, existing)
+		return nil
+	}
+	return fmt.Errorf("row was not applied as it already exists: %v", existing)
 }
 
 func (c *cassandraWriter) writeBatch(session *gocql.Session, msg message.Batch) error {
@@ -271,7 +420,7 @@ func (c *cassandraWriter) writeBatch(session *gocql.Session, msg message.Batch)
 		if err != nil {
 			return fmt.Errorf("parsing args for part: %d: %w", i, err)
 		}
-		batch.Query(c.conf.Query, values...)
+		batch.Query(c.query, values...)
 		return nil
 	}); err != nil {
 		return err
@@ -301,9 +450,67 @@ func (c *cassandraWriter) mapArgs(msg message.Batch, index int) ([]any, error) {
 		for i, v := range j {
 			j[i] = genericValue{v: v}
 		}
-		return j, nil
+		return c.appendUsingArgs(j, msg, index)
+	}
+	return c.appendUsingArgs(nil, msg, index)
+}
+
+// appendUsingArgs appends the values of the TTL and timestamp clauses of the
+// query, which follow all other arguments.
+func (c *cassandraWriter) appendUsingArgs(args []any, msg message.Batch, index int) ([]any, error) {
+	if c.ttlMapping != nil {
+		v, err := mapValue(c.ttlMapping, msg, index)
+		if err != nil {
+			return nil, fmt.Errorf("executing ttl_mapping: %w", err)
+		}
+		ttl, err := ttlSeconds(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ttl_mapping result: %w", err)
+		}
+		args = append(args, ttl)
+	}
+	if c.timestampMapping != nil {
+		v, err := mapValue(c.timestampMapping, msg, index)
+		if err != nil {
+			return nil, fmt.Errorf("executing timestamp_mapping: %w", err)
+		}
+		ts, err := query.IGetTimestamp(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timestamp_mapping result: %w", err)
+		}
+		args = append(args, ts.UnixMicro())
+	}
+	return args, nil
+}
+
+func mapValue(m *mapping.Executor, msg message.Batch, index int) (any, error) {
+	part, err := m.MapPart(index, msg)
+	if err != nil {
+		return nil, err
+	}
+	return part.AsStructured()
+}
+
+// ttlSeconds converts either a number of seconds or a duration string into a
+// TTL in seconds.
+func ttlSeconds(v any) (int, error) {
+	var ttl int64
+	if s, ok := v.(string); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		ttl = int64(d / time.Second)
+	} else {
+		var err error
+		if ttl, err = query.IGetInt(v); err != nil {
+			return 0, err
+		}
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("ttl must not be negative, got %v", ttl)
 	}
-	return nil, nil
+	return int(ttl), nil
 }
 
 func (c *cassandraWriter) Close(context.Context) error {
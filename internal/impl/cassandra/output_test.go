@@ -0,0 +1,112 @@
+package cassandra
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+)
+
+func TestCassandraWriteQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(c *output.CassandraConfig)
+		expected    string
+		errContains string
+	}{
+		{
+			name: "unchanged",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "UPDATE foo.bar SET content = ? WHERE id = ?;"
+			},
+			expected: "UPDATE foo.bar SET content = ? WHERE id = ?;",
+		},
+		{
+			name: "if not exists",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "INSERT INTO foo.bar (id, content) VALUES (?, ?);"
+				c.IfNotExists = true
+			},
+			expected: "INSERT INTO foo.bar (id, content) VALUES (?, ?) IF NOT EXISTS",
+		},
+		{
+			name: "if not exists with ttl",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "insert into foo.bar JSON ?"
+				c.IfNotExists = true
+				c.TTLMapping = "root = 60"
+			},
+			expected: "insert into foo.bar JSON ? IF NOT EXISTS USING TTL ?",
+		},
+		{
+			name: "ttl and timestamp",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "INSERT INTO foo.bar (id, content) VALUES (?, ?)"
+				c.TTLMapping = "root = 60"
+				c.TimestampMapping = "root = now()"
+			},
+			expected: "INSERT INTO foo.bar (id, content) VALUES (?, ?) USING TTL ? AND TIMESTAMP ?",
+		},
+		{
+			name: "not an insert",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "UPDATE foo.bar SET content = ? WHERE id = ?"
+				c.TTLMapping = "root = 60"
+			},
+			errContains: "INSERT",
+		},
+		{
+			name: "if not exists with timestamp",
+			configure: func(c *output.CassandraConfig) {
+				c.Query = "INSERT INTO foo.bar (id, content) VALUES (?, ?)"
+				c.IfNotExists = true
+				c.TimestampMapping = "root = now()"
+			},
+			errContains: "timestamp_mapping cannot be used with if_not_exists",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := output.NewCassandraConfig()
+			test.configure(&conf)
+
+			query, err := writeQuery(conf)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, query)
+		})
+	}
+}
+
+func TestCassandraTTLSeconds(t *testing.T) {
+	for _, test := range []struct {
+		input    any
+		expected int
+		err      bool
+	}{
+		{input: int64(60), expected: 60},
+		{input: json.Number("3600"), expected: 3600},
+		{input: "90s", expected: 90},
+		{input: "24h", expected: 86400},
+		{input: "nope", err: true},
+		{input: int64(-1), err: true},
+		{input: "-5s", err: true},
+	} {
+		ttl, err := ttlSeconds(test.input)
+		if test.err {
+			assert.Error(t, err, "%v", test.input)
+			continue
+		}
+		require.NoError(t, err, "%v", test.input)
+		assert.Equal(t, test.expected, ttl, "%v", test.input)
+	}
+}
@@ -0,0 +1,542 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gocql/gocql"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func tokenRangesField() *service.ConfigField {
+	return service.NewObjectField("token_ranges",
+		service.NewBoolField("enabled").
+			Description("Whether to read the table in parallel by splitting the query across ranges of partition tokens.").
+			Default(false),
+		service.NewStringListField("partition_keys").
+			Description("The partition key columns of the queried table, in the order they are declared.").
+			Default([]any{}).
+			Example([]any{"id"}).
+			Example([]any{"country", "user_email"}),
+		service.NewIntField("splits").
+			Description("The number of ranges the token ring is split into. This must not change while a checkpoint exists within the cache.").
+			Default(64),
+		service.NewIntField("parallelism").
+			Description("The maximum number of ranges to read in parallel.").
+			Default(4),
+		service.NewIntField("page_size").
+			Description("The maximum number of rows to read from a range in each page.").
+			Default(1000),
+		service.NewStringField("checkpoint_cache").
+			Description("An optional [cache resource](/docs/components/caches/about) to store the paging state of each range in, allowing reads to resume from the last acknowledged page of each range after a restart. Once every range has been read the checkpoint marks them all as complete and later runs read nothing, in order to read the table again delete the checkpoint from the cache or change the `checkpoint_key`.").
+			Default(""),
+		service.NewStringField("checkpoint_key").
+			Description("The key to store the paging state of the ranges under.").
+			Default("cassandra_token_ranges"),
+	).
+		Description("Read the results of the query in parallel across ranges of the Murmur3 token ring. The query must select from a single table without a `LIMIT`, `PER PARTITION LIMIT` or `ORDER BY` clause, and conditions restricting the token of the partition key are added to it. Queries that are rejected by the server as invalid are not retried, the error is reported once after which the input ends.").
+		Advanced().
+		Version("4.14.0")
+}
+
+type tokenRangesConfig struct {
+	partitionKeys []string
+	splits        int
+	parallelism   int
+	pageSize      int
+	cacheName     string
+	cacheKey      string
+}
+
+// tokenRangesFromParsed returns nil when token range reads are not enabled.
+func tokenRangesFromParsed(conf *service.ParsedConfig) (*tokenRangesConfig, error) {
+	enabled, err := conf.FieldBool("enabled")
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	t := &tokenRangesConfig{}
+	if t.partitionKeys, err = conf.FieldStringList("partition_keys"); err != nil {
+		return nil, err
+	}
+	if len(t.partitionKeys) == 0 {
+		return nil, errors.New("at least one partition key must be specified")
+	}
+	if t.splits, err = conf.FieldInt("splits"); err != nil {
+		return nil, err
+	}
+	if t.splits < 1 {
+		return nil, errors.New("splits must be greater than zero")
+	}
+	if t.parallelism, err = conf.FieldInt("parallelism"); err != nil {
+		return nil, err
+	}
+	if t.parallelism < 1 {
+		return nil, errors.New("parallelism must be greater than zero")
+	}
+	if t.pageSize, err = conf.FieldInt("page_size"); err != nil {
+		return nil, err
+	}
+	if t.pageSize < 1 {
+		return nil, errors.New("page_size must be greater than zero")
+	}
+	if t.cacheName, err = conf.FieldString("checkpoint_cache"); err != nil {
+		return nil, err
+	}
+	if t.cacheKey, err = conf.FieldString("checkpoint_key"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+//------------------------------------------------------------------------------
+
+// tokenRange is a range of the token ring that excludes its start and includes
+// its end.
+type tokenRange struct {
+	start, end int64
+}
+
+// splitTokenRing splits the Murmur3 token ring into n contiguous ranges of
+// roughly equal size. The minimum token is never assigned to a partition and
+// is therefore excluded.
+func splitTokenRing(n int) []tokenRange {
+	min, max := big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)
+	width := new(big.Int).Sub(max, min)
+	width.Div(width, big.NewInt(int64(n)))
+
+	ranges := make([]tokenRange, n)
+	start := int64(math.MinInt64)
+	for i := range ranges {
+		end := int64(math.MaxInt64)
+		if i < n-1 {
+			endBig := new(big.Int).Mul(width, big.NewInt(int64(i+1)))
+			end = endBig.Add(endBig, min).Int64()
+		}
+		ranges[i] = tokenRange{start: start, end: end}
+		start = end
+	}
+	return ranges
+}
+
+var (
+	// Matches string literals and quoted identifiers, the contents of which
+	// must not be mistaken for clauses of the query.
+	quotedRegexp = regexp.MustCompile(`'(?:[^']|'')*'|"(?:[^"]|"")*"|\$\$(?s:.*?)\$\$`)
+
+	whereClauseRegexp    = regexp.MustCompile(`(?i)\sWHERE\s`)
+	allowFilteringRegexp = regexp.MustCompile(`(?i)\s+ALLOW\s+FILTERING\s*$`)
+
+	// Clauses that apply to the results of the whole query, and therefore
+	// change its results when applied to each range instead.
+	unsupportedClauses = []struct {
+		name   string
+		regexp *regexp.Regexp
+	}{
+		{name: "PER PARTITION LIMIT", regexp: regexp.MustCompile(`(?i)\bPER\s+PARTITION\s+LIMIT\b`)},
+		{name: "LIMIT", regexp: regexp.MustCompile(`(?i)\bLIMIT\b`)},
+		{name: "ORDER BY", regexp: regexp.MustCompile(`(?i)\bORDER\s+BY\b`)},
+	}
+)
+
+// tokenRangeQuery adds conditions on the token of the partition keys to a
+// query, which are bound to the start and end of a range.
+func tokenRangeQuery(query string, partitionKeys []string) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), "; ")
+
+	// Quoted sections are masked with a placeholder of the same length so
+	// that the locations of clauses match the original query.
+	masked := quotedRegexp.ReplaceAllStringFunc(query, func(s string) string {
+		return strings.Repeat("_", len(s))
+	})
+	for _, c := range unsupportedClauses {
+		if c.regexp.MatchString(masked) {
+			return "", fmt.Errorf("queries with a %v clause cannot be read across token ranges", c.name)
+		}
+	}
+
+	var suffix string
+	if loc := allowFilteringRegexp.FindStringIndex(masked); loc != nil {
+		suffix = " ALLOW FILTERING"
+		query, masked = query[:loc[0]], masked[:loc[0]]
+	}
+
+	token := fmt.Sprintf("token(%v)", strings.Join(partitionKeys, ", "))
+	conjunction := " WHERE "
+	if whereClauseRegexp.MatchString(masked) {
+		conjunction = " AND "
+	}
+	return fmt.Sprintf("%v%v%v > ? AND %v <= ?%v", query, conjunction, token, token, suffix), nil
+}
+
+//------------------------------------------------------------------------------
+
+// tokenRangeCursor is the position to resume reading a range from.
+type tokenRangeCursor struct {
+	PageState []byte `json:"page_state,omitempty"`
+	Done      bool   `json:"done,omitempty"`
+
+	seq uint64
+}
+
+type tokenRangesCheckpoint struct {
+	Splits int                      `json:"splits"`
+	Ranges map[int]tokenRangeCursor `json:"ranges"`
+}
+
+type tokenRangeMessage struct {
+	msg   *service.Message
+	ackFn service.AckFunc
+}
+
+// tokenRangeReader reads the rows of a query across ranges of the token ring in
+// parallel, and checkpoints the paging state of each range once the rows of a
+// page have been acknowledged.
+type tokenRangeReader struct {
+	conf    *tokenRangesConfig
+	query   string
+	session *gocql.Session
+	ranges  []tokenRange
+
+	mgr *service.Resources
+	log *service.Logger
+
+	cpMut       sync.Mutex
+	checkpoints []*checkpoint.Uncapped[tokenRangeCursor]
+
+	storeMut sync.Mutex
+	stored   map[int]tokenRangeCursor
+
+	msgChan chan tokenRangeMessage
+	readErr error
+	errMut  sync.Mutex
+	shutSig *shutdown.Signaller
+}
+
+// newTokenRangeReader creates a reader of a query that has already been
+// rewritten with tokenRangeQuery.
+func newTokenRangeReader(conf *tokenRangesConfig, query string, session *gocql.Session, mgr *service.Resources) *tokenRangeReader {
+	t := &tokenRangeReader{
+		conf:        conf,
+		query:       query,
+		session:     session,
+		ranges:      splitTokenRing(conf.splits),
+		mgr:         mgr,
+		log:         mgr.Logger(),
+		checkpoints: make([]*checkpoint.Uncapped[tokenRangeCursor], conf.splits),
+		stored:      map[int]tokenRangeCursor{},
+		msgChan:     make(chan tokenRangeMessage),
+		shutSig:     shutdown.NewSignaller(),
+	}
+	for i := range t.checkpoints {
+		t.checkpoints[i] = checkpoint.NewUncapped[tokenRangeCursor]()
+	}
+	return t
+}
+
+// start loads the checkpoint of the ranges and begins reading those that are
+// yet to be completed.
+func (t *tokenRangeReader) start(ctx context.Context) error {
+	if err := t.loadCheckpoint(ctx); err != nil {
+		return err
+	}
+
+	resume := make([]tokenRangeCursor, len(t.ranges))
+	pending := make(chan int, len(t.ranges))
+	for i := range t.ranges {
+		if resume[i] = t.stored[i]; !resume[i].Done {
+			pending <- i
+		}
+	}
+	close(pending)
+	if len(pending) == 0 {
+		t.log.Infof("All token ranges of checkpoint %v are complete, delete it from the cache in order to read the table again", t.conf.cacheKey)
+	}
+
+	// Reading is abandoned by all workers when the reader is closed or when
+	// any range fails with an error that cannot be resolved by retrying.
+	readCtx, readDone := t.shutSig.CloseNowCtx(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < t.conf.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range pending {
+				err := t.readRange(readCtx, index, resume[index])
+				if err == nil {
+					continue
+				}
+				if readCtx.Err() == nil {
+					t.log.Errorf("Failed to read token range %v: %v", index, err)
+					t.errMut.Lock()
+					if t.readErr == nil {
+						t.readErr = err
+					}
+					t.errMut.Unlock()
+					readDone()
+				}
+				return
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		readDone()
+		close(t.msgChan)
+		t.shutSig.ShutdownComplete()
+	}()
+	return nil
+}
+
+// retryableErr returns whether a failed query might succeed when retried,
+// which is not the case when the query itself is rejected by the server.
+func retryableErr(err error) bool {
+	var reqErr gocql.RequestError
+	if errors.As(err, &reqErr) {
+		switch reqErr.Code() {
+		case gocql.ErrCodeSyntax, gocql.ErrCodeInvalid:
+			return false
+		}
+	}
+	return true
+}
+
+// withRetries attempts fn until it succeeds, fails with an error that cannot
+// be resolved by retrying, or the context is cancelled.
+func (t *tokenRangeReader) withRetries(ctx context.Context, index int, fn func(ctx context.Context) error) error {
+	boff := backoff.NewExponentialBackOff()
+	boff.MaxElapsedTime = 0
+	boff.MaxInterval = time.Second * 30
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryableErr(err) {
+			return err
+		}
+		t.log.Errorf("Failed to read page of token range %v: %v", index, err)
+		select {
+		case <-time.After(boff.NextBackOff()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readRange reads the pages of a range until it is exhausted, the context is
+// cancelled or a page fails with an error that cannot be retried.
+func (t *tokenRangeReader) readRange(ctx context.Context, index int, cursor tokenRangeCursor) error {
+	r := t.ranges[index]
+	for {
+		var rows []map[string]any
+		var nextState []byte
+		if err := t.withRetries(ctx, index, func(ctx context.Context) error {
+			iter := t.session.Query(t.query, r.start, r.end).
+				WithContext(ctx).
+				PageSize(t.conf.pageSize).
+				PageState(cursor.PageState).
+				Iter()
+			nextState = iter.PageState()
+
+			rows = rows[:0]
+			for {
+				row := map[string]any{}
+				if !iter.MapScan(row) {
+					break
+				}
+				rows = append(rows, row)
+			}
+			return iter.Close()
+		}); err != nil {
+			return err
+		}
+
+		next := tokenRangeCursor{
+			PageState: nextState,
+			Done:      len(nextState) == 0,
+			seq:       cursor.seq + 1,
+		}
+		for i, row := range rows {
+			// Rows of a page resume from the start of the page until the last
+			// row is acknowledged, which resumes from the following page.
+			tracked := cursor
+			if i == len(rows)-1 {
+				tracked = next
+			}
+			if err := t.send(ctx, index, row, tracked); err != nil {
+				return err
+			}
+		}
+		if len(rows) == 0 {
+			t.resolveEmptyPage(ctx, index, next)
+		}
+		if next.Done {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (t *tokenRangeReader) send(ctx context.Context, index int, row map[string]any, cursor tokenRangeCursor) error {
+	msg := service.NewMessage(nil)
+	msg.SetStructuredMut(row)
+
+	t.cpMut.Lock()
+	resolveFn := t.checkpoints[index].Track(cursor, 1)
+	t.cpMut.Unlock()
+
+	select {
+	case t.msgChan <- tokenRangeMessage{
+		msg: msg,
+		ackFn: func(ctx context.Context, err error) error {
+			t.cpMut.Lock()
+			highest := resolveFn()
+			t.cpMut.Unlock()
+			if highest == nil {
+				return nil
+			}
+			return t.storeCheckpoint(ctx, index, *highest)
+		},
+	}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resolveEmptyPage checkpoints the cursor following a page without any rows,
+// such as the only page of an empty range, which would otherwise never be
+// stored as there are no rows to acknowledge. The cursor takes effect once the
+// rows of prior pages are acknowledged.
+func (t *tokenRangeReader) resolveEmptyPage(ctx context.Context, index int, cursor tokenRangeCursor) {
+	t.cpMut.Lock()
+	highest := t.checkpoints[index].Track(cursor, 0)()
+	t.cpMut.Unlock()
+	if highest == nil {
+		return
+	}
+	if err := t.storeCheckpoint(ctx, index, *highest); err != nil {
+		t.log.Errorf("Failed to checkpoint token range %v: %v", index, err)
+	}
+}
+
+func (t *tokenRangeReader) loadCheckpoint(ctx context.Context) error {
+	if t.conf.cacheName == "" {
+		return nil
+	}
+
+	var cached []byte
+	var getErr error
+	if err := t.mgr.AccessCache(ctx, t.conf.cacheName, func(c service.Cache) {
+		cached, getErr = c.Get(ctx, t.conf.cacheKey)
+	}); err != nil {
+		return fmt.Errorf("failed to access checkpoint cache: %w", err)
+	}
+	if getErr != nil {
+		if errors.Is(getErr, service.ErrKeyNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get checkpoint: %w", getErr)
+	}
+
+	var cp tokenRangesCheckpoint
+	if err := json.Unmarshal(cached, &cp); err != nil {
+		return fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if cp.Splits != t.conf.splits {
+		return fmt.Errorf("checkpoint was created with %v splits, which differs from the configured %v splits", cp.Splits, t.conf.splits)
+	}
+	for i, cursor := range cp.Ranges {
+		if i >= 0 && i < len(t.ranges) {
+			t.stored[i] = cursor
+		}
+	}
+	return nil
+}
+
+func (t *tokenRangeReader) storeCheckpoint(ctx context.Context, index int, cursor tokenRangeCursor) error {
+	if t.conf.cacheName == "" {
+		return nil
+	}
+
+	t.storeMut.Lock()
+	defer t.storeMut.Unlock()
+
+	// Acknowledgements can arrive out of order, and therefore a cursor older
+	// than the one already stored is ignored.
+	prev := t.stored[index]
+	if cursor.seq <= prev.seq {
+		return nil
+	}
+	t.stored[index] = cursor
+
+	cpBytes, err := json.Marshal(tokenRangesCheckpoint{
+		Splits: t.conf.splits,
+		Ranges: t.stored,
+	})
+	if err != nil {
+		t.stored[index] = prev
+		return fmt.Errorf("failed to serialise checkpoint: %w", err)
+	}
+
+	var setErr error
+	if err := t.mgr.AccessCache(ctx, t.conf.cacheName, func(c service.Cache) {
+		setErr = c.Set(ctx, t.conf.cacheKey, cpBytes, nil)
+	}); err != nil {
+		setErr = fmt.Errorf("failed to access checkpoint cache: %w", err)
+	} else if setErr != nil {
+		setErr = fmt.Errorf("failed to store checkpoint: %w", setErr)
+	}
+	if setErr != nil {
+		t.stored[index] = prev
+	}
+	return setErr
+}
+
+func (t *tokenRangeReader) read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	select {
+	case m, open := <-t.msgChan:
+		if !open {
+			// An error that stopped reading is reported once, after which the
+			// input ends.
+			t.errMut.Lock()
+			err := t.readErr
+			t.readErr = nil
+			t.errMut.Unlock()
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, service.ErrEndOfInput
+		}
+		return m.msg, m.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (t *tokenRangeReader) close(ctx context.Context) error {
+	t.shutSig.CloseNow()
+	select {
+	case <-t.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
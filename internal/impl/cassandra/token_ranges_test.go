@@ -0,0 +1,194 @@
+package cassandra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSplitTokenRing(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 64, 1000} {
+		ranges := splitTokenRing(n)
+		require.Len(t, ranges, n)
+
+		assert.Equal(t, int64(math.MinInt64), ranges[0].start)
+		assert.Equal(t, int64(math.MaxInt64), ranges[n-1].end)
+		for i, r := range ranges {
+			assert.Less(t, r.start, r.end, "range %v of %v", i, n)
+			if i > 0 {
+				assert.Equal(t, ranges[i-1].end, r.start, "range %v of %v", i, n)
+			}
+		}
+	}
+
+	assert.Equal(t, []tokenRange{
+		{start: math.MinInt64, end: -1},
+		{start: -1, end: math.MaxInt64},
+	}, splitTokenRing(2))
+}
+
+func TestTokenRangeQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		keys        []string
+		expected    string
+		errContains string
+	}{
+		{
+			name:     "no where clause",
+			query:    "SELECT * FROM foo.bar",
+			keys:     []string{"id"},
+			expected: "SELECT * FROM foo.bar WHERE token(id) > ? AND token(id) <= ?",
+		},
+		{
+			name:     "composite partition key",
+			query:    "SELECT * FROM foo.bar;",
+			keys:     []string{"country", "user_email"},
+			expected: "SELECT * FROM foo.bar WHERE token(country, user_email) > ? AND token(country, user_email) <= ?",
+		},
+		{
+			name:     "existing where clause",
+			query:    "select id, content from foo.bar where content = 'hello'",
+			keys:     []string{"id"},
+			expected: "select id, content from foo.bar where content = 'hello' AND token(id) > ? AND token(id) <= ?",
+		},
+		{
+			name:     "allow filtering",
+			query:    "SELECT * FROM foo.bar WHERE content = 'hello' ALLOW FILTERING;",
+			keys:     []string{"id"},
+			expected: "SELECT * FROM foo.bar WHERE content = 'hello' AND token(id) > ? AND token(id) <= ? ALLOW FILTERING",
+		},
+		{
+			name:     "where within string literal",
+			query:    "SELECT * FROM foo.bar WHERE content = ' where it''s limit order by '",
+			keys:     []string{"id"},
+			expected: "SELECT * FROM foo.bar WHERE content = ' where it''s limit order by ' AND token(id) > ? AND token(id) <= ?",
+		},
+		{
+			name:     "where only within string literal",
+			query:    "SELECT id, ' WHERE ' FROM foo.bar",
+			keys:     []string{"id"},
+			expected: "SELECT id, ' WHERE ' FROM foo.bar WHERE token(id) > ? AND token(id) <= ?",
+		},
+		{
+			name:     "where within quoted identifier",
+			query:    `SELECT "a where b" FROM foo.bar`,
+			keys:     []string{"id"},
+			expected: `SELECT "a where b" FROM foo.bar WHERE token(id) > ? AND token(id) <= ?`,
+		},
+		{
+			name:        "limit",
+			query:       "SELECT * FROM foo.bar LIMIT 10",
+			keys:        []string{"id"},
+			errContains: "LIMIT clause",
+		},
+		{
+			name:        "per partition limit",
+			query:       "SELECT * FROM foo.bar PER PARTITION LIMIT 1",
+			keys:        []string{"id"},
+			errContains: "PER PARTITION LIMIT clause",
+		},
+		{
+			name:        "order by",
+			query:       "SELECT * FROM foo.bar WHERE id = 'a' order  by created",
+			keys:        []string{"id"},
+			errContains: "ORDER BY clause",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			res, err := tokenRangeQuery(test.query, test.keys)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+type testRequestErr struct {
+	code int
+}
+
+func (e testRequestErr) Code() int       { return e.code }
+func (e testRequestErr) Message() string { return "test" }
+func (e testRequestErr) Error() string   { return "test" }
+
+func TestTokenRangeRetryableErr(t *testing.T) {
+	assert.False(t, retryableErr(testRequestErr{code: gocql.ErrCodeSyntax}))
+	assert.False(t, retryableErr(fmt.Errorf("wrapped: %w", testRequestErr{code: gocql.ErrCodeInvalid})))
+	assert.True(t, retryableErr(testRequestErr{code: gocql.ErrCodeUnavailable}))
+	assert.True(t, retryableErr(gocql.ErrTimeoutNoResponse))
+	assert.True(t, retryableErr(errors.New("nope")))
+}
+
+func TestTokenRangeEmptyPageCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foo"))
+
+	conf := &tokenRangesConfig{splits: 2, parallelism: 1, pageSize: 10, cacheName: "foo", cacheKey: "bar"}
+	r := newTokenRangeReader(conf, "", nil, mgr)
+
+	storedRanges := func() map[int]tokenRangeCursor {
+		t.Helper()
+		var cached []byte
+		var getErr error
+		require.NoError(t, mgr.AccessCache(ctx, "foo", func(c service.Cache) {
+			cached, getErr = c.Get(ctx, "bar")
+		}))
+		if errors.Is(getErr, service.ErrKeyNotFound) {
+			return nil
+		}
+		require.NoError(t, getErr)
+
+		var cp tokenRangesCheckpoint
+		require.NoError(t, json.Unmarshal(cached, &cp))
+		return cp.Ranges
+	}
+
+	// A range without any rows is completed straight away.
+	r.resolveEmptyPage(ctx, 0, tokenRangeCursor{Done: true, seq: 1})
+	assert.Equal(t, map[int]tokenRangeCursor{0: {Done: true}}, storedRanges())
+
+	// An empty last page is only completed once prior rows are acknowledged.
+	go func() {
+		_ = r.send(ctx, 1, map[string]any{"id": 1}, tokenRangeCursor{PageState: []byte("baz"), seq: 1})
+	}()
+	msg, ackFn, err := r.read(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+
+	r.resolveEmptyPage(ctx, 1, tokenRangeCursor{Done: true, seq: 2})
+	assert.Equal(t, map[int]tokenRangeCursor{0: {Done: true}}, storedRanges())
+
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Equal(t, map[int]tokenRangeCursor{0: {Done: true}, 1: {Done: true}}, storedRanges())
+}
+
+func TestTokenRangeReadErrReportedOnce(t *testing.T) {
+	r := newTokenRangeReader(&tokenRangesConfig{splits: 1}, "", nil, service.MockResources())
+	r.readErr = errors.New("test failure")
+	close(r.msgChan)
+
+	_, _, err := r.read(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test failure")
+
+	_, _, err = r.read(context.Background())
+	assert.ErrorIs(t, err, service.ErrEndOfInput)
+}
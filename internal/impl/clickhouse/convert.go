@@ -0,0 +1,554 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// columnConverter converts values of structured messages into the Go types
+// that the driver accepts for a column type.
+type columnConverter interface {
+	convert(v any) (any, error)
+}
+
+type converterFunc func(v any) (any, error)
+
+func (f converterFunc) convert(v any) (any, error) {
+	return f(v)
+}
+
+// newColumnConverter returns a converter for a column type as it is described
+// by ClickHouse, e.g. `Array(LowCardinality(String))`.
+func newColumnConverter(chType string) (columnConverter, error) {
+	chType = strings.TrimSpace(chType)
+	name, params := chType, ""
+	if i := strings.IndexByte(chType, '('); i > 0 && strings.HasSuffix(chType, ")") {
+		name, params = chType[:i], chType[i+1:len(chType)-1]
+	}
+
+	switch name {
+	case "LowCardinality", "SimpleAggregateFunction":
+		args := splitTypeArgs(params)
+		return newColumnConverter(args[len(args)-1])
+	case "Nullable":
+		inner, err := newColumnConverter(params)
+		if err != nil {
+			return nil, err
+		}
+		return converterFunc(func(v any) (any, error) {
+			if v == nil {
+				return nil, nil
+			}
+			return inner.convert(v)
+		}), nil
+	case "Array":
+		inner, err := newColumnConverter(params)
+		if err != nil {
+			return nil, err
+		}
+		return newArrayConverter(inner), nil
+	case "Map":
+		args := splitTypeArgs(params)
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid map type: %v", chType)
+		}
+		keys, err := newColumnConverter(args[0])
+		if err != nil {
+			return nil, err
+		}
+		values, err := newColumnConverter(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return &mapConverter{keys: keys, values: values}, nil
+	case "Tuple":
+		return newTupleConverter(params)
+	case "Nested":
+		// Nested columns that are not flattened are arrays of named tuples.
+		tuple, err := newTupleConverter(params)
+		if err != nil {
+			return nil, err
+		}
+		return newArrayConverter(tuple), nil
+	case "String", "FixedString":
+		return converterFunc(convertString), nil
+	case "UUID":
+		return scalarConverter("00000000-0000-0000-0000-000000000000", convertString), nil
+	case "IPv4":
+		return scalarConverter("0.0.0.0", convertString), nil
+	case "IPv6":
+		return scalarConverter("::", convertString), nil
+	case "Enum8", "Enum16":
+		return converterFunc(convertEnum), nil
+	case "Bool", "Boolean":
+		return scalarConverter(false, func(v any) (any, error) {
+			return query.IToBool(query.ISanitize(v))
+		}), nil
+	case "Int8":
+		return intConverter(math.MinInt8, math.MaxInt8, func(i int64) any { return int8(i) }), nil
+	case "Int16":
+		return intConverter(math.MinInt16, math.MaxInt16, func(i int64) any { return int16(i) }), nil
+	case "Int32":
+		return intConverter(math.MinInt32, math.MaxInt32, func(i int64) any { return int32(i) }), nil
+	case "Int64":
+		return intConverter(math.MinInt64, math.MaxInt64, func(i int64) any { return i }), nil
+	case "UInt8":
+		return uintConverter(math.MaxUint8, func(u uint64) any { return uint8(u) }), nil
+	case "UInt16":
+		return uintConverter(math.MaxUint16, func(u uint64) any { return uint16(u) }), nil
+	case "UInt32":
+		return uintConverter(math.MaxUint32, func(u uint64) any { return uint32(u) }), nil
+	case "UInt64":
+		return uintConverter(math.MaxUint64, func(u uint64) any { return u }), nil
+	case "Int128", "Int256", "UInt128", "UInt256":
+		return scalarConverter(big.NewInt(0), convertBigInt), nil
+	case "Float32":
+		return scalarConverter(float32(0), func(v any) (any, error) {
+			f, err := query.IToNumber(query.ISanitize(v))
+			return float32(f), err
+		}), nil
+	case "Float64":
+		return scalarConverter(float64(0), func(v any) (any, error) {
+			return query.IToNumber(query.ISanitize(v))
+		}), nil
+	case "Decimal", "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		return scalarConverter(decimal.Zero, convertDecimal), nil
+	case "Date", "Date32", "DateTime":
+		return timeConverter(0), nil
+	case "DateTime64":
+		// The precision defaults to milliseconds when omitted.
+		precision := 3
+		if args := splitTypeArgs(params); len(args) > 0 {
+			p, err := strconv.Atoi(args[0])
+			if err != nil || p < 0 || p > 9 {
+				return nil, fmt.Errorf("invalid precision of type %v", chType)
+			}
+			precision = p
+		}
+		return timeConverter(precision), nil
+	}
+	return nil, fmt.Errorf("column type %v is not supported", chType)
+}
+
+// scalarConverter returns a converter that converts null values into the zero
+// value of a column, as the driver does for most types.
+func scalarConverter(zero any, fn func(v any) (any, error)) columnConverter {
+	return converterFunc(func(v any) (any, error) {
+		if v == nil {
+			return zero, nil
+		}
+		return fn(v)
+	})
+}
+
+func intConverter(min, max int64, cast func(i int64) any) columnConverter {
+	return scalarConverter(cast(0), func(v any) (any, error) {
+		i, err := query.IToInt(query.ISanitize(v))
+		if err != nil {
+			return nil, err
+		}
+		if i < min || i > max {
+			return nil, fmt.Errorf("value %v is out of range", i)
+		}
+		return cast(i), nil
+	})
+}
+
+func uintConverter(max uint64, cast func(u uint64) any) columnConverter {
+	return scalarConverter(cast(0), func(v any) (any, error) {
+		u, err := query.IToUint(sanitizeNumber(v))
+		if err != nil {
+			return nil, err
+		}
+		if u > max {
+			return nil, fmt.Errorf("value %v is out of range", u)
+		}
+		return cast(u), nil
+	})
+}
+
+// timeLayouts are the layouts of date and time strings that are accepted in
+// addition to RFC 3339, which match the formats that ClickHouse parses by
+// default. Strings without a time zone are parsed as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// timeConverter converts values into timestamps. Integers are interpreted as
+// ticks of the precision of a column, i.e. unix seconds for a precision of
+// zero and unix milliseconds for a precision of three, as ClickHouse does for
+// DateTime64 columns, and other numbers as unix seconds.
+func timeConverter(precision int) columnConverter {
+	return scalarConverter(time.Unix(0, 0), func(v any) (any, error) {
+		switch t := v.(type) {
+		case string:
+			return parseTime(t)
+		case []byte:
+			return parseTime(string(t))
+		}
+		if precision > 0 {
+			scale := int64(math.Pow10(precision))
+			switch t := query.ISanitize(v).(type) {
+			case int64:
+				return time.Unix(t/scale, (t%scale)*int64(math.Pow10(9-precision))), nil
+			case uint64:
+				return time.Unix(int64(t/uint64(scale)), int64(t%uint64(scale))*int64(math.Pow10(9-precision))), nil
+			}
+		}
+		return query.IGetTimestamp(v)
+	})
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse %q as a timestamp", s)
+}
+
+// convertString converts values into strings, where objects and arrays are
+// serialised as JSON.
+func convertString(v any) (any, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case map[string]any, []any:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return query.IToString(query.ISanitize(v)), nil
+}
+
+// convertEnum converts values into either the name or the value of an enum.
+func convertEnum(v any) (any, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case nil:
+		return nil, errors.New("enum columns require a value")
+	}
+	i, err := query.IToInt(query.ISanitize(v))
+	if err != nil {
+		return nil, err
+	}
+	return int(i), nil
+}
+
+// sanitizeNumber is similar to query.ISanitize but preserves unsigned integers
+// that exceed the range of signed integers.
+func sanitizeNumber(v any) any {
+	if n, ok := v.(json.Number); ok {
+		if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+			return u
+		}
+	}
+	return query.ISanitize(v)
+}
+
+func convertBigInt(v any) (any, error) {
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+	switch t := query.ISanitize(v).(type) {
+	case int64:
+		return big.NewInt(t), nil
+	case uint64:
+		return new(big.Int).SetUint64(t), nil
+	case string, []byte:
+		s := query.IToString(t)
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse %q as an integer", s)
+		}
+		return i, nil
+	}
+	i, err := query.IToInt(query.ISanitize(v))
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(i), nil
+}
+
+func convertDecimal(v any) (any, error) {
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+	switch t := query.ISanitize(v).(type) {
+	case int64:
+		return decimal.NewFromInt(t), nil
+	case float64:
+		return decimal.NewFromFloat(t), nil
+	case string, []byte:
+		return decimal.NewFromString(query.IToString(t))
+	}
+	f, err := query.IToNumber(query.ISanitize(v))
+	if err != nil {
+		return nil, err
+	}
+	return decimal.NewFromFloat(f), nil
+}
+
+//------------------------------------------------------------------------------
+
+// arrayConverter converts arrays into slices. Arrays of arrays are converted
+// into slices of slices, as the driver cannot descend into elements of type
+// any.
+type arrayConverter struct {
+	elems     columnConverter
+	sliceType reflect.Type
+}
+
+func newArrayConverter(elems columnConverter) *arrayConverter {
+	sliceType := reflect.TypeOf([]any{})
+	if inner, ok := elems.(*arrayConverter); ok {
+		sliceType = reflect.SliceOf(inner.sliceType)
+	}
+	return &arrayConverter{elems: elems, sliceType: sliceType}
+}
+
+func (a *arrayConverter) convert(v any) (any, error) {
+	var arr []any
+	switch t := v.(type) {
+	case nil:
+	case []any:
+		arr = t
+	default:
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+
+	slice := reflect.MakeSlice(a.sliceType, len(arr), len(arr))
+	for i, e := range arr {
+		c, err := a.elems.convert(e)
+		if err != nil {
+			return nil, fmt.Errorf("element %v: %w", i, err)
+		}
+		if c != nil {
+			slice.Index(i).Set(reflect.ValueOf(c))
+		}
+	}
+	return slice.Interface(), nil
+}
+
+// orderedMap implements the ordered map interface of the driver, which allows
+// maps to be written without their keys and values being of concrete types.
+type orderedMap struct {
+	keys   []any
+	values map[any]any
+}
+
+func (m *orderedMap) Get(key any) (any, bool) {
+	v, exists := m.values[key]
+	return v, exists
+}
+
+func (m *orderedMap) Put(key, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) Keys() <-chan any {
+	ch := make(chan any, len(m.keys))
+	for _, k := range m.keys {
+		ch <- k
+	}
+	close(ch)
+	return ch
+}
+
+type mapConverter struct {
+	keys   columnConverter
+	values columnConverter
+}
+
+func (m *mapConverter) convert(v any) (any, error) {
+	var obj map[string]any
+	switch t := v.(type) {
+	case nil:
+	case map[string]any:
+		obj = t
+	default:
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	om := &orderedMap{values: make(map[any]any, len(obj))}
+	for _, k := range keys {
+		ck, err := m.keys.convert(k)
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", k, err)
+		}
+		cv, err := m.values.convert(obj[k])
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", k, err)
+		}
+		om.Put(ck, cv)
+	}
+	return om, nil
+}
+
+type tupleElement struct {
+	name string
+	conv columnConverter
+}
+
+// tupleConverter converts objects into named tuples and arrays into unnamed
+// tuples.
+type tupleConverter struct {
+	elems []tupleElement
+	named bool
+}
+
+func newTupleConverter(params string) (*tupleConverter, error) {
+	t := &tupleConverter{}
+	for i, arg := range splitTypeArgs(params) {
+		var name string
+		elemType := arg
+		if sp := strings.IndexByte(arg, ' '); sp > 0 && !strings.ContainsAny(arg[:sp], "(") {
+			name, elemType = strings.Trim(arg[:sp], "`\""), arg[sp+1:]
+		}
+		if i == 0 {
+			t.named = name != ""
+		} else if t.named != (name != "") {
+			return nil, fmt.Errorf("tuple elements must either all be named or unnamed: %v", params)
+		}
+		conv, err := newColumnConverter(elemType)
+		if err != nil {
+			return nil, err
+		}
+		t.elems = append(t.elems, tupleElement{name: name, conv: conv})
+	}
+	if len(t.elems) == 0 {
+		return nil, errors.New("tuples must have at least one element")
+	}
+	return t, nil
+}
+
+func (t *tupleConverter) convert(v any) (any, error) {
+	if t.named {
+		obj, ok := v.(map[string]any)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expected an object, got %T", v)
+		}
+		res := make(map[string]any, len(t.elems))
+		for _, e := range t.elems {
+			c, err := e.conv.convert(obj[e.name])
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %w", e.name, err)
+			}
+			res[e.name] = c
+		}
+		return res, nil
+	}
+
+	arr, ok := v.([]any)
+	if !ok && v != nil {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	if v != nil && len(arr) != len(t.elems) {
+		return nil, fmt.Errorf("expected an array of %v elements, got %v", len(t.elems), len(arr))
+	}
+	res := make([]any, len(t.elems))
+	for i, e := range t.elems {
+		var ev any
+		if i < len(arr) {
+			ev = arr[i]
+		}
+		c, err := e.conv.convert(ev)
+		if err != nil {
+			return nil, fmt.Errorf("element %v: %w", i, err)
+		}
+		res[i] = c
+	}
+	return res, nil
+}
+
+// splitTypeArgs splits the parameters of a type by the commas that are not
+// nested within parentheses or quotes.
+func splitTypeArgs(params string) []string {
+	var args []string
+	var depth int
+	var quote rune
+	start := 0
+	for i, r := range params {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '`' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(params[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(params[start:]); last != "" {
+		args = append(args, last)
+	}
+	return args
+}
+
+//------------------------------------------------------------------------------
+
+// columnValue returns the value of a column from an object. The columns of
+// flattened nested structures, which are named `n.a`, are read either from the
+// field `n.a` or from the field `a` of each object within the array `n`.
+func columnValue(obj map[string]any, column string) any {
+	if v, exists := obj[column]; exists {
+		return v
+	}
+
+	i := strings.IndexByte(column, '.')
+	if i <= 0 {
+		return nil
+	}
+	nested, ok := obj[column[:i]].([]any)
+	if !ok {
+		return nil
+	}
+	field := column[i+1:]
+	values := make([]any, len(nested))
+	for j, e := range nested {
+		if eObj, ok := e.(map[string]any); ok {
+			values[j] = eObj[field]
+		}
+	}
+	return values
+}
@@ -0,0 +1,166 @@
+package clickhouse
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTypeArgs(t *testing.T) {
+	assert.Equal(t, []string{"String", "Array(Tuple(a Int64, b String))"}, splitTypeArgs("String, Array(Tuple(a Int64, b String))"))
+	assert.Equal(t, []string{"'a,b' = 1", "'c' = 2"}, splitTypeArgs("'a,b' = 1, 'c' = 2"))
+	assert.Equal(t, []string{"3", "'Europe/London'"}, splitTypeArgs("3, 'Europe/London'"))
+	assert.Empty(t, splitTypeArgs(""))
+}
+
+func TestColumnConverters(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	tests := []struct {
+		chType   string
+		input    any
+		expected any
+	}{
+		{chType: "String", input: "hello", expected: "hello"},
+		{chType: "String", input: map[string]any{"a": "b"}, expected: `{"a":"b"}`},
+		{chType: "String", input: json.Number("10"), expected: "10"},
+		{chType: "FixedString(2)", input: "ab", expected: "ab"},
+		{chType: "LowCardinality(String)", input: []byte("foo"), expected: "foo"},
+		{chType: "LowCardinality(Nullable(String))", input: nil, expected: nil},
+		{chType: "Nullable(Int32)", input: nil, expected: nil},
+		{chType: "Nullable(Int32)", input: json.Number("5"), expected: int32(5)},
+		{chType: "Int8", input: json.Number("-5"), expected: int8(-5)},
+		{chType: "Int64", input: nil, expected: int64(0)},
+		{chType: "UInt16", input: "65535", expected: uint16(65535)},
+		{chType: "UInt64", input: json.Number("18446744073709551615"), expected: uint64(18446744073709551615)},
+		{chType: "Int128", input: json.Number("170141183460469231731687303715884105727"), expected: func() *big.Int {
+			i, _ := new(big.Int).SetString("170141183460469231731687303715884105727", 10)
+			return i
+		}()},
+		{chType: "Float32", input: json.Number("1.5"), expected: float32(1.5)},
+		{chType: "Float64", input: 2.5, expected: 2.5},
+		{chType: "Bool", input: true, expected: true},
+		{chType: "Decimal(18, 4)", input: json.Number("12345.6789"), expected: decimal.RequireFromString("12345.6789")},
+		{chType: "UUID", input: "7e1c3f70-3c8a-4b5e-9d3a-0d2f3c6f6b1a", expected: "7e1c3f70-3c8a-4b5e-9d3a-0d2f3c6f6b1a"},
+		{chType: "IPv4", input: "127.0.0.1", expected: "127.0.0.1"},
+		{chType: "Enum8('a' = 1, 'b' = 2)", input: "b", expected: "b"},
+		{chType: "Enum8('a' = 1, 'b' = 2)", input: json.Number("1"), expected: 1},
+		{chType: "DateTime", input: json.Number("1672628645"), expected: time.Unix(1672628645, 0)},
+		{chType: "DateTime64(3, 'UTC')", input: "2023-01-02T03:04:05.006Z", expected: ts},
+		{chType: "Date32", input: ts, expected: ts},
+		{chType: "Date", input: "2023-01-02", expected: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{chType: "Date32", input: []byte("2023-01-02"), expected: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{chType: "DateTime", input: "2023-01-02 03:04:05", expected: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{chType: "Nullable(DateTime('UTC'))", input: "2023-01-02T03:04:05", expected: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{chType: "DateTime64(3)", input: "2023-01-02 03:04:05.006", expected: ts},
+		{chType: "DateTime64(3)", input: json.Number("1672628645006"), expected: time.Unix(1672628645, 6000000)},
+		{chType: "DateTime64(6, 'UTC')", input: int64(1672628645006000), expected: time.Unix(1672628645, 6000000)},
+		{chType: "DateTime64(9)", input: json.Number("-1000000001"), expected: time.Unix(-1, -1)},
+		{chType: "DateTime64(0)", input: json.Number("1672628645"), expected: time.Unix(1672628645, 0)},
+		{chType: "DateTime64(3)", input: json.Number("1672628645.5"), expected: time.Unix(1672628645, 500000000)},
+		{chType: "Array(Int32)", input: []any{json.Number("1"), json.Number("2")}, expected: []any{int32(1), int32(2)}},
+		{chType: "Array(Int32)", input: nil, expected: []any{}},
+		{chType: "Array(Array(String))", input: []any{[]any{"a"}, []any{"b", "c"}}, expected: [][]any{{"a"}, {"b", "c"}}},
+		{chType: "Array(Nullable(String))", input: []any{"a", nil}, expected: []any{"a", nil}},
+		{chType: "Tuple(a Int64, b String)", input: map[string]any{"a": json.Number("1")}, expected: map[string]any{"a": int64(1), "b": ""}},
+		{chType: "Tuple(Int64, String)", input: []any{json.Number("1"), "x"}, expected: []any{int64(1), "x"}},
+		{chType: "Nested(a Int64, b String)", input: []any{map[string]any{"a": json.Number("1"), "b": "x"}}, expected: []any{map[string]any{"a": int64(1), "b": "x"}}},
+	}
+
+	for _, test := range tests {
+		conv, err := newColumnConverter(test.chType)
+		require.NoError(t, err, test.chType)
+
+		res, err := conv.convert(test.input)
+		require.NoError(t, err, test.chType)
+		assert.Equal(t, test.expected, res, test.chType)
+
+		// The driver must accept the converted value.
+		col, err := column.Type(test.chType).Column("test", time.UTC)
+		require.NoError(t, err, test.chType)
+		require.NoError(t, col.AppendRow(res), test.chType)
+		assert.Equal(t, 1, col.Rows(), test.chType)
+	}
+}
+
+func TestColumnConverterMap(t *testing.T) {
+	conv, err := newColumnConverter("Map(LowCardinality(String), Array(UInt8))")
+	require.NoError(t, err)
+
+	res, err := conv.convert(map[string]any{
+		"b": []any{json.Number("2")},
+		"a": []any{json.Number("1"), json.Number("3")},
+	})
+	require.NoError(t, err)
+
+	om, ok := res.(*orderedMap)
+	require.True(t, ok)
+	assert.Equal(t, []any{"a", "b"}, om.keys)
+	assert.Equal(t, []any{uint8(1), uint8(3)}, om.values["a"])
+
+	col, err := column.Type("Map(LowCardinality(String), Array(UInt8))").Column("test", time.UTC)
+	require.NoError(t, err)
+	require.NoError(t, col.AppendRow(res))
+	assert.Equal(t, 1, col.Rows())
+
+	intConv, err := newColumnConverter("Map(UInt32, String)")
+	require.NoError(t, err)
+	res, err = intConv.convert(map[string]any{"10": "ten"})
+	require.NoError(t, err)
+	assert.Equal(t, "ten", res.(*orderedMap).values[uint32(10)])
+}
+
+func TestColumnConverterErrors(t *testing.T) {
+	tests := []struct {
+		chType string
+		input  any
+	}{
+		{chType: "Int8", input: json.Number("200")},
+		{chType: "UInt8", input: json.Number("-1")},
+		{chType: "Int64", input: "nope"},
+		{chType: "Array(Int64)", input: "nope"},
+		{chType: "Map(String, String)", input: []any{}},
+		{chType: "Tuple(Int64, String)", input: []any{json.Number("1")}},
+		{chType: "DateTime64(3)", input: "yesterday"},
+		{chType: "Date", input: "2023-01-02 03:04:05 +0100"},
+		{chType: "Enum8('a' = 1)", input: nil},
+	}
+
+	for _, test := range tests {
+		conv, err := newColumnConverter(test.chType)
+		require.NoError(t, err, test.chType)
+
+		_, err = conv.convert(test.input)
+		assert.Error(t, err, test.chType)
+	}
+
+	_, err := newColumnConverter("Object('json')")
+	assert.Error(t, err)
+
+	_, err = newColumnConverter("DateTime64(10)")
+	assert.Error(t, err)
+}
+
+func TestColumnValue(t *testing.T) {
+	obj := map[string]any{
+		"id": json.Number("1"),
+		"n": []any{
+			map[string]any{"a": json.Number("1"), "b": "x"},
+			map[string]any{"a": json.Number("2")},
+		},
+		"m.a": []any{"flat"},
+	}
+
+	assert.Equal(t, json.Number("1"), columnValue(obj, "id"))
+	assert.Equal(t, []any{json.Number("1"), json.Number("2")}, columnValue(obj, "n.a"))
+	assert.Equal(t, []any{"x", nil}, columnValue(obj, "n.b"))
+	assert.Equal(t, []any{"flat"}, columnValue(obj, "m.a"))
+	assert.Nil(t, columnValue(obj, "missing"))
+	assert.Nil(t, columnValue(obj, "missing.a"))
+}
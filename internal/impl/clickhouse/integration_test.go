@@ -0,0 +1,124 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestIntegrationClickHouseOutput(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+	pool.MaxWait = 3 * time.Minute
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   "clickhouse/clickhouse-server",
+		ExposedPorts: []string{"9000/tcp"},
+	})
+	require.NoError(t, err)
+
+	var conn driver.Conn
+	t.Cleanup(func() {
+		if err = pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %s", err)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	})
+
+	addr := fmt.Sprintf("localhost:%s", resource.GetPort("9000/tcp"))
+	require.NoError(t, pool.Retry(func() error {
+		if conn, err = clickhouse.Open(&clickhouse.Options{Addr: []string{addr}}); err != nil {
+			return err
+		}
+		if err = conn.Ping(context.Background()); err != nil {
+			conn.Close()
+			conn = nil
+			return err
+		}
+		return nil
+	}))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	require.NoError(t, conn.Exec(ctx, `CREATE TABLE events (
+  id UInt64,
+  ts DateTime64(3, 'UTC'),
+  level LowCardinality(String),
+  amount Nullable(Decimal(18, 2)),
+  tags Array(String),
+  labels Map(String, String),
+  items Nested(name String, qty UInt32),
+  id_str String MATERIALIZED toString(id)
+) ENGINE = MergeTree ORDER BY id`))
+
+	streamBuilder := service.NewStreamBuilder()
+	require.NoError(t, streamBuilder.SetLoggerYAML(`level: OFF`))
+	require.NoError(t, streamBuilder.AddOutputYAML(fmt.Sprintf(`
+clickhouse:
+  addresses: [ %v ]
+  table: events
+  batching:
+    count: 10
+`, addr)))
+
+	produce, err := streamBuilder.AddBatchProducerFunc()
+	require.NoError(t, err)
+
+	stream, err := streamBuilder.Build()
+	require.NoError(t, err)
+
+	go func() {
+		_ = stream.Run(context.Background())
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, stream.StopWithin(10*time.Second))
+	})
+
+	var batch service.MessageBatch
+	for i := 0; i < 10; i++ {
+		batch = append(batch, service.NewMessage([]byte(fmt.Sprintf(`{
+  "id": %v,
+  "ts": "2023-01-02T03:04:05.%03dZ",
+  "level": "info",
+  "amount": "%v.25",
+  "tags": [ "a", "b" ],
+  "labels": { "host": "foo" },
+  "items": [ { "name": "bar", "qty": %v } ]
+}`, i, i, i, i))))
+	}
+	require.NoError(t, produce(ctx, batch))
+
+	var count uint64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT count() FROM events").Scan(&count))
+	assert.Equal(t, uint64(10), count)
+
+	var (
+		ts     time.Time
+		labels map[string]string
+		names  []string
+		qtys   []uint32
+	)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT ts, labels, items.name, items.qty FROM events WHERE id = 3").Scan(&ts, &labels, &names, &qtys))
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 3000000, time.UTC), ts.UTC())
+	assert.Equal(t, map[string]string{"host": "foo"}, labels)
+	assert.Equal(t, []string{"bar"}, names)
+	assert.Equal(t, []uint32{3}, qtys)
+}
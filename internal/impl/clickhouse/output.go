@@ -0,0 +1,576 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func clickhouseOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Inserts rows into a ClickHouse table using the native protocol, where each message batch is sent as a single block.").
+		Description(`
+Each message must be an object, and the value of each column is taken from the field of the same name. The columns of the table and their types are queried when the output connects, and again when an insert fails because they have been altered, and the fields of each message are converted into the type of their column, including the types `+"`Nullable`, `LowCardinality`, `Array`, `Map`, `Tuple`, `Nested`, `DateTime64` and `Decimal`"+`. Fields that are missing from a message are inserted as null values or as the zero value of their column, and fields that do not match a column are ignored.
+
+Date and time columns accept RFC 3339 timestamps, strings of the form `+"`2006-01-02`"+` or `+"`2006-01-02 15:04:05`"+`, which are parsed as UTC, and unix timestamps. Integer timestamps are interpreted at the precision of `+"`DateTime64`"+` columns, e.g. as milliseconds for `+"`DateTime64(3)`"+`, as ClickHouse does.
+
+Columns of `+"`Nested`"+` structures are named `+"`n.a`, `n.b`"+` and so on when nested structures are flattened, which is the default, and their values can either be provided by fields of those names or by a field `+"`n`"+` that contains an array of objects with the fields `+"`a` and `b`"+`.
+
+Messages that cannot be converted into a row are failed individually, and the remaining messages of the batch are inserted. Failed inserts of a batch are retried according to `+"`backoff`"+` when they are caused by connection errors or by exceptions that are likely to be temporary, such as exceeding the number of parts of a table.
+
+### Asynchronous Inserts
+
+Inserting many small batches into ClickHouse is inefficient, and when batches cannot be made larger the server can buffer them instead with [asynchronous inserts](https://clickhouse.com/docs/en/optimize/asynchronous-inserts), which are enabled with the field `+"`async_insert`"+`. When `+"`async_insert.wait`"+` is disabled batches are acknowledged as soon as they are buffered by the server, and therefore can be lost if the server fails before flushing them.`).
+		Field(service.NewStringListField("addresses").
+			Description("A list of addresses of ClickHouse servers to connect to using the native protocol. Multiple comma separated addresses can be specified on a single line.").
+			Example([]string{"localhost:9000"}).
+			Example([]string{"foo:9000,bar:9000"})).
+		Field(service.NewStringField("database").
+			Description("The database of the table. When empty the default database of the user is used.").
+			Default("")).
+		Field(service.NewStringField("username").
+			Description("The user to authenticate as.").
+			Default("default")).
+		Field(service.NewStringField("password").
+			Description("The password of the user.").
+			Default("").
+			Secret()).
+		Field(service.NewStringField("table").
+			Description("The table to insert rows into, which can be qualified with its database.").
+			Example("events").
+			Example("analytics.events")).
+		Field(service.NewStringListField("columns").
+			Description("An optional list of columns to insert. When empty all columns of the table are inserted, except for those with `MATERIALIZED` or `ALIAS` expressions.").
+			Example([]string{"id", "timestamp", "message"}).
+			Default([]string{}).
+			Advanced()).
+		Field(service.NewStringEnumField("compression", "none", "lz4", "zstd").
+			Description("The compression algorithm of the data that is sent.").
+			Default("lz4").
+			Advanced()).
+		Field(service.NewObjectField("async_insert",
+			service.NewBoolField("enabled").
+				Description("Whether the server buffers batches and inserts them asynchronously.").
+				Default(false),
+			service.NewBoolField("wait").
+				Description("Whether to wait for buffered batches to be inserted into the table before they are acknowledged.").
+				Default(true),
+		).
+			Description("Settings for asynchronous inserts, which require a version of ClickHouse that supports them for the native protocol.").
+			Advanced()).
+		Field(service.NewStringMapField("settings").
+			Description("A map of additional [settings](https://clickhouse.com/docs/en/operations/settings/settings) applied to inserts.").
+			Example(map[string]any{"insert_deduplicate": "0"}).
+			Default(map[string]any{}).
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewDurationField("dial_timeout").
+			Description("The maximum period of time to wait when establishing a connection.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewBackOffField("backoff", false, &backoff.ExponentialBackOff{
+			InitialInterval: time.Second,
+			MaxInterval:     time.Second * 10,
+			MaxElapsedTime:  time.Minute,
+		}).
+			Description("Determines how failed inserts of a batch are retried before the batch is failed.").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Structured Logs", `
+Here we insert structured logs into a table, which was created with `+"`CREATE TABLE logs (timestamp DateTime64(3), level LowCardinality(String), message String, labels Map(String, String)) ENGINE = MergeTree ORDER BY timestamp`"+`, in batches of up to ten thousand rows:`, `
+output:
+  clickhouse:
+    addresses: [ localhost:9000 ]
+    table: logs
+    batching:
+      count: 10000
+      period: 5s
+`).
+		Example("Asynchronous Inserts", `
+Here we insert small batches of events, which the server buffers and inserts asynchronously:`, `
+output:
+  clickhouse:
+    addresses: [ localhost:9000 ]
+    database: analytics
+    table: events
+    async_insert:
+      enabled: true
+      wait: true
+    batching:
+      count: 100
+      period: 1s
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"clickhouse", clickhouseOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newClickHouseOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type clickhouseColumn struct {
+	name string
+	conv columnConverter
+}
+
+type clickhouseOutput struct {
+	addresses   []string
+	database    string
+	username    string
+	password    string
+	table       string
+	columnNames []string
+	compression clickhouse.CompressionMethod
+	settings    clickhouse.Settings
+	tlsConf     *tls.Config
+	tlsEnabled  bool
+	dialTimeout time.Duration
+	backoff     *backoff.ExponentialBackOff
+
+	conn        driver.Conn
+	columns     []clickhouseColumn
+	insertQuery string
+	connMut     sync.RWMutex
+
+	log *service.Logger
+}
+
+func newClickHouseOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*clickhouseOutput, error) {
+	c := &clickhouseOutput{
+		log:      mgr.Logger(),
+		settings: clickhouse.Settings{},
+	}
+
+	addresses, err := conf.FieldStringList("addresses")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addresses {
+		for _, splitAddr := range strings.Split(a, ",") {
+			if splitAddr = strings.TrimSpace(splitAddr); splitAddr != "" {
+				c.addresses = append(c.addresses, splitAddr)
+			}
+		}
+	}
+	if len(c.addresses) == 0 {
+		return nil, errors.New("at least one address must be specified")
+	}
+
+	if c.database, err = conf.FieldString("database"); err != nil {
+		return nil, err
+	}
+	if c.username, err = conf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if c.password, err = conf.FieldString("password"); err != nil {
+		return nil, err
+	}
+	if c.table, err = conf.FieldString("table"); err != nil {
+		return nil, err
+	}
+	if c.columnNames, err = conf.FieldStringList("columns"); err != nil {
+		return nil, err
+	}
+
+	compression, err := conf.FieldString("compression")
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case "none":
+		c.compression = clickhouse.CompressionNone
+	case "lz4":
+		c.compression = clickhouse.CompressionLZ4
+	case "zstd":
+		c.compression = clickhouse.CompressionZSTD
+	default:
+		return nil, fmt.Errorf("compression %v is not supported", compression)
+	}
+
+	settings, err := conf.FieldStringMap("settings")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range settings {
+		c.settings[k] = v
+	}
+
+	asyncConf := conf.Namespace("async_insert")
+	asyncEnabled, err := asyncConf.FieldBool("enabled")
+	if err != nil {
+		return nil, err
+	}
+	if asyncEnabled {
+		asyncWait, err := asyncConf.FieldBool("wait")
+		if err != nil {
+			return nil, err
+		}
+		c.settings["async_insert"] = 1
+		c.settings["wait_for_async_insert"] = 0
+		if asyncWait {
+			c.settings["wait_for_async_insert"] = 1
+		}
+	}
+
+	if c.tlsConf, c.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+	if c.dialTimeout, err = conf.FieldDuration("dial_timeout"); err != nil {
+		return nil, err
+	}
+	if c.backoff, err = conf.FieldBackOff("backoff"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *clickhouseOutput) Connect(ctx context.Context) error {
+	c.connMut.Lock()
+	defer c.connMut.Unlock()
+
+	if c.conn != nil {
+		return nil
+	}
+
+	opts := &clickhouse.Options{
+		Addr: c.addresses,
+		Auth: clickhouse.Auth{
+			Database: c.database,
+			Username: c.username,
+			Password: c.password,
+		},
+		Compression: &clickhouse.Compression{
+			Method: c.compression,
+		},
+		DialTimeout: c.dialTimeout,
+	}
+	if c.tlsEnabled {
+		opts.TLS = c.tlsConf
+	}
+
+	conn, err := clickhouse.Open(opts)
+	if err != nil {
+		return err
+	}
+	if err := conn.Ping(ctx); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	columns, err := c.tableColumns(ctx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c.conn = conn
+	c.columns = columns
+	c.insertQuery = c.insertQueryFor(columns)
+	c.log.Infof("Inserting rows into ClickHouse table %v at addresses: %s", c.table, c.addresses)
+	return nil
+}
+
+// tableColumns queries the insertable columns of the table and creates a
+// converter for each of them.
+func (c *clickhouseOutput) tableColumns(ctx context.Context, conn driver.Conn) ([]clickhouseColumn, error) {
+	database, table := c.database, c.table
+	if i := strings.IndexByte(table, '.'); i > 0 {
+		database, table = table[:i], table[i+1:]
+	}
+
+	rows, err := conn.Query(ctx, `
+SELECT name, type FROM system.columns
+WHERE database = if(? = '', currentDatabase(), ?) AND table = ? AND default_kind NOT IN ('MATERIALIZED', 'ALIAS')
+ORDER BY position`, database, database, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of table %v: %w", c.table, err)
+	}
+	defer rows.Close()
+
+	colTypes := map[string]string{}
+	var tableCols []string
+	for rows.Next() {
+		var name, colType string
+		if err := rows.Scan(&name, &colType); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of table %v: %w", c.table, err)
+		}
+		colTypes[name] = colType
+		tableCols = append(tableCols, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query columns of table %v: %w", c.table, err)
+	}
+	if len(tableCols) == 0 {
+		return nil, fmt.Errorf("table %v was not found or has no insertable columns", c.table)
+	}
+
+	names := c.columnNames
+	if len(names) == 0 {
+		names = tableCols
+	}
+
+	columns := make([]clickhouseColumn, 0, len(names))
+	for _, name := range names {
+		colType, exists := colTypes[name]
+		if !exists {
+			return nil, fmt.Errorf("column %v is not an insertable column of table %v", name, c.table)
+		}
+		conv, err := newColumnConverter(colType)
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %w", name, err)
+		}
+		columns = append(columns, clickhouseColumn{name: name, conv: conv})
+	}
+	return columns, nil
+}
+
+func (c *clickhouseOutput) insertQueryFor(columns []clickhouseColumn) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.name)
+	}
+	return fmt.Sprintf("INSERT INTO %v (%v)", quoteTable(c.table), strings.Join(names, ", "))
+}
+
+// refreshColumns queries the columns of the table again, and replaces the
+// columns used by later batches when the connection has not changed since.
+func (c *clickhouseOutput) refreshColumns(ctx context.Context, conn driver.Conn) ([]clickhouseColumn, string, error) {
+	columns, err := c.tableColumns(ctx, conn)
+	if err != nil {
+		return nil, "", err
+	}
+	insertQuery := c.insertQueryFor(columns)
+
+	c.connMut.Lock()
+	if c.conn == conn {
+		c.columns, c.insertQuery = columns, insertQuery
+	}
+	c.connMut.Unlock()
+	return columns, insertQuery, nil
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
+
+// quoteTable quotes a table name, which can be qualified with its database.
+func quoteTable(name string) string {
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return quoteIdentifier(name[:i]) + "." + quoteIdentifier(name[i+1:])
+	}
+	return quoteIdentifier(name)
+}
+
+//------------------------------------------------------------------------------
+
+// rowFromMessage converts a message into the values of a row.
+func rowFromMessage(columns []clickhouseColumn, msg *service.Message) ([]any, error) {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	row := make([]any, len(columns))
+	for i, col := range columns {
+		if row[i], err = col.conv.convert(columnValue(obj, col.name)); err != nil {
+			return nil, fmt.Errorf("column %v: %w", col.name, err)
+		}
+	}
+	return row, nil
+}
+
+// retryableExceptionCodes are the codes of exceptions that are likely to be
+// temporary.
+var retryableExceptionCodes = map[int32]struct{}{
+	159: {}, // TIMEOUT_EXCEEDED
+	202: {}, // TOO_MANY_SIMULTANEOUS_QUERIES
+	209: {}, // SOCKET_TIMEOUT
+	210: {}, // NETWORK_ERROR
+	241: {}, // MEMORY_LIMIT_EXCEEDED
+	242: {}, // TABLE_IS_READ_ONLY
+	252: {}, // TOO_MANY_PARTS
+	319: {}, // UNKNOWN_STATUS_OF_INSERT
+	425: {}, // SYSTEM_ERROR
+}
+
+// schemaExceptionCodes are the codes of exceptions that indicate that the
+// columns of the table have changed since they were queried.
+var schemaExceptionCodes = map[int32]struct{}{
+	8:  {}, // THERE_IS_NO_COLUMN
+	10: {}, // NOT_FOUND_COLUMN_IN_BLOCK
+	16: {}, // NO_SUCH_COLUMN_IN_TABLE
+	47: {}, // UNKNOWN_IDENTIFIER
+	53: {}, // TYPE_MISMATCH
+}
+
+// isSchemaMismatch returns whether an insert failed because the columns of the
+// table no longer match those that were queried, either due to an exception or
+// due to the driver rejecting a value converted for the previous column type.
+func isSchemaMismatch(err error) bool {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		_, mismatch := schemaExceptionCodes[exception.Code]
+		return mismatch
+	}
+	var blockErr *proto.BlockError
+	if errors.As(err, &blockErr) {
+		var convErr *column.ColumnConverterError
+		return errors.As(blockErr.Err, &convErr)
+	}
+	return false
+}
+
+// isRetryable returns whether an insert that failed with an error could
+// succeed if attempted again.
+func isRetryable(err error) bool {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		_, retryable := retryableExceptionCodes[exception.Code]
+		return retryable
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+func (c *clickhouseOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	c.connMut.RLock()
+	conn, columns, insertQuery := c.conn, c.columns, c.insertQuery
+	c.connMut.RUnlock()
+
+	if conn == nil {
+		return service.ErrNotConnected
+	}
+
+	err := c.writeBatch(ctx, conn, columns, insertQuery, batch)
+	if err == nil || !isSchemaMismatch(err) {
+		return err
+	}
+
+	// The batch is converted again with the current columns of the table, as
+	// they could have been altered since they were queried.
+	c.log.Infof("Columns of table %v do not match the insert, querying them again: %v", c.table, err)
+	if columns, insertQuery, err = c.refreshColumns(ctx, conn); err != nil {
+		return err
+	}
+	return c.writeBatch(ctx, conn, columns, insertQuery, batch)
+}
+
+func (c *clickhouseOutput) writeBatch(ctx context.Context, conn driver.Conn, columns []clickhouseColumn, insertQuery string, batch service.MessageBatch) error {
+	var batchErr *service.BatchError
+	rows := make([][]any, 0, len(batch))
+	for i, msg := range batch {
+		row, err := rowFromMessage(columns, msg)
+		if err != nil {
+			c.log.Debugf("Failed to convert message %v into a row: %v", i, err)
+			if batchErr == nil {
+				batchErr = service.NewBatchError(batch, errors.New("failed to convert messages into rows"))
+			}
+			batchErr.Failed(i, err)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) > 0 {
+		if err := c.insertWithRetries(ctx, conn, insertQuery, rows); err != nil {
+			return err
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (c *clickhouseOutput) insertWithRetries(ctx context.Context, conn driver.Conn, insertQuery string, rows [][]any) error {
+	boff := *c.backoff
+	boff.Reset()
+
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(c.settings))
+	for {
+		retryable, err := c.insert(ctx, conn, insertQuery, rows)
+		if err == nil {
+			return nil
+		}
+		if !retryable || !isRetryable(err) {
+			return err
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		c.log.Warnf("Failed to insert batch, retrying: %v", err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// insert sends rows as a single block, and returns whether a failed insert can
+// be retried, which is not the case when rows do not match their columns.
+func (c *clickhouseOutput) insert(ctx context.Context, conn driver.Conn, insertQuery string, rows [][]any) (bool, error) {
+	b, err := conn.PrepareBatch(ctx, insertQuery)
+	if err != nil {
+		return true, fmt.Errorf("failed to prepare batch: %w", err)
+	}
+	for _, row := range rows {
+		if err := b.Append(row...); err != nil {
+			_ = b.Abort()
+			return false, fmt.Errorf("failed to append row: %w", err)
+		}
+	}
+	if err := b.Send(); err != nil {
+		return true, fmt.Errorf("failed to send batch: %w", err)
+	}
+	return false, nil
+}
+
+func (c *clickhouseOutput) Close(ctx context.Context) error {
+	c.connMut.Lock()
+	defer c.connMut.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
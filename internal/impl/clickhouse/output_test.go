@@ -0,0 +1,100 @@
+package clickhouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestClickHouseOutputConfig(t *testing.T) {
+	conf, err := clickhouseOutputConfig().ParseYAML(`
+addresses: [ "foo:9000,bar:9000", baz:9000 ]
+table: analytics.events
+compression: zstd
+async_insert:
+  enabled: true
+  wait: false
+settings:
+  insert_deduplicate: "0"
+`, nil)
+	require.NoError(t, err)
+
+	o, err := newClickHouseOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"foo:9000", "bar:9000", "baz:9000"}, o.addresses)
+	assert.Equal(t, clickhouse.CompressionZSTD, o.compression)
+	assert.Equal(t, clickhouse.Settings{
+		"async_insert":          1,
+		"wait_for_async_insert": 0,
+		"insert_deduplicate":    "0",
+	}, o.settings)
+}
+
+func TestClickHouseQuoteTable(t *testing.T) {
+	assert.Equal(t, "`events`", quoteTable("events"))
+	assert.Equal(t, "`analytics`.`events`", quoteTable("analytics.events"))
+	assert.Equal(t, "`n.a`", quoteIdentifier("n.a"))
+	assert.Equal(t, "`a\\`b`", quoteIdentifier("a`b"))
+}
+
+func TestClickHouseRowFromMessage(t *testing.T) {
+	columns := make([]clickhouseColumn, 0, 3)
+	for _, c := range [][2]string{
+		{"id", "UInt64"},
+		{"tags", "Array(LowCardinality(String))"},
+		{"n.a", "Array(Int32)"},
+	} {
+		conv, err := newColumnConverter(c[1])
+		require.NoError(t, err)
+		columns = append(columns, clickhouseColumn{name: c[0], conv: conv})
+	}
+
+	row, err := rowFromMessage(columns, service.NewMessage([]byte(`{"id":5,"tags":["a"],"n":[{"a":1},{"a":2}],"ignored":true}`)))
+	require.NoError(t, err)
+	assert.Equal(t, []any{uint64(5), []any{"a"}, []any{int32(1), int32(2)}}, row)
+
+	row, err = rowFromMessage(columns, service.NewMessage([]byte(`{}`)))
+	require.NoError(t, err)
+	assert.Equal(t, []any{uint64(0), []any{}, []any{}}, row)
+
+	_, err = rowFromMessage(columns, service.NewMessage([]byte(`["not","an","object"]`)))
+	assert.Error(t, err)
+
+	_, err = rowFromMessage(columns, service.NewMessage([]byte(`{"id":-1}`)))
+	assert.Error(t, err)
+}
+
+func TestClickHouseIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(io.EOF))
+	assert.True(t, isRetryable(fmt.Errorf("failed to send batch: %w", &clickhouse.Exception{Code: 252})))
+	assert.False(t, isRetryable(fmt.Errorf("failed to send batch: %w", &clickhouse.Exception{Code: 53})))
+	assert.False(t, isRetryable(context.Canceled))
+	assert.True(t, isRetryable(errors.New("connection reset by peer")))
+}
+
+func TestClickHouseIsSchemaMismatch(t *testing.T) {
+	assert.True(t, isSchemaMismatch(fmt.Errorf("failed to prepare batch: %w", &clickhouse.Exception{Code: 16})))
+	assert.True(t, isSchemaMismatch(fmt.Errorf("failed to append row: %w", &proto.BlockError{
+		Op:         "AppendRow",
+		Err:        &column.ColumnConverterError{Op: "AppendRow", To: "String", From: "int64"},
+		ColumnName: "id",
+	})))
+	assert.False(t, isSchemaMismatch(fmt.Errorf("failed to append row: %w", &proto.BlockError{
+		Op:         "AppendRow",
+		Err:        &column.DateOverflowError{},
+		ColumnName: "ts",
+	})))
+	assert.False(t, isSchemaMismatch(fmt.Errorf("failed to send batch: %w", &clickhouse.Exception{Code: 252})))
+	assert.False(t, isSchemaMismatch(io.EOF))
+}
@@ -1,6 +1,7 @@
 package confluent
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/binary"
@@ -15,8 +16,7 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/linkedin/goavro/v2"
-
+	"github.com/benthosdev/benthos/v4/internal/filepath/ifs"
 	"github.com/benthosdev/benthos/v4/internal/httpclient"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -33,7 +33,25 @@ Encodes messages automatically from schemas obtains from a [Confluent Schema Reg
 
 If a message fails to encode under the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported. Protobuf messages are expected to be formatted as [JSON](https://developers.google.com/protocol-buffers/docs/proto3#json) and are encoded as the first message of the schema unless [` + "`protobuf_message`" + `](#protobuf_message) is set. Messages encoded with JSON schemas are validated against the schema and otherwise written unchanged.
+
+### Subjects
+
+The subject of each message can either be set explicitly with the field ` + "[`subject`](#subject)" + `, or derived with a [subject name strategy](https://docs.confluent.io/platform/current/schema-registry/serdes-develop/index.html#subject-name-strategy) by setting the field ` + "[`subject_name_strategy`](#subject_name_strategy)" + `:
+
+- ` + "`topic_name`" + ` uses the subject ` + "`<topic>-value`" + `, or ` + "`<topic>-key`" + ` when ` + "[`key_subject`](#key_subject)" + ` is ` + "`true`" + `.
+- ` + "`record_name`" + ` uses the fully qualified record name of the schema as the subject.
+- ` + "`topic_record_name`" + ` uses the subject ` + "`<topic>-<record name>`" + `.
+
+The record name strategies require a schema to be provided with the field ` + "[`schema`](#schema)" + ` or ` + "[`schema_path`](#schema_path)" + `.
+
+### Registering Schemas
+
+Instead of polling the registry for the latest schema of a subject it is possible to provide a schema with the field ` + "[`schema`](#schema)" + ` or ` + "[`schema_path`](#schema_path)" + `. When ` + "[`auto_register`](#auto_register)" + ` is ` + "`true`" + ` the schema is registered under each subject it is used with, which has no effect when the schema is already registered. Otherwise the schema must already be registered under the subject, and the ID of the matching version is used.
+
+When a schema is registered it is first checked for compatibility with the latest version of the subject, and if it is incompatible then messages will fail to encode rather than registering a new version. This check can be disabled with the field ` + "[`check_compatibility`](#check_compatibility)" + `, in which case the compatibility rules of the registry are still enforced during registration.
+
+Supplied protobuf schemas are registered without references and therefore may only import the well-known types such as ` + "`google/protobuf/timestamp.proto`" + `.
 
 ### Avro JSON Format
 
@@ -55,9 +73,24 @@ However, it is possible to instead consume documents in [standard/raw JSON forma
 Important! There is an outstanding issue in the [avro serializing library](https://github.com/linkedin/goavro) that benthos uses which means it [doesn't encode logical types correctly](https://github.com/linkedin/goavro/issues/252). It's still possible to encode logical types that are in-line with the spec if ` + "`avro_raw_json` is set to true" + `, though now of course non-logical types will not be in-line with the spec.
 `).
 		Field(service.NewURLField("url").Description("The base URL of the schema registry service.")).
-		Field(service.NewInterpolatedStringField("subject").Description("The schema subject to derive schemas from.").
+		Field(service.NewInterpolatedStringField("subject").Description("The schema subject to derive schemas from. Either this field or `subject_name_strategy` must be set.").
 			Example("foo").
-			Example(`${! meta("kafka_topic") }`)).
+			Example(`${! meta("kafka_topic") }`).
+			Optional()).
+		Field(service.NewStringEnumField("subject_name_strategy", "topic_name", "record_name", "topic_record_name").
+			Description("A [strategy](#subjects) used to derive the subject of each message, as an alternative to setting `subject`.").
+			Optional().
+			Version("4.14.0")).
+		Field(service.NewInterpolatedStringField("topic").
+			Description("The topic used to derive subjects when `subject_name_strategy` is `topic_name` or `topic_record_name`.").
+			Default(`${! meta("kafka_topic") }`).
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewBoolField("key_subject").
+			Description("Whether messages are keys rather than values, which determines the suffix of subjects derived with the `topic_name` strategy.").
+			Default(false).
+			Advanced().
+			Version("4.14.0")).
 		Field(service.NewStringField("refresh_period").
 			Description("The period after which a schema is refreshed for each subject, this is done by polling the schema registry service.").
 			Default("10m").
@@ -65,7 +98,47 @@ Important! There is an outstanding issue in the [avro serializing library](https
 			Example("1h")).
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether messages encoded in Avro format should be parsed as normal JSON (\"json that meets the expectations of regular internet json\") rather than [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding). If `true` the schema returned from the subject should be parsed as [standard json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodecForStandardJSONFull) instead of as [avro json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodec). There is a [comment in goavro](https://github.com/linkedin/goavro/blob/5ec5a5ee7ec82e16e6e2b438d610e1cab2588393/union.go#L224-L249), the [underlining library used for avro serialization](https://github.com/linkedin/goavro), that explains in more detail the difference between standard json and avro json.").
-			Advanced().Default(false).Version("3.59.0"))
+			Advanced().Default(false).Version("3.59.0")).
+		Field(service.NewStringField("schema").
+			Description("A schema to encode messages with rather than polling the registry for the latest schema of each subject. The schema must either be registered already or `auto_register` must be `true`.").
+			Optional().
+			Version("4.14.0")).
+		Field(service.NewStringField("schema_path").
+			Description("A path to a file containing a schema to encode messages with, as an alternative to `schema`.").
+			Example("./schemas/foo.avsc").
+			Optional().
+			Version("4.14.0")).
+		Field(service.NewStringEnumField("schema_type", schemaTypeAvro, schemaTypeProtobuf, schemaTypeJSON).
+			Description("The type of the schema provided with `schema` or `schema_path`.").
+			Default(schemaTypeAvro).
+			Version("4.14.0")).
+		Field(service.NewBoolField("auto_register").
+			Description("Whether the schema provided with `schema` or `schema_path` should be registered under each subject it is used with.").
+			Default(false).
+			Version("4.14.0")).
+		Field(service.NewBoolField("check_compatibility").
+			Description("Whether to check that a schema is compatible with the latest version of a subject before registering it.").
+			Default(true).
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewStringField("protobuf_message").
+			Description("The fully qualified name of the message to encode with Protobuf schemas. If left empty the first message of the schema is used.").
+			Example("foo.bar.Baz").
+			Optional().
+			Advanced().
+			Version("4.14.0")).
+		LintRule(`
+root = if this.subject.or("") == "" && this.subject_name_strategy.or("") == "" {
+  "either a subject or a subject_name_strategy must be specified"
+} else if this.subject.or("") != "" && this.subject_name_strategy.or("") != "" {
+  "a subject and subject_name_strategy cannot both be specified"
+} else if this.schema.or("") != "" && this.schema_path.or("") != "" {
+  "a schema and schema_path cannot both be specified"
+} else if this.schema.or("") == "" && this.schema_path.or("") == "" && this.auto_register.or(false) {
+  "a schema or schema_path must be specified when auto_register is true"
+} else if this.schema.or("") == "" && this.schema_path.or("") == "" && ["record_name", "topic_record_name"].contains(this.subject_name_strategy.or("")) {
+  "a schema or schema_path must be specified when using a record name subject_name_strategy"
+}`)
 
 	for _, f := range httpclient.AuthFieldSpecs() {
 		spec = spec.Field(f.Version("4.7.0"))
@@ -89,8 +162,9 @@ func init() {
 
 type schemaRegistryEncoder struct {
 	client             *http.Client
-	subject            *service.InterpolatedString
-	avroRawJSON        bool
+	naming             subjectNaming
+	encoderOpts        schemaEncoderOpts
+	supplied           *suppliedSchema
 	schemaRefreshAfter time.Duration
 
 	schemaRegistryBaseURL *url.URL
@@ -104,6 +178,31 @@ type schemaRegistryEncoder struct {
 	logger *service.Logger
 	mgr    *service.Resources
 	nowFn  func() time.Time
+
+	// The IDs of the supplied schema by subject, which never change once the
+	// schema is registered and are therefore kept across refreshes. Guarded
+	// by requestMut.
+	suppliedIDs map[string]int
+}
+
+// subjectNaming determines the subject of each message, either from an
+// explicit subject or from a subject name strategy.
+type subjectNaming struct {
+	subject  *service.InterpolatedString
+	strategy string
+	topic    *service.InterpolatedString
+	isKey    bool
+}
+
+// suppliedSchema is a schema provided by the config rather than obtained from
+// the registry, which is either registered or looked up for each subject.
+type suppliedSchema struct {
+	schemaType   string
+	schema       string
+	encoder      schemaEncoder
+	recordName   string
+	autoRegister bool
+	checkCompat  bool
 }
 
 func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*schemaRegistryEncoder, error) {
@@ -111,14 +210,52 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, mgr *service
 	if err != nil {
 		return nil, err
 	}
-	subject, err := conf.FieldInterpolatedString("subject")
-	if err != nil {
+
+	var naming subjectNaming
+	if conf.Contains("subject") {
+		if naming.subject, err = conf.FieldInterpolatedString("subject"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("subject_name_strategy") {
+		if naming.strategy, err = conf.FieldString("subject_name_strategy"); err != nil {
+			return nil, err
+		}
+	}
+	if naming.topic, err = conf.FieldInterpolatedString("topic"); err != nil {
 		return nil, err
 	}
-	avroRawJSON, err := conf.FieldBool("avro_raw_json")
+	if naming.isKey, err = conf.FieldBool("key_subject"); err != nil {
+		return nil, err
+	}
+	if (naming.subject == nil) == (naming.strategy == "") {
+		return nil, errors.New("exactly one of subject or subject_name_strategy must be specified")
+	}
+
+	var encoderOpts schemaEncoderOpts
+	if encoderOpts.avroRawJSON, err = conf.FieldBool("avro_raw_json"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("protobuf_message") {
+		if encoderOpts.protobufMessage, err = conf.FieldString("protobuf_message"); err != nil {
+			return nil, err
+		}
+	}
+
+	supplied, err := suppliedSchemaFromConfig(conf, mgr, encoderOpts)
 	if err != nil {
 		return nil, err
 	}
+	switch naming.strategy {
+	case "record_name", "topic_record_name":
+		if supplied == nil {
+			return nil, fmt.Errorf("a schema must be specified in order to use the subject name strategy %v", naming.strategy)
+		}
+		if supplied.recordName == "" {
+			return nil, fmt.Errorf("unable to derive a record name from the schema for the subject name strategy %v", naming.strategy)
+		}
+	}
+
 	refreshPeriodStr, err := conf.FieldString("refresh_period")
 	if err != nil {
 		return nil, err
@@ -139,15 +276,59 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, mgr *service
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, mgr)
+	return newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, naming, encoderOpts, supplied, refreshPeriod, refreshTicker, mgr)
+}
+
+func suppliedSchemaFromConfig(conf *service.ParsedConfig, mgr *service.Resources, encoderOpts schemaEncoderOpts) (*suppliedSchema, error) {
+	var s suppliedSchema
+	var err error
+	if conf.Contains("schema") {
+		if s.schema, err = conf.FieldString("schema"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("schema_path") {
+		if s.schema != "" {
+			return nil, errors.New("a schema and schema_path cannot both be specified")
+		}
+		schemaPath, err := conf.FieldString("schema_path")
+		if err != nil {
+			return nil, err
+		}
+		schemaBytes, err := ifs.ReadFile(mgr.FS(), schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema_path: %w", err)
+		}
+		s.schema = string(schemaBytes)
+	}
+	if s.autoRegister, err = conf.FieldBool("auto_register"); err != nil {
+		return nil, err
+	}
+	if s.schema == "" {
+		if s.autoRegister {
+			return nil, errors.New("a schema or schema_path must be specified when auto_register is true")
+		}
+		return nil, nil
+	}
+	if s.checkCompat, err = conf.FieldBool("check_compatibility"); err != nil {
+		return nil, err
+	}
+	if s.schemaType, err = conf.FieldString("schema_type"); err != nil {
+		return nil, err
+	}
+	if s.encoder, s.recordName, err = newSchemaEncoder(s.schemaType, s.schema, encoderOpts); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return &s, nil
 }
 
 func newSchemaRegistryEncoder(
 	urlStr string,
 	reqSigner httpclient.RequestSigner,
 	tlsConf *tls.Config,
-	subject *service.InterpolatedString,
-	avroRawJSON bool,
+	naming subjectNaming,
+	encoderOpts schemaEncoderOpts,
+	supplied *suppliedSchema,
 	schemaRefreshAfter, schemaRefreshTicker time.Duration,
 	mgr *service.Resources,
 ) (*schemaRegistryEncoder, error) {
@@ -159,10 +340,12 @@ func newSchemaRegistryEncoder(
 	s := &schemaRegistryEncoder{
 		schemaRegistryBaseURL: u,
 		requestSigner:         reqSigner,
-		subject:               subject,
-		avroRawJSON:           avroRawJSON,
+		naming:                naming,
+		encoderOpts:           encoderOpts,
+		supplied:              supplied,
 		schemaRefreshAfter:    schemaRefreshAfter,
 		schemas:               map[string]*cachedSchemaEncoder{},
+		suppliedIDs:           map[string]int{},
 		shutSig:               shutdown.NewSignaller(),
 		logger:                mgr.Logger(),
 		mgr:                   mgr,
@@ -196,10 +379,39 @@ func newSchemaRegistryEncoder(
 	return s, nil
 }
 
+func (s *schemaRegistryEncoder) getSubject(batch service.MessageBatch, i int) (string, error) {
+	if s.naming.subject != nil {
+		return batch.TryInterpolatedString(i, s.naming.subject)
+	}
+
+	var recordName string
+	if s.supplied != nil {
+		recordName = s.supplied.recordName
+	}
+	if s.naming.strategy == "record_name" {
+		return recordName, nil
+	}
+
+	topic, err := batch.TryInterpolatedString(i, s.naming.topic)
+	if err != nil {
+		return "", err
+	}
+	if topic == "" {
+		return "", errors.New("topic is empty")
+	}
+	if s.naming.strategy == "topic_record_name" {
+		return topic + "-" + recordName, nil
+	}
+	if s.naming.isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
 func (s *schemaRegistryEncoder) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
 	batch = batch.Copy()
 	for i, msg := range batch {
-		subject, err := batch.TryInterpolatedString(i, s.subject)
+		subject, err := s.getSubject(batch, i)
 		if err != nil {
 			s.logger.Errorf("Subject interpolation error: %v", err)
 			msg.SetError(fmt.Errorf("subject interpolation error: %w", err))
@@ -296,7 +508,7 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 	if len(refreshTargets) > 0 {
 		s.requestMut.Lock()
 		for _, k := range refreshTargets {
-			encoder, id, err := s.getLatestEncoder(k)
+			encoder, id, err := s.fetchEncoder(k)
 			if err != nil {
 				s.logger.Errorf("Failed to refresh schema subject '%v': %v", k, err)
 			} else {
@@ -311,65 +523,94 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 	}
 }
 
-func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder, int, error) {
+// doRequest performs a request against the schema registry API and returns
+// the response body and status code. A status code of 404 is returned without
+// an error as its meaning depends on the request.
+func (s *schemaRegistryEncoder) doRequest(subject, method, reqPath string, body []byte) ([]byte, int, error) {
 	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
 
 	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/latest", subject))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
-	if err != nil {
-		return nil, 0, err
-	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-	if err := s.requestSigner(s.mgr.FS(), req); err != nil {
-		return nil, 0, err
-	}
+	reqURL.Path = path.Join(reqURL.Path, reqPath)
 
-	var resBytes []byte
+	var err error
 	for i := 0; i < 3; i++ {
+		var bodyReader io.Reader = http.NoBody
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, method, reqURL.String(), bodyReader); err != nil {
+			return nil, 0, err
+		}
+		req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+		if body != nil {
+			req.Header.Add("Content-Type", "application/vnd.schemaregistry.v1+json")
+		}
+		if err = s.requestSigner(s.mgr.FS(), req); err != nil {
+			return nil, 0, err
+		}
+
 		var res *http.Response
 		if res, err = s.client.Do(req); err != nil {
 			s.logger.Errorf("request failed for schema subject '%v': %v", subject, err)
 			continue
 		}
 
+		var resBytes []byte
+		resBytes, err = io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			s.logger.Errorf("failed to read response for schema subject '%v': %v", subject, err)
+			continue
+		}
+
 		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema subject '%v' not found by registry", subject)
-			s.logger.Errorf(err.Error())
-			break
+			return resBytes, res.StatusCode, nil
 		}
 
 		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema subject '%v'", subject)
+			err = fmt.Errorf("request failed for schema subject '%v': %s", subject, bytes.TrimSpace(resBytes))
 			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema subject latest '%v' returned an empty body", subject)
-			err = errors.New("schema request returned an empty body")
+			if res.StatusCode < http.StatusInternalServerError {
+				// Client errors such as incompatible or invalid schemas will
+				// not be resolved by retrying.
+				break
+			}
 			continue
 		}
+		return resBytes, res.StatusCode, nil
+	}
+	return nil, 0, err
+}
 
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
+func (s *schemaRegistryEncoder) fetchEncoder(subject string) (schemaEncoder, int, error) {
+	if s.supplied != nil {
+		id, err := s.getSuppliedSchemaID(subject)
 		if err != nil {
-			s.logger.Errorf("failed to read response for schema subject '%v': %v", subject, err)
-			continue
+			return nil, 0, err
 		}
-
-		break
+		return s.supplied.encoder, id, nil
 	}
+	return s.getLatestEncoder(subject)
+}
+
+func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder, int, error) {
+	resBytes, resCode, err := s.doRequest(subject, "GET", fmt.Sprintf("/subjects/%s/versions/latest", subject), nil)
 	if err != nil {
 		return nil, 0, err
 	}
+	if resCode == http.StatusNotFound {
+		err = fmt.Errorf("schema subject '%v' not found by registry", subject)
+		s.logger.Errorf(err.Error())
+		return nil, 0, err
+	}
 
 	resPayload := struct {
-		Schema string `json:"schema"`
-		ID     int    `json:"id"`
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+		ID         int    `json:"id"`
 	}{}
 	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
 		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
@@ -378,38 +619,96 @@ func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder,
 
 	s.logger.Tracef("Loaded new codec for subject %v: %s", subject, resBytes)
 
-	var codec *goavro.Codec
-	if s.avroRawJSON {
-		if codec, err = goavro.NewCodecForStandardJSONFull(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-			return nil, 0, err
-		}
-	} else {
-		if codec, err = goavro.NewCodec(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-			return nil, 0, err
-		}
+	encoder, _, err := newSchemaEncoder(resPayload.SchemaType, resPayload.Schema, s.encoderOpts)
+	if err != nil {
+		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
+		return nil, 0, err
 	}
+	return encoder, resPayload.ID, nil
+}
 
-	return func(m *service.Message) error {
-		b, err := m.AsBytes()
-		if err != nil {
-			return err
-		}
+// getSuppliedSchemaID obtains the ID of the supplied schema under a subject,
+// registering the schema when auto registration is enabled. The ID is only
+// obtained once per subject, as refreshing it would register the schema again.
+func (s *schemaRegistryEncoder) getSuppliedSchemaID(subject string) (int, error) {
+	if id, exists := s.suppliedIDs[subject]; exists {
+		return id, nil
+	}
+	id, err := s.lookupSuppliedSchemaID(subject)
+	if err != nil {
+		return 0, err
+	}
+	s.suppliedIDs[subject] = id
+	return id, nil
+}
+
+// lookupSuppliedSchemaID requests the ID of the supplied schema under a
+// subject from the registry.
+func (s *schemaRegistryEncoder) lookupSuppliedSchemaID(subject string) (int, error) {
+	reqPayload := struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType,omitempty"`
+	}{
+		Schema: s.supplied.schema,
+	}
+	if s.supplied.schemaType != schemaTypeAvro {
+		reqPayload.SchemaType = s.supplied.schemaType
+	}
+	reqBody, err := json.Marshal(reqPayload)
+	if err != nil {
+		return 0, err
+	}
 
-		datum, _, err := codec.NativeFromTextual(b)
+	var idPayload struct {
+		ID int `json:"id"`
+	}
+
+	if !s.supplied.autoRegister {
+		resBytes, resCode, err := s.doRequest(subject, "POST", fmt.Sprintf("/subjects/%s", subject), reqBody)
 		if err != nil {
-			return err
+			return 0, err
+		}
+		if resCode == http.StatusNotFound {
+			return 0, fmt.Errorf("schema is not registered under subject '%v'", subject)
 		}
+		if err := json.Unmarshal(resBytes, &idPayload); err != nil {
+			return 0, fmt.Errorf("failed to parse response for schema subject '%v': %w", subject, err)
+		}
+		return idPayload.ID, nil
+	}
 
-		binary, err := codec.BinaryFromNative(nil, datum)
+	if s.supplied.checkCompat {
+		resBytes, resCode, err := s.doRequest(subject, "POST", fmt.Sprintf("/compatibility/subjects/%s/versions/latest", subject), reqBody)
 		if err != nil {
-			return err
+			return 0, err
 		}
+		// A subject without any versions is compatible with any schema.
+		if resCode != http.StatusNotFound {
+			var compatPayload struct {
+				IsCompatible bool `json:"is_compatible"`
+			}
+			if err := json.Unmarshal(resBytes, &compatPayload); err != nil {
+				return 0, fmt.Errorf("failed to parse compatibility response for schema subject '%v': %w", subject, err)
+			}
+			if !compatPayload.IsCompatible {
+				return 0, fmt.Errorf("schema is not compatible with the latest version of subject '%v'", subject)
+			}
+		}
+	}
+
+	resBytes, resCode, err := s.doRequest(subject, "POST", fmt.Sprintf("/subjects/%s/versions", subject), reqBody)
+	if err != nil {
+		return 0, err
+	}
+	if resCode == http.StatusNotFound {
+		return 0, fmt.Errorf("failed to register schema under subject '%v': %s", subject, bytes.TrimSpace(resBytes))
+	}
+	if err := json.Unmarshal(resBytes, &idPayload); err != nil {
+		return 0, fmt.Errorf("failed to parse response for schema subject '%v': %w", subject, err)
+	}
 
-		m.SetBytes(binary)
-		return nil
-	}, resPayload.ID, nil
+	s.logger.Debugf("Registered schema under subject %v with ID %v", subject, idPayload.ID)
+	return idPayload.ID, nil
 }
 
 func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int, error) {
@@ -434,7 +733,7 @@ func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int,
 		return c.encoder, c.id, nil
 	}
 
-	encoder, id, err := s.getLatestEncoder(subject)
+	encoder, id, err := s.fetchEncoder(subject)
 	if err != nil {
 		return nil, 0, err
 	}
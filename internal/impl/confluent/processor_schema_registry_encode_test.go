@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -79,6 +82,49 @@ subject: foo
 `,
 			expectedBaseURL: "http://example.com/v1",
 		},
+		{
+			name: "no subject",
+			config: `
+url: http://example.com
+`,
+			errContains: "exactly one of subject or subject_name_strategy must be specified",
+		},
+		{
+			name: "record name strategy without schema",
+			config: `
+url: http://example.com
+subject_name_strategy: record_name
+`,
+			errContains: "a schema must be specified in order to use the subject name strategy record_name",
+		},
+		{
+			name: "auto register without schema",
+			config: `
+url: http://example.com
+subject: foo
+auto_register: true
+`,
+			errContains: "a schema or schema_path must be specified when auto_register is true",
+		},
+		{
+			name: "bad schema",
+			config: `
+url: http://example.com
+subject: foo
+schema: 'not a schema'
+`,
+			errContains: "failed to parse schema",
+		},
+		{
+			name: "record name strategy",
+			config: `
+url: http://example.com
+subject_name_strategy: record_name
+schema_type: JSON
+schema: '{"title":"foo","type":"object"}'
+`,
+			expectedBaseURL: "http://example.com",
+		},
 	}
 
 	spec := schemaRegistryEncoderConfig()
@@ -126,7 +172,7 @@ func TestSchemaRegistryEncodeAvro(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -208,7 +254,7 @@ func TestSchemaRegistryEncodeAvroRawJSON(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, true, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{avroRawJSON: true}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -290,7 +336,7 @@ func TestSchemaRegistryEncodeAvroLogicalTypes(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -367,7 +413,7 @@ func TestSchemaRegistryEncodeAvroRawJSONLogicalTypes(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, true, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{avroRawJSON: true}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -432,7 +478,7 @@ func TestSchemaRegistryEncodeClearExpired(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 	require.NoError(t, encoder.Close(context.Background()))
 
@@ -493,7 +539,7 @@ func TestSchemaRegistryEncodeRefresh(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, service.MockResources())
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
 	require.NoError(t, err)
 	require.NoError(t, encoder.Close(context.Background()))
 
@@ -566,3 +612,409 @@ func TestSchemaRegistryEncodeRefresh(t *testing.T) {
 	assert.Equal(t, int32(1), atomic.LoadInt32(&fooReqs))
 	assert.Equal(t, int32(1), atomic.LoadInt32(&barReqs))
 }
+
+func TestSchemaRegistryEncoderLint(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		errContains string
+	}{
+		{
+			name: "no subject",
+			config: `
+schema_registry_encode:
+  url: http://example.com
+`,
+			errContains: "either a subject or a subject_name_strategy must be specified",
+		},
+		{
+			name: "subject and strategy",
+			config: `
+schema_registry_encode:
+  url: http://example.com
+  subject: foo
+  subject_name_strategy: topic_name
+`,
+			errContains: "a subject and subject_name_strategy cannot both be specified",
+		},
+		{
+			name: "schema and schema path",
+			config: `
+schema_registry_encode:
+  url: http://example.com
+  subject: foo
+  schema: '{"type":"string"}'
+  schema_path: ./foo.avsc
+`,
+			errContains: "a schema and schema_path cannot both be specified",
+		},
+		{
+			name: "record name without schema",
+			config: `
+schema_registry_encode:
+  url: http://example.com
+  subject_name_strategy: topic_record_name
+`,
+			errContains: "a schema or schema_path must be specified when using a record name subject_name_strategy",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := service.NewStreamBuilder().AddProcessorYAML(test.config)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func runSchemaRegistryAPIServer(t *testing.T, fn func(method, path string, body []byte) ([]byte, error)) string {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBody, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, err := fn(r.Method, r.URL.Path, reqBody)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if len(b) == 0 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts.URL
+}
+
+func runEncoderTests(t *testing.T, encoder *schemaRegistryEncoder, tests []encoderTest) {
+	t.Helper()
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			msg := service.NewMessage([]byte(test.input))
+			for k, v := range test.meta {
+				msg.MetaSetMut(k, v)
+			}
+			outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{msg})
+			require.NoError(t, err)
+			require.Len(t, outBatches, 1)
+			require.Len(t, outBatches[0], 1)
+
+			err = outBatches[0][0].GetError()
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+
+				b, err := outBatches[0][0].AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, test.output, string(b))
+			}
+		})
+	}
+}
+
+type encoderTest struct {
+	name        string
+	input       string
+	meta        map[string]any
+	output      string
+	errContains string
+}
+
+const testProtobufSchema = `
+syntax = "proto3";
+package testing;
+
+message Person {
+  string first_name = 1;
+  int32 age = 2;
+
+  message Address {
+    string city = 1;
+  }
+}
+
+message Pet {
+  string name = 1;
+}
+`
+
+func TestSchemaRegistryEncodeProtobuf(t *testing.T) {
+	fooFirst, err := json.Marshal(struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+		ID         int    `json:"id"`
+	}{
+		Schema:     testProtobufSchema,
+		SchemaType: "PROTOBUF",
+		ID:         5,
+	})
+	require.NoError(t, err)
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		if path == "/subjects/foo/versions/latest" {
+			return fooFirst, nil
+		}
+		return nil, errors.New("nope")
+	})
+
+	subj, err := service.NewInterpolatedString("foo")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
+	require.NoError(t, err)
+
+	runEncoderTests(t, encoder, []encoderTest{
+		{
+			name:   "successful message",
+			input:  `{"firstName":"foo","age":10}`,
+			output: "\x00\x00\x00\x00\x05\x00\x0a\x03foo\x10\x0a",
+		},
+		{
+			name:        "message doesnt match schema",
+			input:       `{"firstName":"foo","nope":10}`,
+			errContains: "failed to unmarshal JSON message",
+		},
+	})
+	require.NoError(t, encoder.Close(context.Background()))
+
+	for _, test := range []struct {
+		message string
+		output  string
+	}{
+		{message: "testing.Pet", output: "\x00\x00\x00\x00\x05\x02\x02\x0a\x03foo"},
+		{message: "testing.Person.Address", output: "\x00\x00\x00\x00\x05\x04\x00\x00\x0a\x03foo"},
+	} {
+		encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{protobufMessage: test.message}, nil, time.Minute*10, time.Minute, service.MockResources())
+		require.NoError(t, err)
+
+		field := "name"
+		if test.message == "testing.Person.Address" {
+			field = "city"
+		}
+		runEncoderTests(t, encoder, []encoderTest{
+			{
+				name:   test.message,
+				input:  fmt.Sprintf(`{"%v":"foo"}`, field),
+				output: test.output,
+			},
+		})
+		require.NoError(t, encoder.Close(context.Background()))
+	}
+}
+
+func TestSchemaRegistryEncodeJSONSchema(t *testing.T) {
+	fooFirst, err := json.Marshal(struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+		ID         int    `json:"id"`
+	}{
+		Schema:     `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`,
+		SchemaType: "JSON",
+		ID:         6,
+	})
+	require.NoError(t, err)
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		if path == "/subjects/foo/versions/latest" {
+			return fooFirst, nil
+		}
+		return nil, errors.New("nope")
+	})
+
+	subj, err := service.NewInterpolatedString("foo")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subjectNaming{subject: subj}, schemaEncoderOpts{}, nil, time.Minute*10, time.Minute, service.MockResources())
+	require.NoError(t, err)
+
+	runEncoderTests(t, encoder, []encoderTest{
+		{
+			name:   "successful message",
+			input:  `{"name":"foo"}`,
+			output: "\x00\x00\x00\x00\x06" + `{"name":"foo"}`,
+		},
+		{
+			name:        "message doesnt match schema",
+			input:       `{"name":10}`,
+			errContains: "name: Invalid type",
+		},
+	})
+	require.NoError(t, encoder.Close(context.Background()))
+}
+
+func TestSchemaRegistryEncodeAutoRegister(t *testing.T) {
+	var registered []string
+	urlStr := runSchemaRegistryAPIServer(t, func(method, path string, body []byte) ([]byte, error) {
+		var req struct {
+			Schema     string `json:"schema"`
+			SchemaType string `json:"schemaType"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "POST", method)
+		assert.Equal(t, "JSON", req.SchemaType)
+
+		switch path {
+		case "/compatibility/subjects/foo-value/versions/latest":
+			return nil, nil
+		case "/compatibility/subjects/bar-value/versions/latest":
+			return []byte(`{"is_compatible":true}`), nil
+		case "/compatibility/subjects/baz-value/versions/latest":
+			return []byte(`{"is_compatible":false}`), nil
+		case "/subjects/foo-value/versions":
+			registered = append(registered, "foo-value")
+			return []byte(`{"id":7}`), nil
+		case "/subjects/bar-value/versions":
+			registered = append(registered, "bar-value")
+			return []byte(`{"id":8}`), nil
+		}
+		return nil, fmt.Errorf("unexpected path: %v", path)
+	})
+
+	conf, err := schemaRegistryEncoderConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+subject_name_strategy: topic_name
+schema_type: JSON
+schema: '{"title":"foo","type":"object","properties":{"name":{"type":"string"}}}'
+auto_register: true
+`, urlStr), nil)
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoderFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	runEncoderTests(t, encoder, []encoderTest{
+		{
+			name:   "new subject",
+			input:  `{"name":"foo"}`,
+			meta:   map[string]any{"kafka_topic": "foo"},
+			output: "\x00\x00\x00\x00\x07" + `{"name":"foo"}`,
+		},
+		{
+			name:   "compatible subject",
+			input:  `{"name":"bar"}`,
+			meta:   map[string]any{"kafka_topic": "bar"},
+			output: "\x00\x00\x00\x00\x08" + `{"name":"bar"}`,
+		},
+		{
+			name:        "incompatible subject",
+			input:       `{"name":"baz"}`,
+			meta:        map[string]any{"kafka_topic": "baz"},
+			errContains: "schema is not compatible with the latest version of subject 'baz-value'",
+		},
+	})
+
+	// Refreshing subjects must not register the schema again.
+	encoder.cacheMut.Lock()
+	for _, c := range encoder.schemas {
+		c.lastUpdatedUnixSeconds = 0
+	}
+	encoder.cacheMut.Unlock()
+	encoder.refreshEncoders()
+
+	encoder.cacheMut.RLock()
+	assert.Equal(t, 7, encoder.schemas["foo-value"].id)
+	assert.Equal(t, 8, encoder.schemas["bar-value"].id)
+	encoder.cacheMut.RUnlock()
+	require.NoError(t, encoder.Close(context.Background()))
+
+	assert.Equal(t, []string{"foo-value", "bar-value"}, registered)
+}
+
+func TestSchemaRegistryEncodeProtobufImports(t *testing.T) {
+	_, _, err := newProtobufEncoder(`
+syntax = "proto3";
+package testing;
+
+import "google/protobuf/timestamp.proto";
+
+message Event {
+  google.protobuf.Timestamp at = 1;
+}
+`, "")
+	require.NoError(t, err)
+
+	_, _, err = newProtobufEncoder(`
+syntax = "proto3";
+package testing;
+
+import "other.proto";
+
+message Event {
+  testing.Other other = 1;
+}
+`, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to import other.proto, schema references are not supported")
+}
+
+func TestSchemaRegistryEncodeSuppliedSchemaLookup(t *testing.T) {
+	urlStr := runSchemaRegistryAPIServer(t, func(method, path string, body []byte) ([]byte, error) {
+		var req struct {
+			Schema     string `json:"schema"`
+			SchemaType string `json:"schemaType"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "POST", method)
+		assert.Equal(t, "", req.SchemaType)
+
+		switch path {
+		case "/subjects/foo.namespace.com.identity":
+			return []byte(`{"subject":"foo.namespace.com.identity","id":9,"version":1}`), nil
+		case "/subjects/bar-foo.namespace.com.identity":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected path: %v", path)
+	})
+
+	for _, test := range []struct {
+		strategy string
+		tests    []encoderTest
+	}{
+		{
+			strategy: "record_name",
+			tests: []encoderTest{
+				{
+					name:   "registered schema",
+					input:  `{"Name":"foo","MaybeHobby":null}`,
+					output: "\x00\x00\x00\x00\x09\x06foo\x00\x00",
+				},
+			},
+		},
+		{
+			strategy: "topic_record_name",
+			tests: []encoderTest{
+				{
+					name:        "unregistered schema",
+					input:       `{"Name":"foo","MaybeHobby":null}`,
+					meta:        map[string]any{"kafka_topic": "bar"},
+					errContains: "schema is not registered under subject 'bar-foo.namespace.com.identity'",
+				},
+			},
+		},
+	} {
+		conf, err := schemaRegistryEncoderConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+subject_name_strategy: %v
+schema: %v
+`, urlStr, test.strategy, strconv.Quote(testSchema)), nil)
+		require.NoError(t, err)
+
+		encoder, err := newSchemaRegistryEncoderFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+
+		runEncoderTests(t, encoder, test.tests)
+		require.NoError(t, encoder.Close(context.Background()))
+	}
+}
@@ -0,0 +1,244 @@
+package confluent
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/jsonpb"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+
+	jsonschema "github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// Schema types as named by the schema registry API, where an empty type
+// implies Avro.
+const (
+	schemaTypeAvro     = "AVRO"
+	schemaTypeProtobuf = "PROTOBUF"
+	schemaTypeJSON     = "JSON"
+)
+
+// schemaEncoderOpts are options that determine how a schema of any type is
+// converted into an encoder.
+type schemaEncoderOpts struct {
+	avroRawJSON     bool
+	protobufMessage string
+}
+
+// newSchemaEncoder creates an encoder from a schema of a given type, and also
+// returns the fully qualified record name of the schema, which is used by the
+// record name subject strategies.
+func newSchemaEncoder(schemaType, schema string, opts schemaEncoderOpts) (schemaEncoder, string, error) {
+	switch schemaType {
+	case "", schemaTypeAvro:
+		return newAvroEncoder(schema, opts.avroRawJSON)
+	case schemaTypeProtobuf:
+		return newProtobufEncoder(schema, opts.protobufMessage)
+	case schemaTypeJSON:
+		return newJSONSchemaEncoder(schema)
+	}
+	return nil, "", fmt.Errorf("schema type %v is not supported", schemaType)
+}
+
+//------------------------------------------------------------------------------
+
+func avroRecordName(schema string) string {
+	var s struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return ""
+	}
+	if s.Namespace == "" || strings.Contains(s.Name, ".") {
+		return s.Name
+	}
+	return s.Namespace + "." + s.Name
+}
+
+func newAvroEncoder(schema string, rawJSON bool) (schemaEncoder, string, error) {
+	var codec *goavro.Codec
+	var err error
+	if rawJSON {
+		codec, err = goavro.NewCodecForStandardJSONFull(schema)
+	} else {
+		codec, err = goavro.NewCodec(schema)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		datum, _, err := codec.NativeFromTextual(b)
+		if err != nil {
+			return err
+		}
+
+		binary, err := codec.BinaryFromNative(nil, datum)
+		if err != nil {
+			return err
+		}
+
+		m.SetBytes(binary)
+		return nil
+	}, avroRecordName(schema), nil
+}
+
+//------------------------------------------------------------------------------
+
+// protobufMessageIndexes returns the path of indexes that locate a message
+// descriptor within its file, starting with the index of the top level message.
+func protobufMessageIndexes(m *desc.MessageDescriptor) []int {
+	var indexes []int
+	for m != nil {
+		var siblings []*desc.MessageDescriptor
+		var parentMsg *desc.MessageDescriptor
+		switch p := m.GetParent().(type) {
+		case *desc.MessageDescriptor:
+			siblings = p.GetNestedMessageTypes()
+			parentMsg = p
+		case *desc.FileDescriptor:
+			siblings = p.GetMessageTypes()
+		}
+		for i, s := range siblings {
+			if s == m {
+				indexes = append([]int{i}, indexes...)
+				break
+			}
+		}
+		m = parentMsg
+	}
+	return indexes
+}
+
+// protobufIndexesHeader encodes message indexes in the format expected by
+// Confluent serializers, where the common case of the first message within a
+// file is encoded as a single zero byte.
+func protobufIndexesHeader(indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return []byte{0}
+	}
+	buf := make([]byte, binary.MaxVarintLen64*(len(indexes)+1))
+	n := binary.PutVarint(buf, int64(len(indexes)))
+	for _, i := range indexes {
+		n += binary.PutVarint(buf[n:], int64(i))
+	}
+	return buf[:n]
+}
+
+func newProtobufEncoder(schema, message string) (schemaEncoder, string, error) {
+	const fileName = "schema.proto"
+
+	accessor := protoparse.FileContentsFromMap(map[string]string{
+		fileName: schema,
+	})
+	parser := protoparse.Parser{
+		// Schema references are not supported and so only the well-known
+		// types, which the parser resolves itself, can be imported.
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			if filename != fileName {
+				return nil, fmt.Errorf("unable to import %v, schema references are not supported and only well-known types can be imported", filename)
+			}
+			return accessor(filename)
+		},
+	}
+	fds, err := parser.ParseFiles(fileName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse protobuf schema: %w", err)
+	}
+
+	var msgDesc *desc.MessageDescriptor
+	if message == "" {
+		if msgs := fds[0].GetMessageTypes(); len(msgs) > 0 {
+			msgDesc = msgs[0]
+		}
+	} else {
+		msgDesc = fds[0].FindMessage(message)
+	}
+	if msgDesc == nil {
+		if message == "" {
+			return nil, "", errors.New("protobuf schema does not contain any messages")
+		}
+		return nil, "", fmt.Errorf("unable to find message '%v' definition within protobuf schema", message)
+	}
+
+	header := protobufIndexesHeader(protobufMessageIndexes(msgDesc))
+	unmarshaler := &jsonpb.Unmarshaler{
+		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), fds...),
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		msg := dynamic.NewMessage(msgDesc)
+		if err := msg.UnmarshalJSONPB(unmarshaler, b); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+		}
+
+		data, err := msg.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf message: %w", err)
+		}
+
+		m.SetBytes(append(append([]byte{}, header...), data...))
+		return nil
+	}, msgDesc.GetFullyQualifiedName(), nil
+}
+
+//------------------------------------------------------------------------------
+
+func newJSONSchemaEncoder(schema string) (schemaEncoder, string, error) {
+	loader := jsonschema.NewStringLoader(schema)
+	s, err := jsonschema.NewSchema(loader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+
+	var meta struct {
+		Title string `json:"title"`
+	}
+	_ = json.Unmarshal([]byte(schema), &meta)
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		result, err := s.Validate(jsonschema.NewBytesLoader(b))
+		if err != nil {
+			return err
+		}
+		if !result.Valid() {
+			var errStrs []string
+			for _, desc := range result.Errors() {
+				errStrs = append(errStrs, desc.String())
+			}
+			return errors.New(strings.Join(errStrs, "\n"))
+		}
+
+		// Confluent serializers write JSON documents as they are, the schema is
+		// only used for validation.
+		return nil
+	}, meta.Title, nil
+}
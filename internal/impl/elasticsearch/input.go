@@ -0,0 +1,566 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/olivere/elastic/v7"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	esPaginationPIT    = "point_in_time"
+	esPaginationScroll = "scroll"
+)
+
+func elasticsearchInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Executes a search against an Elasticsearch or OpenSearch index and creates a message for each document hit.").
+		Description(`
+Results are paged through in batches of `+"`batch_size`"+` hits, and each page is emitted as a batch of messages. Once all hits have been consumed the input shuts down, which makes it suitable for reindexing and migrating the contents of indices.
+
+### Pagination
+
+The default `+"`point_in_time`"+` pagination opens a point in time on the index and pages through it with `+"[`search_after`](https://www.elastic.co/guide/en/elasticsearch/reference/current/paginate-search-results.html#search-after)"+`, which requires Elasticsearch 7.10 or later. OpenSearch clusters and older versions of Elasticsearch should instead use `+"`scroll`"+` pagination. In both cases the `+"`keep_alive`"+` must exceed the time it takes to process a page of hits.
+
+### Slicing
+
+When `+"`slices`"+` is greater than one the search is split into that many disjoint slices that are read in parallel, which can speed up consuming large indices considerably. Slicing point in time searches requires Elasticsearch 7.16 or later.
+
+### Errors
+
+Requests that fail due to connection problems, rate limiting or server errors are retried with a backoff. Any other error, such as a malformed query or an expired search context, stops the search and is logged and reported once, after which the input shuts down rather than retrying.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- elasticsearch_index
+- elasticsearch_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Field(service.NewStringListField("urls").
+			Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
+			Example([]string{"http://localhost:9200"})).
+		Field(service.NewStringField("index").
+			Description("The index to search. Multiple indices can be searched by separating them with commas or by using wildcards.").
+			Example("foo").
+			Example("foo-*")).
+		Field(service.NewStringField("query").
+			Description("A [query DSL](https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl.html) object, in JSON format, selecting the documents to read.").
+			Example(`{"range":{"timestamp":{"gte":"now-1d"}}}`).
+			Default(`{"match_all":{}}`)).
+		Field(service.NewStringAnnotatedEnumField("pagination", map[string]string{
+			esPaginationPIT:    "Page through a point in time using `search_after`, which is supported by Elasticsearch 7.10 or later.",
+			esPaginationScroll: "Page through a scroll context, which is supported by OpenSearch and all versions of Elasticsearch.",
+		}).
+			Description("The method of paging through results.").
+			Default(esPaginationPIT)).
+		Field(service.NewStringField("sort").
+			Description("An optional sort, in JSON format, that hits are read in. When omitted hits are read in the cheapest order possible, which is `[\"_shard_doc\"]` for `point_in_time` pagination and `[\"_doc\"]` for `scroll` pagination. A sort used with `point_in_time` pagination should include a unique tiebreaker field.").
+			Example(`[{"timestamp":"asc"},"_shard_doc"]`).
+			Optional().
+			Advanced()).
+		Field(service.NewIntField("batch_size").
+			Description("The maximum number of hits to read in each page.").
+			Default(1000)).
+		Field(service.NewStringField("keep_alive").
+			Description("The period of time for which the point in time or scroll context is kept alive between pages.").
+			Default("1m").
+			Advanced()).
+		Field(service.NewIntField("slices").
+			Description("The number of slices the search is split into, which are read in parallel.").
+			Default(1).
+			Advanced()).
+		Field(service.NewBoolField("sniff").
+			Description("Prompts Benthos to sniff for brokers to connect to when establishing a connection.").
+			Default(false).
+			Advanced()).
+		Field(service.NewBoolField("healthcheck").
+			Description("Whether to enable healthchecks.").
+			Default(false).
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum time to wait before abandoning a request (and trying again).").
+			Default("5s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(httpclient.BasicAuthField()).
+		Example("Reindexing", `
+Here we copy the documents of an index into another, preserving their IDs:`, `
+input:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: source_index
+    slices: 4
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: target_index
+    id: ${! meta("elasticsearch_id") }
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"elasticsearch", elasticsearchInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newElasticsearchInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type elasticsearchInput struct {
+	urls        []string
+	index       string
+	query       any
+	sort        any
+	pagination  string
+	batchSize   int
+	keepAlive   string
+	slices      int
+	sniff       bool
+	healthcheck bool
+	timeout     time.Duration
+	tlsConf     *tls.Config
+	tlsEnabled  bool
+
+	authEnabled bool
+	username    string
+	password    string
+
+	client  *elastic.Client
+	batches chan service.MessageBatch
+	readErr error
+	connMut sync.Mutex
+
+	// The ID of a point in time can change between pages, and so the initial
+	// ID and the latest ID of each slice are kept in order to be closed.
+	pitID    string
+	slicePIT []string
+	pitMut   sync.Mutex
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newElasticsearchInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*elasticsearchInput, error) {
+	e := &elasticsearchInput{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	urls, err := conf.FieldStringList("urls")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range urls {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				e.urls = append(e.urls, splitURL)
+			}
+		}
+	}
+
+	if e.index, err = conf.FieldString("index"); err != nil {
+		return nil, err
+	}
+
+	queryStr, err := conf.FieldString("query")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(queryStr), &e.query); err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	if e.pagination, err = conf.FieldString("pagination"); err != nil {
+		return nil, err
+	}
+
+	if conf.Contains("sort") {
+		sortStr, err := conf.FieldString("sort")
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(sortStr), &e.sort); err != nil {
+			return nil, fmt.Errorf("failed to parse sort: %w", err)
+		}
+	} else if e.pagination == esPaginationPIT {
+		e.sort = []any{"_shard_doc"}
+	} else {
+		e.sort = []any{"_doc"}
+	}
+
+	if e.batchSize, err = conf.FieldInt("batch_size"); err != nil {
+		return nil, err
+	}
+	if e.batchSize < 1 {
+		return nil, errors.New("batch_size must be greater than zero")
+	}
+	if e.keepAlive, err = conf.FieldString("keep_alive"); err != nil {
+		return nil, err
+	}
+	if e.slices, err = conf.FieldInt("slices"); err != nil {
+		return nil, err
+	}
+	if e.slices < 1 {
+		return nil, errors.New("slices must be greater than zero")
+	}
+	if e.sniff, err = conf.FieldBool("sniff"); err != nil {
+		return nil, err
+	}
+	if e.healthcheck, err = conf.FieldBool("healthcheck"); err != nil {
+		return nil, err
+	}
+	if e.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if e.tlsConf, e.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+
+	authConf := conf.Namespace("basic_auth")
+	if e.authEnabled, err = authConf.FieldBool("enabled"); err != nil {
+		return nil, err
+	}
+	if e.username, err = authConf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if e.password, err = authConf.FieldString("password"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// searchBody returns the body of a search request for a slice, continuing
+// after the sort values of the last hit of the previous page when provided.
+func (e *elasticsearchInput) searchBody(slice int, pitID string, searchAfter []any) map[string]any {
+	body := map[string]any{
+		"query": e.query,
+		"sort":  e.sort,
+		"size":  e.batchSize,
+	}
+	if e.slices > 1 {
+		body["slice"] = map[string]any{
+			"id":  slice,
+			"max": e.slices,
+		}
+	}
+	if pitID != "" {
+		body["pit"] = map[string]any{
+			"id":         pitID,
+			"keep_alive": e.keepAlive,
+		}
+	}
+	if len(searchAfter) > 0 {
+		body["search_after"] = searchAfter
+	}
+	return body
+}
+
+func hitsToBatch(hits []*elastic.SearchHit) service.MessageBatch {
+	batch := make(service.MessageBatch, 0, len(hits))
+	for _, hit := range hits {
+		msg := service.NewMessage(hit.Source)
+		msg.MetaSetMut("elasticsearch_index", hit.Index)
+		msg.MetaSetMut("elasticsearch_id", hit.Id)
+		batch = append(batch, msg)
+	}
+	return batch
+}
+
+func (e *elasticsearchInput) Connect(ctx context.Context) error {
+	e.connMut.Lock()
+	defer e.connMut.Unlock()
+
+	if e.client != nil {
+		return nil
+	}
+
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(e.urls...),
+		elastic.SetSniff(e.sniff),
+		elastic.SetHealthcheck(e.healthcheck),
+	}
+	if e.authEnabled {
+		opts = append(opts, elastic.SetBasicAuth(e.username, e.password))
+	}
+
+	httpClient := &http.Client{Timeout: e.timeout}
+	if e.tlsEnabled {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: e.tlsConf,
+		}
+	}
+	opts = append(opts, elastic.SetHttpClient(httpClient))
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	if e.pagination == esPaginationPIT {
+		res, err := client.OpenPointInTime(e.index).KeepAlive(e.keepAlive).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to open point in time: %w", err)
+		}
+		e.pitID = res.Id
+	}
+
+	e.client = client
+	e.batches = make(chan service.MessageBatch)
+	e.slicePIT = make([]string, e.slices)
+
+	// Reading is abandoned by all slices when the input is closed or when any
+	// slice fails with an error that cannot be resolved by retrying.
+	readCtx, readDone := e.shutSig.CloseNowCtx(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.slices; i++ {
+		wg.Add(1)
+		go func(slice int) {
+			defer wg.Done()
+			var err error
+			if e.pagination == esPaginationPIT {
+				err = e.readPIT(readCtx, slice)
+			} else {
+				err = e.readScroll(readCtx, slice)
+			}
+			if err != nil && readCtx.Err() == nil {
+				e.log.Errorf("Failed to read slice %v: %v", slice, err)
+				e.connMut.Lock()
+				if e.readErr == nil {
+					e.readErr = err
+				}
+				e.connMut.Unlock()
+				readDone()
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		readDone()
+		close(e.batches)
+		e.closeContexts()
+		e.shutSig.ShutdownComplete()
+	}()
+
+	e.log.Infof("Reading documents from Elasticsearch index %v at urls: %s", e.index, e.urls)
+	return nil
+}
+
+// retryableErr returns whether a failed request might succeed when retried,
+// which is the case for transport errors, rate limiting and server errors.
+func retryableErr(err error) bool {
+	var eErr *elastic.Error
+	if errors.As(err, &eErr) {
+		return eErr.Status == http.StatusTooManyRequests || eErr.Status >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// withRetries attempts fn until it succeeds, fails with an error that cannot
+// be resolved by retrying, or the context is cancelled.
+func (e *elasticsearchInput) withRetries(ctx context.Context, slice int, fn func(ctx context.Context) error) error {
+	boff := backoff.NewExponentialBackOff()
+	boff.MaxElapsedTime = 0
+	boff.MaxInterval = time.Second * 30
+	for {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryableErr(err) {
+			return err
+		}
+		e.log.Errorf("Failed to read page of slice %v: %v", slice, err)
+		select {
+		case <-time.After(boff.NextBackOff()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *elasticsearchInput) send(ctx context.Context, batch service.MessageBatch) error {
+	select {
+	case e.batches <- batch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *elasticsearchInput) readPIT(ctx context.Context, slice int) error {
+	pitID := e.pitID
+
+	var searchAfter []any
+	for {
+		var res *elastic.SearchResult
+		if err := e.withRetries(ctx, slice, func(ctx context.Context) (err error) {
+			res, err = e.client.Search().Source(e.searchBody(slice, pitID, searchAfter)).Do(ctx)
+			return
+		}); err != nil {
+			return err
+		}
+		if res.PitId != "" && res.PitId != pitID {
+			pitID = res.PitId
+			e.pitMut.Lock()
+			e.slicePIT[slice] = pitID
+			e.pitMut.Unlock()
+		}
+		if res.Hits == nil || len(res.Hits.Hits) == 0 {
+			return nil
+		}
+
+		hits := res.Hits.Hits
+		searchAfter = hits[len(hits)-1].Sort
+		if err := e.send(ctx, hitsToBatch(hits)); err != nil {
+			return err
+		}
+		if len(hits) < e.batchSize {
+			return nil
+		}
+	}
+}
+
+func (e *elasticsearchInput) readScroll(ctx context.Context, slice int) error {
+	scroll := e.client.Scroll(e.index).
+		Body(e.searchBody(slice, "", nil)).
+		Scroll(e.keepAlive)
+	defer func() {
+		ctx, done := context.WithTimeout(context.Background(), e.timeout)
+		defer done()
+		if err := scroll.Clear(ctx); err != nil {
+			e.log.Debugf("Failed to clear scroll of slice %v: %v", slice, err)
+		}
+	}()
+
+	for {
+		var res *elastic.SearchResult
+		var finished bool
+		if err := e.withRetries(ctx, slice, func(ctx context.Context) (err error) {
+			if res, err = scroll.Do(ctx); errors.Is(err, io.EOF) {
+				finished, err = true, nil
+			}
+			return
+		}); err != nil || finished {
+			return err
+		}
+		if res.Hits == nil || len(res.Hits.Hits) == 0 {
+			return nil
+		}
+		if err := e.send(ctx, hitsToBatch(res.Hits.Hits)); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *elasticsearchInput) closeContexts() {
+	if e.pitID == "" {
+		return
+	}
+
+	e.pitMut.Lock()
+	pitIDs := []string{e.pitID}
+	for _, id := range e.slicePIT {
+		if id == "" {
+			continue
+		}
+		seen := false
+		for _, existing := range pitIDs {
+			if existing == id {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			pitIDs = append(pitIDs, id)
+		}
+	}
+	e.pitMut.Unlock()
+
+	ctx, done := context.WithTimeout(context.Background(), e.timeout)
+	defer done()
+	for _, id := range pitIDs {
+		if _, err := e.client.ClosePointInTime(id).Do(ctx); err != nil {
+			e.log.Debugf("Failed to close point in time: %v", err)
+		}
+	}
+}
+
+func (e *elasticsearchInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	e.connMut.Lock()
+	batches := e.batches
+	e.connMut.Unlock()
+	if batches == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case batch, open := <-batches:
+		if !open {
+			// An error that stopped the search is reported once, after which
+			// the input ends.
+			e.connMut.Lock()
+			err := e.readErr
+			e.readErr = nil
+			e.connMut.Unlock()
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, service.ErrEndOfInput
+		}
+		return batch, func(context.Context, error) error {
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (e *elasticsearchInput) Close(ctx context.Context) error {
+	e.shutSig.CloseNow()
+	e.connMut.Lock()
+	isNil := e.client == nil
+	e.connMut.Unlock()
+	if isNil {
+		return nil
+	}
+	select {
+	case <-e.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeSearchServer serves the subset of the search API used by the input over
+// a fixed set of documents, where documents are assigned to slices by their
+// position.
+type fakeSearchServer struct {
+	docs int
+
+	// Requests to the search endpoint fail with these statuses in order before
+	// succeeding.
+	failures []int
+
+	mut         sync.Mutex
+	searches    int
+	pitsClosed  []string
+	scrollsDone []string
+	slicesSeen  map[int]struct{}
+}
+
+func (f *fakeSearchServer) sliceDocs(body map[string]any) []int {
+	id, total := 0, 1
+	if s, ok := body["slice"].(map[string]any); ok {
+		id, total = int(s["id"].(float64)), int(s["max"].(float64))
+		f.slicesSeen[id] = struct{}{}
+	}
+	var docs []int
+	for i := 0; i < f.docs; i++ {
+		if i%total == id {
+			docs = append(docs, i)
+		}
+	}
+	return docs
+}
+
+func hitsResponse(docs []int) map[string]any {
+	hits := []any{}
+	for _, d := range docs {
+		hits = append(hits, map[string]any{
+			"_index":  "foo",
+			"_id":     strconv.Itoa(d),
+			"_source": map[string]any{"n": d},
+			"sort":    []any{d},
+		})
+	}
+	return map[string]any{
+		"hits": map[string]any{
+			"total": map[string]any{"value": len(docs), "relation": "eq"},
+			"hits":  hits,
+		},
+	}
+}
+
+func (f *fakeSearchServer) page(docs []int, after, size int) []int {
+	start := 0
+	for start < len(docs) && docs[start] <= after {
+		start++
+	}
+	end := start + size
+	if end > len(docs) {
+		end = len(docs)
+	}
+	return docs[start:end]
+}
+
+func (f *fakeSearchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	writeJSON := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	if r.URL.Path == "/_search" || r.URL.Path == "/foo/_search" || r.URL.Path == "/_search/scroll" {
+		if r.Method != http.MethodDelete && len(f.failures) > 0 {
+			status := f.failures[0]
+			f.failures = f.failures[1:]
+			writeJSON(status, map[string]any{
+				"status": status,
+				"error":  map[string]any{"type": "test_error", "reason": "test failure"},
+			})
+			return
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/foo/_pit":
+		writeJSON(http.StatusOK, map[string]any{"id": "pit0"})
+
+	case r.Method == http.MethodDelete && r.URL.Path == "/_pit":
+		f.pitsClosed = append(f.pitsClosed, body["id"].(string))
+		writeJSON(http.StatusOK, map[string]any{"succeeded": true, "num_freed": 1})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_search":
+		f.searches++
+		after := -1
+		if sa, ok := body["search_after"].([]any); ok {
+			after = int(sa[0].(float64))
+		}
+		res := hitsResponse(f.page(f.sliceDocs(body), after, int(body["size"].(float64))))
+		res["pit_id"] = fmt.Sprintf("pit%v", f.searches)
+		writeJSON(http.StatusOK, res)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/foo/_search":
+		docs := f.sliceDocs(body)
+		size := int(body["size"].(float64))
+		page := f.page(docs, -1, size)
+		res := hitsResponse(page)
+		res["_scroll_id"] = scrollID(docs, page)
+		writeJSON(http.StatusOK, res)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_search/scroll":
+		docs, after, size := parseScrollID(body["scroll_id"].(string))
+		page := f.page(docs, after, size)
+		res := hitsResponse(page)
+		res["_scroll_id"] = scrollID(docs, page)
+		writeJSON(http.StatusOK, res)
+
+	case r.Method == http.MethodDelete && r.URL.Path == "/_search/scroll":
+		for _, id := range body["scroll_id"].([]any) {
+			f.scrollsDone = append(f.scrollsDone, id.(string))
+		}
+		writeJSON(http.StatusOK, map[string]any{"succeeded": true})
+
+	default:
+		writeJSON(http.StatusNotFound, map[string]any{"status": 404})
+	}
+}
+
+// scrollID encodes the documents of a slice, the last document read and the
+// page size, which keeps the fake server stateless across scrolls.
+func scrollID(docs, page []int) string {
+	after := -1
+	if len(page) > 0 {
+		after = page[len(page)-1]
+	}
+	strs := make([]string, 0, len(docs))
+	for _, d := range docs {
+		strs = append(strs, strconv.Itoa(d))
+	}
+	size := len(page)
+	if size == 0 {
+		size = 1
+	}
+	return fmt.Sprintf("%v:%v:%v", strings.Join(strs, ","), after, size)
+}
+
+func parseScrollID(id string) (docs []int, after, size int) {
+	parts := strings.Split(id, ":")
+	for _, s := range strings.Split(parts[0], ",") {
+		if s == "" {
+			continue
+		}
+		d, _ := strconv.Atoi(s)
+		docs = append(docs, d)
+	}
+	after, _ = strconv.Atoi(parts[1])
+	size, _ = strconv.Atoi(parts[2])
+	return
+}
+
+func testInput(t *testing.T, url, extra string) *elasticsearchInput {
+	t.Helper()
+
+	conf, err := elasticsearchInputConfig().ParseYAML(fmt.Sprintf(`
+urls: [ %v ]
+index: foo
+batch_size: 3
+%v
+`, url, extra), nil)
+	require.NoError(t, err)
+
+	i, err := newElasticsearchInputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, i.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = i.Close(context.Background())
+	})
+	return i
+}
+
+func readAllDocs(t *testing.T, i *elasticsearchInput) ([]int, error) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	var docs []int
+	for {
+		batch, _, err := i.ReadBatch(ctx)
+		if err != nil {
+			if errors.Is(err, service.ErrEndOfInput) {
+				err = nil
+			}
+			sort.Ints(docs)
+			return docs, err
+		}
+		for _, msg := range batch {
+			var doc struct {
+				N int `json:"n"`
+			}
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(b, &doc))
+
+			id, _ := msg.MetaGet("elasticsearch_id")
+			assert.Equal(t, strconv.Itoa(doc.N), id)
+			docs = append(docs, doc.N)
+		}
+	}
+}
+
+func expectedDocs(n int) []int {
+	docs := make([]int, n)
+	for i := range docs {
+		docs[i] = i
+	}
+	return docs
+}
+
+func newFakeSearchServer(t *testing.T, docs int, failures ...int) (*fakeSearchServer, string) {
+	t.Helper()
+
+	f := &fakeSearchServer{
+		docs:       docs,
+		failures:   failures,
+		slicesSeen: map[int]struct{}{},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func waitForClosed(t *testing.T, i *elasticsearchInput) {
+	t.Helper()
+
+	select {
+	case <-i.shutSig.HasClosedChan():
+	case <-time.After(time.Second * 30):
+		t.Fatal("timed out waiting for input to close")
+	}
+}
+
+func TestElasticsearchInputPIT(t *testing.T) {
+	for _, slices := range []int{1, 3} {
+		slices := slices
+		t.Run(fmt.Sprintf("%v slices", slices), func(t *testing.T) {
+			f, url := newFakeSearchServer(t, 10)
+
+			i := testInput(t, url, fmt.Sprintf("slices: %v", slices))
+
+			docs, err := readAllDocs(t, i)
+			require.NoError(t, err)
+			assert.Equal(t, expectedDocs(10), docs)
+			waitForClosed(t, i)
+
+			f.mut.Lock()
+			defer f.mut.Unlock()
+
+			if slices > 1 {
+				assert.Len(t, f.slicesSeen, slices)
+			} else {
+				assert.Empty(t, f.slicesSeen)
+			}
+
+			// The initial point in time and the latest ID returned to each
+			// slice are closed.
+			assert.Len(t, f.pitsClosed, slices+1)
+			assert.Contains(t, f.pitsClosed, "pit0")
+			assert.Contains(t, f.pitsClosed, fmt.Sprintf("pit%v", f.searches))
+		})
+	}
+}
+
+func TestElasticsearchInputScroll(t *testing.T) {
+	for _, slices := range []int{1, 3} {
+		slices := slices
+		t.Run(fmt.Sprintf("%v slices", slices), func(t *testing.T) {
+			f, url := newFakeSearchServer(t, 10)
+
+			i := testInput(t, url, fmt.Sprintf(`
+pagination: scroll
+slices: %v
+`, slices))
+
+			docs, err := readAllDocs(t, i)
+			require.NoError(t, err)
+			assert.Equal(t, expectedDocs(10), docs)
+			waitForClosed(t, i)
+
+			f.mut.Lock()
+			defer f.mut.Unlock()
+
+			if slices > 1 {
+				assert.Len(t, f.slicesSeen, slices)
+			}
+			assert.Len(t, f.scrollsDone, slices)
+			assert.Empty(t, f.pitsClosed)
+		})
+	}
+}
+
+func TestElasticsearchInputRetries(t *testing.T) {
+	f, url := newFakeSearchServer(t, 5, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	i := testInput(t, url, "")
+
+	docs, err := readAllDocs(t, i)
+	require.NoError(t, err)
+	assert.Equal(t, expectedDocs(5), docs)
+
+	f.mut.Lock()
+	assert.Empty(t, f.failures)
+	f.mut.Unlock()
+}
+
+func TestElasticsearchInputClientError(t *testing.T) {
+	for _, pagination := range []string{esPaginationPIT, esPaginationScroll} {
+		pagination := pagination
+		t.Run(pagination, func(t *testing.T) {
+			f, url := newFakeSearchServer(t, 5, http.StatusBadRequest)
+
+			i := testInput(t, url, fmt.Sprintf(`
+pagination: %v
+slices: 2
+`, pagination))
+
+			_, err := readAllDocs(t, i)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "test failure")
+			waitForClosed(t, i)
+
+			// Once reported the input ends rather than reporting the error
+			// indefinitely.
+			_, _, err = i.ReadBatch(context.Background())
+			assert.ErrorIs(t, err, service.ErrEndOfInput)
+
+			if pagination == esPaginationPIT {
+				f.mut.Lock()
+				assert.Contains(t, f.pitsClosed, "pit0")
+				f.mut.Unlock()
+			}
+		})
+	}
+}
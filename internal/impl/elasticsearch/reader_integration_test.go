@@ -0,0 +1,136 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestIntegrationElasticsearchInput(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Minute * 3
+	resource, err := pool.Run("elasticsearch", "7.17.2", []string{
+		"discovery.type=single-node",
+		"ES_JAVA_OPTS=-Xms512m -Xmx512m",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	url := fmt.Sprintf("http://localhost:%v", resource.GetPort("9200/tcp"))
+
+	var client *elastic.Client
+	if err = pool.Retry(func() error {
+		var cerr error
+		if client, cerr = elastic.NewClient(
+			elastic.SetURL(url),
+			elastic.SetHttpClient(&http.Client{
+				Timeout: time.Second,
+			}),
+			elastic.SetSniff(false),
+		); cerr == nil {
+			_, cerr = client.
+				CreateIndex("test_input_index").
+				Timeout("20s").
+				Body(elasticIndex).
+				Do(context.Background())
+		}
+		return cerr
+	}); err != nil {
+		t.Fatalf("Could not connect to docker resource: %s", err)
+	}
+
+	_ = resource.Expire(900)
+
+	var expected []string
+	bulk := client.Bulk().Refresh("true")
+	for i := 0; i < 55; i++ {
+		id := fmt.Sprintf("doc%v", i)
+		expected = append(expected, id)
+		bulk.Add(elastic.NewBulkIndexRequest().
+			Index("test_input_index").
+			Id(id).
+			Doc(map[string]any{"user": fmt.Sprintf("user%v", i%3), "message": id}))
+	}
+	_, err = bulk.Do(context.Background())
+	require.NoError(t, err)
+	sort.Strings(expected)
+
+	readAll := func(t *testing.T, extra string) []string {
+		t.Helper()
+
+		ctx, done := context.WithTimeout(context.Background(), time.Minute)
+		defer done()
+
+		conf, err := elasticsearchInputConfig().ParseYAML(fmt.Sprintf(`
+urls: [ %v ]
+index: test_input_index
+batch_size: 10
+%v
+`, url, extra), nil)
+		require.NoError(t, err)
+
+		in, err := newElasticsearchInputFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+		require.NoError(t, in.Connect(ctx))
+		t.Cleanup(func() {
+			_ = in.Close(context.Background())
+		})
+
+		var ids []string
+		for {
+			batch, ackFn, err := in.ReadBatch(ctx)
+			if err == service.ErrEndOfInput {
+				break
+			}
+			require.NoError(t, err)
+			for _, msg := range batch {
+				index, _ := msg.MetaGet("elasticsearch_index")
+				assert.Equal(t, "test_input_index", index)
+				id, _ := msg.MetaGet("elasticsearch_id")
+				ids = append(ids, id)
+			}
+			require.NoError(t, ackFn(ctx, nil))
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	t.Run("point in time", func(t *testing.T) {
+		assert.Equal(t, expected, readAll(t, ""))
+	})
+
+	t.Run("point in time sliced", func(t *testing.T) {
+		assert.Equal(t, expected, readAll(t, "slices: 3"))
+	})
+
+	t.Run("scroll sliced", func(t *testing.T) {
+		assert.Equal(t, expected, readAll(t, "pagination: scroll\nslices: 2"))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		var userZero []string
+		for i := 0; i < 55; i += 3 {
+			userZero = append(userZero, fmt.Sprintf("doc%v", i))
+		}
+		sort.Strings(userZero)
+		assert.Equal(t, userZero, readAll(t, `query: '{"term":{"user":"user0"}}'`))
+	})
+}
@@ -0,0 +1,164 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type franzTransactRegistryKey struct{}
+
+// franzTransactionsFor returns the registry that pairs kafka_franz inputs and
+// outputs sharing a transactional ID within the components of a stream,
+// including its resources. When paired the input owns a single client, which
+// both consumes and produces, so that records written by the output and the
+// offsets of the records consumed by the input are committed within the same
+// Kafka transaction.
+func franzTransactionsFor(mgr *service.Resources) *franzTransactRegistry {
+	r, _ := mgr.GetOrSetGeneric(franzTransactRegistryKey{}, newFranzTransactRegistry())
+	return r.(*franzTransactRegistry)
+}
+
+type franzTransactPair struct {
+	inputClaimed bool
+	producerOpts []kgo.Opt
+	session      *kgo.GroupTransactSession
+}
+
+type franzTransactRegistry struct {
+	mut   sync.Mutex
+	pairs map[string]*franzTransactPair
+}
+
+func newFranzTransactRegistry() *franzTransactRegistry {
+	return &franzTransactRegistry{
+		pairs: map[string]*franzTransactPair{},
+	}
+}
+
+func (r *franzTransactRegistry) getPair(id string) *franzTransactPair {
+	p, exists := r.pairs[id]
+	if !exists {
+		p = &franzTransactPair{}
+		r.pairs[id] = p
+	}
+	return p
+}
+
+func (r *franzTransactRegistry) prune(id string) {
+	if p := r.pairs[id]; p != nil && !p.inputClaimed && p.producerOpts == nil {
+		delete(r.pairs, id)
+	}
+}
+
+// claimInput registers an input as the owner of a transactional ID, only one
+// input may own a given ID at a time.
+func (r *franzTransactRegistry) claimInput(id string) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	p := r.getPair(id)
+	if p.inputClaimed {
+		return fmt.Errorf("transactional_id %v is already in use by another kafka_franz input", id)
+	}
+	p.inputClaimed = true
+	return nil
+}
+
+func (r *franzTransactRegistry) releaseInput(id string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if p := r.pairs[id]; p != nil {
+		p.inputClaimed = false
+		p.session = nil
+	}
+	r.prune(id)
+}
+
+// registerOutput provides the producer options of an output for a
+// transactional ID. Outputs may be created before the input that they pair
+// with (resources are created before the stream input), and so whether an
+// input has claimed the same ID must be checked with inputClaimed each time
+// the output connects or writes.
+func (r *franzTransactRegistry) registerOutput(id string, producerOpts []kgo.Opt) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	p := r.getPair(id)
+	if p.producerOpts != nil {
+		return fmt.Errorf("transactional_id %v is already in use by another kafka_franz output", id)
+	}
+	p.producerOpts = producerOpts
+	return nil
+}
+
+// inputClaimed returns whether an input has claimed a transactional ID, in
+// which case an output of the same ID should write records using the session
+// of the input.
+func (r *franzTransactRegistry) inputClaimed(id string) bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if p := r.pairs[id]; p != nil {
+		return p.inputClaimed
+	}
+	return false
+}
+
+func (r *franzTransactRegistry) releaseOutput(id string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if p := r.pairs[id]; p != nil {
+		p.producerOpts = nil
+	}
+	r.prune(id)
+}
+
+func (r *franzTransactRegistry) getProducerOpts(id string) ([]kgo.Opt, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	p := r.pairs[id]
+	if p == nil || p.producerOpts == nil {
+		return nil, fmt.Errorf("no kafka_franz output has been configured with transactional_id %v", id)
+	}
+	return p.producerOpts, nil
+}
+
+func (r *franzTransactRegistry) setSession(id string, s *kgo.GroupTransactSession) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if p := r.pairs[id]; p != nil {
+		p.session = s
+	}
+}
+
+func (r *franzTransactRegistry) getSession(id string) *kgo.GroupTransactSession {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if p := r.pairs[id]; p != nil {
+		return p.session
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// isFencedErr returns true if an error indicates that the producer of a
+// transactional ID has been fenced by a newer producer, or has otherwise
+// entered a state that requires a new client in order to recover.
+func isFencedErr(err error) bool {
+	return errors.Is(err, kerr.ProducerFenced) ||
+		errors.Is(err, kerr.InvalidProducerEpoch) ||
+		errors.Is(err, kerr.InvalidProducerIDMapping) ||
+		errors.Is(err, kerr.TransactionalIDAuthorizationFailed)
+}
@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestFranzTransactRegistry(t *testing.T) {
+	r := newFranzTransactRegistry()
+
+	_, err := r.getProducerOpts("foo")
+	require.Error(t, err)
+
+	require.NoError(t, r.claimInput("foo"))
+	require.Error(t, r.claimInput("foo"))
+
+	require.NoError(t, r.registerOutput("foo", []kgo.Opt{kgo.AllowAutoTopicCreation()}))
+	assert.True(t, r.inputClaimed("foo"))
+
+	require.Error(t, r.registerOutput("foo", []kgo.Opt{}))
+
+	opts, err := r.getProducerOpts("foo")
+	require.NoError(t, err)
+	assert.Len(t, opts, 1)
+
+	require.NoError(t, r.registerOutput("bar", []kgo.Opt{}))
+	assert.False(t, r.inputClaimed("bar"))
+
+	assert.Nil(t, r.getSession("foo"))
+
+	r.releaseInput("foo")
+	r.releaseOutput("foo")
+	assert.NotContains(t, r.pairs, "foo")
+
+	require.NoError(t, r.claimInput("foo"))
+}
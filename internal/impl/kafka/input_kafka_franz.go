@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,6 +29,12 @@ func franzKafkaInputConfig() *service.ConfigSpec {
 		Description(`
 Consumes one or more topics by balancing the partitions across any other connected clients with the same consumer group.
 
+Alternatively, explicit partitions can be consumed by specifying them after the topic name, in which case a consumer group cannot be used and therefore offsets are not committed. The starting offset of each partition is determined by the fields ` + "`start_offset`, `start_timestamp` and `start_from_oldest`" + `.
+
+### Metrics
+
+This input emits a ` + "`input_kafka_lag`" + ` gauge metric with the labels ` + "`topic` and `partition`" + `, which is the calculated difference between the high water mark offset of each partition and the offset of the most recently consumed record of that partition. When a partition is revoked from or lost by the consumer group its gauge is reset to zero.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -41,6 +48,12 @@ This input adds the following metadata fields to each message:
 - kafka_tombstone_message
 - All record headers
 ` + "```" + `
+
+### Exactly-Once Delivery
+
+When the field ` + "`transactional_id`" + ` is set this input consumes records within Kafka transactions, and must be paired with a ` + "[`kafka_franz` output](/docs/components/outputs/kafka_franz)" + ` within the same config that is configured with the same ` + "`transactional_id`" + `. Records are consumed within a transaction for up to the ` + "`commit_period`" + `, during which the output writes messages as part of that transaction. Once the period has elapsed consumption pauses until all records of the transaction have been delivered, at which point the offsets of those records are committed within the same transaction. Since consumption pauses at the end of each transaction a ` + "`batching`" + ` policy of the output must include a ` + "`period`" + ` when its ` + "`count`" + ` or ` + "`byte_size`" + ` might not be reached by the records of a transaction.
+
+If any message of a transaction is rejected, or the partitions of the consumer group are rebalanced before the transaction ends, the transaction is aborted and the records are consumed again. Consumers of the topics written to should use a ` + "`read_committed`" + ` isolation level in order to ignore records of aborted transactions.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -48,12 +61,19 @@ This input adds the following metadata fields to each message:
 			Example([]string{"foo:9092", "bar:9092"}).
 			Example([]string{"foo:9092,bar:9092"})).
 		Field(service.NewStringListField("topics").
-			Description("A list of topics to consume from, partitions are automatically shared across consumers sharing the consumer group.")).
+			Description("A list of topics to consume from. Multiple comma separated topics can be listed in a single element. When a `consumer_group` is specified partitions are automatically distributed across consumers of a topic, otherwise all partitions are consumed. Alternatively, it's possible to specify explicit partitions to consume from with a colon after the topic name, e.g. `foo:0` would consume the partition 0 of the topic foo. This syntax supports ranges, e.g. `foo:0-10` would consume partitions 0 through to 10 inclusive.").
+			Example([]string{"foo", "bar"}).
+			Example([]string{"things.*"}).
+			Example([]string{"foo,bar"}).
+			Example([]string{"foo:0", "bar:1", "bar:3"}).
+			Example([]string{"foo:0,bar:1,bar:3"}).
+			Example([]string{"foo:0-5"})).
 		Field(service.NewBoolField("regexp_topics").
 			Description("Whether listed topics should be interpretted as regular expression patterns for matching multiple topics.").
 			Default(false)).
 		Field(service.NewStringField("consumer_group").
-			Description("A consumer group to consume as. Partitions are automatically distributed across consumers sharing a consumer group, and partition offsets are automatically committed and resumed under this name.")).
+			Description("An optional consumer group to consume as. When specified the partitions of specified topics are automatically distributed across consumers sharing a consumer group, and partition offsets are automatically committed and resumed under this name. Consumer groups are not supported when specifying explicit partitions to consume from in the `topics` field.").
+			Optional()).
 		Field(service.NewIntField("checkpoint_limit").
 			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. When a message of a given offset is delivered to the output the offset is only allowed to be committed when all messages of prior offsets have also been delivered, this ensures at-least-once delivery guarantees. However, this mechanism also increases the likelihood of duplicates in the event of crashes or server faults, reducing the checkpoint limit will mitigate this.").
 			Default(1024).
@@ -66,18 +86,64 @@ This input adds the following metadata fields to each message:
 			Description("If an offset is not found for a topic partition, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").
 			Default(true).
 			Advanced()).
+		Field(service.NewStringField("start_timestamp").
+			Description("An optional RFC3339 timestamp, if an offset is not found for a topic partition then consumption begins from the first record with a timestamp at or after it. Takes precedence over `start_from_oldest`.").
+			Example("2023-03-20T09:00:00Z").
+			Optional().
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewIntField("start_offset").
+			Description("An optional explicit offset, if an offset is not found for a topic partition then consumption begins from this offset. Takes precedence over `start_timestamp` and `start_from_oldest`.").
+			Optional().
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewStringAnnotatedEnumField("rebalance_strategy", map[string]string{
+			"cooperative_sticky": "Assigns partitions as evenly as possible whilst retaining prior assignments, and moves partitions between members incrementally without stopping the consumption of other partitions.",
+			"sticky":             "Assigns partitions as evenly as possible whilst retaining prior assignments.",
+			"range":              "Assigns contiguous ranges of partitions of each topic to members.",
+			"round_robin":        "Assigns partitions to members in a round-robin fashion.",
+		}).
+			Description("The strategy used for assigning partitions to the members of a consumer group. All members of a consumer group must use a compatible strategy.").
+			Default("cooperative_sticky").
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewStringAnnotatedEnumField("isolation_level", map[string]string{
+			"read_uncommitted": "Consume all records, including those of transactions that are open or were aborted.",
+			"read_committed":   "Only consume records of transactions that have been committed, as well as records that were written without a transaction.",
+		}).
+			Description("The isolation level with which records are consumed.").
+			Default("read_uncommitted").
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID, when set records are consumed and committed within Kafka transactions in coordination with a `kafka_franz` output configured with the same transactional ID. A `consumer_group` must be specified when this field is set.").
+			Example("benthos-orders-eos").
+			Optional().
+			Advanced().
+			Version("4.14.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField()).
-		Field(service.NewBoolField("multi_header").Description("Decode headers into lists to allow handling of multiple values with the same key").Default(false).Advanced())
+		Field(service.NewBoolField("multi_header").Description("Decode headers into lists to allow handling of multiple values with the same key").Default(false).Advanced()).
+		LintRule(`
+root = if this.transactional_id.or("") != "" && this.consumer_group.or("") == "" {
+  "a consumer_group must be specified when a transactional_id is set"
+} else if this.start_offset != null && this.start_timestamp.or("") != "" {
+  "a start_offset and start_timestamp cannot both be specified"
+}`)
 }
 
 func init() {
 	err := service.RegisterInput("kafka_franz", franzKafkaInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger())
+			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr)
 			if err != nil {
 				return nil, err
 			}
+			if rdr.transactionalID != "" {
+				// Rejected messages abort the transaction that they were
+				// consumed within, and are therefore consumed again.
+				return rdr, nil
+			}
 			return service.AutoRetryNacks(rdr), nil
 		})
 	if err != nil {
@@ -88,14 +154,18 @@ func init() {
 //------------------------------------------------------------------------------
 
 type msgWithAckFn struct {
-	onAck func()
+	onAck func(err error)
 	msg   *service.Message
 }
 
 type franzKafkaReader struct {
 	seedBrokers     []string
 	topics          []string
+	topicPartitions map[string][]int32
 	consumerGroup   string
+	balancer        kgo.GroupBalancer
+	startOffset     *int64
+	startTimestamp  *time.Time
 	tlsConf         *tls.Config
 	saslConfs       []sasl.Mechanism
 	checkpointLimit int
@@ -103,10 +173,14 @@ type franzKafkaReader struct {
 	commitPeriod    time.Duration
 	regexPattern    bool
 	multiHeader     bool
-
-	msgChan atomic.Value
-	log     *service.Logger
-	shutSig *shutdown.Signaller
+	readCommitted   bool
+	transactionalID string
+	transactions    *franzTransactRegistry
+
+	msgChan  atomic.Value
+	log      *service.Logger
+	lagGauge *service.MetricGauge
+	shutSig  *shutdown.Signaller
 }
 
 func (f *franzKafkaReader) getMsgChan() chan msgWithAckFn {
@@ -118,10 +192,12 @@ func (f *franzKafkaReader) storeMsgChan(c chan msgWithAckFn) {
 	f.msgChan.Store(c)
 }
 
-func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaReader, error) {
+func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*franzKafkaReader, error) {
 	f := franzKafkaReader{
-		log:     log,
-		shutSig: shutdown.NewSignaller(),
+		topicPartitions: map[string][]int32{},
+		log:             mgr.Logger(),
+		lagGauge:        mgr.Metrics().NewGauge("input_kafka_lag", "topic", "partition"),
+		shutSig:         shutdown.NewSignaller(),
 	}
 
 	brokerList, err := conf.FieldStringList("seed_brokers")
@@ -137,16 +213,61 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 	for _, t := range topicList {
-		f.topics = append(f.topics, strings.Split(t, ",")...)
+		for _, topic := range strings.Split(t, ",") {
+			withParts := strings.Split(topic, ":")
+			if len(withParts) == 1 {
+				if len(f.topicPartitions) > 0 {
+					return nil, errCannotMixBalanced
+				}
+				f.topics = append(f.topics, topic)
+				continue
+			}
+			if len(f.topics) > 0 {
+				return nil, errCannotMixBalanced
+			}
+			if len(withParts) > 2 {
+				return nil, fmt.Errorf("topic '%v' is invalid, only one partition should be specified and the same topic can be listed multiple times, e.g. use `foo:0,foo:1` not `foo:0:1`", topic)
+			}
+			parts, err := parsePartitions(withParts[1])
+			if err != nil {
+				return nil, err
+			}
+			f.topicPartitions[withParts[0]] = append(f.topicPartitions[withParts[0]], parts...)
+		}
 	}
 
 	if f.regexPattern, err = conf.FieldBool("regexp_topics"); err != nil {
 		return nil, err
 	}
+	if f.regexPattern && len(f.topicPartitions) > 0 {
+		return nil, errors.New("explicit partitions cannot be specified when regexp_topics is enabled")
+	}
+
+	if conf.Contains("consumer_group") {
+		if f.consumerGroup, err = conf.FieldString("consumer_group"); err != nil {
+			return nil, err
+		}
+	}
+	if f.consumerGroup != "" && len(f.topicPartitions) > 0 {
+		return nil, errors.New("a consumer_group cannot be specified when consuming explicit partitions")
+	}
 
-	if f.consumerGroup, err = conf.FieldString("consumer_group"); err != nil {
+	balancerStr, err := conf.FieldString("rebalance_strategy")
+	if err != nil {
 		return nil, err
 	}
+	switch balancerStr {
+	case "cooperative_sticky":
+		f.balancer = kgo.CooperativeStickyBalancer()
+	case "sticky":
+		f.balancer = kgo.StickyBalancer()
+	case "range":
+		f.balancer = kgo.RangeBalancer()
+	case "round_robin":
+		f.balancer = kgo.RoundRobinBalancer()
+	default:
+		return nil, fmt.Errorf("rebalance strategy not recognised: %v", balancerStr)
+	}
 
 	if f.checkpointLimit, err = conf.FieldInt("checkpoint_limit"); err != nil {
 		return nil, err
@@ -160,6 +281,27 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if conf.Contains("start_offset") {
+		startOffset, err := conf.FieldInt("start_offset")
+		if err != nil {
+			return nil, err
+		}
+		offset := int64(startOffset)
+		f.startOffset = &offset
+	}
+
+	if conf.Contains("start_timestamp") {
+		tsStr, err := conf.FieldString("start_timestamp")
+		if err != nil {
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339Nano, tsStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse start_timestamp: %w", err)
+		}
+		f.startTimestamp = &ts
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -174,6 +316,33 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	isolationLevel, err := conf.FieldString("isolation_level")
+	if err != nil {
+		return nil, err
+	}
+	switch isolationLevel {
+	case "read_uncommitted":
+	case "read_committed":
+		f.readCommitted = true
+	default:
+		return nil, fmt.Errorf("isolation level not recognised: %v", isolationLevel)
+	}
+
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+	}
+	if f.transactionalID != "" {
+		if f.consumerGroup == "" {
+			return nil, errors.New("a consumer_group must be specified when a transactional_id is set")
+		}
+		f.transactions = franzTransactionsFor(mgr)
+		if err = f.transactions.claimInput(f.transactionalID); err != nil {
+			return nil, err
+		}
+	}
+
 	return &f, nil
 }
 
@@ -274,31 +443,10 @@ func (c *checkpointTracker) removeTopicPartitions(m map[string][]int32) {
 
 //------------------------------------------------------------------------------
 
-func (f *franzKafkaReader) Connect(ctx context.Context) error {
-	if f.getMsgChan() != nil {
-		return nil
-	}
-
-	if f.shutSig.ShouldCloseAtLeisure() {
-		f.shutSig.ShutdownComplete()
-		return service.ErrEndOfInput
-	}
-
-	checkpoints := newCheckpointTracker()
-
-	var initialOffset kgo.Offset
-	if f.startFromOldest {
-		initialOffset = kgo.NewOffset().AtStart()
-	} else {
-		initialOffset = kgo.NewOffset().AtEnd()
-	}
-
-	clientOpts := []kgo.Opt{
-		kgo.SeedBrokers(f.seedBrokers...),
-		kgo.ConsumerGroup(f.consumerGroup),
-		kgo.ConsumeTopics(f.topics...),
-		kgo.ConsumeResetOffset(initialOffset),
-		kgo.SASL(f.saslConfs...),
+// groupCommitOpts returns client options for committing the offsets of
+// delivered records when consuming as a consumer group.
+func (f *franzKafkaReader) groupCommitOpts(checkpoints *checkpointTracker) []kgo.Opt {
+	return []kgo.Opt{
 		kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
 			// Note: this is a best attempt, there's a chance of duplicates if
 			// the checkpoint limit is borked with slow moving pending messages,
@@ -324,16 +472,96 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				f.log.Errorf("Commit error on partition revoke: %v", commitErr)
 			})
 			checkpoints.removeTopicPartitions(m)
+			f.resetLag(m)
 		}),
 		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
+			f.resetLag(m)
 		}),
 		kgo.AutoCommitMarks(),
 		kgo.AutoCommitInterval(f.commitPeriod),
+	}
+}
+
+// recordLag updates the lag gauge of each partition of a fetch, which is the
+// difference between the high water mark of the partition and the offset of the
+// last record consumed from it.
+func (f *franzKafkaReader) recordLag(fetches kgo.Fetches) {
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		lag := p.HighWatermark - p.Records[len(p.Records)-1].Offset - 1
+		if lag < 0 {
+			lag = 0
+		}
+		f.lagGauge.Set(lag, p.Topic, strconv.Itoa(int(p.Partition)))
+	})
+}
+
+// resetLag zeroes the lag gauge of partitions that are no longer consumed by
+// this reader, as they would otherwise report their last lag indefinitely.
+func (f *franzKafkaReader) resetLag(m map[string][]int32) {
+	for topic, parts := range m {
+		for _, part := range parts {
+			f.lagGauge.Set(0, topic, strconv.Itoa(int(part)))
+		}
+	}
+}
+
+func (f *franzKafkaReader) Connect(ctx context.Context) error {
+	if f.getMsgChan() != nil {
+		return nil
+	}
+
+	if f.shutSig.ShouldCloseAtLeisure() {
+		f.shutSig.ShutdownComplete()
+		return service.ErrEndOfInput
+	}
+
+	checkpoints := newCheckpointTracker()
+
+	var initialOffset kgo.Offset
+	switch {
+	case f.startOffset != nil:
+		initialOffset = kgo.NewOffset().At(*f.startOffset)
+	case f.startTimestamp != nil:
+		initialOffset = kgo.NewOffset().AfterMilli(f.startTimestamp.UnixMilli())
+	case f.startFromOldest:
+		initialOffset = kgo.NewOffset().AtStart()
+	default:
+		initialOffset = kgo.NewOffset().AtEnd()
+	}
+
+	clientOpts := []kgo.Opt{
+		kgo.SeedBrokers(f.seedBrokers...),
+		kgo.ConsumeResetOffset(initialOffset),
+		kgo.SASL(f.saslConfs...),
 		kgo.WithLogger(&kgoLogger{f.log}),
 	}
 
+	if len(f.topicPartitions) > 0 {
+		partitions := map[string]map[int32]kgo.Offset{}
+		for topic, parts := range f.topicPartitions {
+			partOffsets := map[int32]kgo.Offset{}
+			for _, part := range parts {
+				partOffsets[part] = initialOffset
+			}
+			partitions[topic] = partOffsets
+		}
+		clientOpts = append(clientOpts, kgo.ConsumePartitions(partitions))
+	} else {
+		clientOpts = append(clientOpts, kgo.ConsumeTopics(f.topics...))
+	}
+
+	if f.consumerGroup != "" {
+		clientOpts = append(clientOpts,
+			kgo.ConsumerGroup(f.consumerGroup),
+			kgo.Balancers(f.balancer),
+		)
+	}
+
 	if f.tlsConf != nil {
 		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
 	}
@@ -342,6 +570,18 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		clientOpts = append(clientOpts, kgo.ConsumeRegex())
 	}
 
+	if f.readCommitted {
+		clientOpts = append(clientOpts, kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	}
+
+	if f.transactionalID != "" {
+		return f.connectTransactional(clientOpts)
+	}
+
+	if f.consumerGroup != "" {
+		clientOpts = append(clientOpts, f.groupCommitOpts(checkpoints)...)
+	}
+
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
 		return err
@@ -372,30 +612,14 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 			fetches := cl.PollFetches(stallCtx)
 			pollDone()
 
-			if errs := fetches.Errors(); len(errs) > 0 {
-				// Any non-temporal error sets this true and we close the client
-				// forcing a reconnect.
-				nonTemporalErr := false
-
-				for _, kerr := range errs {
-					// TODO: The documentation from franz-go is top-tier, it
-					// should be straight forward to expand this to include more
-					// errors that are safe to disregard.
-					if errors.Is(kerr.Err, context.DeadlineExceeded) {
-						continue
-					}
-					nonTemporalErr = true
-					f.log.Errorf("Kafka poll error on topic %v, partition %v: %v", kerr.Topic, kerr.Partition, kerr.Err)
-				}
-
-				if nonTemporalErr {
-					cl.Close()
-					return
-				}
+			if f.hasNonTemporalErr(fetches) {
+				cl.Close()
+				return
 			}
 			if closeCtx.Err() != nil {
 				return
 			}
+			f.recordLag(fetches)
 
 			pauseTopicPartitions := map[string][]int32{}
 			iter := fetches.RecordIter()
@@ -426,8 +650,8 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				select {
 				case msgChan <- msgWithAckFn{
 					msg: msg,
-					onAck: func() {
-						if maxRec := releaseFn(); maxRec != nil {
+					onAck: func(error) {
+						if maxRec := releaseFn(); maxRec != nil && f.consumerGroup != "" {
 							cl.MarkCommitRecords(maxRec)
 						}
 					},
@@ -456,7 +680,227 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	}()
 
 	f.storeMsgChan(msgChan)
-	f.log.Infof("Receiving messages from Kafka topics: %v", f.topics)
+	if len(f.topicPartitions) > 0 {
+		f.log.Infof("Receiving messages from Kafka topic partitions: %v", f.topicPartitions)
+	} else {
+		f.log.Infof("Receiving messages from Kafka topics: %v", f.topics)
+	}
+	return nil
+}
+
+// hasNonTemporalErr logs any errors of a fetch and returns true if any of them
+// are non-temporal, in which case we close the client forcing a reconnect.
+func (f *franzKafkaReader) hasNonTemporalErr(fetches kgo.Fetches) bool {
+	nonTemporalErr := false
+	for _, kerr := range fetches.Errors() {
+		// TODO: The documentation from franz-go is top-tier, it
+		// should be straight forward to expand this to include more
+		// errors that are safe to disregard.
+		if errors.Is(kerr.Err, context.DeadlineExceeded) {
+			continue
+		}
+		nonTemporalErr = true
+		f.log.Errorf("Kafka poll error on topic %v, partition %v: %v", kerr.Topic, kerr.Partition, kerr.Err)
+	}
+	return nonTemporalErr
+}
+
+// franzTxnTracker tracks the acknowledgements of records delivered within the
+// current transaction.
+type franzTxnTracker struct {
+	mut      sync.Mutex
+	pending  int
+	rejected bool
+	drained  chan struct{}
+}
+
+func (t *franzTxnTracker) reset() {
+	t.mut.Lock()
+	t.pending = 0
+	t.rejected = false
+	t.drained = nil
+	t.mut.Unlock()
+}
+
+func (t *franzTxnTracker) add(n int) {
+	t.mut.Lock()
+	t.pending += n
+	t.mut.Unlock()
+}
+
+func (t *franzTxnTracker) ack(err error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if err != nil {
+		t.rejected = true
+	}
+	if t.pending--; t.pending == 0 && t.drained != nil {
+		close(t.drained)
+		t.drained = nil
+	}
+}
+
+func (t *franzTxnTracker) isRejected() bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.rejected
+}
+
+// waitDrained returns a channel that is closed once all delivered records have
+// been acknowledged.
+func (t *franzTxnTracker) waitDrained() <-chan struct{} {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if t.pending == 0 {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if t.drained == nil {
+		t.drained = make(chan struct{})
+	}
+	return t.drained
+}
+
+// connectTransactional creates a client that consumes records within
+// transactions. Records of any number of fetches are delivered within a
+// transaction until it has been open for the commit period, at which point
+// consumption pauses until all delivered records have been acknowledged, and
+// the transaction is ended along with the offsets of those records.
+func (f *franzKafkaReader) connectTransactional(clientOpts []kgo.Opt) error {
+	producerOpts, err := f.transactions.getProducerOpts(f.transactionalID)
+	if err != nil {
+		return err
+	}
+	clientOpts = append(clientOpts, producerOpts...)
+	clientOpts = append(clientOpts,
+		kgo.TransactionalID(f.transactionalID),
+		kgo.RequireStableFetchOffsets(),
+	)
+
+	sess, err := kgo.NewGroupTransactSession(clientOpts...)
+	if err != nil {
+		return err
+	}
+	f.transactions.setSession(f.transactionalID, sess)
+
+	msgChan := make(chan msgWithAckFn)
+	go func() {
+		defer func() {
+			f.transactions.setSession(f.transactionalID, nil)
+			sess.Close()
+			f.storeMsgChan(nil)
+			close(msgChan)
+			if f.shutSig.ShouldCloseAtLeisure() {
+				f.shutSig.ShutdownComplete()
+			}
+		}()
+
+		closeCtx, done := f.shutSig.CloseAtLeisureCtx(context.Background())
+		defer done()
+
+		var inTxn bool
+		var txnStarted time.Time
+		var tracker franzTxnTracker
+
+		abort := func() {
+			if !inTxn {
+				return
+			}
+			abortCtx, abortDone := context.WithTimeout(context.Background(), time.Second*5)
+			defer abortDone()
+			if _, err := sess.End(abortCtx, kgo.TryAbort); err != nil {
+				f.log.Errorf("Failed to abort transaction: %v", err)
+			}
+		}
+
+		for {
+			if inTxn && (time.Since(txnStarted) >= f.commitPeriod || tracker.isRejected()) {
+				// Polling again would mark the offsets of further records to
+				// be committed, and so we wait for all delivered records to be
+				// acknowledged before ending the transaction.
+				select {
+				case <-tracker.waitDrained():
+				case <-closeCtx.Done():
+					abort()
+					return
+				}
+
+				commit := kgo.TryCommit
+				if tracker.isRejected() {
+					commit = kgo.TryAbort
+				}
+				inTxn = false
+				committed, err := sess.End(closeCtx, commit)
+				if err != nil {
+					if isFencedErr(err) {
+						f.log.Errorf("Transactional producer has been fenced, reconnecting: %v", err)
+					} else {
+						f.log.Errorf("Failed to end transaction: %v", err)
+					}
+					return
+				}
+				if !committed {
+					f.log.Warnf("Transaction was aborted, records of the transaction will be consumed again")
+				}
+				continue
+			}
+
+			pollTimeout := time.Second
+			if inTxn {
+				if untilEnd := f.commitPeriod - time.Since(txnStarted); untilEnd < pollTimeout {
+					pollTimeout = untilEnd
+				}
+			}
+			stallCtx, pollDone := context.WithTimeout(closeCtx, pollTimeout)
+			fetches := sess.PollFetches(stallCtx)
+			pollDone()
+
+			if f.hasNonTemporalErr(fetches) {
+				abort()
+				return
+			}
+			if closeCtx.Err() != nil {
+				abort()
+				return
+			}
+			f.recordLag(fetches)
+
+			numRecords := fetches.NumRecords()
+			if numRecords == 0 {
+				continue
+			}
+
+			if !inTxn {
+				if err := sess.Begin(); err != nil {
+					f.log.Errorf("Failed to begin transaction: %v", err)
+					return
+				}
+				inTxn = true
+				txnStarted = time.Now()
+				tracker.reset()
+			}
+			tracker.add(numRecords)
+
+			iter := fetches.RecordIter()
+			for !iter.Done() {
+				select {
+				case msgChan <- msgWithAckFn{
+					msg:   recordToMessage(iter.Next(), f.multiHeader),
+					onAck: tracker.ack,
+				}:
+				case <-closeCtx.Done():
+					abort()
+					return
+				}
+			}
+		}
+	}()
+
+	f.storeMsgChan(msgChan)
+	f.log.Infof("Receiving messages from Kafka topics within transactions: %v", f.topics)
 	return nil
 }
 
@@ -505,13 +949,17 @@ func (f *franzKafkaReader) Read(ctx context.Context) (*service.Message, service.
 	}
 
 	return mAck.msg, func(ctx context.Context, res error) error {
-		// Res will always be nil because we initialize with service.AutoRetryNacks
-		mAck.onAck()
+		// Res will always be nil unless we're consuming within transactions
+		// because we otherwise initialize with service.AutoRetryNacks
+		mAck.onAck(res)
 		return nil
 	}, nil
 }
 
 func (f *franzKafkaReader) Close(ctx context.Context) error {
+	if f.transactionalID != "" {
+		defer f.transactions.releaseInput(f.transactionalID)
+	}
 	go func() {
 		f.shutSig.CloseAtLeisure()
 		if f.getMsgChan() == nil {
@@ -0,0 +1,258 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestKafkaFranzInputBadParams(t *testing.T) {
+	testCases := []struct {
+		name        string
+		conf        string
+		errContains string
+	}{
+		{
+			name: "transactional with a consumer group",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  consumer_group: bar
+  isolation_level: read_committed
+  transactional_id: baz
+`,
+		},
+		{
+			name: "transactional without a consumer group",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  transactional_id: baz
+`,
+			errContains: "a consumer_group must be specified when a transactional_id is set",
+		},
+		{
+			name: "bad isolation level",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  isolation_level: read_everything
+`,
+			errContains: "read_everything",
+		},
+		{
+			name: "start offset and timestamp",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  start_offset: 10
+  start_timestamp: 2023-03-20T09:00:00Z
+`,
+			errContains: "a start_offset and start_timestamp cannot both be specified",
+		},
+		{
+			name: "bad rebalance strategy",
+			conf: `
+kafka_franz:
+  seed_brokers: [ foo:1234 ]
+  topics: [ foo ]
+  rebalance_strategy: whatever
+`,
+			errContains: "whatever",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			err := service.NewStreamBuilder().AddInputYAML(test.conf)
+			if test.errContains == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			}
+		})
+	}
+}
+
+func TestKafkaFranzInputTopicPartitions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		conf        string
+		topics      []string
+		partitions  map[string][]int32
+		errContains string
+	}{
+		{
+			name: "balanced topics",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topics: [ foo, "bar,baz" ]
+consumer_group: meow
+`,
+			topics:     []string{"foo", "bar", "baz"},
+			partitions: map[string][]int32{},
+		},
+		{
+			name: "explicit partitions",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topics: [ "foo:0", "bar:1-3,foo:5" ]
+`,
+			partitions: map[string][]int32{
+				"foo": {0, 5},
+				"bar": {1, 2, 3},
+			},
+		},
+		{
+			name: "mixed topics",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topics: [ "foo:0", "bar" ]
+`,
+			errContains: "it is not currently possible to include balanced and explicit partition topics",
+		},
+		{
+			name: "explicit partitions with a group",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topics: [ "foo:0" ]
+consumer_group: meow
+`,
+			errContains: "a consumer_group cannot be specified when consuming explicit partitions",
+		},
+		{
+			name: "bad partition range",
+			conf: `
+seed_brokers: [ foo:1234 ]
+topics: [ "foo:0-1-2" ]
+`,
+			errContains: "only one range can be specified",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := franzKafkaInputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			rdr, err := newFranzKafkaReaderFromConfig(pConf, service.MockResources())
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.topics, rdr.topics)
+			assert.Equal(t, test.partitions, rdr.topicPartitions)
+		})
+	}
+}
+
+func TestKafkaFranzInputStartOffsets(t *testing.T) {
+	pConf, err := franzKafkaInputConfig().ParseYAML(`
+seed_brokers: [ foo:1234 ]
+topics: [ foo ]
+start_timestamp: 2023-03-20T09:00:00Z
+`, nil)
+	require.NoError(t, err)
+
+	rdr, err := newFranzKafkaReaderFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	require.NotNil(t, rdr.startTimestamp)
+	assert.Equal(t, time.Date(2023, 3, 20, 9, 0, 0, 0, time.UTC), rdr.startTimestamp.UTC())
+	assert.Nil(t, rdr.startOffset)
+
+	pConf, err = franzKafkaInputConfig().ParseYAML(`
+seed_brokers: [ foo:1234 ]
+topics: [ foo ]
+start_offset: 100
+`, nil)
+	require.NoError(t, err)
+
+	rdr, err = newFranzKafkaReaderFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	require.NotNil(t, rdr.startOffset)
+	assert.Equal(t, int64(100), *rdr.startOffset)
+}
+
+func TestKafkaFranzInputLag(t *testing.T) {
+	pConf, err := franzKafkaInputConfig().ParseYAML(`
+seed_brokers: [ foo:1234 ]
+topics: [ foo ]
+consumer_group: bar
+`, nil)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	rdr, err := newFranzKafkaReaderFromConfig(pConf, service.MockResources(func(m *mock.Manager) {
+		m.M = stats
+	}))
+	require.NoError(t, err)
+
+	rdr.recordLag(kgo.Fetches{{Topics: []kgo.FetchTopic{{
+		Topic: "foo",
+		Partitions: []kgo.FetchPartition{
+			{Partition: 0, HighWatermark: 10, Records: []*kgo.Record{{Offset: 4}}},
+			{Partition: 1, HighWatermark: 20, Records: []*kgo.Record{{Offset: 9}}},
+		},
+	}}}})
+	assert.Equal(t, map[string]int64{
+		`input_kafka_lag{partition="0",topic="foo"}`: 5,
+		`input_kafka_lag{partition="1",topic="foo"}`: 10,
+	}, stats.GetCounters())
+
+	rdr.resetLag(map[string][]int32{"foo": {1}})
+	assert.Equal(t, map[string]int64{
+		`input_kafka_lag{partition="0",topic="foo"}`: 5,
+		`input_kafka_lag{partition="1",topic="foo"}`: 0,
+	}, stats.GetCounters())
+}
+
+func TestKafkaFranzTxnTracker(t *testing.T) {
+	var tracker franzTxnTracker
+
+	select {
+	case <-tracker.waitDrained():
+	default:
+		t.Fatal("expected tracker without pending records to be drained")
+	}
+
+	// Records of several fetches are tracked within the same transaction.
+	tracker.add(2)
+	tracker.add(1)
+	drained := tracker.waitDrained()
+
+	tracker.ack(nil)
+	tracker.ack(errors.New("nope"))
+	select {
+	case <-drained:
+		t.Fatal("expected tracker to have pending records")
+	default:
+	}
+	assert.True(t, tracker.isRejected())
+
+	tracker.ack(nil)
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("expected tracker to be drained")
+	}
+
+	tracker.reset()
+	assert.False(t, tracker.isRejected())
+}
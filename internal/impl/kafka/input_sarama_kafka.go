@@ -113,6 +113,12 @@ Unfortunately this error message will appear for a wide range of connection prob
 		Categories: []string{
 			"Services",
 		},
+		Migrations: []docs.ComponentMigration{
+			{
+				To:      "kafka_franz",
+				Mapping: saramaInputMigration,
+			},
+		},
 	})
 	if err != nil {
 		panic(err)
@@ -7,6 +7,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -23,7 +24,13 @@ func franzKafkaOutputConfig() *service.ConfigSpec {
 		Version("3.61.0").
 		Summary("An alternative Kafka output using the [Franz Kafka client library](https://github.com/twmb/franz-go).").
 		Description(`
-Writes a batch of messages to Kafka brokers and waits for acknowledgement before propagating it back to the input.`).
+Writes a batch of messages to Kafka brokers and waits for acknowledgement before propagating it back to the input.
+
+### Transactions
+
+When the field ` + "`transactional_id`" + ` is set each batch of messages is written within a Kafka transaction, and consumers reading with a ` + "`read_committed`" + ` isolation level will only see messages of batches that were written in full.
+
+If a ` + "[`kafka_franz` input](/docs/components/inputs/kafka_franz)" + ` within the same config is configured with the same ` + "`transactional_id`" + ` then messages are instead written within the transactions of that input, where the offsets of consumed messages are committed as part of the same transaction, providing exactly-once delivery for Kafka to Kafka pipelines. In this mode the client of the input is used in order to produce messages, and therefore the fields ` + "`seed_brokers`" + `, ` + "`tls`" + ` and ` + "`sasl`" + ` of this output are ignored.`).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
 			Example([]string{"localhost:9092"}).
@@ -66,6 +73,17 @@ Writes a batch of messages to Kafka brokers and waits for acknowledgement before
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID to write messages with, when set each batch of messages is written within a transaction. Only one producer may use a given transactional ID at any time, and a producer that connects with the ID of an existing producer will fence the older producer.").
+			Example("benthos-orders-eos").
+			Optional().
+			Advanced().
+			Version("4.14.0")).
+		Field(service.NewDurationField("transaction_timeout").
+			Description("The maximum period of time that a transaction may remain open before it is aborted by the broker. This field is only relevant when a `transactional_id` is set.").
+			Default("1m").
+			Advanced().
+			Version("4.14.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField()).
 		LintRule(`
@@ -92,7 +110,7 @@ func init() {
 			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 				return
 			}
-			output, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger())
+			output, err = newFranzKafkaWriterFromConfig(conf, mgr)
 			return
 		})
 	if err != nil {
@@ -116,14 +134,21 @@ type franzKafkaWriter struct {
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
 
-	client *kgo.Client
+	transactionalID    string
+	transactionTimeout time.Duration
+	transactions       *franzTransactRegistry
+
+	txnMut    sync.Mutex
+	clientMut sync.Mutex
+	client    *kgo.Client
 
 	log *service.Logger
 }
 
-func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaWriter, error) {
+func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*franzKafkaWriter, error) {
 	f := franzKafkaWriter{
-		log: log,
+		transactions: franzTransactionsFor(mgr),
+		log:          mgr.Logger(),
 	}
 
 	brokerList, err := conf.FieldStringList("seed_brokers")
@@ -231,12 +256,72 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+	}
+	if f.transactionTimeout, err = conf.FieldDuration("transaction_timeout"); err != nil {
+		return nil, err
+	}
+	if f.transactionalID != "" {
+		if err = f.transactions.registerOutput(f.transactionalID, f.producerOpts()); err != nil {
+			return nil, err
+		}
+	}
+
 	return &f, nil
 }
 
+// producerOpts returns the client options specific to producing records,
+// which are also provided to the client of an input when paired within a
+// transaction.
+func (f *franzKafkaWriter) producerOpts() []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.AllowAutoTopicCreation(), // TODO: Configure this
+		kgo.ProducerBatchMaxBytes(f.produceMaxBytes),
+		kgo.ProduceRequestTimeout(f.timeout),
+	}
+	if f.partitioner != nil {
+		opts = append(opts, kgo.RecordPartitioner(f.partitioner))
+	}
+	if len(f.compressionPrefs) > 0 {
+		opts = append(opts, kgo.ProducerBatchCompression(f.compressionPrefs...))
+	}
+	if f.transactionalID != "" {
+		opts = append(opts, kgo.TransactionTimeout(f.transactionTimeout))
+	}
+	return opts
+}
+
+// pairedWithInput returns whether a kafka_franz input has claimed the
+// transactional ID of this output. This is checked lazily as the output might
+// have been created before the input, e.g. when it is a resource.
+func (f *franzKafkaWriter) pairedWithInput() bool {
+	return f.transactionalID != "" && f.transactions.inputClaimed(f.transactionalID)
+}
+
 //------------------------------------------------------------------------------
 
 func (f *franzKafkaWriter) Connect(ctx context.Context) error {
+	f.clientMut.Lock()
+	defer f.clientMut.Unlock()
+
+	if f.pairedWithInput() {
+		if f.client != nil {
+			// We connected before the input was created, and since a
+			// transactional producer only initialises its producer ID once it
+			// first produces the input has not been fenced.
+			f.client.Close()
+			f.client = nil
+		}
+		if f.transactions.getSession(f.transactionalID) == nil {
+			return fmt.Errorf("waiting for kafka_franz input with transactional_id %v to connect", f.transactionalID)
+		}
+		f.log.Infof("Writing messages to Kafka topic within the transactions of input %v: %v", f.transactionalID, f.topicStr)
+		return nil
+	}
+
 	if f.client != nil {
 		return nil
 	}
@@ -244,19 +329,14 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	clientOpts := []kgo.Opt{
 		kgo.SeedBrokers(f.seedBrokers...),
 		kgo.SASL(f.saslConfs...),
-		kgo.AllowAutoTopicCreation(), // TODO: Configure this
-		kgo.ProducerBatchMaxBytes(f.produceMaxBytes),
-		kgo.ProduceRequestTimeout(f.timeout),
 		kgo.WithLogger(&kgoLogger{f.log}),
 	}
+	clientOpts = append(clientOpts, f.producerOpts()...)
 	if f.tlsConf != nil {
 		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
 	}
-	if f.partitioner != nil {
-		clientOpts = append(clientOpts, kgo.RecordPartitioner(f.partitioner))
-	}
-	if len(f.compressionPrefs) > 0 {
-		clientOpts = append(clientOpts, kgo.ProducerBatchCompression(f.compressionPrefs...))
+	if f.transactionalID != "" {
+		clientOpts = append(clientOpts, kgo.TransactionalID(f.transactionalID))
 	}
 
 	cl, err := kgo.NewClient(clientOpts...)
@@ -269,16 +349,79 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) (err error) {
-	if f.client == nil {
+func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	records, err := f.batchToRecords(b)
+	if err != nil {
+		return err
+	}
+
+	if f.pairedWithInput() {
+		// The input owns the session and therefore the beginning and ending
+		// of transactions, we simply produce within the current one.
+		f.disconnect()
+		session := f.transactions.getSession(f.transactionalID)
+		if session == nil {
+			return service.ErrNotConnected
+		}
+		return session.ProduceSync(ctx, records...).FirstErr()
+	}
+
+	if f.transactionalID != "" {
+		// A client can only have one open transaction at a time.
+		f.txnMut.Lock()
+		defer f.txnMut.Unlock()
+	}
+
+	f.clientMut.Lock()
+	client := f.client
+	f.clientMut.Unlock()
+	if client == nil {
 		return service.ErrNotConnected
 	}
 
-	records := make([]*kgo.Record, 0, len(b))
+	if f.transactionalID == "" {
+		// TODO: This is very cool and allows us to easily return granular errors,
+		// so we should honor travis by doing it.
+		return client.ProduceSync(ctx, records...).FirstErr()
+	}
+
+	if err := client.BeginTransaction(); err != nil {
+		return f.transactionErr(client, err)
+	}
+	if err := client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		if abortErr := client.EndTransaction(ctx, kgo.TryAbort); abortErr != nil {
+			f.log.Errorf("Failed to abort transaction: %v", abortErr)
+			return f.transactionErr(client, abortErr)
+		}
+		return f.transactionErr(client, err)
+	}
+	return f.transactionErr(client, client.EndTransaction(ctx, kgo.TryCommit))
+}
+
+// transactionErr checks whether a transaction error indicates that our
+// producer has been fenced, in which case the client is closed in order to
+// force a reconnect with a new producer epoch.
+func (f *franzKafkaWriter) transactionErr(client *kgo.Client, err error) error {
+	if err == nil || !isFencedErr(err) {
+		return err
+	}
+	f.log.Errorf("Transactional producer has been fenced, reconnecting: %v", err)
+
+	f.clientMut.Lock()
+	defer f.clientMut.Unlock()
+	if f.client == client {
+		f.client.Close()
+		f.client = nil
+	}
+	return service.ErrNotConnected
+}
+
+func (f *franzKafkaWriter) batchToRecords(b service.MessageBatch) (records []*kgo.Record, err error) {
+	records = make([]*kgo.Record, 0, len(b))
 	for i, msg := range b {
 		var topic string
 		if topic, err = b.TryInterpolatedString(i, f.topic); err != nil {
-			return fmt.Errorf("topic interpolation error: %w", err)
+			return nil, fmt.Errorf("topic interpolation error: %w", err)
 		}
 
 		record := &kgo.Record{Topic: topic}
@@ -287,17 +430,17 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		}
 		if f.key != nil {
 			if record.Key, err = b.TryInterpolatedBytes(i, f.key); err != nil {
-				return fmt.Errorf("key interpolation error: %w", err)
+				return nil, fmt.Errorf("key interpolation error: %w", err)
 			}
 		}
 		if f.partition != nil {
 			partStr, err := b.TryInterpolatedString(i, f.partition)
 			if err != nil {
-				return fmt.Errorf("partition interpolation error: %w", err)
+				return nil, fmt.Errorf("partition interpolation error: %w", err)
 			}
 			partInt, err := strconv.Atoi(partStr)
 			if err != nil {
-				return fmt.Errorf("partition parse error: %w", err)
+				return nil, fmt.Errorf("partition parse error: %w", err)
 			}
 			record.Partition = int32(partInt)
 		}
@@ -310,14 +453,13 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		})
 		records = append(records, record)
 	}
-
-	// TODO: This is very cool and allows us to easily return granular errors,
-	// so we should honor travis by doing it.
-	err = f.client.ProduceSync(ctx, records...).FirstErr()
 	return
 }
 
 func (f *franzKafkaWriter) disconnect() {
+	f.clientMut.Lock()
+	defer f.clientMut.Unlock()
+
 	if f.client == nil {
 		return
 	}
@@ -327,5 +469,8 @@ func (f *franzKafkaWriter) disconnect() {
 
 func (f *franzKafkaWriter) Close(ctx context.Context) error {
 	f.disconnect()
+	if f.transactionalID != "" {
+		f.transactions.releaseOutput(f.transactionalID)
+	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,3 +69,71 @@ kafka_franz:
 		})
 	}
 }
+
+func TestKafkaFranzOutputTransactionalResource(t *testing.T) {
+	// Resource outputs are created before the input of a stream, and therefore
+	// before the input has claimed the transactional ID.
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+transactional_id: resource_txn
+`, nil)
+	require.NoError(t, err)
+
+	mgr := service.MockResources()
+	w, err := newFranzKafkaWriterFromConfig(conf, mgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+
+	assert.False(t, w.pairedWithInput())
+	require.NoError(t, w.Connect(context.Background()))
+	assert.NotNil(t, w.client)
+
+	transactions := franzTransactionsFor(mgr)
+	require.NoError(t, transactions.claimInput("resource_txn"))
+	t.Cleanup(func() {
+		transactions.releaseInput("resource_txn")
+	})
+
+	assert.True(t, w.pairedWithInput())
+
+	// The output must not write with its own transactional producer once the
+	// input has claimed the ID, as this would fence the session of the input.
+	err = w.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for kafka_franz input with transactional_id resource_txn to connect")
+	assert.Nil(t, w.client)
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	assert.Equal(t, service.ErrNotConnected, err)
+
+	opts, err := transactions.getProducerOpts("resource_txn")
+	require.NoError(t, err)
+	assert.NotEmpty(t, opts)
+}
+
+func TestKafkaFranzOutputTransactionalScope(t *testing.T) {
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+transactional_id: scoped_txn
+`, nil)
+	require.NoError(t, err)
+
+	// Components of separate streams do not share transactional IDs.
+	for i := 0; i < 2; i++ {
+		w, err := newFranzKafkaWriterFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = w.Close(context.Background())
+		})
+	}
+
+	mgr := service.MockResources()
+	_, err = newFranzKafkaWriterFromConfig(conf, mgr)
+	require.NoError(t, err)
+	_, err = newFranzKafkaWriterFromConfig(conf, mgr)
+	require.Error(t, err)
+}
@@ -82,6 +82,12 @@ Unfortunately this error message will appear for a wide range of connection prob
 		Categories: []string{
 			"Services",
 		},
+		Migrations: []docs.ComponentMigration{
+			{
+				To:      "kafka_franz",
+				Mapping: saramaOutputMigration,
+			},
+		},
 	})
 	if err != nil {
 		panic(err)
@@ -0,0 +1,320 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	kceOperatorUnwrap = "unwrap"
+	kceOperatorWrap   = "wrap"
+
+	kceDebeziumFlatten = "flatten"
+	kceDebeziumKeep    = "keep"
+)
+
+func kafkaConnectEnvelopeConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing", "Integration").
+		Version("4.14.0").
+		Summary("Converts messages to and from the JSON envelopes produced and consumed by Kafka Connect converters, including Debezium change events.").
+		Description(`
+## Operators
+
+### `+"`unwrap`"+`
+
+Removes the `+"`schema`/`payload`"+` envelope added by the Kafka Connect `+"`JsonConverter`"+` when schemas are enabled, leaving the payload as a structured message. Messages without an envelope are treated as a payload already, and therefore both forms of the converter are supported.
+
+When the payload is a [Debezium change event](https://debezium.io/documentation/reference/stable/connectors/index.html) the `+"`debezium`"+` field determines how it is handled. With `+"`flatten`"+` the message becomes the state of the row after the change, or the state before the change for deletes, and the following metadata fields are added:
+
+`+"```text"+`
+- debezium_op
+- debezium_ts_ms
+- debezium_source
+- debezium_before
+`+"```"+`
+
+Where `+"`debezium_op`"+` is the operation of the event (`+"`c`, `u`, `d`, `r` or `t`"+`), and `+"`debezium_source` and `debezium_before`"+` are structured values containing the source information of the event and the state of the row before the change respectively. With `+"`keep`"+` the event is left as it is, and the `+"`op`, `before`, `after` and `source`"+` fields can be accessed within the message.
+
+Debezium emits a tombstone message with an empty payload after each delete event in order to support log compaction, these are dropped when `+"`drop_tombstones`"+` is enabled.
+
+### `+"`wrap`"+`
+
+Wraps structured messages with a `+"`schema`/`payload`"+` envelope that can be read by a Kafka Connect `+"`JsonConverter`"+` with schemas enabled. The schema is inferred from the structure of each message unless an explicit `+"`schema`"+` is provided. Inferred integers become `+"`int64`"+`, other numbers become `+"`double`"+`, and null values become optional strings.`).
+		Field(service.NewStringAnnotatedEnumField("operator", map[string]string{
+			kceOperatorUnwrap: "Remove envelopes from messages.",
+			kceOperatorWrap:   "Add envelopes to messages.",
+		}).Description("The [operator](#operators) to execute.")).
+		Field(service.NewStringAnnotatedEnumField("debezium", map[string]string{
+			kceDebeziumFlatten: "Replace Debezium change events with the state of the row and add the remaining fields as metadata.",
+			kceDebeziumKeep:    "Leave Debezium change events as they are.",
+		}).
+			Description("How Debezium change events are handled by the `unwrap` operator.").
+			Default(kceDebeziumFlatten)).
+		Field(service.NewBoolField("drop_tombstones").
+			Description("Whether messages with an empty payload should be dropped by the `unwrap` operator.").
+			Default(true)).
+		Field(service.NewStringField("schema").
+			Description("An explicit Kafka Connect schema to add to messages with the `wrap` operator, expressed as a JSON document. When empty the schema is inferred from each message.").
+			Default("").
+			Example(`{"type":"struct","name":"foo","optional":false,"fields":[{"field":"id","type":"int64","optional":false}]}`)).
+		Field(service.NewStringField("schema_name").
+			Description("An optional name to give schemas inferred with the `wrap` operator.").
+			Default("").
+			Advanced()).
+		Example("Unwrap Debezium Events", `
+Debezium events consumed from Kafka can be flattened into the state of each row, with the operation available as metadata:`,
+			`
+input:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topics: [ dbserver1.inventory.customers ]
+    consumer_group: benthos
+
+pipeline:
+  processors:
+    - kafka_connect_envelope:
+        operator: unwrap
+    - mapping: |
+        root = this
+        root.deleted = @debezium_op == "d"
+`,
+		).
+		Example("Wrap Messages for Connect Sinks", `
+Messages can be written with an envelope that allows Kafka Connect sinks using the `+"`JsonConverter`"+` to read them:`,
+			`
+pipeline:
+  processors:
+    - kafka_connect_envelope:
+        operator: wrap
+        schema_name: users
+
+output:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topic: users
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"kafka_connect_envelope", kafkaConnectEnvelopeConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newKafkaConnectEnvelopeFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kafkaConnectEnvelope struct {
+	operator       string
+	debezium       string
+	dropTombstones bool
+	schema         any
+	schemaName     string
+
+	log *service.Logger
+}
+
+func newKafkaConnectEnvelopeFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*kafkaConnectEnvelope, error) {
+	k := &kafkaConnectEnvelope{log: mgr.Logger()}
+
+	var err error
+	if k.operator, err = conf.FieldString("operator"); err != nil {
+		return nil, err
+	}
+	if k.operator != kceOperatorUnwrap && k.operator != kceOperatorWrap {
+		return nil, fmt.Errorf("operator not recognised: %v", k.operator)
+	}
+	if k.debezium, err = conf.FieldString("debezium"); err != nil {
+		return nil, err
+	}
+	if k.dropTombstones, err = conf.FieldBool("drop_tombstones"); err != nil {
+		return nil, err
+	}
+	if k.schemaName, err = conf.FieldString("schema_name"); err != nil {
+		return nil, err
+	}
+
+	schemaStr, err := conf.FieldString("schema")
+	if err != nil {
+		return nil, err
+	}
+	if schemaStr != "" {
+		if err := json.Unmarshal([]byte(schemaStr), &k.schema); err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+		if _, isObj := k.schema.(map[string]any); !isObj {
+			return nil, errors.New("schema must be a JSON object")
+		}
+	}
+	return k, nil
+}
+
+func (k *kafkaConnectEnvelope) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	var err error
+	if k.operator == kceOperatorWrap {
+		err = k.wrap(msg)
+	} else {
+		var keep bool
+		if keep, err = k.unwrap(msg); err == nil && !keep {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		k.log.Debugf("Operator failed: %v", err)
+		return nil, err
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+// unwrap removes the envelope from a message and returns false if the message
+// is a tombstone that should be dropped.
+func (k *kafkaConnectEnvelope) unwrap(msg *service.Message) (bool, error) {
+	msgBytes, err := msg.AsBytes()
+	if err != nil {
+		return false, err
+	}
+	if len(msgBytes) == 0 {
+		return !k.dropTombstones, nil
+	}
+
+	v, err := msg.AsStructuredMut()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+
+	if obj, isObj := v.(map[string]any); isObj && len(obj) == 2 {
+		_, hasSchema := obj["schema"]
+		payload, hasPayload := obj["payload"]
+		if hasSchema && hasPayload {
+			v = payload
+		}
+	}
+	if v == nil {
+		if k.dropTombstones {
+			return false, nil
+		}
+		msg.SetStructuredMut(nil)
+		return true, nil
+	}
+
+	if event, isEvent := debeziumEvent(v); isEvent && k.debezium == kceDebeziumFlatten {
+		op, _ := event["op"].(string)
+		msg.MetaSetMut("debezium_op", op)
+		if ts, exists := event["ts_ms"]; exists && ts != nil {
+			msg.MetaSetMut("debezium_ts_ms", ts)
+		}
+		if source, exists := event["source"]; exists && source != nil {
+			msg.MetaSetMut("debezium_source", source)
+		}
+		if before, exists := event["before"]; exists && before != nil {
+			msg.MetaSetMut("debezium_before", before)
+		}
+		if op == "d" {
+			v = event["before"]
+		} else {
+			v = event["after"]
+		}
+	}
+
+	msg.SetStructuredMut(v)
+	return true, nil
+}
+
+// debeziumEvent returns the fields of a Debezium change event, or false if the
+// value is not a change event.
+func debeziumEvent(v any) (map[string]any, bool) {
+	obj, isObj := v.(map[string]any)
+	if !isObj {
+		return nil, false
+	}
+	if _, isStr := obj["op"].(string); !isStr {
+		return nil, false
+	}
+	_, hasBefore := obj["before"]
+	_, hasAfter := obj["after"]
+	_, hasSource := obj["source"]
+	if !hasSource && !hasBefore && !hasAfter {
+		return nil, false
+	}
+	return obj, true
+}
+
+func (k *kafkaConnectEnvelope) wrap(msg *service.Message) error {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+
+	schema := k.schema
+	if schema == nil {
+		inferred := inferConnectSchema(v)
+		if k.schemaName != "" {
+			inferred["name"] = k.schemaName
+		}
+		schema = inferred
+	}
+
+	msg.SetStructuredMut(map[string]any{
+		"schema":  schema,
+		"payload": v,
+	})
+	return nil
+}
+
+// inferConnectSchema returns a Kafka Connect schema that describes a value.
+func inferConnectSchema(v any) map[string]any {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		fields := make([]any, 0, len(keys))
+		for _, k := range keys {
+			field := inferConnectSchema(t[k])
+			field["field"] = k
+			fields = append(fields, field)
+		}
+		return map[string]any{"type": "struct", "fields": fields, "optional": false}
+	case []any:
+		items := map[string]any{"type": "string", "optional": true}
+		if len(t) > 0 {
+			items = inferConnectSchema(t[0])
+		}
+		return map[string]any{"type": "array", "items": items, "optional": false}
+	case string:
+		return map[string]any{"type": "string", "optional": false}
+	case bool:
+		return map[string]any{"type": "boolean", "optional": false}
+	case int, int32, int64, uint, uint32, uint64:
+		return map[string]any{"type": "int64", "optional": false}
+	case float32, float64:
+		return map[string]any{"type": "double", "optional": false}
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return map[string]any{"type": "int64", "optional": false}
+		}
+		return map[string]any{"type": "double", "optional": false}
+	case []byte:
+		return map[string]any{"type": "bytes", "optional": false}
+	}
+	return map[string]any{"type": "string", "optional": true}
+}
+
+func (k *kafkaConnectEnvelope) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,172 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testKafkaConnectEnvelope(t *testing.T, conf string) *kafkaConnectEnvelope {
+	t.Helper()
+
+	pConf, err := kafkaConnectEnvelopeConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	k, err := newKafkaConnectEnvelopeFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return k
+}
+
+func TestKafkaConnectEnvelopeUnwrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		input    string
+		output   string
+		metadata map[string]any
+	}{
+		{
+			name:   "schema envelope",
+			conf:   `operator: unwrap`,
+			input:  `{"schema":{"type":"struct","fields":[{"field":"id","type":"int64","optional":false}],"optional":false},"payload":{"id":5}}`,
+			output: `{"id":5}`,
+		},
+		{
+			name:   "no envelope",
+			conf:   `operator: unwrap`,
+			input:  `{"id":5,"payload":"foo"}`,
+			output: `{"id":5,"payload":"foo"}`,
+		},
+		{
+			name:   "debezium create",
+			conf:   `operator: unwrap`,
+			input:  `{"schema":{},"payload":{"before":null,"after":{"id":1,"name":"foo"},"source":{"db":"inventory","table":"customers"},"op":"c","ts_ms":1000}}`,
+			output: `{"id":1,"name":"foo"}`,
+			metadata: map[string]any{
+				"debezium_op":     "c",
+				"debezium_source": map[string]any{"db": "inventory", "table": "customers"},
+			},
+		},
+		{
+			name:   "debezium update",
+			conf:   `operator: unwrap`,
+			input:  `{"before":{"id":1,"name":"foo"},"after":{"id":1,"name":"bar"},"source":{"db":"inventory"},"op":"u","ts_ms":1000}`,
+			output: `{"id":1,"name":"bar"}`,
+			metadata: map[string]any{
+				"debezium_op":     "u",
+				"debezium_before": map[string]any{"id": json.Number("1"), "name": "foo"},
+			},
+		},
+		{
+			name:   "debezium delete",
+			conf:   `operator: unwrap`,
+			input:  `{"before":{"id":1,"name":"foo"},"after":null,"source":{"db":"inventory"},"op":"d","ts_ms":1000}`,
+			output: `{"id":1,"name":"foo"}`,
+			metadata: map[string]any{
+				"debezium_op": "d",
+			},
+		},
+		{
+			name:   "debezium keep",
+			conf:   "operator: unwrap\ndebezium: keep",
+			input:  `{"schema":{},"payload":{"before":null,"after":{"id":1},"source":{"db":"inventory"},"op":"c","ts_ms":1000}}`,
+			output: `{"after":{"id":1},"before":null,"op":"c","source":{"db":"inventory"},"ts_ms":1000}`,
+		},
+		{
+			name:   "tombstone kept",
+			conf:   "operator: unwrap\ndrop_tombstones: false",
+			input:  `{"schema":null,"payload":null}`,
+			output: `null`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			k := testKafkaConnectEnvelope(t, test.conf)
+
+			batch, err := k.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			b, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+
+			for key, exp := range test.metadata {
+				v, exists := batch[0].MetaGetMut(key)
+				require.True(t, exists, key)
+				assert.Equal(t, exp, v, key)
+			}
+		})
+	}
+}
+
+func TestKafkaConnectEnvelopeTombstones(t *testing.T) {
+	k := testKafkaConnectEnvelope(t, `operator: unwrap`)
+
+	for _, input := range []string{"", "null", `{"schema":null,"payload":null}`} {
+		batch, err := k.Process(context.Background(), service.NewMessage([]byte(input)))
+		require.NoError(t, err)
+		assert.Empty(t, batch, input)
+	}
+}
+
+func TestKafkaConnectEnvelopeWrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		input  string
+		output string
+	}{
+		{
+			name:   "inferred schema",
+			conf:   "operator: wrap\nschema_name: foo",
+			input:  `{"id":5,"name":"bar","score":1.5,"active":true,"tags":["a"],"address":{"city":"baz"},"deleted_at":null}`,
+			output: `{"payload":{"active":true,"address":{"city":"baz"},"deleted_at":null,"id":5,"name":"bar","score":1.5,"tags":["a"]},"schema":{"fields":[{"field":"active","optional":false,"type":"boolean"},{"field":"address","fields":[{"field":"city","optional":false,"type":"string"}],"optional":false,"type":"struct"},{"field":"deleted_at","optional":true,"type":"string"},{"field":"id","optional":false,"type":"int64"},{"field":"name","optional":false,"type":"string"},{"field":"score","optional":false,"type":"double"},{"field":"tags","items":{"optional":false,"type":"string"},"optional":false,"type":"array"}],"name":"foo","optional":false,"type":"struct"}}`,
+		},
+		{
+			name:   "explicit schema",
+			conf:   "operator: wrap\nschema: '{\"type\":\"struct\",\"fields\":[{\"field\":\"id\",\"type\":\"int32\"}]}'",
+			input:  `{"id":5}`,
+			output: `{"payload":{"id":5},"schema":{"fields":[{"field":"id","type":"int32"}],"type":"struct"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			k := testKafkaConnectEnvelope(t, test.conf)
+
+			batch, err := k.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			require.NoError(t, err)
+			require.Len(t, batch, 1)
+
+			b, err := batch[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+		})
+	}
+}
+
+func TestKafkaConnectEnvelopeRoundTrip(t *testing.T) {
+	wrap := testKafkaConnectEnvelope(t, `operator: wrap`)
+	unwrap := testKafkaConnectEnvelope(t, `operator: unwrap`)
+
+	batch, err := wrap.Process(context.Background(), service.NewMessage([]byte(`{"id":5,"name":"bar"}`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	batch, err = unwrap.Process(context.Background(), batch[0])
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":5,"name":"bar"}`, string(b))
+}
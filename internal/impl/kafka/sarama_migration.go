@@ -0,0 +1,69 @@
+package kafka
+
+// saramaSASLMigration is a Bloblang snippet shared by the migration rules of
+// the Sarama based components that converts a sasl object into the list
+// expected by the franz-go based components.
+const saramaSASLMigration = `
+let _ = if this.sasl.token_cache.or("") != "" { throw("the field sasl.token_cache is not supported by kafka_franz") }
+root.sasl = if this.sasl.mechanism.or("none") != "none" {
+  [
+    {
+      "mechanism": this.sasl.mechanism,
+      "username": this.sasl.user | deleted(),
+      "password": this.sasl.password | deleted(),
+      "token": this.sasl.access_token | deleted(),
+    }
+  ]
+}
+`
+
+// saramaInputMigration migrates a kafka input config to kafka_franz.
+const saramaInputMigration = `
+let _ = if this.topics.or([]).any(t -> t.contains(":")) && this.consumer_group.or("") != "" {
+  throw("explicit topic partitions cannot be consumed with a consumer group by kafka_franz")
+}
+let _ = if this.batching.count.or(0) > 0 || this.batching.byte_size.or(0) > 0 || this.batching.period.or("") != "" || this.batching.check.or("") != "" {
+  throw("input level batching is not supported by kafka_franz, consider using a broker with batching instead")
+}
+let _ = if this.extract_tracing_map.or("") != "" { throw("the field extract_tracing_map is not supported by kafka_franz") }
+let _ = if this.rack_id.or("") != "" { throw("the field rack_id is not supported by kafka_franz") }
+
+root.seed_brokers = this.addresses | deleted()
+root.topics = this.topics | deleted()
+root.consumer_group = if this.consumer_group.or("") != "" { this.consumer_group }
+root.checkpoint_limit = this.checkpoint_limit | deleted()
+root.commit_period = this.commit_period | deleted()
+root.start_from_oldest = this.start_from_oldest | deleted()
+root.tls = this.tls | deleted()
+root.multi_header = this.multi_header | deleted()
+` + saramaSASLMigration
+
+// saramaOutputMigration migrates a kafka output config to kafka_franz.
+const saramaOutputMigration = `
+let partitioner = this.partitioner.or("fnv1a_hash")
+let _ = if $partitioner == "fnv1a_hash" && this.key.or("") != "" {
+  throw("the fnv1a_hash partitioner is not supported by kafka_franz, keyed messages would be written to different partitions")
+}
+let _ = if this.static_headers.or({}).keys().length() > 0 { throw("the field static_headers is not supported by kafka_franz") }
+let _ = if this.metadata.exclude_prefixes.or([]).length() > 0 { throw("the field metadata.exclude_prefixes is not supported by kafka_franz") }
+let _ = if this.rack_id.or("") != "" { throw("the field rack_id is not supported by kafka_franz") }
+
+root.seed_brokers = this.addresses | deleted()
+root.topic = this.topic | deleted()
+root.key = if this.key.or("") != "" { this.key }
+root.partitioner = match $partitioner {
+  "murmur2_hash" => $partitioner,
+  "round_robin" => $partitioner,
+  "random" => "round_robin",
+  "manual" => $partitioner,
+  _ => deleted(),
+}
+root.partition = if this.partition.or("") != "" { this.partition }
+root.metadata.include_patterns = [ ".*" ]
+root.max_in_flight = this.max_in_flight | deleted()
+root.timeout = this.timeout | deleted()
+root.batching = this.batching | deleted()
+root.max_message_bytes = this.max_msg_bytes.string() | deleted()
+root.compression = this.compression | deleted()
+root.tls = this.tls | deleted()
+` + saramaSASLMigration
@@ -0,0 +1,149 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+)
+
+func TestSaramaMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]any
+		errs     []string
+	}{
+		{
+			name: "input and output",
+			input: `
+input:
+  kafka:
+    addresses: [ "foo:9092", "bar:9092" ]
+    topics: [ foo, bar ]
+    consumer_group: benthos_group
+    checkpoint_limit: 100
+    start_from_oldest: false
+    sasl:
+      mechanism: SCRAM-SHA-256
+      user: foo
+      password: bar
+output:
+  kafka:
+    addresses: [ "foo:9092" ]
+    topic: baz
+    key: ${! json("id") }
+    partitioner: murmur2_hash
+    client_id: bar_client
+    max_msg_bytes: 2000000
+    compression: snappy
+`,
+			expected: map[string]any{
+				"input": map[string]any{
+					"kafka_franz": map[string]any{
+						"seed_brokers":      []any{"foo:9092", "bar:9092"},
+						"topics":            []any{"foo", "bar"},
+						"consumer_group":    "benthos_group",
+						"checkpoint_limit":  100,
+						"start_from_oldest": false,
+						"sasl": []any{
+							map[string]any{
+								"mechanism": "SCRAM-SHA-256",
+								"username":  "foo",
+								"password":  "bar",
+							},
+						},
+					},
+				},
+				"output": map[string]any{
+					"kafka_franz": map[string]any{
+						"seed_brokers":      []any{"foo:9092"},
+						"topic":             "baz",
+						"key":               `${! json("id") }`,
+						"partitioner":       "murmur2_hash",
+						"max_message_bytes": "2000000",
+						"compression":       "snappy",
+						"metadata": map[string]any{
+							"include_patterns": []any{".*"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "unsupported fields",
+			input: `
+input:
+  kafka:
+    addresses: [ "foo:9092" ]
+    topics: [ foo ]
+    batching:
+      count: 10
+output:
+  kafka:
+    addresses: [ "foo:9092" ]
+    topic: baz
+    key: ${! json("id") }
+`,
+			errs: []string{
+				"input level batching is not supported by kafka_franz",
+				"the fnv1a_hash partitioner is not supported by kafka_franz",
+			},
+		},
+		{
+			name: "rack id",
+			input: `
+input:
+  kafka:
+    addresses: [ "foo:9092" ]
+    topics: [ foo ]
+    rack_id: foo_rack
+output:
+  kafka:
+    addresses: [ "foo:9092" ]
+    topic: baz
+    rack_id: foo_rack
+`,
+			errs: []string{
+				"the field rack_id is not supported by kafka_franz",
+				"the field rack_id is not supported by kafka_franz",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			newBytes, migrated, err := config.MigrateBytes([]byte(test.input))
+			require.NoError(t, err)
+
+			var errs []string
+			for _, m := range migrated {
+				assert.Equal(t, "kafka", m.From)
+				assert.Equal(t, "kafka_franz", m.To)
+				if m.Err != nil {
+					errs = append(errs, m.Err.Error())
+				}
+			}
+			require.Len(t, errs, len(test.errs))
+			for i, exp := range test.errs {
+				assert.Contains(t, errs[i], exp)
+			}
+			if test.expected == nil {
+				assert.Nil(t, newBytes)
+				return
+			}
+
+			var actual map[string]any
+			require.NoError(t, yaml.Unmarshal(newBytes, &actual))
+			assert.Equal(t, test.expected, actual)
+
+			lints, err := config.LintBytes(config.LintOptions{}, newBytes)
+			require.NoError(t, err)
+			assert.Empty(t, lints)
+		})
+	}
+}
@@ -2,19 +2,31 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/impl/mongodb/client"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 // mongodb input component allowed operations.
 const (
-	FindInputOperation      = "find"
-	AggregateInputOperation = "aggregate"
+	FindInputOperation         = "find"
+	AggregateInputOperation    = "aggregate"
+	ChangeStreamInputOperation = "change_stream"
+)
+
+// mongodb input change stream scopes.
+const (
+	changeStreamScopeCollection = "collection"
+	changeStreamScopeDatabase   = "database"
+	changeStreamScopeDeployment = "deployment"
 )
 
 func mongoConfigSpec() *service.ConfigSpec {
@@ -23,13 +35,19 @@ func mongoConfigSpec() *service.ConfigSpec {
 		Version("3.64.0").
 		Categories("Services").
 		Summary("Executes a find query and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).`).
+		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+### Change Streams
+
+When the `+"`operation`"+` is `+"`change_stream`"+` this input instead watches a collection, database or deployment for changes and creates a message for each [change event](https://www.mongodb.com/docs/manual/reference/change-events/), which requires the server to be a replica set or sharded cluster. The `+"`query`"+` is an aggregation pipeline used to filter and modify change events, and the events of a deployment are watched regardless of the `+"`database`"+` and `+"`collection`"+` fields.
+
+The resume token of the last change event that has been acknowledged, along with all prior events, is stored within a [cache resource](/docs/components/caches/about), and is used in order to resume the stream from the same position when the input is restarted. The input shuts down when the stream is invalidated, for example when the watched collection is dropped.`).
 		Field(urlField).
 		Field(service.NewStringField("database").Description("The name of the target MongoDB database.")).
-		Field(service.NewStringField("collection").Description("The collection to select from.")).
+		Field(service.NewStringField("collection").Description("The collection to select from.").Default("")).
 		Field(service.NewStringField("username").Description("The username to connect to the database.").Default("")).
 		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("")).
-		Field(service.NewStringEnumField("operation", FindInputOperation, AggregateInputOperation).
+		Field(service.NewStringEnumField("operation", FindInputOperation, AggregateInputOperation, ChangeStreamInputOperation).
 			Description("The mongodb operation to perform.").
 			Default(FindInputOperation).Advanced().
 			Version("4.2.0")).
@@ -43,21 +61,68 @@ func mongoConfigSpec() *service.ConfigSpec {
 			Default(string(client.JSONMarshalModeCanonical)).
 			Advanced().
 			Version("4.7.0")).
-		Field(queryField)
+		Field(queryField).
+		Field(service.NewObjectField("change_stream",
+			service.NewStringAnnotatedEnumField("scope", map[string]string{
+				changeStreamScopeCollection: "Watch the collection specified by the `collection` field.",
+				changeStreamScopeDatabase:   "Watch all collections of the database specified by the `database` field.",
+				changeStreamScopeDeployment: "Watch all databases of the deployment.",
+			}).
+				Description("The scope of the change stream.").
+				Default(changeStreamScopeCollection),
+			service.NewStringAnnotatedEnumField("full_document", map[string]string{
+				string(options.Default):      "Only events of inserts and replacements contain the full document.",
+				string(options.UpdateLookup): "Events of updates also contain the most recent majority-committed version of the updated document.",
+			}).
+				Description("Whether change events of updates contain the full document.").
+				Default(string(options.UpdateLookup)),
+			service.NewStringField("cache").
+				Description("A [cache resource](/docs/components/caches/about) to store the resume token of the last acknowledged change event in.").
+				Default(""),
+			service.NewStringField("cache_key").
+				Description("The key to store the resume token under. When empty a key is derived from the database and collection.").
+				Default("").
+				Advanced(),
+		).
+			Description("Options for the `change_stream` operation.").
+			Advanced().
+			Version("4.14.0")).
+		Example("Change Stream", `
+Watch a collection for inserts, and store the resume token of the last acknowledged event within a redis cache so that the stream resumes from the same position after a restart:`,
+			`
+input:
+  mongodb:
+    url: mongodb://localhost:27017
+    database: foo
+    collection: bar
+    operation: change_stream
+    query: |
+      root = [
+        { "$match": { "operationType": "insert" } }
+      ]
+    change_stream:
+      cache: checkpoints
+
+cache_resources:
+  - label: checkpoints
+    redis:
+      url: redis://localhost:6379
+`,
+		)
 }
 
 func init() {
 	err := service.RegisterInput(
 		"mongodb", mongoConfigSpec(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			return newMongoInput(conf)
+			return newMongoInput(conf, mgr)
 		})
 	if err != nil {
 		panic(err)
 	}
 }
 
-func newMongoInput(conf *service.ParsedConfig) (service.Input, error) {
+func newMongoInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
 	url, err := conf.FieldString("url")
 	if err != nil {
 		return nil, err
@@ -101,12 +166,26 @@ func newMongoInput(conf *service.ParsedConfig) (service.Input, error) {
 		Username:   username,
 		Password:   password,
 	}
-	return service.AutoRetryNacks(&mongoInput{
+	m := &mongoInput{
 		query:        query,
 		config:       config,
 		operation:    operation,
 		marshalCanon: marshalMode == string(client.JSONMarshalModeCanonical),
-	}), nil
+		mgr:          mgr,
+	}
+	if operation == ChangeStreamInputOperation {
+		if err := m.changeStreamFromParsed(conf.Namespace("change_stream")); err != nil {
+			return nil, err
+		}
+	} else if collection == "" {
+		return nil, fmt.Errorf("a collection must be specified for the %v operation", operation)
+	}
+	return service.AutoRetryNacks(m), nil
+}
+
+type changeStreamPosition struct {
+	token bson.Raw
+	seq   int64
 }
 
 type mongoInput struct {
@@ -116,16 +195,72 @@ type mongoInput struct {
 	cursor       *mongo.Cursor
 	operation    string
 	marshalCanon bool
+
+	scope        string
+	fullDocument options.FullDocument
+	cacheName    string
+	cacheKey     string
+	stream       *mongo.ChangeStream
+	seq          int64
+
+	cpMut       sync.Mutex
+	checkpoints *checkpoint.Uncapped[changeStreamPosition]
+
+	storeMut  sync.Mutex
+	storedSeq int64
+
+	mgr *service.Resources
+}
+
+func (m *mongoInput) changeStreamFromParsed(conf *service.ParsedConfig) (err error) {
+	if m.scope, err = conf.FieldString("scope"); err != nil {
+		return
+	}
+	if m.scope == changeStreamScopeCollection && m.config.Collection == "" {
+		return errors.New("a collection must be specified for change streams with the collection scope")
+	}
+
+	var fullDocument string
+	if fullDocument, err = conf.FieldString("full_document"); err != nil {
+		return
+	}
+	m.fullDocument = options.FullDocument(fullDocument)
+
+	if m.cacheName, err = conf.FieldString("cache"); err != nil {
+		return
+	}
+	if m.cacheName == "" {
+		return errors.New("a cache must be specified for the change_stream operation")
+	}
+	if !m.mgr.HasCache(m.cacheName) {
+		return fmt.Errorf("cache resource '%v' was not found", m.cacheName)
+	}
+	if m.cacheKey, err = conf.FieldString("cache_key"); err != nil {
+		return
+	}
+	if m.cacheKey == "" {
+		switch m.scope {
+		case changeStreamScopeCollection:
+			m.cacheKey = "mongodb_change_stream_" + m.config.Database + "_" + m.config.Collection
+		case changeStreamScopeDatabase:
+			m.cacheKey = "mongodb_change_stream_" + m.config.Database
+		default:
+			m.cacheKey = "mongodb_change_stream"
+		}
+	}
+	return nil
 }
 
 func (m *mongoInput) Connect(ctx context.Context) error {
 	var err error
-	m.client, err = m.config.Client()
-	if err != nil {
-		return err
-	}
-	if err = m.client.Connect(ctx); err != nil {
-		return fmt.Errorf("failed to connect: %w", err)
+	if m.client == nil {
+		if m.client, err = m.config.Client(); err != nil {
+			return err
+		}
+		if err = m.client.Connect(ctx); err != nil {
+			m.client = nil
+			return fmt.Errorf("failed to connect: %w", err)
+		}
 	}
 
 	if err = m.client.Ping(ctx, nil); err != nil {
@@ -137,17 +272,60 @@ func (m *mongoInput) Connect(ctx context.Context) error {
 		m.cursor, err = collection.Find(ctx, m.query)
 	case "aggregate":
 		m.cursor, err = collection.Aggregate(ctx, m.query)
+	case "change_stream":
+		err = m.watch(ctx)
 	default:
-		return fmt.Errorf("opertaion %s not supported. the supported values are \"find\" and \"aggregate\"", m.operation)
+		return fmt.Errorf("opertaion %s not supported. the supported values are \"find\", \"aggregate\" and \"change_stream\"", m.operation)
 	}
 	if err != nil {
 		_ = m.client.Disconnect(ctx)
+		m.client = nil
 		return err
 	}
 	return nil
 }
 
+// watch opens a change stream that resumes after the last acknowledged change
+// event, if any.
+func (m *mongoInput) watch(ctx context.Context) error {
+	token, err := m.loadResumeToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := options.ChangeStream().SetFullDocument(m.fullDocument)
+	if token != nil {
+		opts.SetStartAfter(token)
+	}
+
+	pipeline := m.query
+	if pipeline == nil {
+		pipeline = []any{}
+	}
+
+	switch m.scope {
+	case changeStreamScopeDeployment:
+		m.stream, err = m.client.Watch(ctx, pipeline, opts)
+	case changeStreamScopeDatabase:
+		m.stream, err = m.client.Database(m.config.Database).Watch(ctx, pipeline, opts)
+	default:
+		m.stream, err = m.client.Database(m.config.Database).Collection(m.config.Collection).Watch(ctx, pipeline, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+
+	m.cpMut.Lock()
+	m.checkpoints = checkpoint.NewUncapped[changeStreamPosition]()
+	m.cpMut.Unlock()
+	return nil
+}
+
 func (m *mongoInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if m.operation == ChangeStreamInputOperation {
+		return m.readChangeStream(ctx)
+	}
+
 	if !m.cursor.Next(ctx) {
 		return nil, nil, service.ErrEndOfInput
 	}
@@ -168,7 +346,112 @@ func (m *mongoInput) Read(ctx context.Context) (*service.Message, service.AckFun
 	}, nil
 }
 
+func (m *mongoInput) readChangeStream(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if m.stream == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	if !m.stream.Next(ctx) {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		err := m.stream.Err()
+		_ = m.stream.Close(context.Background())
+		m.stream = nil
+		if err != nil {
+			m.mgr.Logger().Errorf("Change stream failed: %v", err)
+			return nil, nil, service.ErrNotConnected
+		}
+		return nil, nil, service.ErrEndOfInput
+	}
+
+	var decoded any
+	if err := m.stream.Decode(&decoded); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := bson.MarshalExtJSON(decoded, m.marshalCanon, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.seq++
+	pos := changeStreamPosition{
+		token: append(bson.Raw(nil), m.stream.ResumeToken()...),
+		seq:   m.seq,
+	}
+
+	m.cpMut.Lock()
+	resolveFn := m.checkpoints.Track(pos, 1)
+	m.cpMut.Unlock()
+
+	msg := service.NewMessage(nil)
+	msg.SetBytes(data)
+	return msg, func(ctx context.Context, err error) error {
+		m.cpMut.Lock()
+		highest := resolveFn()
+		m.cpMut.Unlock()
+		if highest == nil {
+			return nil
+		}
+		return m.storeResumeToken(ctx, *highest)
+	}, nil
+}
+
+func (m *mongoInput) loadResumeToken(ctx context.Context) (bson.Raw, error) {
+	var cached []byte
+	var getErr error
+	if err := m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		cached, getErr = c.Get(ctx, m.cacheKey)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to access resume token cache: %w", err)
+	}
+	if getErr != nil {
+		if errors.Is(getErr, service.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get resume token: %w", getErr)
+	}
+
+	var token bson.Raw
+	if err := bson.UnmarshalExtJSON(cached, true, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse resume token: %w", err)
+	}
+	return token, nil
+}
+
+func (m *mongoInput) storeResumeToken(ctx context.Context, pos changeStreamPosition) error {
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	// Acknowledgements can arrive out of order, and therefore a token older
+	// than the one already stored is ignored.
+	if pos.seq <= m.storedSeq {
+		return nil
+	}
+
+	tokenBytes, err := bson.MarshalExtJSON(pos.token, true, false)
+	if err != nil {
+		return fmt.Errorf("failed to serialise resume token: %w", err)
+	}
+
+	var setErr error
+	if err := m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		setErr = c.Set(ctx, m.cacheKey, tokenBytes, nil)
+	}); err != nil {
+		return fmt.Errorf("failed to access resume token cache: %w", err)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to store resume token: %w", setErr)
+	}
+	m.storedSeq = pos.seq
+	return nil
+}
+
 func (m *mongoInput) Close(ctx context.Context) error {
+	if m.stream != nil {
+		_ = m.stream.Close(ctx)
+	}
 	if m.client != nil {
 		return m.client.Disconnect(ctx)
 	}
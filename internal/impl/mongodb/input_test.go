@@ -2,8 +2,10 @@ package mongodb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
@@ -36,7 +38,7 @@ query: |
 	mongoConfig, err := spec.ParseYAML(conf, env)
 	require.NoError(t, err)
 
-	selectInput, err := newMongoInput(mongoConfig)
+	selectInput, err := newMongoInput(mongoConfig, service.MockResources())
 	require.NoError(t, err)
 	require.NoError(t, selectInput.Close(context.Background()))
 }
@@ -230,7 +232,7 @@ func testInput(
 	mongoConfig, err := spec.ParseYAML(conf, env)
 	require.NoError(t, err)
 
-	selectInput, err := newMongoInput(mongoConfig)
+	selectInput, err := newMongoInput(mongoConfig, service.MockResources())
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -250,3 +252,234 @@ func testInput(
 
 	require.NoError(t, selectInput.Close(context.Background()))
 }
+
+func TestInputChangeStreamConfig(t *testing.T) {
+	spec := mongoConfigSpec()
+	env := service.NewEnvironment()
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	tests := []struct {
+		name   string
+		conf   string
+		errStr string
+	}{
+		{
+			name: "missing cache",
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+collection: "bar"
+operation: change_stream
+query: root = []
+`,
+			errStr: "a cache must be specified",
+		},
+		{
+			name: "unknown cache",
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+collection: "bar"
+operation: change_stream
+query: root = []
+change_stream:
+  cache: nope
+`,
+			errStr: "cache resource 'nope' was not found",
+		},
+		{
+			name: "missing collection",
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+operation: change_stream
+query: root = []
+change_stream:
+  cache: foocache
+`,
+			errStr: "a collection must be specified",
+		},
+		{
+			name: "database scope",
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+operation: change_stream
+query: root = []
+change_stream:
+  scope: database
+  cache: foocache
+`,
+		},
+		{
+			name: "find without collection",
+			conf: `
+url: "mongodb://localhost:27017"
+database: "foo"
+query: root.age = 5
+`,
+			errStr: "a collection must be specified",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mongoConfig, err := spec.ParseYAML(test.conf, env)
+			require.NoError(t, err)
+
+			_, err = newMongoInput(mongoConfig, mgr)
+			if test.errStr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errStr)
+			}
+		})
+	}
+}
+
+func TestInputChangeStreamResumeTokens(t *testing.T) {
+	m := &mongoInput{
+		cacheName: "foocache",
+		cacheKey:  "footoken",
+		mgr:       service.MockResources(service.MockResourcesOptAddCache("foocache")),
+	}
+	ctx := context.Background()
+
+	token, err := m.loadResumeToken(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, token)
+
+	newToken := func(data string) bson.Raw {
+		b, err := bson.Marshal(bson.M{"_data": data})
+		require.NoError(t, err)
+		return b
+	}
+
+	require.NoError(t, m.storeResumeToken(ctx, changeStreamPosition{token: newToken("second"), seq: 2}))
+	require.NoError(t, m.storeResumeToken(ctx, changeStreamPosition{token: newToken("first"), seq: 1}))
+
+	token, err = m.loadResumeToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "second", token.Lookup("_data").StringValue())
+}
+
+func TestInputChangeStreamIntegration(t *testing.T) {
+	integration.CheckSkip(t)
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   "mongo",
+		Tag:          "latest",
+		Cmd:          []string{"--replSet", "rs0"},
+		ExposedPorts: []string{"27017"},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	port := resource.GetPort("27017/tcp")
+	url := "mongodb://localhost:" + port + "/?directConnection=true"
+
+	var mongoClient *mongo.Client
+	require.NoError(t, pool.Retry(func() error {
+		if mongoClient == nil {
+			if mongoClient, err = mongo.Connect(context.Background(), options.Client().ApplyURI(url)); err != nil {
+				return err
+			}
+		}
+		_ = mongoClient.Database("admin").RunCommand(context.Background(), bson.M{
+			"replSetInitiate": bson.M{
+				"_id":     "rs0",
+				"members": []bson.M{{"_id": 0, "host": "localhost:27017"}},
+			},
+		}).Err()
+		return mongoClient.Database("TestDB").CreateCollection(context.Background(), "TestCollection")
+	}))
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+
+	coll := mongoClient.Database("TestDB").Collection("TestCollection")
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	newInput := func() service.Input {
+		t.Helper()
+
+		mongoConfig, err := mongoConfigSpec().ParseYAML(fmt.Sprintf(`
+url: %v
+database: TestDB
+collection: TestCollection
+operation: change_stream
+json_marshal_mode: relaxed
+query: |
+  root = [
+    { "$match": { "operationType": { "$in": [ "insert", "update" ] } } },
+    { "$project": { "operationType": 1, "fullDocument": 1 } }
+  ]
+change_stream:
+  cache: foocache
+`, url), service.NewEnvironment())
+		require.NoError(t, err)
+
+		input, err := newMongoInput(mongoConfig, mgr)
+		require.NoError(t, err)
+		require.NoError(t, input.Connect(context.Background()))
+		return input
+	}
+
+	readEvent := func(input service.Input) map[string]any {
+		t.Helper()
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		defer done()
+
+		msg, ackFn, err := input.Read(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		event := v.(map[string]any)
+		delete(event, "_id")
+		delete(event["fullDocument"].(map[string]any), "_id")
+		return event
+	}
+
+	input := newInput()
+
+	_, err = coll.InsertOne(context.Background(), bson.M{"name": "foo", "age": 10})
+	require.NoError(t, err)
+	_, err = coll.UpdateOne(context.Background(), bson.M{"name": "foo"}, bson.M{"$set": bson.M{"age": 11}})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"name": "foo", "age": json.Number("10")},
+	}, readEvent(input))
+	assert.Equal(t, map[string]any{
+		"operationType": "update",
+		"fullDocument":  map[string]any{"name": "foo", "age": json.Number("11")},
+	}, readEvent(input))
+	require.NoError(t, input.Close(context.Background()))
+
+	_, err = coll.InsertOne(context.Background(), bson.M{"name": "bar", "age": 20})
+	require.NoError(t, err)
+
+	input = newInput()
+	t.Cleanup(func() {
+		_ = input.Close(context.Background())
+	})
+
+	assert.Equal(t, map[string]any{
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"name": "bar", "age": json.Number("20")},
+	}, readEvent(input))
+}
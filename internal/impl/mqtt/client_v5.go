@@ -0,0 +1,167 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+
+	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
+)
+
+const (
+	protocolVersion311 = "3.1.1"
+	protocolVersion5   = "5"
+)
+
+// dialV5 attempts to open a network connection to each URL in turn, returning
+// the first that succeeds. MQTT 5 clients require an established connection
+// before the protocol handshake, which the 3.1.1 client would otherwise take
+// care of.
+func dialV5(ctx context.Context, urls []string, tlsConf *tls.Config) (net.Conn, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no urls specified")
+	}
+
+	var err error
+	for _, u := range urls {
+		var parsed *url.URL
+		if parsed, err = url.Parse(u); err != nil {
+			err = fmt.Errorf("failed to parse url '%v': %w", u, err)
+			continue
+		}
+
+		var conn net.Conn
+		switch parsed.Scheme {
+		case "tcp", "mqtt":
+			var d net.Dialer
+			conn, err = d.DialContext(ctx, "tcp", parsed.Host)
+		case "ssl", "tls", "tcps", "mqtts":
+			if tlsConf == nil {
+				tlsConf = &tls.Config{}
+			}
+			d := tls.Dialer{Config: tlsConf}
+			conn, err = d.DialContext(ctx, "tcp", parsed.Host)
+		default:
+			err = fmt.Errorf("url scheme '%v' is not supported with MQTT 5", parsed.Scheme)
+		}
+		if err == nil {
+			return packets.NewThreadSafeConn(conn), nil
+		}
+	}
+	return nil, err
+}
+
+// connectPacketV5 creates the CONNECT packet of an MQTT 5 client from common
+// connection fields.
+func connectPacketV5(clientID, user, password string, keepAlive int64, cleanStart bool, will mqttconf.Will, topicAliasMaximum uint16) *paho.Connect {
+	cp := &paho.Connect{
+		ClientID:   clientID,
+		KeepAlive:  uint16(keepAlive),
+		CleanStart: cleanStart,
+		Properties: &paho.ConnectProperties{
+			// The protocol default, which some brokers require in order to
+			// forward user properties.
+			RequestProblemInfo: true,
+		},
+	}
+	if !cleanStart {
+		// Without an expiry interval the session would end with the
+		// connection, which would differ from the behaviour of clean_session
+		// with MQTT 3.1.1.
+		cp.Properties.SessionExpiryInterval = paho.Uint32(^uint32(0))
+	}
+	if topicAliasMaximum > 0 {
+		cp.Properties.TopicAliasMaximum = paho.Uint16(topicAliasMaximum)
+	}
+	if user != "" {
+		cp.Username = user
+		cp.UsernameFlag = true
+	}
+	if password != "" {
+		cp.Password = []byte(password)
+		cp.PasswordFlag = true
+	}
+	if will.Enabled {
+		cp.WillMessage = &paho.WillMessage{
+			Retain:  will.Retained,
+			QoS:     will.QoS,
+			Topic:   will.Topic,
+			Payload: []byte(will.Payload),
+		}
+	}
+	return cp
+}
+
+// reasonCodeErr returns an error for MQTT 5 reason codes that indicate a
+// failure.
+func reasonCodeErr(code byte, reason string) error {
+	if code < 0x80 {
+		return nil
+	}
+	if reason != "" {
+		return fmt.Errorf("reason code 0x%02x: %v", code, reason)
+	}
+	return fmt.Errorf("reason code 0x%02x", code)
+}
+
+//------------------------------------------------------------------------------
+
+// routerV5 passes every received publish packet to a single handler along with
+// its topic, resolving topic aliases set by the server. The packet is passed
+// as is rather than converted as it carries fields such as the duplicate flag.
+type routerV5 struct {
+	mut     sync.Mutex
+	aliases map[uint16]string
+	handler func(pb *packets.Publish, topic string)
+}
+
+func newRouterV5(handler func(pb *packets.Publish, topic string)) *routerV5 {
+	return &routerV5{
+		aliases: map[uint16]string{},
+		handler: handler,
+	}
+}
+
+func (r *routerV5) RegisterHandler(string, paho.MessageHandler) {}
+
+func (r *routerV5) UnregisterHandler(string) {}
+
+func (r *routerV5) SetDebugLogger(paho.Logger) {}
+
+func (r *routerV5) Route(pb *packets.Publish) {
+	topic := pb.Topic
+	if pb.Properties != nil && pb.Properties.TopicAlias != nil {
+		alias := *pb.Properties.TopicAlias
+		r.mut.Lock()
+		if topic != "" {
+			r.aliases[alias] = topic
+		} else {
+			topic = r.aliases[alias]
+		}
+		r.mut.Unlock()
+	}
+	r.handler(pb, topic)
+}
+
+//------------------------------------------------------------------------------
+
+// messageExpirySeconds parses a message expiry duration into the interval sent
+// with MQTT 5 publish packets, an empty string results in no expiry.
+func messageExpirySeconds(s string) (*uint32, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse message expiry duration string: %w", err)
+	}
+	return paho.Uint32(uint32(d / time.Second)), nil
+}
@@ -23,7 +23,16 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		m, err := newMQTTReader(conf.MQTT, nm)
+		var m input.Async
+		var err error
+		switch conf.MQTT.ProtocolVersion {
+		case protocolVersion311, "":
+			m, err = newMQTTReader(conf.MQTT, nm)
+		case protocolVersion5:
+			m, err = newMQTT5Reader(conf.MQTT, nm)
+		default:
+			err = fmt.Errorf("protocol_version not recognised: %v", conf.MQTT.ProtocolVersion)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -46,10 +55,26 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#bloblang-queries).`,
+[function interpolation](/docs/configuration/interpolation#bloblang-queries).
+
+### MQTT 5
+
+When ` + "`protocol_version`" + ` is set to ` + "`5`" + ` the user properties of each message are added as metadata fields, along with the following fields when they are set:
+
+` + "``` text" + `
+- mqtt_response_topic
+- mqtt_correlation_data
+- mqtt_content_type
+- mqtt_message_expiry
+` + "```" + `
+
+Messages that have a response topic can be replied to with a [` + "`sync_response`" + ` output](/docs/components/outputs/sync_response), where the resulting messages are published to the response topic along with the correlation data of the request once the message is acknowledged.
+
+Messages can be consumed in a load balanced fashion across multiple clients by subscribing to [shared subscriptions](https://docs.oasis-open.org/mqtt/mqtt/v5.0/os/mqtt-v5.0-os.html#_Toc3901250) with topics of the form ` + "`$share/<group>/<topic>`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldURL("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").Array(),
-			docs.FieldString("topics", "A list of topics to consume from.").Array(),
+			docs.FieldString("protocol_version", "The version of the MQTT protocol to connect with.").HasOptions(protocolVersion311, protocolVersion5).AtVersion("4.14.0"),
+			docs.FieldString("topics", "A list of topics to consume from. Shared subscriptions of the form `$share/<group>/<topic>` are supported by brokers with MQTT 5 capabilities.", []string{"foo/bar", "$share/benthos/foo/#"}).Array(),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
 				"nanoid", "append a nanoid of length 21 characters",
@@ -61,6 +86,7 @@ You can access these metadata fields using
 			docs.FieldString("user", "A username to assume for the connection.").Advanced(),
 			docs.FieldString("password", "A password to provide for the connection.").Advanced().Secret(),
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
+			docs.FieldInt("topic_alias_maximum", "The maximum number of topic aliases that the broker is allowed to use when sending messages. Only applicable when `protocol_version` is `5`.").Advanced().AtVersion("4.14.0"),
 			tls.FieldSpec().AtVersion("3.45.0"),
 		).ChildDefaultAndTypesFromStruct(input.NewMQTTConfig()),
 		Categories: []string{
@@ -0,0 +1,306 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+)
+
+type receivedPublishV5 struct {
+	pb    *packets.Publish
+	topic string
+}
+
+type mqtt5Reader struct {
+	client   *paho.Client
+	msgChan  chan receivedPublishV5
+	lostChan chan struct{}
+	cMut     sync.Mutex
+
+	connectTimeout time.Duration
+	conf           input.MQTTConfig
+
+	interruptChan chan struct{}
+
+	urls []string
+
+	log log.Modular
+	mgr bundle.NewManagement
+}
+
+func newMQTT5Reader(conf input.MQTTConfig, mgr bundle.NewManagement) (*mqtt5Reader, error) {
+	m := &mqtt5Reader{
+		conf:          conf,
+		interruptChan: make(chan struct{}),
+		log:           mgr.Logger(),
+		mgr:           mgr,
+	}
+
+	var err error
+	if m.connectTimeout, err = time.ParseDuration(conf.ConnectTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse connect timeout duration string: %w", err)
+	}
+
+	switch m.conf.DynamicClientIDSuffix {
+	case "nanoid":
+		nid, err := gonanoid.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nanoid: %w", err)
+		}
+		m.conf.ClientID += nid
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown dynamic_client_id_suffix: %v", m.conf.DynamicClientIDSuffix)
+	}
+
+	if err := m.conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				m.urls = append(m.urls, splitURL)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *mqtt5Reader) Connect(ctx context.Context) error {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	// The message channel is never closed, instead the lost channel is closed
+	// once the connection is lost, which allows the router to send messages
+	// without holding a lock that the close would otherwise contend on.
+	msgChan := make(chan receivedPublishV5)
+	lostChan := make(chan struct{})
+
+	var lostOnce sync.Once
+	connLost := func() {
+		lostOnce.Do(func() {
+			close(lostChan)
+		})
+	}
+
+	var tlsConf *tls.Config
+	if m.conf.TLS.Enabled {
+		var err error
+		if tlsConf, err = m.conf.TLS.Get(m.mgr.FS()); err != nil {
+			return err
+		}
+	}
+
+	connectCtx, done := context.WithTimeout(ctx, m.connectTimeout)
+	defer done()
+
+	conn, err := dialV5(connectCtx, m.urls, tlsConf)
+	if err != nil {
+		return err
+	}
+
+	client := paho.NewClient(paho.ClientConfig{
+		Conn: conn,
+		Router: newRouterV5(func(pb *packets.Publish, topic string) {
+			select {
+			case msgChan <- receivedPublishV5{pb: pb, topic: topic}:
+			case <-lostChan:
+			case <-m.interruptChan:
+			}
+		}),
+		PacketTimeout:              m.connectTimeout,
+		EnableManualAcknowledgment: true,
+		OnClientError: func(err error) {
+			connLost()
+			select {
+			case <-m.interruptChan:
+				// Errors are expected once the connection is closed by us.
+			default:
+				m.log.Errorf("Connection lost due to: %v", err)
+			}
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			connLost()
+			var reason string
+			if d.Properties != nil {
+				reason = d.Properties.ReasonString
+			}
+			m.log.Errorf("Connection closed by server: %v", reasonCodeErr(d.ReasonCode, reason))
+		},
+	})
+
+	cp := connectPacketV5(m.conf.ClientID, m.conf.User, m.conf.Password, m.conf.KeepAlive, m.conf.CleanSession, m.conf.Will, m.conf.TopicAliasMaximum)
+	if _, err := client.Connect(connectCtx, cp); err != nil {
+		return err
+	}
+
+	subs := paho.Subscribe{
+		Subscriptions: map[string]paho.SubscribeOptions{},
+	}
+	for _, topic := range m.conf.Topics {
+		subs.Subscriptions[topic] = paho.SubscribeOptions{QoS: m.conf.QoS}
+	}
+	sa, err := client.Subscribe(connectCtx, &subs)
+	if err == nil {
+		for _, code := range sa.Reasons {
+			if err = reasonCodeErr(code, ""); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = client.Disconnect(&paho.Disconnect{})
+		return fmt.Errorf("failed to subscribe to topics '%v': %w", m.conf.Topics, err)
+	}
+
+	m.log.Infof("Receiving MQTT 5 messages from topics: %v", m.conf.Topics)
+
+	m.client = client
+	m.msgChan = msgChan
+	m.lostChan = lostChan
+	return nil
+}
+
+func (m *mqtt5Reader) ReadBatch(ctx context.Context) (message.Batch, input.AsyncAckFn, error) {
+	m.cMut.Lock()
+	msgChan, lostChan := m.msgChan, m.lostChan
+	client := m.client
+	m.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, component.ErrNotConnected
+	}
+
+	select {
+	case <-lostChan:
+		m.cMut.Lock()
+		if m.client == client {
+			m.msgChan = nil
+			m.lostChan = nil
+			m.client = nil
+		}
+		m.cMut.Unlock()
+
+		// Release the lost client before a reconnect creates a new one, the
+		// disconnect packet is expected to fail on a broken connection.
+		_ = client.Disconnect(&paho.Disconnect{})
+		return nil, nil, component.ErrNotConnected
+	case rp := <-msgChan:
+		pb := rp.pb
+		msg := message.QuickBatch([][]byte{pb.Payload})
+
+		p := msg.Get(0)
+		p.MetaSetMut("mqtt_duplicate", pb.Duplicate)
+		p.MetaSetMut("mqtt_qos", int(pb.QoS))
+		p.MetaSetMut("mqtt_retained", pb.Retain)
+		p.MetaSetMut("mqtt_topic", rp.topic)
+		p.MetaSetMut("mqtt_message_id", int(pb.PacketID))
+
+		var responseTopic string
+		var correlationData []byte
+		if props := pb.Properties; props != nil {
+			for _, u := range props.User {
+				p.MetaSetMut(u.Key, u.Value)
+			}
+			if props.ResponseTopic != "" {
+				responseTopic = props.ResponseTopic
+				p.MetaSetMut("mqtt_response_topic", props.ResponseTopic)
+			}
+			if len(props.CorrelationData) > 0 {
+				correlationData = props.CorrelationData
+				p.MetaSetMut("mqtt_correlation_data", string(props.CorrelationData))
+			}
+			if props.ContentType != "" {
+				p.MetaSetMut("mqtt_content_type", props.ContentType)
+			}
+			if props.MessageExpiry != nil {
+				p.MetaSetMut("mqtt_message_expiry", int(*props.MessageExpiry))
+			}
+		}
+
+		var store transaction.ResultStore
+		if responseTopic != "" {
+			store = transaction.NewResultStore()
+			transaction.AddResultStore(msg, store)
+		}
+
+		return msg, func(ctx context.Context, res error) error {
+			if res != nil {
+				return nil
+			}
+			if store != nil {
+				if err := m.sendResponses(ctx, client, responseTopic, correlationData, store); err != nil {
+					m.log.Errorf("Failed to send response to topic '%v': %v", responseTopic, err)
+				}
+			}
+			_ = client.Ack(paho.PublishFromPacketPublish(pb))
+			return nil
+		}, nil
+	case <-ctx.Done():
+	case <-m.interruptChan:
+		return nil, nil, component.ErrTypeClosed
+	}
+	return nil, nil, component.ErrTimeout
+}
+
+// sendResponses publishes messages stored by a sync_response output to the
+// response topic of a request, along with the correlation data of the request.
+func (m *mqtt5Reader) sendResponses(ctx context.Context, client *paho.Client, topic string, correlationData []byte, store transaction.ResultStore) error {
+	for _, batch := range store.Get() {
+		for _, part := range batch {
+			res, err := client.Publish(ctx, &paho.Publish{
+				Topic:   topic,
+				QoS:     m.conf.QoS,
+				Payload: part.AsBytes(),
+				Properties: &paho.PublishProperties{
+					CorrelationData: correlationData,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if res != nil {
+				var reason string
+				if res.Properties != nil {
+					reason = res.Properties.ReasonString
+				}
+				if err := reasonCodeErr(res.ReasonCode, reason); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mqtt5Reader) Close(ctx context.Context) (err error) {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		close(m.interruptChan)
+		_ = m.client.Disconnect(&paho.Disconnect{})
+		m.client = nil
+	}
+	return
+}
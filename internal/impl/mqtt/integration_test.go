@@ -95,3 +95,76 @@ input:
 		)
 	})
 }
+
+func TestIntegrationMQTT5(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.Run("emqx/emqx", "5.0.20", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		inConf := mqtt.NewClientOptions().SetClientID("UNIT_TEST")
+		inConf = inConf.AddBroker(fmt.Sprintf("tcp://localhost:%v", resource.GetPort("1883/tcp")))
+
+		mIn := mqtt.NewClient(inConf)
+		tok := mIn.Connect()
+		tok.Wait()
+		if cErr := tok.Error(); cErr != nil {
+			return cErr
+		}
+		mIn.Disconnect(0)
+		return nil
+	}))
+
+	template := `
+output:
+  mqtt:
+    urls: [ tcp://localhost:$PORT ]
+    protocol_version: "5"
+    qos: 1
+    topic: topic-$ID
+    client_id: client-output-$ID
+    topic_alias_maximum: 10
+    max_in_flight: $MAX_IN_FLIGHT
+
+input:
+  mqtt:
+    urls: [ tcp://localhost:$PORT ]
+    protocol_version: "5"
+    topics: [ topic-$ID ]
+    client_id: client-input-$ID
+    clean_session: false
+    topic_alias_maximum: 10
+`
+	suite := integration.StreamTests(
+		integration.StreamTestOpenClose(),
+		integration.StreamTestMetadata(),
+		integration.StreamTestSendBatch(10),
+		integration.StreamTestStreamParallel(1000),
+	)
+	suite.Run(
+		t, template,
+		integration.StreamTestOptSleepAfterInput(100*time.Millisecond),
+		integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
+		integration.StreamTestOptPort(resource.GetPort("1883/tcp")),
+	)
+	t.Run("with max in flight", func(t *testing.T) {
+		t.Parallel()
+		suite.Run(
+			t, template,
+			integration.StreamTestOptSleepAfterInput(100*time.Millisecond),
+			integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
+			integration.StreamTestOptPort(resource.GetPort("1883/tcp")),
+			integration.StreamTestOptMaxInFlight(10),
+		)
+	})
+}
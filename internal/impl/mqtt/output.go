@@ -20,12 +20,22 @@ import (
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/tls"
 )
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		w, err := newMQTTWriter(conf.MQTT, nm)
+		var w output.AsyncSink
+		var err error
+		switch conf.MQTT.ProtocolVersion {
+		case protocolVersion311, "":
+			w, err = newMQTTWriter(conf.MQTT, nm)
+		case protocolVersion5:
+			w, err = newMQTT5Writer(conf.MQTT, nm)
+		default:
+			err = fmt.Errorf("protocol_version not recognised: %v", conf.MQTT.ProtocolVersion)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -41,9 +51,14 @@ Pushes messages to an MQTT broker.`,
 		Description: output.Description(true, false, `
 The `+"`topic`"+` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
-messages these interpolations are performed per message part.`),
+messages these interpolations are performed per message part.
+
+### MQTT 5
+
+When `+"`protocol_version`"+` is set to `+"`5`"+` the metadata of each message is sent as user properties, which can be filtered with the field `+"`metadata`"+`. Messages can also be sent with a message expiry interval, a content type, and a response topic and correlation data for request/response interactions. Topics are replaced with topic aliases when `+"`topic_alias_maximum`"+` is set and the broker supports them.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldURL("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:1883"}).Array(),
+			docs.FieldString("protocol_version", "The version of the MQTT protocol to connect with.").HasOptions(protocolVersion311, protocolVersion5).AtVersion("4.14.0"),
 			docs.FieldString("topic", "The topic to publish messages to."),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
@@ -58,6 +73,12 @@ messages these interpolations are performed per message part.`),
 			docs.FieldString("user", "A username to connect with.").Advanced(),
 			docs.FieldString("password", "A password to connect with.").Advanced().Secret(),
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
+			docs.FieldInt("topic_alias_maximum", "The maximum number of topic aliases to use when sending messages, limited by the maximum supported by the broker. Only applicable when `protocol_version` is `5`.").Advanced().AtVersion("4.14.0"),
+			docs.FieldString("message_expiry", "An optional expiry interval of each message, after which the broker stops delivering it. Only applicable when `protocol_version` is `5`.", "60s", "1h").Advanced().AtVersion("4.14.0"),
+			docs.FieldString("response_topic", "An optional response topic to send with each message, allowing consumers to reply to requests. Only applicable when `protocol_version` is `5`.").IsInterpolated().Advanced().AtVersion("4.14.0"),
+			docs.FieldString("correlation_data", "Optional correlation data to send with each message, allowing responses to be matched with requests. Only applicable when `protocol_version` is `5`.", `${! meta("request_id") }`).IsInterpolated().Advanced().AtVersion("4.14.0"),
+			docs.FieldString("content_type", "An optional content type to send with each message. Only applicable when `protocol_version` is `5`.", "application/json").IsInterpolated().Advanced().AtVersion("4.14.0"),
+			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent as user properties. Only applicable when `protocol_version` is `5`.").WithChildren(metadata.ExcludeFilterFields()...).AtVersion("4.14.0"),
 			tls.FieldSpec().AtVersion("3.45.0"),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		).ChildDefaultAndTypesFromStruct(output.NewMQTTConfig()),
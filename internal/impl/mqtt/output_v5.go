@@ -0,0 +1,280 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/eclipse/paho.golang/paho/extensions/topicaliases"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
+)
+
+type mqtt5Writer struct {
+	log log.Modular
+	mgr bundle.NewManagement
+
+	connectTimeout time.Duration
+	writeTimeout   time.Duration
+	messageExpiry  *uint32
+
+	urls            []string
+	conf            output.MQTTConfig
+	topic           *field.Expression
+	retained        *field.Expression
+	responseTopic   *field.Expression
+	correlationData *field.Expression
+	contentType     *field.Expression
+	metaFilter      *metadata.ExcludeFilter
+
+	client  *paho.Client
+	connMut sync.RWMutex
+}
+
+func newMQTT5Writer(conf output.MQTTConfig, mgr bundle.NewManagement) (*mqtt5Writer, error) {
+	m := &mqtt5Writer{
+		log:  mgr.Logger(),
+		mgr:  mgr,
+		conf: conf,
+	}
+
+	var err error
+	if m.connectTimeout, err = time.ParseDuration(conf.ConnectTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse connect timeout duration string: %w", err)
+	}
+	if m.writeTimeout, err = time.ParseDuration(conf.WriteTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse write timeout duration string: %w", err)
+	}
+	if m.messageExpiry, err = messageExpirySeconds(conf.MessageExpiry); err != nil {
+		return nil, err
+	}
+
+	if m.topic, err = mgr.BloblEnvironment().NewField(conf.Topic); err != nil {
+		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
+	}
+	if conf.RetainedInterpolated != "" {
+		if m.retained, err = mgr.BloblEnvironment().NewField(conf.RetainedInterpolated); err != nil {
+			return nil, fmt.Errorf("failed to parse retained expression: %v", err)
+		}
+	}
+	if conf.ResponseTopic != "" {
+		if m.responseTopic, err = mgr.BloblEnvironment().NewField(conf.ResponseTopic); err != nil {
+			return nil, fmt.Errorf("failed to parse response topic expression: %v", err)
+		}
+	}
+	if conf.CorrelationData != "" {
+		if m.correlationData, err = mgr.BloblEnvironment().NewField(conf.CorrelationData); err != nil {
+			return nil, fmt.Errorf("failed to parse correlation data expression: %v", err)
+		}
+	}
+	if conf.ContentType != "" {
+		if m.contentType, err = mgr.BloblEnvironment().NewField(conf.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+		}
+	}
+	if m.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
+
+	switch m.conf.DynamicClientIDSuffix {
+	case "nanoid":
+		nid, err := gonanoid.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate nanoid: %w", err)
+		}
+		m.conf.ClientID += nid
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown dynamic_client_id_suffix: %v", m.conf.DynamicClientIDSuffix)
+	}
+
+	if err := m.conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				m.urls = append(m.urls, splitURL)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m *mqtt5Writer) Connect(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	var tlsConf *tls.Config
+	if m.conf.TLS.Enabled {
+		var err error
+		if tlsConf, err = m.conf.TLS.Get(m.mgr.FS()); err != nil {
+			return err
+		}
+	}
+
+	connectCtx, done := context.WithTimeout(ctx, m.connectTimeout)
+	defer done()
+
+	conn, err := dialV5(connectCtx, m.urls, tlsConf)
+	if err != nil {
+		return err
+	}
+
+	var client *paho.Client
+	// Resets the client if it's still in use, returning false if the client
+	// has already been closed or replaced.
+	resetClient := func() bool {
+		m.connMut.Lock()
+		defer m.connMut.Unlock()
+		if m.client != client {
+			return false
+		}
+		m.client = nil
+		return true
+	}
+
+	client = paho.NewClient(paho.ClientConfig{
+		Conn:          conn,
+		PacketTimeout: m.writeTimeout,
+		OnClientError: func(err error) {
+			if resetClient() {
+				m.log.Errorf("Connection lost due to: %v\n", err)
+			}
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			if !resetClient() {
+				return
+			}
+			var reason string
+			if d.Properties != nil {
+				reason = d.Properties.ReasonString
+			}
+			m.log.Errorf("Connection closed by server: %v\n", reasonCodeErr(d.ReasonCode, reason))
+		},
+	})
+
+	cp := connectPacketV5(m.conf.ClientID, m.conf.User, m.conf.Password, m.conf.KeepAlive, true, m.conf.Will, 0)
+	ca, err := client.Connect(connectCtx, cp)
+	if err != nil {
+		return err
+	}
+
+	// Topic aliases are limited to the maximum supported by the server, which
+	// is zero unless stated otherwise.
+	if m.conf.TopicAliasMaximum > 0 && ca.Properties != nil && ca.Properties.TopicAliasMaximum != nil {
+		aliasMax := m.conf.TopicAliasMaximum
+		if serverMax := *ca.Properties.TopicAliasMaximum; serverMax < aliasMax {
+			aliasMax = serverMax
+		}
+		if aliasMax > 0 {
+			client.PublishHook = topicaliases.NewTAHandler(aliasMax).PublishHook
+		}
+	}
+
+	m.client = client
+	return nil
+}
+
+func (m *mqtt5Writer) WriteBatch(ctx context.Context, msg message.Batch) error {
+	m.connMut.RLock()
+	client := m.client
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return component.ErrNotConnected
+	}
+
+	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		retained := m.conf.Retained
+		if m.retained != nil {
+			retainedStr, parseErr := m.retained.String(i, msg)
+			if parseErr != nil {
+				m.log.Errorf("Retained interpolation error: %v", parseErr)
+			} else if retained, parseErr = strconv.ParseBool(retainedStr); parseErr != nil {
+				m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
+			}
+		}
+
+		topicStr, err := m.topic.String(i, msg)
+		if err != nil {
+			return fmt.Errorf("topic interpolation error: %w", err)
+		}
+
+		props := &paho.PublishProperties{
+			MessageExpiry: m.messageExpiry,
+		}
+		_ = m.metaFilter.IterStr(p, func(k, v string) error {
+			props.User.Add(k, v)
+			return nil
+		})
+		if m.responseTopic != nil {
+			if props.ResponseTopic, err = m.responseTopic.String(i, msg); err != nil {
+				return fmt.Errorf("response topic interpolation error: %w", err)
+			}
+		}
+		if m.correlationData != nil {
+			var data []byte
+			if data, err = m.correlationData.Bytes(i, msg); err != nil {
+				return fmt.Errorf("correlation data interpolation error: %w", err)
+			}
+			if len(data) > 0 {
+				props.CorrelationData = data
+			}
+		}
+		if m.contentType != nil {
+			if props.ContentType, err = m.contentType.String(i, msg); err != nil {
+				return fmt.Errorf("content type interpolation error: %w", err)
+			}
+		}
+
+		res, err := client.Publish(ctx, &paho.Publish{
+			Topic:      topicStr,
+			QoS:        m.conf.QoS,
+			Retain:     retained,
+			Payload:    p.AsBytes(),
+			Properties: props,
+		})
+		if err != nil {
+			return err
+		}
+		if res != nil {
+			var reason string
+			if res.Properties != nil {
+				reason = res.Properties.ReasonString
+			}
+			return reasonCodeErr(res.ReasonCode, reason)
+		}
+		return nil
+	})
+}
+
+func (m *mqtt5Writer) Close(context.Context) error {
+	m.connMut.Lock()
+	client := m.client
+	m.client = nil
+	m.connMut.Unlock()
+
+	if client != nil {
+		_ = client.Disconnect(&paho.Disconnect{})
+	}
+	return nil
+}
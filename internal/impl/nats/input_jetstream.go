@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,6 +42,16 @@ This input adds the following metadata fields to each message:
 You can access these metadata fields using
 [function interpolation](/docs/configuration/interpolation#bloblang-queries).
 
+### Pull Consumers
+
+When ` + "`pull`" + ` is set to ` + "`true`" + ` messages are consumed with a pull consumer, where messages are fetched in batches of up to ` + "`fetch_size`" + `. When binding to an existing consumer with ` + "`bind`" + ` the type of the consumer is used instead.
+
+### Acknowledgements
+
+Messages are acknowledged once they have been successfully delivered by an output, and are otherwise negatively acknowledged so that they can be redelivered. For messages that may take longer than ` + "`ack_wait`" + ` to process the field ` + "`in_progress_period`" + ` can be used in order to periodically notify the server that a message is still being worked on, which prevents it from being redelivered in the meantime.
+
+Messages that can never be processed successfully can be terminated, which stops the server from redelivering them, by specifying a regular expression with ` + "`term_error_pattern`" + `. A message that fails with an error matching the pattern is terminated rather than negatively acknowledged.
+
 ` + auth.Description()).
 		Field(service.NewStringListField("urls").
 			Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
@@ -78,6 +89,26 @@ You can access these metadata fields using
 			Description("The maximum number of outstanding acks to be allowed before consuming is halted.").
 			Advanced().
 			Default(1024)).
+		Field(service.NewBoolField("pull").
+			Description("Whether to consume with a pull consumer rather than a push consumer. This field is ignored when `bind` is true, where the type of the existing consumer is used instead.").
+			Version("4.14.0").
+			Default(false)).
+		Field(service.NewIntField("fetch_size").
+			Description("The maximum number of messages to fetch from a pull consumer at a time.").
+			Advanced().
+			Version("4.14.0").
+			Default(1)).
+		Field(service.NewStringField("in_progress_period").
+			Description("An optional period of time between in progress acknowledgements sent for each message that is yet to be acknowledged, preventing messages that take a long time to process from being redelivered. This period should be shorter than `ack_wait`.").
+			Version("4.14.0").
+			Optional().
+			Example("10s")).
+		Field(service.NewStringField("term_error_pattern").
+			Description("An optional regular expression that, when matching the error of a message that failed to be processed or delivered, causes the message to be terminated rather than negatively acknowledged, preventing it from being redelivered.").
+			Version("4.14.0").
+			Optional().
+			Example("^invalid schema").
+			Example("(?i)unmarshal")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(service.NewInternalField(auth.FieldSpec()))
 }
@@ -95,6 +126,11 @@ func init() {
 
 //------------------------------------------------------------------------------
 
+type fetchedJetStreamMsg struct {
+	msg            *nats.Msg
+	stopInProgress func()
+}
+
 type jetStreamReader struct {
 	urls          string
 	deliverOpt    nats.SubOpt
@@ -106,6 +142,9 @@ type jetStreamReader struct {
 	durable       string
 	ackWait       time.Duration
 	maxAckPending int
+	fetchSize     int
+	inProgress    time.Duration
+	termPattern   *regexp.Regexp
 	authConf      auth.Config
 	tlsConf       *tls.Config
 
@@ -116,6 +155,10 @@ type jetStreamReader struct {
 	natsConn *nats.Conn
 	natsSub  *nats.Subscription
 
+	// Messages fetched from a pull consumer that are yet to be read.
+	fetchedMut sync.Mutex
+	fetched    []fetchedJetStreamMsg
+
 	shutSig *shutdown.Signaller
 }
 
@@ -196,6 +239,36 @@ func newJetStreamReaderFromConfig(conf *service.ParsedConfig, log *service.Logge
 		return nil, err
 	}
 
+	if j.pull, err = conf.FieldBool("pull"); err != nil {
+		return nil, err
+	}
+	if j.fetchSize, err = conf.FieldInt("fetch_size"); err != nil {
+		return nil, err
+	}
+	if j.fetchSize < 1 {
+		return nil, fmt.Errorf("fetch_size must be at least 1, got %v", j.fetchSize)
+	}
+
+	if conf.Contains("in_progress_period") {
+		inProgressStr, err := conf.FieldString("in_progress_period")
+		if err != nil {
+			return nil, err
+		}
+		if j.inProgress, err = time.ParseDuration(inProgressStr); err != nil {
+			return nil, fmt.Errorf("failed to parse in progress period duration: %v", err)
+		}
+	}
+
+	if conf.Contains("term_error_pattern") {
+		termPatternStr, err := conf.FieldString("term_error_pattern")
+		if err != nil {
+			return nil, err
+		}
+		if j.termPattern, err = regexp.Compile(termPatternStr); err != nil {
+			return nil, fmt.Errorf("failed to compile term error pattern: %v", err)
+		}
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -272,9 +345,22 @@ func (j *jetStreamReader) Connect(ctx context.Context) error {
 		nats.ManualAck(),
 	}
 
-	if j.pull {
+	if j.pull && j.bind {
 		options = append(options, nats.Bind(j.stream, j.durable))
 
+		natsSub, err = jCtx.PullSubscribe(j.subject, j.durable, options...)
+	} else if j.pull {
+		options = append(options, j.deliverOpt)
+		if j.ackWait > 0 {
+			options = append(options, nats.AckWait(j.ackWait))
+		}
+		if j.maxAckPending != 0 {
+			options = append(options, nats.MaxAckPending(j.maxAckPending))
+		}
+		if j.stream != "" {
+			options = append(options, nats.BindStream(j.stream))
+		}
+
 		natsSub, err = jCtx.PullSubscribe(j.subject, j.durable, options...)
 	} else {
 		if j.durable != "" {
@@ -317,6 +403,16 @@ func (j *jetStreamReader) disconnect() {
 	j.connMut.Lock()
 	defer j.connMut.Unlock()
 
+	// Messages fetched but not yet read are returned to the server so that
+	// they can be redelivered without waiting for the ack deadline.
+	j.fetchedMut.Lock()
+	for _, f := range j.fetched {
+		f.stopInProgress()
+		_ = f.msg.Nak()
+	}
+	j.fetched = nil
+	j.fetchedMut.Unlock()
+
 	if j.natsSub != nil {
 		_ = j.natsSub.Drain()
 		j.natsSub = nil
@@ -341,11 +437,22 @@ func (j *jetStreamReader) Read(ctx context.Context) (*service.Message, service.A
 			// TODO: Any errors need capturing here to signal a lost connection?
 			return nil, nil, err
 		}
-		return convertMessage(nmsg)
+		return j.convertMessage(nmsg, j.startInProgress(nmsg))
 	}
 
+	j.fetchedMut.Lock()
+	if len(j.fetched) > 0 {
+		f := j.fetched[0]
+		j.fetched = j.fetched[1:]
+		j.fetchedMut.Unlock()
+		return j.convertMessage(f.msg, f.stopInProgress)
+	}
+	j.fetchedMut.Unlock()
+
+	// The lock is not held whilst fetching as it blocks until messages arrive,
+	// and a disconnect must be able to return fetched messages in the meantime.
 	for {
-		msgs, err := natsSub.Fetch(1, nats.Context(ctx))
+		msgs, err := natsSub.Fetch(j.fetchSize, nats.Context(ctx))
 		if err != nil {
 			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 				// NATS enforces its own context that might time out faster than the original context
@@ -362,7 +469,29 @@ func (j *jetStreamReader) Read(ctx context.Context) (*service.Message, service.A
 		if len(msgs) == 0 {
 			continue
 		}
-		return convertMessage(msgs[0])
+		j.connMut.Lock()
+		if j.natsSub != natsSub {
+			// We were disconnected during the fetch, and so the messages are
+			// returned for redelivery.
+			j.connMut.Unlock()
+			for _, m := range msgs {
+				_ = m.Nak()
+			}
+			return nil, nil, service.ErrNotConnected
+		}
+
+		// In progress acknowledgements begin as soon as messages are fetched
+		// as those waiting to be read would otherwise be redelivered.
+		j.fetchedMut.Lock()
+		for _, m := range msgs[1:] {
+			j.fetched = append(j.fetched, fetchedJetStreamMsg{
+				msg:            m,
+				stopInProgress: j.startInProgress(m),
+			})
+		}
+		j.fetchedMut.Unlock()
+		j.connMut.Unlock()
+		return j.convertMessage(msgs[0], j.startInProgress(msgs[0]))
 	}
 }
 
@@ -379,7 +508,7 @@ func (j *jetStreamReader) Close(ctx context.Context) error {
 	return nil
 }
 
-func convertMessage(m *nats.Msg) (*service.Message, service.AckFunc, error) {
+func (j *jetStreamReader) convertMessage(m *nats.Msg, stopInProgress func()) (*service.Message, service.AckFunc, error) {
 	msg := service.NewMessage(m.Data)
 	msg.MetaSet("nats_subject", m.Subject)
 
@@ -400,10 +529,64 @@ func convertMessage(m *nats.Msg) (*service.Message, service.AckFunc, error) {
 		}
 	}
 
-	return msg, func(ctx context.Context, res error) error {
+	return msg, j.ackFunc(m, stopInProgress), nil
+}
+
+// jetStreamAcker is the subset of the methods of *nats.Msg that are used in
+// order to acknowledge messages.
+type jetStreamAcker interface {
+	Ack(opts ...nats.AckOpt) error
+	Nak(opts ...nats.AckOpt) error
+	Term(opts ...nats.AckOpt) error
+	InProgress(opts ...nats.AckOpt) error
+}
+
+// ackFunc returns an ack func for a message that terminates it when the error
+// matches the term pattern, and otherwise naks it for redelivery.
+func (j *jetStreamReader) ackFunc(m jetStreamAcker, stopInProgress func()) service.AckFunc {
+	return func(ctx context.Context, res error) error {
+		stopInProgress()
 		if res == nil {
 			return m.Ack()
 		}
+		if j.termPattern != nil && j.termPattern.MatchString(res.Error()) {
+			j.log.Debugf("Terminating message due to error: %v", res)
+			return m.Term()
+		}
 		return m.Nak()
-	}, nil
+	}
+}
+
+// startInProgress periodically notifies the server that a message is still
+// being processed until the returned func is called or the reader is closed.
+func (j *jetStreamReader) startInProgress(m jetStreamAcker) func() {
+	if j.inProgress <= 0 {
+		return func() {}
+	}
+
+	stopChan := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(j.inProgress)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.InProgress(); err != nil {
+					j.log.Debugf("Failed to send in progress acknowledgement: %v", err)
+					return
+				}
+			case <-stopChan:
+				return
+			case <-j.shutSig.HasClosedChan():
+				return
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(stopChan)
+		})
+	}
 }
@@ -1,8 +1,13 @@
 package nats
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -32,3 +37,176 @@ auth:
 	assert.Equal(t, "test auth n key file", e.authConf.NKeyFile)
 	assert.Equal(t, "test auth user creds file", e.authConf.UserCredentialsFile)
 }
+
+func TestInputJetStreamPullConfigParse(t *testing.T) {
+	spec := natsJetStreamInputConfig()
+	env := service.NewEnvironment()
+
+	inputConfig := `
+urls: [ url1 ]
+subject: testsubject
+durable: testdurable
+pull: true
+fetch_size: 10
+in_progress_period: 10s
+term_error_pattern: ^poison
+`
+
+	conf, err := spec.ParseYAML(inputConfig, env)
+	require.NoError(t, err)
+
+	e, err := newJetStreamReaderFromConfig(conf, nil, nil)
+	require.NoError(t, err)
+
+	assert.True(t, e.pull)
+	assert.Equal(t, 10, e.fetchSize)
+	assert.Equal(t, time.Second*10, e.inProgress)
+	assert.True(t, e.termPattern.MatchString("poison pill"))
+	assert.False(t, e.termPattern.MatchString("not a poison pill"))
+
+	for _, badConf := range []string{
+		`
+urls: [ url1 ]
+subject: testsubject
+fetch_size: 0
+`,
+		`
+urls: [ url1 ]
+subject: testsubject
+in_progress_period: nope
+`,
+		`
+urls: [ url1 ]
+subject: testsubject
+term_error_pattern: '('
+`,
+	} {
+		conf, err := spec.ParseYAML(badConf, env)
+		require.NoError(t, err)
+
+		_, err = newJetStreamReaderFromConfig(conf, nil, nil)
+		require.Error(t, err)
+	}
+}
+
+type mockJetStreamAcker struct {
+	mut        sync.Mutex
+	acks       int
+	naks       int
+	terms      int
+	inProgress int
+}
+
+func (m *mockJetStreamAcker) Ack(opts ...nats.AckOpt) error {
+	m.mut.Lock()
+	m.acks++
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockJetStreamAcker) Nak(opts ...nats.AckOpt) error {
+	m.mut.Lock()
+	m.naks++
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockJetStreamAcker) Term(opts ...nats.AckOpt) error {
+	m.mut.Lock()
+	m.terms++
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockJetStreamAcker) InProgress(opts ...nats.AckOpt) error {
+	m.mut.Lock()
+	m.inProgress++
+	m.mut.Unlock()
+	return nil
+}
+
+func (m *mockJetStreamAcker) counts() (acks, naks, terms, inProgress int) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.acks, m.naks, m.terms, m.inProgress
+}
+
+func TestInputJetStreamAckTerm(t *testing.T) {
+	spec := natsJetStreamInputConfig()
+	env := service.NewEnvironment()
+
+	conf, err := spec.ParseYAML(`
+urls: [ url1 ]
+subject: testsubject
+term_error_pattern: '^poison'
+`, env)
+	require.NoError(t, err)
+
+	j, err := newJetStreamReaderFromConfig(conf, service.MockResources().Logger(), nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	m := &mockJetStreamAcker{}
+	require.NoError(t, j.ackFunc(m, func() {})(ctx, nil))
+	require.NoError(t, j.ackFunc(m, func() {})(ctx, errors.New("temporary failure")))
+	require.NoError(t, j.ackFunc(m, func() {})(ctx, errors.New("poison pill detected")))
+	require.NoError(t, j.ackFunc(m, func() {})(ctx, errors.New("not a poison pill")))
+
+	acks, naks, terms, _ := m.counts()
+	assert.Equal(t, 1, acks)
+	assert.Equal(t, 2, naks)
+	assert.Equal(t, 1, terms)
+}
+
+func TestInputJetStreamAckInProgress(t *testing.T) {
+	spec := natsJetStreamInputConfig()
+	env := service.NewEnvironment()
+
+	conf, err := spec.ParseYAML(`
+urls: [ url1 ]
+subject: testsubject
+in_progress_period: 10ms
+`, env)
+	require.NoError(t, err)
+
+	j, err := newJetStreamReaderFromConfig(conf, service.MockResources().Logger(), nil)
+	require.NoError(t, err)
+
+	m := &mockJetStreamAcker{}
+	ackFn := j.ackFunc(m, j.startInProgress(m))
+
+	assert.Eventually(t, func() bool {
+		_, _, _, inProgress := m.counts()
+		return inProgress >= 3
+	}, time.Second, time.Millisecond*5)
+
+	// Acknowledging the message stops in progress notifications.
+	require.NoError(t, ackFn(context.Background(), nil))
+	_, _, _, inProgress := m.counts()
+	<-time.After(time.Millisecond * 50)
+	acks, _, _, inProgressAfter := m.counts()
+	assert.Equal(t, 1, acks)
+	assert.Equal(t, inProgress, inProgressAfter)
+
+	// As does closing the reader.
+	m = &mockJetStreamAcker{}
+	_ = j.startInProgress(m)
+	assert.Eventually(t, func() bool {
+		_, _, _, inProgress := m.counts()
+		return inProgress >= 1
+	}, time.Second, time.Millisecond*5)
+	require.NoError(t, j.Close(context.Background()))
+	_, _, _, inProgress = m.counts()
+	<-time.After(time.Millisecond * 50)
+	_, _, _, inProgressAfter = m.counts()
+	assert.Equal(t, inProgress, inProgressAfter)
+
+	// Disabled when the period is zero.
+	j.inProgress = 0
+	m = &mockJetStreamAcker{}
+	_ = j.startInProgress(m)
+	<-time.After(time.Millisecond * 50)
+	_, _, _, inProgress = m.counts()
+	assert.Equal(t, 0, inProgress)
+}
@@ -82,6 +82,40 @@ input:
 		integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
 		integration.StreamTestOptPort(resource.GetPort("4222/tcp")),
 	)
+
+	t.Run("pull", func(t *testing.T) {
+		pullTemplate := `
+output:
+  nats_jetstream:
+    urls: [ nats://localhost:$PORT ]
+    subject: subject-$ID
+
+input:
+  nats_jetstream:
+    urls: [ nats://localhost:$PORT ]
+    subject: subject-$ID
+    durable: durable-$ID
+    pull: true
+    fetch_size: 10
+    in_progress_period: 1s
+`
+		suite.Run(
+			t, pullTemplate,
+			integration.StreamTestOptPreTest(func(t testing.TB, ctx context.Context, testID string, vars *integration.StreamTestConfigVars) {
+				js, err := natsConn.JetStream()
+				require.NoError(t, err)
+
+				_, err = js.AddStream(&nats.StreamConfig{
+					Name:     "stream-" + testID,
+					Subjects: []string{"subject-" + testID},
+				})
+				require.NoError(t, err)
+			}),
+			integration.StreamTestOptSleepAfterInput(100*time.Millisecond),
+			integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
+			integration.StreamTestOptPort(resource.GetPort("4222/tcp")),
+		)
+	})
 }
 
 func TestIntegrationNatsPullConsumer(t *testing.T) {
@@ -0,0 +1,160 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationOpenSearch(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Minute * 3
+	resource, err := pool.Run("opensearchproject/opensearch", "2.4.0", []string{
+		"discovery.type=single-node",
+		"DISABLE_SECURITY_PLUGIN=true",
+		"OPENSEARCH_JAVA_OPTS=-Xms512m -Xmx512m",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	client, err := opensearch.NewClient(opensearch.Config{
+		Addresses: []string{fmt.Sprintf("http://localhost:%v", resource.GetPort("9200/tcp"))},
+	})
+	require.NoError(t, err)
+
+	if err = pool.Retry(func() error {
+		res, err := client.Info()
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("info request failed: %v", res.String())
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Could not connect to docker resource: %s", err)
+	}
+
+	_ = resource.Expire(900)
+
+	template := `
+output:
+  opensearch:
+    urls:
+      - http://localhost:$PORT
+    index: $ID
+    id: ${!json("id")}
+`
+	queryGetFn := func(ctx context.Context, testID, messageID string) (string, []string, error) {
+		res, err := opensearchapi.GetRequest{
+			Index:      testID,
+			DocumentID: messageID,
+		}.Do(ctx, client)
+		if err != nil {
+			return "", nil, err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return "", nil, fmt.Errorf("document %v not found: %v", messageID, res.String())
+		}
+
+		var doc struct {
+			Source json.RawMessage `json:"_source"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+			return "", nil, err
+		}
+		return string(doc.Source), nil, nil
+	}
+
+	suite := integration.StreamTests(
+		integration.StreamTestOutputOnlySendSequential(10, queryGetFn),
+		integration.StreamTestOutputOnlySendBatch(10, queryGetFn),
+	)
+	suite.Run(
+		t, template,
+		integration.StreamTestOptPort(resource.GetPort("9200/tcp")),
+	)
+
+	t.Run("data stream", func(t *testing.T) {
+		ctx, done := context.WithTimeout(context.Background(), time.Minute)
+		defer done()
+
+		out := testOutput(t, fmt.Sprintf("http://localhost:%v", resource.GetPort("9200/tcp")), `
+index: logs-benthos
+action: create
+index_template:
+  name: logs
+  body: '{"index_patterns":["logs-*"],"data_stream":{},"priority":100}'
+`)
+		require.NoError(t, out.Connect(ctx))
+		t.Cleanup(func() {
+			_ = out.Close(context.Background())
+		})
+
+		require.NoError(t, out.WriteBatch(ctx, testBatch(
+			`{"@timestamp":"2022-01-01T00:00:00Z","message":"foo"}`,
+			`{"@timestamp":"2022-01-01T00:00:01Z","message":"bar"}`,
+		)))
+
+		refreshRes, err := opensearchapi.IndicesRefreshRequest{
+			Index: []string{"logs-benthos"},
+		}.Do(ctx, client)
+		require.NoError(t, err)
+		refreshRes.Body.Close()
+
+		countRes, err := opensearchapi.CountRequest{
+			Index: []string{"logs-benthos"},
+		}.Do(ctx, client)
+		require.NoError(t, err)
+		defer countRes.Body.Close()
+		require.False(t, countRes.IsError(), countRes.String())
+
+		var count struct {
+			Count int `json:"count"`
+		}
+		require.NoError(t, json.NewDecoder(countRes.Body).Decode(&count))
+		assert.Equal(t, 2, count.Count)
+	})
+
+	t.Run("versioned", func(t *testing.T) {
+		ctx, done := context.WithTimeout(context.Background(), time.Minute)
+		defer done()
+
+		out := testOutput(t, fmt.Sprintf("http://localhost:%v", resource.GetPort("9200/tcp")), `
+index: versioned
+id: ${! json("id") }
+version: ${! json("rev") }
+`)
+		require.NoError(t, out.Connect(ctx))
+		t.Cleanup(func() {
+			_ = out.Close(context.Background())
+		})
+
+		require.NoError(t, out.WriteBatch(ctx, testBatch(`{"id":"a","rev":2,"name":"new"}`)))
+		require.NoError(t, out.WriteBatch(ctx, testBatch(`{"id":"a","rev":1,"name":"old"}`)))
+
+		doc, _, err := queryGetFn(ctx, "versioned", "a")
+		require.NoError(t, err)
+		assert.True(t, strings.Contains(doc, `"new"`), doc)
+	})
+}
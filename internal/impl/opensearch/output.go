@@ -0,0 +1,557 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func opensearchOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Publishes messages into an OpenSearch index.").
+		Description(`
+Messages are written using the bulk API, and the result of each message of a bulk request is handled individually:
+
+- Messages that are rejected because of throttling (status 429) or server errors are retried according to `+"`backoff`"+`.
+- Messages that are rejected because of a version conflict (status 409) are considered delivered, as a document with the same or a newer version already exists.
+- Messages that are rejected for any other reason, such as mapping conflicts, are failed individually without affecting the rest of the batch. These failures can be routed to a dead letter queue with a `+"[`fallback`](/docs/components/outputs/fallback)"+` or `+"[`switch`](/docs/components/outputs/switch)"+` output.
+
+### Data Streams
+
+Documents can only be added to [data streams](https://opensearch.org/docs/latest/opensearch/data-streams/) with the `+"`create`"+` action, and a matching index template must exist for a data stream to be created automatically. The template can be created by this output when it connects with the `+"`index_template`"+` field.`).
+		Field(service.NewStringListField("urls").
+			Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
+			Example([]string{"http://localhost:9200"})).
+		Field(service.NewInterpolatedStringField("index").
+			Description("The index or data stream to place messages.")).
+		Field(service.NewInterpolatedStringField("action").
+			Description("The action to take on the document. This field must resolve to one of the following action types: `create`, `index`, `update`, `upsert` or `delete`.").
+			Default("index")).
+		Field(service.NewInterpolatedStringField("id").
+			Description("The ID for indexed messages. When this resolves to an empty string OpenSearch generates an ID, which is not possible for the `update`, `upsert` and `delete` actions.").
+			Example(`${! json("id") }`).
+			Default("")).
+		Field(service.NewInterpolatedStringField("pipeline").
+			Description("An optional pipeline id to preprocess incoming documents.").
+			Default("").
+			Advanced()).
+		Field(service.NewInterpolatedStringField("routing").
+			Description("The routing key to use for the document.").
+			Default("").
+			Advanced()).
+		Field(service.NewInterpolatedStringField("version").
+			Description("An optional version of the document, which must resolve to an integer. Versions can only be used with the `index` and `delete` actions.").
+			Example(`${! json("updated_at").ts_unix_nano() }`).
+			Optional().
+			Advanced()).
+		Field(service.NewStringAnnotatedEnumField("version_type", map[string]string{
+			"external":     "The document is written if the version is greater than the version of the stored document.",
+			"external_gte": "The document is written if the version is greater than or equal to the version of the stored document.",
+		}).
+			Description("The type of versioning used when a `version` is set.").
+			Default("external").
+			Advanced()).
+		Field(service.NewObjectField("index_template",
+			service.NewStringField("name").
+				Description("The name of the index template. When empty no template is created.").
+				Default(""),
+			service.NewStringField("body").
+				Description("The body of the index template in JSON format, which is described in the [OpenSearch documentation](https://opensearch.org/docs/latest/opensearch/index-templates/).").
+				Example(`{"index_patterns":["logs-*"],"data_stream":{},"priority":100}`).
+				Default(""),
+		).
+			Description("An optional index template that is created or updated when the output connects.").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(httpclient.BasicAuthField()).
+		Field(service.NewBackOffField("backoff", false, &backoff.ExponentialBackOff{
+			InitialInterval: time.Second,
+			MaxInterval:     time.Second * 5,
+			MaxElapsedTime:  time.Second * 30,
+		}).
+			Description("Determines how messages rejected with retryable errors are retried within a batch.").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Data Stream", `
+Here we write logs to a data stream, creating an index template for it when the output connects:`, `
+output:
+  opensearch:
+    urls: [ http://localhost:9200 ]
+    index: logs-benthos
+    action: create
+    index_template:
+      name: logs
+      body: |
+        {
+          "index_patterns": [ "logs-*" ],
+          "data_stream": {},
+          "priority": 100
+        }
+`).
+		Example("Versioned Documents", `
+Here we index documents with an external version taken from a field of each document, which prevents older copies of a document from overwriting newer ones when they are delivered out of order:`, `
+output:
+  opensearch:
+    urls: [ http://localhost:9200 ]
+    index: users
+    id: ${! json("id") }
+    version: ${! json("revision") }
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"opensearch", opensearchOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newOpenSearchOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type opensearchOutput struct {
+	urls        []string
+	index       *service.InterpolatedString
+	action      *service.InterpolatedString
+	id          *service.InterpolatedString
+	pipeline    *service.InterpolatedString
+	routing     *service.InterpolatedString
+	version     *service.InterpolatedString
+	versionType string
+	backoff     *backoff.ExponentialBackOff
+
+	templateName string
+	templateBody string
+
+	tlsConf     *tls.Config
+	tlsEnabled  bool
+	authEnabled bool
+	username    string
+	password    string
+
+	client    *opensearch.Client
+	clientMut sync.RWMutex
+
+	log *service.Logger
+}
+
+func newOpenSearchOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*opensearchOutput, error) {
+	o := &opensearchOutput{
+		log: mgr.Logger(),
+	}
+
+	urls, err := conf.FieldStringList("urls")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range urls {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) > 0 {
+				o.urls = append(o.urls, splitURL)
+			}
+		}
+	}
+
+	if o.index, err = conf.FieldInterpolatedString("index"); err != nil {
+		return nil, err
+	}
+	if o.action, err = conf.FieldInterpolatedString("action"); err != nil {
+		return nil, err
+	}
+	if o.id, err = conf.FieldInterpolatedString("id"); err != nil {
+		return nil, err
+	}
+	if o.pipeline, err = conf.FieldInterpolatedString("pipeline"); err != nil {
+		return nil, err
+	}
+	if o.routing, err = conf.FieldInterpolatedString("routing"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("version") {
+		if o.version, err = conf.FieldInterpolatedString("version"); err != nil {
+			return nil, err
+		}
+	}
+	if o.versionType, err = conf.FieldString("version_type"); err != nil {
+		return nil, err
+	}
+	if o.backoff, err = conf.FieldBackOff("backoff"); err != nil {
+		return nil, err
+	}
+
+	templateConf := conf.Namespace("index_template")
+	if o.templateName, err = templateConf.FieldString("name"); err != nil {
+		return nil, err
+	}
+	if o.templateBody, err = templateConf.FieldString("body"); err != nil {
+		return nil, err
+	}
+	if o.templateName != "" && !json.Valid([]byte(o.templateBody)) {
+		return nil, errors.New("index_template body must be a valid JSON document")
+	}
+
+	if o.tlsConf, o.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+
+	authConf := conf.Namespace("basic_auth")
+	if o.authEnabled, err = authConf.FieldBool("enabled"); err != nil {
+		return nil, err
+	}
+	if o.username, err = authConf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if o.password, err = authConf.FieldString("password"); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *opensearchOutput) Connect(ctx context.Context) error {
+	o.clientMut.Lock()
+	defer o.clientMut.Unlock()
+
+	if o.client != nil {
+		return nil
+	}
+
+	osConf := opensearch.Config{
+		Addresses: o.urls,
+	}
+	if o.authEnabled {
+		osConf.Username = o.username
+		osConf.Password = o.password
+	}
+	if o.tlsEnabled {
+		osConf.Transport = &http.Transport{
+			TLSClientConfig: o.tlsConf,
+		}
+	}
+
+	client, err := opensearch.NewClient(osConf)
+	if err != nil {
+		return err
+	}
+
+	if o.templateName != "" {
+		res, err := opensearchapi.IndicesPutIndexTemplateRequest{
+			Name: o.templateName,
+			Body: strings.NewReader(o.templateBody),
+		}.Do(ctx, client)
+		if err != nil {
+			return fmt.Errorf("failed to put index template: %w", err)
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("failed to put index template: %v", res.String())
+		}
+	}
+
+	o.client = client
+	o.log.Infof("Sending messages to OpenSearch at urls: %s", o.urls)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bulkItemOutcome describes how the result of a single bulk item is handled.
+type bulkItemOutcome int
+
+const (
+	bulkItemDelivered bulkItemOutcome = iota
+	bulkItemRetry
+	bulkItemRejected
+)
+
+// classifyBulkItem determines the outcome of a bulk item from its status and
+// error type.
+func classifyBulkItem(status int, errType string) bulkItemOutcome {
+	switch {
+	case status >= 200 && status <= 299:
+		return bulkItemDelivered
+	case status == http.StatusConflict && errType == "version_conflict_engine_exception":
+		// A document with the same or a newer version has already been
+		// written, and therefore this message is redundant.
+		return bulkItemDelivered
+	case status == http.StatusTooManyRequests || status >= 500:
+		return bulkItemRetry
+	}
+	return bulkItemRejected
+}
+
+type bulkItemError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type bulkItemResult struct {
+	Index  string         `json:"_index"`
+	ID     string         `json:"_id"`
+	Status int            `json:"status"`
+	Error  *bulkItemError `json:"error"`
+}
+
+func (r *bulkItemResult) errorType() string {
+	if r.Error == nil {
+		return ""
+	}
+	return r.Error.Type
+}
+
+func (r *bulkItemResult) reason() string {
+	if r.Error == nil {
+		return fmt.Sprintf("status [%v]: no reason given", r.Status)
+	}
+	return fmt.Sprintf("status [%v]: %v: %v", r.Status, r.Error.Type, r.Error.Reason)
+}
+
+type bulkResponse struct {
+	Errors bool                         `json:"errors"`
+	Items  []map[string]*bulkItemResult `json:"items"`
+}
+
+// bulkItem is a message of a batch serialised as the lines of a bulk request.
+type bulkItem struct {
+	index int
+	lines []byte
+}
+
+func (o *opensearchOutput) buildBulkItem(i int, batch service.MessageBatch) (*bulkItem, error) {
+	action, err := batch.TryInterpolatedString(i, o.action)
+	if err != nil {
+		return nil, fmt.Errorf("action interpolation error: %w", err)
+	}
+	index, err := batch.TryInterpolatedString(i, o.index)
+	if err != nil {
+		return nil, fmt.Errorf("index interpolation error: %w", err)
+	}
+	id, err := batch.TryInterpolatedString(i, o.id)
+	if err != nil {
+		return nil, fmt.Errorf("id interpolation error: %w", err)
+	}
+	routing, err := batch.TryInterpolatedString(i, o.routing)
+	if err != nil {
+		return nil, fmt.Errorf("routing interpolation error: %w", err)
+	}
+	pipeline, err := batch.TryInterpolatedString(i, o.pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline interpolation error: %w", err)
+	}
+
+	meta := map[string]any{"_index": index}
+	if id != "" {
+		meta["_id"] = id
+	}
+	if routing != "" {
+		meta["routing"] = routing
+	}
+
+	var doc any
+	switch action {
+	case "index", "create":
+		if pipeline != "" {
+			meta["pipeline"] = pipeline
+		}
+		if doc, err = batch[i].AsStructured(); err != nil {
+			return nil, fmt.Errorf("failed to parse message as a JSON document: %w", err)
+		}
+	case "update", "upsert":
+		structured, err := batch[i].AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message as a JSON document: %w", err)
+		}
+		updateDoc := map[string]any{"doc": structured}
+		if action == "upsert" {
+			updateDoc["doc_as_upsert"] = true
+		}
+		doc, action = updateDoc, "update"
+	case "delete":
+	default:
+		return nil, fmt.Errorf("opensearch action '%s' is not allowed", action)
+	}
+	if id == "" && action != "index" && action != "create" {
+		return nil, fmt.Errorf("an id is required for the %v action", action)
+	}
+
+	if o.version != nil {
+		versionStr, err := batch.TryInterpolatedString(i, o.version)
+		if err != nil {
+			return nil, fmt.Errorf("version interpolation error: %w", err)
+		}
+		if action != "index" && action != "delete" {
+			return nil, fmt.Errorf("versions cannot be used with the %v action", action)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version: %w", err)
+		}
+		meta["version"] = version
+		meta["version_type"] = o.versionType
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(map[string]any{action: meta}); err != nil {
+		return nil, err
+	}
+	if doc != nil {
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to serialise document: %w", err)
+		}
+	}
+	return &bulkItem{index: i, lines: buf.Bytes()}, nil
+}
+
+func (o *opensearchOutput) bulk(ctx context.Context, client *opensearch.Client, items []*bulkItem) ([]*bulkItemResult, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		body.Write(item.lines)
+	}
+
+	res, err := opensearchapi.BulkRequest{Body: &body}.Do(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		resBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("bulk request failed with status [%v]: %s", res.StatusCode, resBytes)
+	}
+
+	var resBody bulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return nil, fmt.Errorf("failed to parse bulk response: %w", err)
+	}
+	if len(resBody.Items) != len(items) {
+		return nil, fmt.Errorf("bulk response contained %v items, expected %v", len(resBody.Items), len(items))
+	}
+
+	results := make([]*bulkItemResult, len(items))
+	for i, item := range resBody.Items {
+		for _, result := range item {
+			results[i] = result
+		}
+		if results[i] == nil {
+			return nil, fmt.Errorf("bulk response item %v is empty", i)
+		}
+	}
+	return results, nil
+}
+
+func (o *opensearchOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	o.clientMut.RLock()
+	client := o.client
+	o.clientMut.RUnlock()
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	var batchErr *service.BatchError
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = service.NewBatchError(batch, errors.New("failed to send some messages"))
+		}
+		batchErr.Failed(i, err)
+	}
+
+	pending := make([]*bulkItem, 0, len(batch))
+	for i := range batch {
+		item, err := o.buildBulkItem(i, batch)
+		if err != nil {
+			failed(i, err)
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	boff := *o.backoff
+	boff.Reset()
+
+	for len(pending) > 0 {
+		results, err := o.bulk(ctx, client, pending)
+		if err != nil {
+			return err
+		}
+
+		var retries []*bulkItem
+		var lastReason string
+		for i, res := range results {
+			item := pending[i]
+			switch classifyBulkItem(res.Status, res.errorType()) {
+			case bulkItemDelivered:
+				if res.Status == http.StatusConflict {
+					o.log.Debugf("Ignoring version conflict of document '%v': %v", res.ID, res.reason())
+				}
+			case bulkItemRetry:
+				lastReason = res.reason()
+				retries = append(retries, item)
+			case bulkItemRejected:
+				o.log.Errorf("OpenSearch message '%v' rejected with %v", res.ID, res.reason())
+				failed(item.index, fmt.Errorf("document rejected with %v", res.reason()))
+			}
+		}
+
+		if pending = retries; len(pending) == 0 {
+			break
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			for _, item := range pending {
+				failed(item.index, fmt.Errorf("retries exhausted, last error reported as %v", lastReason))
+			}
+			break
+		}
+		o.log.Warnf("Retrying %v messages after %v, last error reported as %v", len(pending), wait, lastReason)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (o *opensearchOutput) Close(context.Context) error {
+	o.clientMut.Lock()
+	o.client = nil
+	o.clientMut.Unlock()
+	return nil
+}
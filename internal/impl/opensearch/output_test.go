@@ -0,0 +1,212 @@
+package opensearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestClassifyBulkItem(t *testing.T) {
+	tests := []struct {
+		status   int
+		errType  string
+		expected bulkItemOutcome
+	}{
+		{status: 200, expected: bulkItemDelivered},
+		{status: 201, expected: bulkItemDelivered},
+		{status: 409, errType: "version_conflict_engine_exception", expected: bulkItemDelivered},
+		{status: 409, errType: "resource_already_exists_exception", expected: bulkItemRejected},
+		{status: 429, errType: "es_rejected_execution_exception", expected: bulkItemRetry},
+		{status: 503, errType: "unavailable_shards_exception", expected: bulkItemRetry},
+		{status: 400, errType: "mapper_parsing_exception", expected: bulkItemRejected},
+		{status: 404, errType: "index_not_found_exception", expected: bulkItemRejected},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, classifyBulkItem(test.status, test.errType), "%v %v", test.status, test.errType)
+	}
+}
+
+func testBatch(docs ...string) service.MessageBatch {
+	batch := make(service.MessageBatch, 0, len(docs))
+	for _, d := range docs {
+		batch = append(batch, service.NewMessage([]byte(d)))
+	}
+	return batch
+}
+
+func testOutput(t *testing.T, urls, extra string) *opensearchOutput {
+	t.Helper()
+
+	conf, err := opensearchOutputConfig().ParseYAML(fmt.Sprintf(`
+urls: [ %v ]
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+  max_elapsed_time: 1s
+%v
+`, urls, extra), nil)
+	require.NoError(t, err)
+
+	out, err := newOpenSearchOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	return out
+}
+
+func TestOpenSearchBuildBulkItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		extra    string
+		expected string
+		errs     bool
+	}{
+		{
+			name:     "index",
+			expected: `{"index":{"_id":"a","_index":"foo"}}` + "\n" + `{"id":"a","value":1}` + "\n",
+		},
+		{
+			name:     "upsert",
+			extra:    "action: upsert\nrouting: bar",
+			expected: `{"update":{"_id":"a","_index":"foo","routing":"bar"}}` + "\n" + `{"doc":{"id":"a","value":1},"doc_as_upsert":true}` + "\n",
+		},
+		{
+			name:     "delete",
+			extra:    "action: delete",
+			expected: `{"delete":{"_id":"a","_index":"foo"}}` + "\n",
+		},
+		{
+			name:     "versioned",
+			extra:    `version: ${! json("value") }` + "\nversion_type: external_gte",
+			expected: `{"index":{"_id":"a","_index":"foo","version":1,"version_type":"external_gte"}}` + "\n" + `{"id":"a","value":1}` + "\n",
+		},
+		{
+			name:  "versioned update",
+			extra: "action: update\nversion: 5",
+			errs:  true,
+		},
+		{
+			name:  "bad action",
+			extra: "action: nope",
+			errs:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			out := testOutput(t, "http://localhost:9200", "index: foo\nid: ${! json(\"id\") }\n"+test.extra)
+			item, err := out.buildBulkItem(0, service.MessageBatch{
+				service.NewMessage([]byte(`{"id":"a","value":1}`)),
+			})
+			if test.errs {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(item.lines))
+		})
+	}
+}
+
+func TestOpenSearchWriteBatchErrorClassification(t *testing.T) {
+	var mut sync.Mutex
+	var bulkRequests [][]string
+	var templateBody string
+	throttled := map[string]bool{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+
+		if r.URL.Path == "/_index_template/logs" {
+			b, _ := io.ReadAll(r.Body)
+			templateBody = string(b)
+			_, _ = w.Write([]byte(`{"acknowledged":true}`))
+			return
+		}
+		require.Equal(t, "/_bulk", r.URL.Path)
+
+		var ids []string
+		var items []any
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var meta map[string]map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &meta))
+			require.True(t, scanner.Scan())
+
+			var doc struct {
+				ID   string `json:"id"`
+				Kind string `json:"kind"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &doc))
+			ids = append(ids, doc.ID)
+
+			res := map[string]any{"_index": "foo", "_id": doc.ID, "status": 201}
+			switch doc.Kind {
+			case "mapping":
+				res["status"] = 400
+				res["error"] = map[string]any{"type": "mapper_parsing_exception", "reason": "failed to parse field [kind]"}
+			case "conflict":
+				res["status"] = 409
+				res["error"] = map[string]any{"type": "version_conflict_engine_exception", "reason": "version conflict"}
+			case "throttle":
+				if !throttled[doc.ID] {
+					throttled[doc.ID] = true
+					res["status"] = 429
+					res["error"] = map[string]any{"type": "es_rejected_execution_exception", "reason": "rejected execution"}
+				}
+			}
+			items = append(items, map[string]any{"index": res})
+		}
+		bulkRequests = append(bulkRequests, ids)
+
+		resBytes, _ := json.Marshal(map[string]any{"errors": true, "items": items})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}))
+	t.Cleanup(server.Close)
+
+	out := testOutput(t, server.URL, `
+index: foo
+id: ${! json("id") }
+index_template:
+  name: logs
+  body: '{"index_patterns":["logs-*"],"data_stream":{}}'
+`)
+	require.NoError(t, out.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = out.Close(context.Background())
+	})
+
+	err := out.WriteBatch(context.Background(), testBatch(
+		`{"id":"a","kind":"ok"}`,
+		`{"id":"b","kind":"mapping"}`,
+		`{"id":"c","kind":"conflict"}`,
+		`{"id":"d","kind":"throttle"}`,
+		`not json`,
+	))
+	require.Error(t, err)
+
+	var batchErr *service.BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 2, batchErr.IndexedErrors())
+
+	mut.Lock()
+	defer mut.Unlock()
+	// The invalid message is never sent, the mapping conflict and version
+	// conflict are not retried, and the throttled message is retried.
+	assert.Equal(t, [][]string{{"a", "b", "c", "d"}, {"d"}}, bulkRequests)
+	assert.Equal(t, `{"index_patterns":["logs-*"],"data_stream":{}}`, templateBody)
+}
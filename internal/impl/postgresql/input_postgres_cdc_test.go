@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+)
+
+func TestPostgresCDCConfirmedLSN(t *testing.T) {
+	p := &postgresCDCInput{
+		checkpoints: checkpoint.NewUncapped[pglogrepl.LSN](),
+	}
+
+	// Keepalive positions are confirmed when nothing is outstanding.
+	p.receivedUpTo(100)
+	assert.Equal(t, pglogrepl.LSN(100), p.confirmedLSN())
+
+	p.receivedUpTo(210)
+	p.cpMut.Lock()
+	resolveFirst := p.checkpoints.Track(200, 1)
+	resolveSecond := p.checkpoints.Track(205, 1)
+	p.cpMut.Unlock()
+
+	// Received positions are held back while changes are outstanding.
+	p.receivedUpTo(300)
+	assert.Equal(t, pglogrepl.LSN(100), p.confirmedLSN())
+
+	p.cpMut.Lock()
+	p.resolve(resolveFirst)
+	p.cpMut.Unlock()
+	assert.Equal(t, pglogrepl.LSN(200), p.confirmedLSN())
+
+	p.cpMut.Lock()
+	p.resolve(resolveSecond)
+	p.cpMut.Unlock()
+	assert.Equal(t, pglogrepl.LSN(300), p.confirmedLSN())
+
+	// Positions never move backwards.
+	p.receivedUpTo(250)
+	assert.Equal(t, pglogrepl.LSN(300), p.confirmedLSN())
+}
@@ -0,0 +1,192 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// decodeTextValue converts the text representation of a column value into a
+// value that maps naturally onto JSON. Types without an obvious equivalent are
+// kept as the string representation given by the server.
+func decodeTextValue(dataType uint32, data []byte) (any, error) {
+	switch dataType {
+	case pgtype.BoolOID:
+		return string(data) == "t", nil
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID:
+		return strconv.ParseInt(string(data), 10, 64)
+	case pgtype.Float4OID, pgtype.Float8OID:
+		f, err := strconv.ParseFloat(string(data), 64)
+		if err != nil {
+			return nil, err
+		}
+		// NaN and infinite values cannot be represented in JSON and are
+		// therefore kept as strings, i.e. NaN, Infinity and -Infinity.
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return string(data), nil
+		}
+		return f, nil
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return string(data), nil
+}
+
+// tupleToMap converts the tuple of a change event into a map of column names
+// to values. Unchanged TOAST values are not sent by the server and are
+// therefore omitted, as are columns outside of the replica identity when the
+// tuple only contains the key.
+func tupleToMap(rel *pglogrepl.RelationMessage, tuple *pglogrepl.TupleData, keyOnly bool) (map[string]any, error) {
+	if tuple == nil {
+		return nil, nil
+	}
+	if len(tuple.Columns) > len(rel.Columns) {
+		return nil, fmt.Errorf("tuple of relation %v.%v has %v columns, expected %v", rel.Namespace, rel.RelationName, len(tuple.Columns), len(rel.Columns))
+	}
+
+	values := make(map[string]any, len(tuple.Columns))
+	for i, col := range tuple.Columns {
+		relCol := rel.Columns[i]
+		// The first flag bit marks columns that are part of the key.
+		if keyOnly && relCol.Flags&1 == 0 {
+			continue
+		}
+		switch col.DataType {
+		case pglogrepl.TupleDataTypeNull:
+			values[relCol.Name] = nil
+		case pglogrepl.TupleDataTypeToast:
+		case pglogrepl.TupleDataTypeText:
+			v, err := decodeTextValue(relCol.DataType, col.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode column %v: %w", relCol.Name, err)
+			}
+			values[relCol.Name] = v
+		default:
+			values[relCol.Name] = col.Data
+		}
+	}
+	return values, nil
+}
+
+// changeEvent is a single row change decoded from the replication stream or
+// read from a snapshot.
+type changeEvent struct {
+	operation string
+	schema    string
+	table     string
+	before    map[string]any
+	after     map[string]any
+}
+
+func (c *changeEvent) body() map[string]any {
+	var before, after any
+	if c.before != nil {
+		before = c.before
+	}
+	if c.after != nil {
+		after = c.after
+	}
+	return map[string]any{
+		"before": before,
+		"after":  after,
+	}
+}
+
+func (c *changeEvent) message() *service.Message {
+	msg := service.NewMessage(nil)
+	msg.SetStructuredMut(c.body())
+	msg.MetaSetMut("postgres_operation", c.operation)
+	msg.MetaSetMut("postgres_schema", c.schema)
+	msg.MetaSetMut("postgres_table", c.table)
+	return msg
+}
+
+// relationCache keeps track of relation messages, which are sent by the
+// server before the first change event of each table within a session.
+type relationCache map[uint32]*pglogrepl.RelationMessage
+
+func (r relationCache) get(id uint32) (*pglogrepl.RelationMessage, error) {
+	rel, exists := r[id]
+	if !exists {
+		return nil, fmt.Errorf("received change event for unknown relation %v", id)
+	}
+	return rel, nil
+}
+
+// toChangeEvent converts a logical replication message into a change event,
+// and returns nil for messages that do not describe row changes.
+func (r relationCache) toChangeEvent(msg pglogrepl.Message) (*changeEvent, error) {
+	var rel *pglogrepl.RelationMessage
+	var err error
+
+	event := &changeEvent{}
+	switch t := msg.(type) {
+	case *pglogrepl.RelationMessage:
+		r[t.RelationID] = t
+		return nil, nil
+	case *pglogrepl.InsertMessage:
+		if rel, err = r.get(t.RelationID); err != nil {
+			return nil, err
+		}
+		event.operation = "insert"
+		if event.after, err = tupleToMap(rel, t.Tuple, false); err != nil {
+			return nil, err
+		}
+	case *pglogrepl.UpdateMessage:
+		if rel, err = r.get(t.RelationID); err != nil {
+			return nil, err
+		}
+		event.operation = "update"
+		if event.before, err = tupleToMap(rel, t.OldTuple, t.OldTupleType == pglogrepl.UpdateMessageTupleTypeKey); err != nil {
+			return nil, err
+		}
+		if event.after, err = tupleToMap(rel, t.NewTuple, false); err != nil {
+			return nil, err
+		}
+	case *pglogrepl.DeleteMessage:
+		if rel, err = r.get(t.RelationID); err != nil {
+			return nil, err
+		}
+		event.operation = "delete"
+		if event.before, err = tupleToMap(rel, t.OldTuple, t.OldTupleType == pglogrepl.UpdateMessageTupleTypeKey); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	event.schema = rel.Namespace
+	event.table = rel.RelationName
+	return event, nil
+}
+
+//------------------------------------------------------------------------------
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// quoteTableName quotes a table name that is optionally qualified with a
+// schema.
+func quoteTableName(s string) string {
+	parts := strings.SplitN(s, ".", 2)
+	for i, p := range parts {
+		parts[i] = quoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,119 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTextValue(t *testing.T) {
+	tests := []struct {
+		dataType uint32
+		input    string
+		output   any
+	}{
+		{dataType: pgtype.BoolOID, input: "t", output: true},
+		{dataType: pgtype.BoolOID, input: "f", output: false},
+		{dataType: pgtype.Int4OID, input: "-15", output: int64(-15)},
+		{dataType: pgtype.Int8OID, input: "9000000000", output: int64(9000000000)},
+		{dataType: pgtype.Float8OID, input: "1.5", output: 1.5},
+		{dataType: pgtype.Float8OID, input: "NaN", output: "NaN"},
+		{dataType: pgtype.Float4OID, input: "Infinity", output: "Infinity"},
+		{dataType: pgtype.Float8OID, input: "-Infinity", output: "-Infinity"},
+		{dataType: pgtype.JSONBOID, input: `{"foo":["bar"]}`, output: map[string]any{"foo": []any{"bar"}}},
+		{dataType: pgtype.TextOID, input: "hello", output: "hello"},
+		{dataType: pgtype.TimestamptzOID, input: "2023-03-18 14:03:37+00", output: "2023-03-18 14:03:37+00"},
+	}
+
+	for _, test := range tests {
+		v, err := decodeTextValue(test.dataType, []byte(test.input))
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.output, v, test.input)
+	}
+
+	_, err := decodeTextValue(pgtype.Int4OID, []byte("nope"))
+	require.Error(t, err)
+}
+
+func textColumn(s string) *pglogrepl.TupleDataColumn {
+	return &pglogrepl.TupleDataColumn{DataType: pglogrepl.TupleDataTypeText, Data: []byte(s)}
+}
+
+func TestRelationCacheChangeEvents(t *testing.T) {
+	relations := relationCache{}
+
+	event, err := relations.toChangeEvent(&pglogrepl.RelationMessage{
+		RelationID:   10,
+		Namespace:    "public",
+		RelationName: "users",
+		Columns: []*pglogrepl.RelationMessageColumn{
+			{Name: "id", DataType: pgtype.Int4OID, Flags: 1},
+			{Name: "name", DataType: pgtype.TextOID},
+			{Name: "bio", DataType: pgtype.TextOID},
+		},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	event, err = relations.toChangeEvent(&pglogrepl.InsertMessage{
+		RelationID: 10,
+		Tuple: &pglogrepl.TupleData{Columns: []*pglogrepl.TupleDataColumn{
+			textColumn("1"), textColumn("foo"), {DataType: pglogrepl.TupleDataTypeNull},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &changeEvent{
+		operation: "insert",
+		schema:    "public",
+		table:     "users",
+		after:     map[string]any{"id": int64(1), "name": "foo", "bio": nil},
+	}, event)
+
+	event, err = relations.toChangeEvent(&pglogrepl.UpdateMessage{
+		RelationID:   10,
+		OldTupleType: pglogrepl.UpdateMessageTupleTypeOld,
+		OldTuple: &pglogrepl.TupleData{Columns: []*pglogrepl.TupleDataColumn{
+			textColumn("1"), textColumn("foo"), {DataType: pglogrepl.TupleDataTypeNull},
+		}},
+		NewTuple: &pglogrepl.TupleData{Columns: []*pglogrepl.TupleDataColumn{
+			textColumn("1"), textColumn("bar"), {DataType: pglogrepl.TupleDataTypeToast},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "update", event.operation)
+	assert.Equal(t, map[string]any{"id": int64(1), "name": "foo", "bio": nil}, event.before)
+	assert.Equal(t, map[string]any{"id": int64(1), "name": "bar"}, event.after)
+
+	event, err = relations.toChangeEvent(&pglogrepl.DeleteMessage{
+		RelationID:   10,
+		OldTupleType: pglogrepl.UpdateMessageTupleTypeKey,
+		OldTuple: &pglogrepl.TupleData{Columns: []*pglogrepl.TupleDataColumn{
+			textColumn("1"), {DataType: pglogrepl.TupleDataTypeNull}, {DataType: pglogrepl.TupleDataTypeNull},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "delete", event.operation)
+	assert.Nil(t, event.after)
+
+	msg := event.message()
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"after":null,"before":{"id":1}}`, string(b))
+
+	v, _ := msg.MetaGet("postgres_operation")
+	assert.Equal(t, "delete", v)
+	v, _ = msg.MetaGet("postgres_table")
+	assert.Equal(t, "users", v)
+
+	_, err = relations.toChangeEvent(&pglogrepl.InsertMessage{RelationID: 11})
+	require.Error(t, err)
+}
+
+func TestQuoteTableName(t *testing.T) {
+	assert.Equal(t, `"users"`, quoteTableName("users"))
+	assert.Equal(t, `"public"."users"`, quoteTableName("public.users"))
+	assert.Equal(t, `"foo""bar"`, quoteTableName(`foo"bar`))
+}
@@ -44,6 +44,14 @@ This input adds the following metadata fields to each message:
 
 You can access these metadata fields using
 [function interpolation](/docs/configuration/interpolation#bloblang-queries).
+
+### Ordering
+
+With a `+"`key_shared`"+` subscription messages that share a key, or an ordering key when set, are delivered to the same consumer. By default messages may be delivered out of order, which can be prevented by setting `+"`key_shared_allow_out_of_order_delivery`"+` to `+"`false`"+`. However, in that case messages that are negatively acknowledged are redelivered after `+"`nack_redelivery_delay`"+`, during which the delivery of subsequent messages with the same key is blocked.
+
+### Schemas
+
+When a `+"`schema`"+` is configured messages that cannot be decoded are passed on with their raw payload and flagged as having failed, allowing them to be handled with [error handling patterns](/docs/configuration/error_handling).
 `).
 			Field(service.NewURLField("url").
 				Description("A URL to connect to.").
@@ -55,8 +63,19 @@ You can access these metadata fields using
 			Field(service.NewStringField("subscription_name").
 				Description("Specify the subscription name for this consumer.")).
 			Field(service.NewStringEnumField("subscription_type", "shared", "key_shared", "failover", "exclusive").
-				Description("Specify the subscription type for this consumer.\n\n> NOTE: Using a `key_shared` subscription type will __allow out-of-order delivery__ since nack-ing messages sets non-zero nack delivery delay - this can potentially cause consumers to stall. See [Pulsar documentation](https://pulsar.apache.org/docs/en/2.8.1/concepts-messaging/#negative-acknowledgement) and [this Github issue](https://github.com/apache/pulsar/issues/12208) for more details.").
+				Description("Specify the subscription type for this consumer.\n\n> NOTE: Using a `key_shared` subscription type will __allow out-of-order delivery__ by default since nack-ing messages sets non-zero nack delivery delay - this can potentially cause consumers to stall. See [Pulsar documentation](https://pulsar.apache.org/docs/en/2.8.1/concepts-messaging/#negative-acknowledgement) and [this Github issue](https://github.com/apache/pulsar/issues/12208) for more details.").
 				Default(defaultSubscriptionType)).
+			Field(service.NewBoolField("key_shared_allow_out_of_order_delivery").
+				Description("Whether messages of a `key_shared` subscription are allowed to be delivered out of order, which prevents the consumer from stalling when messages are negatively acknowledged.").
+				Advanced().
+				Version("4.14.0").
+				Default(true)).
+			Field(service.NewStringField("nack_redelivery_delay").
+				Description("The delay after which messages that are negatively acknowledged are redelivered.").
+				Advanced().
+				Version("4.14.0").
+				Default("1m")).
+			Field(schemaField()).
 			Field(service.NewObjectField("tls",
 				service.NewStringField("root_cas_file").
 					Description("An optional path of a root certificate authority file to use. This is a file, often with a .pem extension, containing a certificate chain from the parent trusted root certificate, to possible intermediate signing certificates, to the host certificate.").
@@ -87,6 +106,10 @@ type pulsarReader struct {
 	subName     string
 	subType     string
 	rootCasFile string
+	schema      *pulsarSchema
+
+	allowOutOfOrder bool
+	nackDelay       time.Duration
 }
 
 func newPulsarReaderFromParsed(conf *service.ParsedConfig, log *service.Logger) (p *pulsarReader, err error) {
@@ -113,6 +136,20 @@ func newPulsarReaderFromParsed(conf *service.ParsedConfig, log *service.Logger)
 	if p.rootCasFile, err = conf.FieldString("tls", "root_cas_file"); err != nil {
 		return
 	}
+	if p.allowOutOfOrder, err = conf.FieldBool("key_shared_allow_out_of_order_delivery"); err != nil {
+		return
+	}
+	var nackDelayStr string
+	if nackDelayStr, err = conf.FieldString("nack_redelivery_delay"); err != nil {
+		return
+	}
+	if p.nackDelay, err = time.ParseDuration(nackDelayStr); err != nil {
+		err = fmt.Errorf("failed to parse nack_redelivery_delay: %w", err)
+		return
+	}
+	if p.schema, err = schemaFromParsed(conf); err != nil {
+		return
+	}
 
 	if p.url == "" {
 		err = errors.New("field url must not be empty")
@@ -192,14 +229,20 @@ func (p *pulsarReader) Connect(ctx context.Context) error {
 		return err
 	}
 
-	if consumer, err = client.Subscribe(pulsar.ConsumerOptions{
+	consOpts := pulsar.ConsumerOptions{
 		Topics:           p.topics,
 		SubscriptionName: p.subName,
 		Type:             subType,
 		KeySharedPolicy: &pulsar.KeySharedPolicy{
-			AllowOutOfOrderDelivery: true,
+			AllowOutOfOrderDelivery: p.allowOutOfOrder,
 		},
-	}); err != nil {
+		NackRedeliveryDelay: p.nackDelay,
+	}
+	if p.schema != nil {
+		consOpts.Schema = p.schema.schema
+	}
+
+	if consumer, err = client.Subscribe(consOpts); err != nil {
 		client.Close()
 		return err
 	}
@@ -252,7 +295,21 @@ func (p *pulsarReader) Read(ctx context.Context) (*service.Message, service.AckF
 		return nil, nil, err
 	}
 
-	msg := service.NewMessage(pulMsg.Payload())
+	var decodeErr error
+	payload := pulMsg.Payload()
+	if p.schema != nil {
+		if payload, decodeErr = p.schema.decode(payload); decodeErr != nil {
+			// Messages that cannot be decoded are passed on as they are and
+			// flagged as errored so that they can be routed accordingly.
+			p.log.Debugf("Failed to decode message with schema: %v\n", decodeErr)
+			payload = pulMsg.Payload()
+		}
+	}
+
+	msg := service.NewMessage(payload)
+	if decodeErr != nil {
+		msg.SetError(fmt.Errorf("failed to decode message with schema: %w", decodeErr))
+	}
 
 	msg.MetaSet("pulsar_message_id", string(pulMsg.ID().Serialize()))
 	msg.MetaSet("pulsar_topic", pulMsg.Topic())
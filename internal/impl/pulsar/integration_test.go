@@ -88,4 +88,43 @@ input:
 			integration.StreamTestOptMaxInFlight(10),
 		)
 	})
+
+	t.Run("with schema and properties", func(t *testing.T) {
+		t.Parallel()
+		// Protobuf payloads are passed through as they are, which allows the
+		// plain text messages of the test suite to be sent with a schema.
+		schemaTemplate := `
+output:
+  pulsar:
+    url: pulsar://localhost:$PORT/
+    topic: "topic-schema-$ID"
+    max_in_flight: $MAX_IN_FLIGHT
+    event_time: ${! timestamp_unix() }
+    compression: lz4
+    metadata:
+      include_patterns: [ ".*" ]
+    schema:
+      type: protobuf
+      schema: '{"type":"record","name":"doc","fields":[{"name":"content","type":"string"},{"name":"id","type":"string"}]}'
+
+input:
+  pulsar:
+    url: pulsar://localhost:$PORT/
+    topics: [ "topic-schema-$ID" ]
+    subscription_name: "sub-$ID"
+    schema:
+      type: protobuf
+      schema: '{"type":"record","name":"doc","fields":[{"name":"content","type":"string"},{"name":"id","type":"string"}]}'
+`
+		integration.StreamTests(
+			integration.StreamTestOpenClose(),
+			integration.StreamTestMetadata(),
+			integration.StreamTestSendBatch(10),
+		).Run(
+			t, schemaTemplate,
+			integration.StreamTestOptSleepAfterInput(500*time.Millisecond),
+			integration.StreamTestOptSleepAfterOutput(500*time.Millisecond),
+			integration.StreamTestOptPort(resource.GetPort("6650/tcp")),
+		)
+	})
 }
@@ -2,6 +2,8 @@ package pulsar
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,6 +20,15 @@ func init() {
 			Version("3.43.0").
 			Categories("Services").
 			Summary("Write messages to an Apache Pulsar server.").
+			Description(`
+### Metadata
+
+Metadata fields of messages can be attached to messages as properties with the `+"`metadata`"+` field.
+
+### Batching and Compression
+
+Messages are batched by the producer before being sent to the server, where a batch is sent once any of the limits `+"`batching_max_messages`, `batching_max_size` or `batching_max_publish_delay`"+` are reached. Batches can be compressed with the field `+"`compression`"+`.
+`).
 			Field(service.NewURLField("url").
 				Description("A URL to connect to.").
 				Example("pulsar://localhost:6650").
@@ -37,6 +48,53 @@ func init() {
 			Field(service.NewInterpolatedStringField("ordering_key").
 				Description("The ordering key to publish messages with.").
 				Default("")).
+			Field(service.NewInterpolatedStringField("event_time").
+				Description("An optional event time to publish messages with, as a unix timestamp in seconds.").
+				Example(`${! meta("pulsar_event_time_unix") }`).
+				Example(`${! timestamp_unix() }`).
+				Version("4.14.0").
+				Optional()).
+			Field(service.NewInterpolatedStringField("sequence_id").
+				Description("An optional sequence ID to publish messages with, which must be an integer. When specified the sequence IDs of messages from a producer must be increasing, and the server uses them for deduplication when enabled.").
+				Example(`${! meta("sequence") }`).
+				Advanced().
+				Version("4.14.0").
+				Optional()).
+			Field(service.NewMetadataFilterField("metadata").
+				Description("Determine which (if any) metadata values should be added to messages as properties.").
+				Version("4.14.0").
+				Optional()).
+			Field(service.NewBoolField("disable_batching").
+				Description("Whether to disable batching of messages by the producer.").
+				Advanced().
+				Version("4.14.0").
+				Default(false)).
+			Field(service.NewStringField("batching_max_publish_delay").
+				Description("The maximum period of time to wait for a batch of messages to fill before it is sent.").
+				Advanced().
+				Version("4.14.0").
+				Default("10ms")).
+			Field(service.NewIntField("batching_max_messages").
+				Description("The maximum number of messages within a batch.").
+				Advanced().
+				Version("4.14.0").
+				Default(1000)).
+			Field(service.NewIntField("batching_max_size").
+				Description("The maximum size of a batch in bytes.").
+				Advanced().
+				Version("4.14.0").
+				Default(131072)).
+			Field(service.NewStringEnumField("compression", "none", "lz4", "zlib", "zstd").
+				Description("The compression algorithm to apply to batches of messages.").
+				Advanced().
+				Version("4.14.0").
+				Default("none")).
+			Field(service.NewStringEnumField("compression_level", "default", "faster", "better").
+				Description("The level of compression to apply, which is only supported by the `zstd` algorithm.").
+				Advanced().
+				Version("4.14.0").
+				Default("default")).
+			Field(schemaField()).
 			Field(service.NewIntField("max_in_flight").
 				Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
 				Default(64)).
@@ -72,6 +130,17 @@ type pulsarWriter struct {
 	rootCasFile string
 	key         *service.InterpolatedString
 	orderingKey *service.InterpolatedString
+	eventTime   *service.InterpolatedString
+	sequenceID  *service.InterpolatedString
+	metaFilter  *service.MetadataFilter
+	schema      *pulsarSchema
+
+	disableBatching  bool
+	batchingDelay    time.Duration
+	batchingMessages uint
+	batchingSize     uint
+	compressionType  pulsar.CompressionType
+	compressionLevel pulsar.CompressionLevel
 }
 
 func newPulsarWriterFromParsed(conf *service.ParsedConfig, log *service.Logger) (p *pulsarWriter, err error) {
@@ -98,9 +167,91 @@ func newPulsarWriterFromParsed(conf *service.ParsedConfig, log *service.Logger)
 	if p.orderingKey, err = conf.FieldInterpolatedString("ordering_key"); err != nil {
 		return
 	}
+	if conf.Contains("event_time") {
+		if p.eventTime, err = conf.FieldInterpolatedString("event_time"); err != nil {
+			return
+		}
+	}
+	if conf.Contains("sequence_id") {
+		if p.sequenceID, err = conf.FieldInterpolatedString("sequence_id"); err != nil {
+			return
+		}
+	}
+	if conf.Contains("metadata") {
+		if p.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
+			return
+		}
+	}
+	if p.schema, err = schemaFromParsed(conf); err != nil {
+		return
+	}
+
+	if p.disableBatching, err = conf.FieldBool("disable_batching"); err != nil {
+		return
+	}
+	var delayStr string
+	if delayStr, err = conf.FieldString("batching_max_publish_delay"); err != nil {
+		return
+	}
+	if p.batchingDelay, err = time.ParseDuration(delayStr); err != nil {
+		err = fmt.Errorf("failed to parse batching_max_publish_delay: %w", err)
+		return
+	}
+	var batchingMessages, batchingSize int
+	if batchingMessages, err = conf.FieldInt("batching_max_messages"); err != nil {
+		return
+	}
+	if batchingSize, err = conf.FieldInt("batching_max_size"); err != nil {
+		return
+	}
+	if batchingMessages < 0 || batchingSize < 0 {
+		err = fmt.Errorf("batching limits must not be negative")
+		return
+	}
+	p.batchingMessages, p.batchingSize = uint(batchingMessages), uint(batchingSize)
+
+	var compressionStr string
+	if compressionStr, err = conf.FieldString("compression"); err != nil {
+		return
+	}
+	if p.compressionType, err = parseCompressionType(compressionStr); err != nil {
+		return
+	}
+	if compressionStr, err = conf.FieldString("compression_level"); err != nil {
+		return
+	}
+	if p.compressionLevel, err = parseCompressionLevel(compressionStr); err != nil {
+		return
+	}
 	return
 }
 
+func parseCompressionType(compression string) (pulsar.CompressionType, error) {
+	switch compression {
+	case "none":
+		return pulsar.NoCompression, nil
+	case "lz4":
+		return pulsar.LZ4, nil
+	case "zlib":
+		return pulsar.ZLib, nil
+	case "zstd":
+		return pulsar.ZSTD, nil
+	}
+	return pulsar.NoCompression, fmt.Errorf("could not parse compression type: %s", compression)
+}
+
+func parseCompressionLevel(level string) (pulsar.CompressionLevel, error) {
+	switch level {
+	case "default":
+		return pulsar.Default, nil
+	case "faster":
+		return pulsar.Faster, nil
+	case "better":
+		return pulsar.Better, nil
+	}
+	return pulsar.Default, fmt.Errorf("could not parse compression level: %s", level)
+}
+
 //------------------------------------------------------------------------------
 
 func (p *pulsarWriter) Connect(ctx context.Context) error {
@@ -134,9 +285,20 @@ func (p *pulsarWriter) Connect(ctx context.Context) error {
 		return err
 	}
 
-	if producer, err = client.CreateProducer(pulsar.ProducerOptions{
-		Topic: p.topic,
-	}); err != nil {
+	prodOpts := pulsar.ProducerOptions{
+		Topic:                   p.topic,
+		DisableBatching:         p.disableBatching,
+		BatchingMaxPublishDelay: p.batchingDelay,
+		BatchingMaxMessages:     p.batchingMessages,
+		BatchingMaxSize:         p.batchingSize,
+		CompressionType:         p.compressionType,
+		CompressionLevel:        p.compressionLevel,
+	}
+	if p.schema != nil {
+		prodOpts.Schema = p.schema.schema
+	}
+
+	if producer, err = client.CreateProducer(prodOpts); err != nil {
 		client.Close()
 		return err
 	}
@@ -184,8 +346,13 @@ func (p *pulsarWriter) Write(ctx context.Context, msg *service.Message) error {
 		return err
 	}
 
-	m := &pulsar.ProducerMessage{
-		Payload: b,
+	m := &pulsar.ProducerMessage{}
+	if p.schema != nil {
+		if err := p.schema.setValue(b, m); err != nil {
+			return err
+		}
+	} else {
+		m.Payload = b
 	}
 	if key := p.key.Bytes(msg); len(key) > 0 {
 		m.Key = string(key)
@@ -193,6 +360,31 @@ func (p *pulsarWriter) Write(ctx context.Context, msg *service.Message) error {
 	if orderingKey := p.orderingKey.Bytes(msg); len(orderingKey) > 0 {
 		m.OrderingKey = string(orderingKey)
 	}
+	if p.eventTime != nil {
+		if eventTimeStr := p.eventTime.String(msg); eventTimeStr != "" {
+			eventTime, err := strconv.ParseInt(eventTimeStr, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse event time: %w", err)
+			}
+			m.EventTime = time.Unix(eventTime, 0)
+		}
+	}
+	if p.sequenceID != nil {
+		if sequenceIDStr := p.sequenceID.String(msg); sequenceIDStr != "" {
+			sequenceID, err := strconv.ParseInt(sequenceIDStr, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse sequence id: %w", err)
+			}
+			m.SequenceID = &sequenceID
+		}
+	}
+	_ = p.metaFilter.Walk(msg, func(key, value string) error {
+		if m.Properties == nil {
+			m.Properties = map[string]string{}
+		}
+		m.Properties[key] = value
+		return nil
+	})
 
 	_, err = r.Send(context.Background(), m)
 	return err
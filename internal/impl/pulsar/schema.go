@@ -0,0 +1,128 @@
+package pulsar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	schemaTypeNone     = "none"
+	schemaTypeAvro     = "avro"
+	schemaTypeJSON     = "json"
+	schemaTypeProtobuf = "protobuf"
+)
+
+func schemaField() *service.ConfigField {
+	return service.NewObjectField("schema",
+		service.NewStringAnnotatedEnumField("type", map[string]string{
+			schemaTypeNone:     "Messages are sent and received as raw bytes without a schema.",
+			schemaTypeAvro:     "Messages are JSON documents that are converted to and from Avro binary using the schema.",
+			schemaTypeJSON:     "Messages are JSON documents, the schema is used for compatibility checks by the server.",
+			schemaTypeProtobuf: "Messages are already serialised protobuf messages, the schema is used for compatibility checks by the server. The `protobuf` processor can be used in order to convert between JSON and protobuf.",
+		}).
+			Description("The type of the schema.").
+			Default(schemaTypeNone),
+		service.NewStringField("schema").
+			Description("The schema definition, which must be expressed as an Avro schema for all schema types as required by Pulsar.").
+			Default("").
+			Example(`{"type":"record","name":"foo","fields":[{"name":"bar","type":"string"}]}`),
+	).
+		Description("Optionally associate a schema with messages, which is registered with and checked for compatibility by the server.").
+		Advanced().
+		Version("4.14.0")
+}
+
+// pulsarSchema converts messages according to a schema, which is also given to
+// producers and consumers in order to register the schema with the server.
+type pulsarSchema struct {
+	schemaType string
+	codec      *goavro.Codec
+	schema     pulsar.Schema
+}
+
+func schemaFromParsed(conf *service.ParsedConfig) (*pulsarSchema, error) {
+	schemaType, err := conf.FieldString("schema", "type")
+	if err != nil {
+		return nil, err
+	}
+	if schemaType == schemaTypeNone {
+		return nil, nil
+	}
+
+	def, err := conf.FieldString("schema", "schema")
+	if err != nil {
+		return nil, err
+	}
+	if def == "" {
+		return nil, fmt.Errorf("a schema definition must be provided for schema type %v", schemaType)
+	}
+
+	// The Pulsar client terminates the process when a schema definition is
+	// invalid, and therefore we parse it first in order to return an error.
+	s := &pulsarSchema{schemaType: schemaType}
+	if s.codec, err = goavro.NewCodec(def); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	switch schemaType {
+	case schemaTypeAvro:
+		s.schema = pulsar.NewAvroSchema(def, nil)
+	case schemaTypeJSON:
+		s.schema = pulsar.NewJSONSchema(def, nil)
+	case schemaTypeProtobuf:
+		s.schema = pulsar.NewProtoSchema(def, nil)
+	default:
+		return nil, fmt.Errorf("schema type %v is not supported", schemaType)
+	}
+	return s, nil
+}
+
+// rawProtoMessage is a serialised protobuf message that satisfies the
+// interface expected by the Pulsar protobuf schema.
+type rawProtoMessage []byte
+
+func (r rawProtoMessage) Reset()                   {}
+func (r rawProtoMessage) String() string           { return string(r) }
+func (r rawProtoMessage) ProtoMessage()            {}
+func (r rawProtoMessage) Marshal() ([]byte, error) { return r, nil }
+
+// setValue sets the schema value of a producer message from the raw bytes of a
+// message. Producers with a schema always encode the value of messages, and
+// are unable to report encoding failures, therefore values are validated here
+// and given in a form that encodes to the provided bytes.
+func (s *pulsarSchema) setValue(b []byte, m *pulsar.ProducerMessage) error {
+	switch s.schemaType {
+	case schemaTypeAvro:
+		if _, _, err := s.codec.NativeFromTextual(b); err != nil {
+			return fmt.Errorf("failed to convert message to avro: %w", err)
+		}
+		m.Value = json.RawMessage(b)
+	case schemaTypeJSON:
+		if !json.Valid(b) {
+			return errors.New("message is not a valid JSON document")
+		}
+		m.Value = json.RawMessage(b)
+	case schemaTypeProtobuf:
+		m.Value = rawProtoMessage(b)
+	}
+	return nil
+}
+
+// decode converts the payload of a consumed message into the form used for
+// messages of the schema type.
+func (s *pulsarSchema) decode(payload []byte) ([]byte, error) {
+	if s.schemaType != schemaTypeAvro {
+		return payload, nil
+	}
+	native, _, err := s.codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avro message: %w", err)
+	}
+	return s.codec.TextualFromNative(nil, native)
+}
@@ -0,0 +1,93 @@
+package pulsar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func parseTestSchema(t *testing.T, conf string) (*pulsarSchema, error) {
+	t.Helper()
+
+	spec := service.NewConfigSpec().Field(schemaField())
+	parsed, err := spec.ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	return schemaFromParsed(parsed)
+}
+
+func TestSchemaAvro(t *testing.T) {
+	s, err := parseTestSchema(t, `
+schema:
+  type: avro
+  schema: '{"type":"record","name":"foo","fields":[{"name":"bar","type":"string"}]}'
+`)
+	require.NoError(t, err)
+
+	m := &pulsar.ProducerMessage{}
+	require.NoError(t, s.setValue([]byte(`{"bar":"baz"}`), m))
+
+	encoded, err := s.schema.Encode(m.Value)
+	require.NoError(t, err)
+
+	decoded, err := s.decode(encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"bar":"baz"}`, string(decoded))
+
+	require.Error(t, s.setValue([]byte(`{"bar":10}`), m))
+}
+
+func TestSchemaJSON(t *testing.T) {
+	s, err := parseTestSchema(t, `
+schema:
+  type: json
+  schema: '{"type":"record","name":"foo","fields":[{"name":"bar","type":"string"}]}'
+`)
+	require.NoError(t, err)
+
+	m := &pulsar.ProducerMessage{}
+	require.NoError(t, s.setValue([]byte(`{"bar":"baz"}`), m))
+	assert.Equal(t, json.RawMessage(`{"bar":"baz"}`), m.Value)
+
+	require.Error(t, s.setValue([]byte(`not json`), m))
+}
+
+func TestSchemaProtobuf(t *testing.T) {
+	s, err := parseTestSchema(t, `
+schema:
+  type: protobuf
+  schema: '{"type":"record","name":"foo","fields":[{"name":"bar","type":"string"}]}'
+`)
+	require.NoError(t, err)
+
+	m := &pulsar.ProducerMessage{}
+	require.NoError(t, s.setValue([]byte{0x0a, 0x03, 'b', 'a', 'z'}, m))
+
+	encoded, err := s.schema.Encode(m.Value)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0x03, 'b', 'a', 'z'}, encoded)
+}
+
+func TestSchemaErrors(t *testing.T) {
+	s, err := parseTestSchema(t, `{}`)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = parseTestSchema(t, `
+schema:
+  type: avro
+`)
+	require.Error(t, err)
+
+	_, err = parseTestSchema(t, `
+schema:
+  type: avro
+  schema: 'not a schema'
+`)
+	require.Error(t, err)
+}
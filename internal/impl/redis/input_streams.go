@@ -14,6 +14,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/impl/redis/old"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -29,7 +30,41 @@ Pulls messages from Redis (v5.0+) streams with the XREADGROUP command. The
 		Description: `
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key that contains the body of the message. All other keys/value pairs are saved
-as metadata fields.`,
+as metadata fields.
+
+### Pending Entries
+
+Entries that are delivered to a consumer remain within the pending entries list
+(PEL) of the consumer group until they are acknowledged. When a consumer stops
+without acknowledging its entries, for example due to a crash, those entries
+would otherwise remain pending indefinitely. Setting the field
+` + "`claim_min_idle`" + ` causes this input to periodically claim entries of other
+consumers that have been pending for at least that duration with the XPENDING
+and XCLAIM commands (Redis v6.2+), and claimed entries are then consumed as
+normal with the metadata field ` + "`redis_stream_delivery_count`" + ` set.
+
+Entries pending for this consumer, as identified by ` + "`client_id`" + `, are
+only claimed once they have been rejected, as otherwise they are still being
+processed. With claiming enabled rejected entries are therefore not retried
+immediately, instead they are left pending and claimed again once they have
+been idle for ` + "`claim_min_idle`" + `, which counts as another delivery. For
+this reason XPENDING and XCLAIM are used rather than XAUTOCLAIM, which cannot
+tell apart entries that are still being processed by this consumer.
+
+When ` + "`max_deliveries`" + ` is set, claimed entries that have been delivered
+more times than the limit are instead written to the stream
+` + "`dead_letter_stream`" + ` and acknowledged. Claimed entries that do not contain
+the ` + "`body_key`" + ` can never be consumed, and are therefore always
+dead-lettered. Dead-lettered entries keep their original key/value pairs, along
+with the keys ` + "`redis_dead_letter_source_stream`" + `,
+` + "`redis_dead_letter_source_id`" + `, ` + "`redis_dead_letter_delivery_count`" + `
+and ` + "`redis_dead_letter_reason`" + `.
+
+### Metrics
+
+This input emits an ` + "`input_redis_streams_pending`" + ` gauge metric with the
+label ` + "`stream`" + `, which is the size of the pending entries list of the
+consumer group for each stream, refreshed every ` + "`claim_period`" + `.`,
 		Config: docs.FieldComponent().WithChildren(old.ConfigDocs()...).WithChildren(
 			docs.FieldString("body_key", "The field key to extract the raw message from. All other keys will be stored in the message as metadata."),
 			docs.FieldString("streams", "A list of streams to consume from.").Array(),
@@ -40,6 +75,10 @@ as metadata fields.`,
 			docs.FieldBool("start_from_oldest", "If an offset is not found for a stream, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").Advanced(),
 			docs.FieldString("commit_period", "The period of time between each commit of the current offset. Offsets are always committed during shutdown.").Advanced(),
 			docs.FieldString("timeout", "The length of time to poll for new messages before reattempting.").Advanced(),
+			docs.FieldString("claim_min_idle", "The minimum period of time that an entry must have been pending for before it is claimed from another consumer of the group, or claimed again by this consumer after being rejected. Claiming is disabled when empty, in which case rejected entries are retried immediately.", "5m").AtVersion("4.14.0"),
+			docs.FieldString("claim_period", "The period of time between each attempt to claim pending entries, which is also the period between refreshes of pending entry metrics.").Advanced().AtVersion("4.14.0"),
+			docs.FieldInt("max_deliveries", "The maximum number of times that an entry can be delivered before a claimed entry is sent to the `dead_letter_stream` and acknowledged. Set to zero in order to disable the limit.").AtVersion("4.14.0"),
+			docs.FieldString("dead_letter_stream", "A stream to write entries to once they exceed `max_deliveries`, or when claimed entries do not contain the `body_key`. When empty these entries are acknowledged and dropped.").AtVersion("4.14.0"),
 		).ChildDefaultAndTypesFromStruct(input.NewRedisStreamsConfig()),
 		Categories: []string{
 			"Services",
@@ -51,12 +90,18 @@ as metadata fields.`,
 }
 
 func newRedisStreamsInput(conf input.Config, mgr bundle.NewManagement) (input.Streamed, error) {
-	var c input.Async
-	var err error
-	if c, err = newRedisStreamsReader(conf.RedisStreams, mgr); err != nil {
+	r, err := newRedisStreamsReader(conf.RedisStreams, mgr)
+	if err != nil {
 		return nil, err
 	}
-	c = input.NewAsyncPreserver(c)
+
+	// When claiming is enabled rejected entries are left pending in order to
+	// be claimed again, which counts towards their deliveries, otherwise they
+	// are retried from memory until they succeed.
+	var c input.Async = r
+	if r.claimMinIdle <= 0 {
+		c = input.NewAsyncPreserver(c)
+	}
 	return input.NewAsyncReader("redis_streams", c, mgr)
 }
 
@@ -74,6 +119,16 @@ type redisStreamsReader struct {
 
 	timeout      time.Duration
 	commitPeriod time.Duration
+	claimMinIdle time.Duration
+	claimPeriod  time.Duration
+
+	// Only accessed from the claim goroutine.
+	claimCursors map[string]string
+
+	// Entries of this consumer that were rejected and can be claimed again.
+	nacked       map[string]map[string]struct{}
+	nackedMut    sync.Mutex
+	pendingGauge metrics.StatGaugeVec
 
 	conf input.RedisStreamsConfig
 
@@ -92,13 +147,16 @@ type redisStreamsReader struct {
 
 func newRedisStreamsReader(conf input.RedisStreamsConfig, mgr bundle.NewManagement) (*redisStreamsReader, error) {
 	r := &redisStreamsReader{
-		conf:       conf,
-		log:        mgr.Logger(),
-		mgr:        mgr,
-		backlogs:   make(map[string]string, len(conf.Streams)),
-		ackSend:    make(map[string][]string, len(conf.Streams)),
-		closeChan:  make(chan struct{}),
-		closedChan: make(chan struct{}),
+		conf:         conf,
+		log:          mgr.Logger(),
+		mgr:          mgr,
+		backlogs:     make(map[string]string, len(conf.Streams)),
+		ackSend:      make(map[string][]string, len(conf.Streams)),
+		claimCursors: make(map[string]string, len(conf.Streams)),
+		nacked:       make(map[string]map[string]struct{}, len(conf.Streams)),
+		pendingGauge: mgr.Metrics().GetGaugeVec("input_redis_streams_pending", "stream"),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
 	}
 
 	for _, str := range conf.Streams {
@@ -123,6 +181,21 @@ func newRedisStreamsReader(conf input.RedisStreamsConfig, mgr bundle.NewManageme
 		}
 	}
 
+	if tout := conf.ClaimMinIdle; len(tout) > 0 {
+		var err error
+		if r.claimMinIdle, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse claim min idle string: %v", err)
+		}
+	}
+
+	r.claimPeriod = time.Second * 30
+	if tout := conf.ClaimPeriod; len(tout) > 0 {
+		var err error
+		if r.claimPeriod, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse claim period string: %v", err)
+		}
+	}
+
 	go r.loop()
 	return r, nil
 }
@@ -130,7 +203,9 @@ func newRedisStreamsReader(conf input.RedisStreamsConfig, mgr bundle.NewManageme
 //------------------------------------------------------------------------------
 
 func (r *redisStreamsReader) loop() {
+	var wg sync.WaitGroup
 	defer func() {
+		wg.Wait()
 		var client redis.UniversalClient
 		r.cMut.Lock()
 		client = r.client
@@ -141,7 +216,17 @@ func (r *redisStreamsReader) loop() {
 		}
 		close(r.closedChan)
 	}()
+
+	// Claiming can take a while and must not hold up acknowledgements, and so
+	// it has its own goroutine.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.claimLoop()
+	}()
+
 	commitTimer := time.NewTicker(r.commitPeriod)
+	defer commitTimer.Stop()
 
 	ctx := context.Background()
 
@@ -156,6 +241,33 @@ func (r *redisStreamsReader) loop() {
 	}
 }
 
+func (r *redisStreamsReader) claimLoop() {
+	claimTimer := time.NewTicker(r.claimPeriod)
+	defer claimTimer.Stop()
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	go func() {
+		select {
+		case <-r.closeChan:
+			done()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-claimTimer.C:
+			if r.claimMinIdle > 0 {
+				r.claimPending(ctx)
+			}
+			r.refreshPendingMetrics(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (r *redisStreamsReader) addAsyncAcks(stream string, ids ...string) {
 	r.aMut.Lock()
 	if acks, exists := r.ackSend[stream]; exists {
@@ -192,6 +304,184 @@ func (r *redisStreamsReader) sendAcks(ctx context.Context) {
 	}
 }
 
+func (r *redisStreamsReader) setNacked(stream, id string, nacked bool) {
+	r.nackedMut.Lock()
+	defer r.nackedMut.Unlock()
+
+	ids := r.nacked[stream]
+	if !nacked {
+		delete(ids, id)
+		return
+	}
+	if ids == nil {
+		ids = map[string]struct{}{}
+		r.nacked[stream] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (r *redisStreamsReader) isNacked(stream, id string) bool {
+	r.nackedMut.Lock()
+	_, exists := r.nacked[stream][id]
+	r.nackedMut.Unlock()
+	return exists
+}
+
+// claimPending claims entries of each stream that have been left pending for
+// longer than the configured minimum idle period, and adds them to the pending
+// messages to be consumed. Entries that exceed the maximum number of
+// deliveries, or that do not contain a body, are dead-lettered instead.
+//
+// Entries owned by this consumer are only claimed once they have been
+// rejected, as otherwise they are still in flight and claiming them would
+// deliver duplicates. This is also why XAUTOCLAIM is not used, as it cannot
+// exclude entries by their consumer.
+func (r *redisStreamsReader) claimPending(ctx context.Context) {
+	var client redis.UniversalClient
+	r.cMut.Lock()
+	client = r.client
+	r.cMut.Unlock()
+
+	if client == nil {
+		return
+	}
+
+	count := r.conf.Limit
+	if count <= 0 {
+		count = 100
+	}
+
+	for _, stream := range r.conf.Streams {
+		start := r.claimCursors[stream]
+		if start == "" {
+			start = "-"
+		}
+
+		pending, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  r.conf.ConsumerGroup,
+			Idle:   r.claimMinIdle,
+			Start:  start,
+			End:    "+",
+			Count:  count,
+		}).Result()
+		if err != nil {
+			r.log.Errorf("Failed to obtain pending entries of stream %v: %v\n", stream, err)
+			continue
+		}
+
+		// Continue from the last entry on the next attempt, or start again
+		// from the beginning once we've reached the end.
+		if int64(len(pending)) < count {
+			delete(r.claimCursors, stream)
+		} else {
+			r.claimCursors[stream] = "(" + pending[len(pending)-1].ID
+		}
+
+		var ids []string
+		deliveries := make(map[string]int64, len(pending))
+		for _, p := range pending {
+			if p.Consumer == r.conf.ClientID && !r.isNacked(stream, p.ID) {
+				continue
+			}
+			ids = append(ids, p.ID)
+			// Claiming an entry counts as a delivery.
+			deliveries[p.ID] = p.RetryCount + 1
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		xmsgs, err := client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   stream,
+			Group:    r.conf.ConsumerGroup,
+			Consumer: r.conf.ClientID,
+			MinIdle:  r.claimMinIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			r.log.Errorf("Failed to claim pending entries of stream %v: %v\n", stream, err)
+			continue
+		}
+
+		var claimed []pendingRedisStreamMsg
+		for _, xmsg := range xmsgs {
+			r.setNacked(stream, xmsg.ID, false)
+			count := deliveries[xmsg.ID]
+			if r.conf.MaxDeliveries > 0 && count > r.conf.MaxDeliveries {
+				r.deadLetter(ctx, client, stream, xmsg, count, fmt.Sprintf("exceeded %v deliveries", r.conf.MaxDeliveries))
+				continue
+			}
+			msg, ok := r.toPendingMsg(stream, xmsg)
+			if !ok {
+				// Without a body the entry can never be consumed, and leaving
+				// it pending would see it claimed again and again.
+				r.deadLetter(ctx, client, stream, xmsg, count, fmt.Sprintf("missing body key %v", r.conf.BodyKey))
+				continue
+			}
+			msg.payload.Get(0).MetaSetMut("redis_stream_delivery_count", count)
+			claimed = append(claimed, msg)
+		}
+
+		if len(claimed) > 0 {
+			r.log.Debugf("Claimed %v pending entries from stream %v\n", len(claimed), stream)
+			r.pendingMsgsMut.Lock()
+			r.pendingMsgs = append(r.pendingMsgs, claimed...)
+			r.pendingMsgsMut.Unlock()
+		}
+	}
+}
+
+// deadLetter writes an entry to the dead letter stream, if configured, and
+// acknowledges it. Entries that fail to be written remain pending in order to
+// be attempted again.
+func (r *redisStreamsReader) deadLetter(ctx context.Context, client redis.UniversalClient, stream string, xmsg redis.XMessage, deliveries int64, reason string) {
+	if r.conf.DeadLetterStream != "" {
+		values := make(map[string]any, len(xmsg.Values)+3)
+		for k, v := range xmsg.Values {
+			values[k] = v
+		}
+		values["redis_dead_letter_source_stream"] = stream
+		values["redis_dead_letter_source_id"] = xmsg.ID
+		values["redis_dead_letter_delivery_count"] = deliveries
+		values["redis_dead_letter_reason"] = reason
+
+		if err := client.XAdd(ctx, &redis.XAddArgs{
+			Stream: r.conf.DeadLetterStream,
+			Values: values,
+		}).Err(); err != nil {
+			r.log.Errorf("Failed to write entry %v of stream %v to dead letter stream: %v\n", xmsg.ID, stream, err)
+			return
+		}
+	} else {
+		r.log.Warnf("Dropping entry %v of stream %v after %v deliveries: %v\n", xmsg.ID, stream, deliveries, reason)
+	}
+
+	if err := client.XAck(ctx, stream, r.conf.ConsumerGroup, xmsg.ID).Err(); err != nil {
+		r.log.Errorf("Failed to ack stream %v: %v\n", stream, err)
+	}
+}
+
+func (r *redisStreamsReader) refreshPendingMetrics(ctx context.Context) {
+	var client redis.UniversalClient
+	r.cMut.Lock()
+	client = r.client
+	r.cMut.Unlock()
+
+	if client == nil {
+		return
+	}
+
+	for _, stream := range r.conf.Streams {
+		pending, err := client.XPending(ctx, stream, r.conf.ConsumerGroup).Result()
+		if err != nil {
+			r.log.Debugf("Failed to obtain pending entries of stream %v: %v\n", stream, err)
+			continue
+		}
+		r.pendingGauge.With(stream).Set(pending.Count)
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Connect establishes a connection to a Redis server.
@@ -233,6 +523,37 @@ func (r *redisStreamsReader) Connect(ctx context.Context) error {
 	return nil
 }
 
+func (r *redisStreamsReader) toPendingMsg(stream string, xmsg redis.XMessage) (pendingRedisStreamMsg, bool) {
+	body, exists := xmsg.Values[r.conf.BodyKey]
+	if !exists {
+		return pendingRedisStreamMsg{}, false
+	}
+	delete(xmsg.Values, r.conf.BodyKey)
+
+	var bodyBytes []byte
+	switch t := body.(type) {
+	case string:
+		bodyBytes = []byte(t)
+	case []byte:
+		bodyBytes = t
+	}
+	if bodyBytes == nil {
+		return pendingRedisStreamMsg{}, false
+	}
+
+	part := message.NewPart(bodyBytes)
+	part.MetaSetMut("redis_stream", xmsg.ID)
+	for k, v := range xmsg.Values {
+		part.MetaSetMut(k, v)
+	}
+
+	return pendingRedisStreamMsg{
+		payload: message.Batch{part},
+		stream:  stream,
+		id:      xmsg.ID,
+	}, true
+}
+
 func (r *redisStreamsReader) read(ctx context.Context) (pendingRedisStreamMsg, error) {
 	var client redis.UniversalClient
 	var msg pendingRedisStreamMsg
@@ -290,35 +611,10 @@ func (r *redisStreamsReader) read(ctx context.Context) (pendingRedisStreamMsg, e
 			}
 		}
 		for _, xmsg := range strRes.Messages {
-			body, exists := xmsg.Values[r.conf.BodyKey]
-			if !exists {
+			nextMsg, ok := r.toPendingMsg(strRes.Stream, xmsg)
+			if !ok {
 				continue
 			}
-			delete(xmsg.Values, r.conf.BodyKey)
-
-			var bodyBytes []byte
-			switch t := body.(type) {
-			case string:
-				bodyBytes = []byte(t)
-			case []byte:
-				bodyBytes = t
-			}
-			if bodyBytes == nil {
-				continue
-			}
-
-			part := message.NewPart(bodyBytes)
-			part.MetaSetMut("redis_stream", xmsg.ID)
-			for k, v := range xmsg.Values {
-				part.MetaSetMut(k, v)
-			}
-
-			nextMsg := pendingRedisStreamMsg{
-				payload: message.QuickBatch(nil),
-				stream:  strRes.Stream,
-				id:      xmsg.ID,
-			}
-			nextMsg.payload = append(nextMsg.payload, part)
 			if msg.payload == nil {
 				msg = nextMsg
 			} else {
@@ -350,7 +646,10 @@ func (r *redisStreamsReader) ReadBatch(ctx context.Context) (message.Batch, inpu
 		}
 	}
 	return msg.payload, func(rctx context.Context, res error) error {
-		if res != nil {
+		if res != nil && r.claimMinIdle > 0 {
+			// Leave the entry pending so that it is claimed again once idle.
+			r.setNacked(msg.stream, msg.id, true)
+		} else if res != nil {
 			r.pendingMsgsMut.Lock()
 			r.pendingMsgs = append(r.pendingMsgs, msg)
 			r.pendingMsgsMut.Unlock()
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -11,9 +12,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/integration"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
 	_ "github.com/benthosdev/benthos/v4/public/components/pure"
 )
 
@@ -189,6 +193,173 @@ input:
 		})
 	})
 
+	t.Run("streams claim pending", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		defer done()
+
+		client := redis.NewClient(&redis.Options{
+			Addr:    fmt.Sprintf("localhost:%v", resource.GetPort("6379/tcp")),
+			Network: "tcp",
+		})
+		t.Cleanup(func() {
+			_ = client.Close()
+		})
+
+		require.NoError(t, client.XGroupCreateMkStream(ctx, "claim-stream", "claim-group", "0").Err())
+		for _, body := range []string{"foo", "bar"} {
+			require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{
+				Stream: "claim-stream",
+				Values: map[string]any{"body": body},
+			}).Err())
+		}
+		require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{
+			Stream: "claim-stream",
+			Values: map[string]any{"not_body": "baz"},
+		}).Err())
+
+		// Consume all entries with a consumer that never acknowledges them,
+		// and then bump the delivery count of the second entry beyond the
+		// limit.
+		res, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    "claim-group",
+			Consumer: "crashed",
+			Streams:  []string{"claim-stream", ">"},
+		}).Result()
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Len(t, res[0].Messages, 3)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, client.XClaim(ctx, &redis.XClaimArgs{
+				Stream:   "claim-stream",
+				Group:    "claim-group",
+				Consumer: "crashed",
+				Messages: []string{res[0].Messages[1].ID},
+			}).Err())
+		}
+
+		conf := input.NewRedisStreamsConfig()
+		conf.URL = fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))
+		conf.Streams = []string{"claim-stream"}
+		conf.ConsumerGroup = "claim-group"
+		conf.ClientID = "claimer"
+		conf.ClaimMinIdle = "1ms"
+		conf.ClaimPeriod = "100ms"
+		conf.MaxDeliveries = 3
+		conf.DeadLetterStream = "claim-stream-dlq"
+
+		r, err := newRedisStreamsReader(conf, mock.NewManager())
+		require.NoError(t, err)
+		require.NoError(t, r.Connect(ctx))
+		t.Cleanup(func() {
+			_ = r.Close(context.Background())
+		})
+
+		var batch message.Batch
+		var ackFn input.AsyncAckFn
+		for batch == nil {
+			if batch, ackFn, err = r.ReadBatch(ctx); err != nil {
+				require.ErrorIs(t, err, component.ErrTimeout)
+			}
+		}
+		require.Len(t, batch, 1)
+		assert.Equal(t, "foo", string(batch.Get(0).AsBytes()))
+		count, _ := batch.Get(0).MetaGetMut("redis_stream_delivery_count")
+		assert.Equal(t, int64(2), count)
+
+		// Whilst the entry is in flight it is pending for our own consumer,
+		// and must not be claimed again.
+		<-time.After(time.Millisecond * 500)
+		r.pendingMsgsMut.Lock()
+		assert.Empty(t, r.pendingMsgs)
+		r.pendingMsgsMut.Unlock()
+
+		require.NoError(t, ackFn(ctx, nil))
+
+		assert.Eventually(t, func() bool {
+			dlq, err := client.XRange(ctx, "claim-stream-dlq", "-", "+").Result()
+			if err != nil || len(dlq) != 2 {
+				return false
+			}
+			return dlq[0].Values["body"] == "bar" &&
+				dlq[0].Values["redis_dead_letter_source_stream"] == "claim-stream" &&
+				dlq[1].Values["not_body"] == "baz" &&
+				dlq[1].Values["redis_dead_letter_reason"] == "missing body key body"
+		}, time.Second*5, time.Millisecond*100)
+
+		assert.Eventually(t, func() bool {
+			pending, err := client.XPending(ctx, "claim-stream", "claim-group").Result()
+			return err == nil && pending.Count == 0
+		}, time.Second*5, time.Millisecond*100)
+	})
+
+	t.Run("streams nack dead letter", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		defer done()
+
+		client := redis.NewClient(&redis.Options{
+			Addr:    fmt.Sprintf("localhost:%v", resource.GetPort("6379/tcp")),
+			Network: "tcp",
+		})
+		t.Cleanup(func() {
+			_ = client.Close()
+		})
+
+		conf := input.NewRedisStreamsConfig()
+		conf.URL = fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))
+		conf.Streams = []string{"nack-stream"}
+		conf.ConsumerGroup = "nack-group"
+		conf.ClientID = "nacker"
+		conf.CreateStreams = true
+		conf.StartFromOldest = true
+		conf.ClaimMinIdle = "1ms"
+		conf.ClaimPeriod = "100ms"
+		conf.MaxDeliveries = 2
+		conf.DeadLetterStream = "nack-stream-dlq"
+
+		r, err := newRedisStreamsReader(conf, mock.NewManager())
+		require.NoError(t, err)
+		require.NoError(t, r.Connect(ctx))
+		t.Cleanup(func() {
+			_ = r.Close(context.Background())
+		})
+
+		require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{
+			Stream: "nack-stream",
+			Values: map[string]any{"body": "poison"},
+		}).Err())
+
+		// A rejected entry is left pending and claimed again by the only
+		// consumer of the group until it exceeds the delivery limit.
+		for i := 0; i < 2; i++ {
+			var batch message.Batch
+			var ackFn input.AsyncAckFn
+			for batch == nil {
+				if batch, ackFn, err = r.ReadBatch(ctx); err != nil {
+					require.ErrorIs(t, err, component.ErrTimeout)
+				}
+			}
+			require.Len(t, batch, 1)
+			assert.Equal(t, "poison", string(batch.Get(0).AsBytes()))
+			require.NoError(t, ackFn(ctx, errors.New("nope")))
+		}
+
+		assert.Eventually(t, func() bool {
+			dlq, err := client.XRange(ctx, "nack-stream-dlq", "-", "+").Result()
+			return err == nil && len(dlq) == 1 &&
+				dlq[0].Values["body"] == "poison" &&
+				dlq[0].Values["redis_dead_letter_delivery_count"] == "3"
+		}, time.Second*5, time.Millisecond*100)
+
+		assert.Eventually(t, func() bool {
+			pending, err := client.XPending(ctx, "nack-stream", "nack-group").Result()
+			return err == nil && pending.Count == 0
+		}, time.Second*5, time.Millisecond*100)
+	})
+
 	// HASH
 	t.Run("hash", func(t *testing.T) {
 		t.Parallel()
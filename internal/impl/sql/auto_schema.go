@@ -0,0 +1,397 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type sqlValueKind int
+
+const (
+	sqlValueString sqlValueKind = iota
+	sqlValueInt
+	sqlValueFloat
+	sqlValueBool
+	sqlValueTimestamp
+	sqlValueBytes
+	sqlValueJSON
+)
+
+// sqlDriverTypes maps the kinds of values that can be inferred onto the column
+// types of each driver.
+var sqlDriverTypes = map[string]map[sqlValueKind]string{
+	"postgres": {
+		sqlValueString:    "TEXT",
+		sqlValueInt:       "BIGINT",
+		sqlValueFloat:     "DOUBLE PRECISION",
+		sqlValueBool:      "BOOLEAN",
+		sqlValueTimestamp: "TIMESTAMPTZ",
+		sqlValueBytes:     "BYTEA",
+		sqlValueJSON:      "JSONB",
+	},
+	"mysql": {
+		sqlValueString:    "TEXT",
+		sqlValueInt:       "BIGINT",
+		sqlValueFloat:     "DOUBLE",
+		sqlValueBool:      "BOOLEAN",
+		sqlValueTimestamp: "DATETIME(6)",
+		sqlValueBytes:     "BLOB",
+		sqlValueJSON:      "JSON",
+	},
+	"clickhouse": {
+		sqlValueString:    "String",
+		sqlValueInt:       "Int64",
+		sqlValueFloat:     "Float64",
+		sqlValueBool:      "Bool",
+		sqlValueTimestamp: "DateTime64(6)",
+		sqlValueBytes:     "String",
+		sqlValueJSON:      "String",
+	},
+	"mssql": {
+		sqlValueString:    "NVARCHAR(MAX)",
+		sqlValueInt:       "BIGINT",
+		sqlValueFloat:     "FLOAT",
+		sqlValueBool:      "BIT",
+		sqlValueTimestamp: "DATETIMEOFFSET",
+		sqlValueBytes:     "VARBINARY(MAX)",
+		sqlValueJSON:      "NVARCHAR(MAX)",
+	},
+	"sqlite": {
+		sqlValueString:    "TEXT",
+		sqlValueInt:       "INTEGER",
+		sqlValueFloat:     "REAL",
+		sqlValueBool:      "BOOLEAN",
+		sqlValueTimestamp: "DATETIME",
+		sqlValueBytes:     "BLOB",
+		sqlValueJSON:      "TEXT",
+	},
+	"oracle": {
+		sqlValueString:    "CLOB",
+		sqlValueInt:       "NUMBER(19)",
+		sqlValueFloat:     "BINARY_DOUBLE",
+		sqlValueBool:      "NUMBER(1)",
+		sqlValueTimestamp: "TIMESTAMP WITH TIME ZONE",
+		sqlValueBytes:     "BLOB",
+		sqlValueJSON:      "CLOB",
+	},
+	"snowflake": {
+		sqlValueString:    "VARCHAR",
+		sqlValueInt:       "INTEGER",
+		sqlValueFloat:     "FLOAT",
+		sqlValueBool:      "BOOLEAN",
+		sqlValueTimestamp: "TIMESTAMP_TZ",
+		sqlValueBytes:     "BINARY",
+		sqlValueJSON:      "VARCHAR",
+	},
+}
+
+// sqlKeyStringTypes overrides the string type of drivers that cannot index
+// columns of unbounded length, and is used for primary key columns.
+var sqlKeyStringTypes = map[string]string{
+	"mysql":  "VARCHAR(255)",
+	"mssql":  "NVARCHAR(450)",
+	"oracle": "VARCHAR2(4000)",
+}
+
+func sqlValueKindOf(v any) sqlValueKind {
+	switch t := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return sqlValueInt
+	case float32, float64:
+		return sqlValueFloat
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return sqlValueInt
+		}
+		return sqlValueFloat
+	case bool:
+		return sqlValueBool
+	case time.Time:
+		return sqlValueTimestamp
+	case []byte:
+		return sqlValueBytes
+	case map[string]any, []any:
+		return sqlValueJSON
+	}
+	return sqlValueString
+}
+
+//------------------------------------------------------------------------------
+
+var sqlColumnNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// objectRows converts a batch of object messages into rows of values, where
+// the columns are the sorted union of the keys of all messages and keys
+// missing from a message are given null values.
+func objectRows(batch service.MessageBatch) ([]string, [][]any, error) {
+	objs := make([]map[string]any, 0, len(batch))
+	keys := map[string]struct{}{}
+	for i, msg := range batch {
+		v, err := msg.AsStructured()
+		if err != nil {
+			return nil, nil, fmt.Errorf("message %v: %w", i, err)
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("message %v: expected an object, got %T", i, v)
+		}
+		for k := range obj {
+			if _, exists := keys[k]; exists {
+				continue
+			}
+			if !sqlColumnNameRegexp.MatchString(k) {
+				return nil, nil, fmt.Errorf("message %v: field %q is not a valid column name", i, k)
+			}
+			keys[k] = struct{}{}
+		}
+		objs = append(objs, obj)
+	}
+
+	columns := make([]string, 0, len(keys))
+	for k := range keys {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	rows := make([][]any, 0, len(objs))
+	for _, obj := range objs {
+		row := make([]any, len(columns))
+		for i, c := range columns {
+			row[i] = obj[c]
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// normaliseObjectRows converts values of object messages into types that can
+// be passed to drivers, where objects and arrays are serialised as JSON.
+func normaliseObjectRows(rows [][]any) error {
+	for _, row := range rows {
+		for i, v := range row {
+			switch t := v.(type) {
+			case json.Number:
+				if n, err := t.Int64(); err == nil {
+					row[i] = n
+				} else if f, err := t.Float64(); err == nil {
+					row[i] = f
+				} else {
+					row[i] = t.String()
+				}
+			case map[string]any, []any:
+				b, err := json.Marshal(t)
+				if err != nil {
+					return err
+				}
+				row[i] = string(b)
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// sqlAutoSchema creates a table and adds columns to it as they appear within
+// batches.
+type sqlAutoSchema struct {
+	driver      string
+	table       string
+	columnTypes map[string]string
+	keyColumns  []string
+	dryRun      bool
+
+	logger *service.Logger
+
+	mut   sync.Mutex
+	known map[string]struct{}
+}
+
+// autoSchemaFromParsed returns nil when automatic schema management is not
+// enabled.
+func autoSchemaFromParsed(conf *service.ParsedConfig, driver, table string, upsert *sqlUpsertConfig, logger *service.Logger) (*sqlAutoSchema, error) {
+	enabled, err := conf.FieldBool("enabled")
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	if _, exists := sqlDriverTypes[driver]; !exists {
+		return nil, fmt.Errorf("driver %v is not supported", driver)
+	}
+
+	a := &sqlAutoSchema{
+		driver: driver,
+		table:  table,
+		logger: logger,
+	}
+	if a.columnTypes, err = conf.FieldStringMap("column_types"); err != nil {
+		return nil, err
+	}
+	if a.dryRun, err = conf.FieldBool("dry_run"); err != nil {
+		return nil, err
+	}
+	if upsert != nil {
+		a.keyColumns = upsert.conflictColumns
+	}
+	return a, nil
+}
+
+func (a *sqlAutoSchema) isKeyColumn(column string) bool {
+	for _, k := range a.keyColumns {
+		if k == column {
+			return true
+		}
+	}
+	return false
+}
+
+// columnType returns the type of a column, either as declared or inferred from
+// the first non-null value of the column within the rows.
+func (a *sqlAutoSchema) columnType(column string, index int, rows [][]any) string {
+	if t, exists := a.columnTypes[column]; exists {
+		return t
+	}
+
+	kind := sqlValueString
+	for _, row := range rows {
+		if index < len(row) && row[index] != nil {
+			kind = sqlValueKindOf(row[index])
+			break
+		}
+	}
+
+	isKey := a.isKeyColumn(column)
+	t := sqlDriverTypes[a.driver][kind]
+	if kt, exists := sqlKeyStringTypes[a.driver]; exists && isKey && kind == sqlValueString {
+		t = kt
+	}
+	if a.driver == "clickhouse" && !isKey {
+		t = "Nullable(" + t + ")"
+	}
+	return t
+}
+
+func (a *sqlAutoSchema) createTableSQL(columns []string, rows [][]any) string {
+	defs := make([]string, 0, len(columns)+1)
+	for i, c := range columns {
+		defs = append(defs, c+" "+a.columnType(c, i, rows))
+	}
+	if len(a.keyColumns) > 0 && a.driver != "clickhouse" {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%v)", strings.Join(a.keyColumns, ", ")))
+	}
+
+	stmt := "CREATE TABLE IF NOT EXISTS"
+	if a.driver == "mssql" || a.driver == "oracle" {
+		stmt = "CREATE TABLE"
+	}
+	stmt = fmt.Sprintf("%v %v (%v)", stmt, a.table, strings.Join(defs, ", "))
+
+	if a.driver == "clickhouse" {
+		if len(a.keyColumns) > 0 {
+			stmt += fmt.Sprintf(" ENGINE = ReplacingMergeTree ORDER BY (%v)", strings.Join(a.keyColumns, ", "))
+		} else {
+			stmt += " ENGINE = MergeTree ORDER BY tuple()"
+		}
+	}
+	return stmt
+}
+
+func (a *sqlAutoSchema) addColumnSQL(column, columnType string) string {
+	switch a.driver {
+	case "mssql":
+		return fmt.Sprintf("ALTER TABLE %v ADD %v %v", a.table, column, columnType)
+	case "oracle":
+		return fmt.Sprintf("ALTER TABLE %v ADD (%v %v)", a.table, column, columnType)
+	}
+	return fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", a.table, column, columnType)
+}
+
+func (a *sqlAutoSchema) exec(ctx context.Context, db *sql.DB, stmt string) error {
+	if a.dryRun {
+		a.logger.Infof("Dry run enabled, skipping statement: %v", stmt)
+		return nil
+	}
+	a.logger.Infof("Updating schema of table %v: %v", a.table, stmt)
+	_, err := db.ExecContext(ctx, stmt)
+	return err
+}
+
+// sqlTableNotFoundRegexp matches the messages of errors returned by drivers
+// when a queried table does not exist. Drivers do not share a common error type
+// for this and therefore the messages of each are matched instead.
+var sqlTableNotFoundRegexp = regexp.MustCompile(strings.Join([]string{
+	`no such table`,                              // sqlite
+	`table \S+ doesn't exist`,                    // mysql
+	`(relation|table|object) \S+ does not exist`, // postgres, clickhouse and snowflake
+	`unknown_table`,                              // clickhouse
+	`invalid object name`,                        // mssql
+	`ora-00942`,                                  // oracle
+}, "|"))
+
+func isTableNotFoundErr(err error) bool {
+	return sqlTableNotFoundRegexp.MatchString(strings.ToLower(err.Error()))
+}
+
+func (a *sqlAutoSchema) existingColumns(ctx context.Context, db *sql.DB) (map[string]struct{}, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %v WHERE 1 = 0", a.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]struct{}, len(columns))
+	for _, c := range columns {
+		known[strings.ToLower(c)] = struct{}{}
+	}
+	return known, nil
+}
+
+// ensure creates the table when it does not yet exist, or otherwise adds any
+// columns that are missing from it. Column names are compared without case as
+// some databases fold unquoted identifiers.
+func (a *sqlAutoSchema) ensure(ctx context.Context, db *sql.DB, columns []string, rows [][]any) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if a.known == nil {
+		known, err := a.existingColumns(ctx, db)
+		if err != nil {
+			if !isTableNotFoundErr(err) {
+				return fmt.Errorf("failed to query columns of table %v: %w", a.table, err)
+			}
+			a.logger.Debugf("Table %v does not exist: %v", a.table, err)
+			if err := a.exec(ctx, db, a.createTableSQL(columns, rows)); err != nil {
+				return fmt.Errorf("failed to create table %v: %w", a.table, err)
+			}
+			known = make(map[string]struct{}, len(columns))
+			for _, c := range columns {
+				known[strings.ToLower(c)] = struct{}{}
+			}
+		}
+		a.known = known
+	}
+
+	for i, c := range columns {
+		if _, exists := a.known[strings.ToLower(c)]; exists {
+			continue
+		}
+		if err := a.exec(ctx, db, a.addColumnSQL(c, a.columnType(c, i, rows))); err != nil {
+			return fmt.Errorf("failed to add column %v to table %v: %w", c, a.table, err)
+		}
+		a.known[strings.ToLower(c)] = struct{}{}
+	}
+	return nil
+}
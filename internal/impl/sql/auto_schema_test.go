@@ -0,0 +1,305 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSQLAutoSchemaCreateTable(t *testing.T) {
+	columns := []string{"id", "name", "score", "active", "doc", "missing"}
+	rows := [][]any{
+		{json.Number("1"), nil, json.Number("1.5"), true, map[string]any{"a": "b"}, nil},
+		{json.Number("2"), "bar", json.Number("2"), false, nil, nil},
+	}
+
+	tests := []struct {
+		driver     string
+		keyColumns []string
+		expected   string
+	}{
+		{
+			driver:   "postgres",
+			expected: "CREATE TABLE IF NOT EXISTS things (id BIGINT, name TEXT, score DOUBLE PRECISION, active BOOLEAN, doc JSONB, missing TEXT)",
+		},
+		{
+			driver:     "mysql",
+			keyColumns: []string{"name"},
+			expected:   "CREATE TABLE IF NOT EXISTS things (id BIGINT, name VARCHAR(255), score DOUBLE, active BOOLEAN, doc JSON, missing TEXT, PRIMARY KEY (name))",
+		},
+		{
+			driver:   "clickhouse",
+			expected: "CREATE TABLE IF NOT EXISTS things (id Nullable(Int64), name Nullable(String), score Nullable(Float64), active Nullable(Bool), doc Nullable(String), missing Nullable(String)) ENGINE = MergeTree ORDER BY tuple()",
+		},
+		{
+			driver:     "clickhouse",
+			keyColumns: []string{"id"},
+			expected:   "CREATE TABLE IF NOT EXISTS things (id Int64, name Nullable(String), score Nullable(Float64), active Nullable(Bool), doc Nullable(String), missing Nullable(String)) ENGINE = ReplacingMergeTree ORDER BY (id)",
+		},
+		{
+			driver:     "mssql",
+			keyColumns: []string{"id"},
+			expected:   "CREATE TABLE things (id BIGINT, name NVARCHAR(MAX), score FLOAT, active BIT, doc NVARCHAR(MAX), missing NVARCHAR(MAX), PRIMARY KEY (id))",
+		},
+		{
+			driver:   "oracle",
+			expected: "CREATE TABLE things (id NUMBER(19), name CLOB, score BINARY_DOUBLE, active NUMBER(1), doc CLOB, missing CLOB)",
+		},
+		{
+			driver:   "snowflake",
+			expected: "CREATE TABLE IF NOT EXISTS things (id INTEGER, name VARCHAR, score FLOAT, active BOOLEAN, doc VARCHAR, missing VARCHAR)",
+		},
+		{
+			driver:   "sqlite",
+			expected: "CREATE TABLE IF NOT EXISTS things (id INTEGER, name TEXT, score REAL, active BOOLEAN, doc TEXT, missing TEXT)",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(fmt.Sprintf("%v %v", test.driver, test.keyColumns), func(t *testing.T) {
+			a := &sqlAutoSchema{
+				driver:     test.driver,
+				table:      "things",
+				keyColumns: test.keyColumns,
+			}
+			assert.Equal(t, test.expected, a.createTableSQL(columns, rows))
+		})
+	}
+}
+
+func TestSQLAutoSchemaAddColumn(t *testing.T) {
+	for driver, expected := range map[string]string{
+		"postgres": "ALTER TABLE things ADD COLUMN foo TIMESTAMPTZ",
+		"mssql":    "ALTER TABLE things ADD foo DATETIMEOFFSET",
+		"oracle":   "ALTER TABLE things ADD (foo TIMESTAMP WITH TIME ZONE)",
+	} {
+		a := &sqlAutoSchema{driver: driver, table: "things"}
+		assert.Equal(t, expected, a.addColumnSQL("foo", a.columnType("foo", 0, [][]any{{time.Now()}})), driver)
+	}
+
+	a := &sqlAutoSchema{
+		driver:      "postgres",
+		table:       "things",
+		columnTypes: map[string]string{"foo": "VARCHAR(10)"},
+	}
+	assert.Equal(t, "ALTER TABLE things ADD COLUMN foo VARCHAR(10)", a.addColumnSQL("foo", a.columnType("foo", 0, [][]any{{json.Number("10")}})))
+}
+
+func TestSQLObjectRows(t *testing.T) {
+	columns, rows, err := objectRows(service.MessageBatch{
+		service.NewMessage([]byte(`{"b":1,"a":"foo"}`)),
+		service.NewMessage([]byte(`{"c":{"d":true},"a":"bar"}`)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, columns)
+
+	require.NoError(t, normaliseObjectRows(rows))
+	assert.Equal(t, [][]any{
+		{"foo", int64(1), nil},
+		{"bar", nil, `{"d":true}`},
+	}, rows)
+
+	_, _, err = objectRows(service.MessageBatch{
+		service.NewMessage([]byte(`{"a; DROP TABLE foo":1}`)),
+	})
+	require.Error(t, err)
+
+	_, _, err = objectRows(service.MessageBatch{
+		service.NewMessage([]byte(`[1,2,3]`)),
+	})
+	require.Error(t, err)
+}
+
+func TestSQLInsertOutputAutoSchemaSQLite(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dsn := fmt.Sprintf("file:%v/foo.db?_pragma=journal_mode(WAL)", t.TempDir())
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	newOutput := func(dryRun bool) *sqlInsertOutput {
+		t.Helper()
+
+		conf, err := sqlInsertOutputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: things
+auto_schema:
+  enabled: true
+  dry_run: %v
+  column_types:
+    id: TEXT
+`, dsn, dryRun), nil)
+		require.NoError(t, err)
+
+		out, err := newSQLInsertOutputFromConfig(conf, service.MockResources())
+		require.NoError(t, err)
+		require.NoError(t, out.Connect(ctx))
+		t.Cleanup(func() {
+			_ = out.Close(context.Background())
+		})
+		return out
+	}
+
+	dryOut := newOutput(true)
+	require.Error(t, dryOut.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","count":1}`)),
+	}))
+
+	out := newOutput(false)
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","count":1}`)),
+		service.NewMessage([]byte(`{"id":"b","count":2}`)),
+	}))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"c","count":3,"tags":["x","y"]}`)),
+	}))
+
+	rows, err := db.Query(`SELECT id, count, tags FROM things ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var id string
+		var count int
+		var tags sql.NullString
+		require.NoError(t, rows.Scan(&id, &count, &tags))
+		results = append(results, fmt.Sprintf("%v %v %v", id, count, tags.String))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{
+		"a 1 ",
+		"b 2 ",
+		`c 3 ["x","y"]`,
+	}, results)
+
+	colTypes, err := db.Query(`SELECT name, type FROM pragma_table_info('things') ORDER BY cid`)
+	require.NoError(t, err)
+	defer colTypes.Close()
+
+	var types []string
+	for colTypes.Next() {
+		var name, colType string
+		require.NoError(t, colTypes.Scan(&name, &colType))
+		types = append(types, name+" "+colType)
+	}
+	require.NoError(t, colTypes.Err())
+	assert.Equal(t, []string{"count INTEGER", "id TEXT", "tags TEXT"}, types)
+}
+
+func TestSQLTableNotFoundErr(t *testing.T) {
+	for _, msg := range []string{
+		"SQL logic error: no such table: things (1)",
+		"Error 1146 (42S02): Table 'db.things' doesn't exist",
+		`pq: relation "things" does not exist`,
+		"code: 60, message: Table default.things does not exist",
+		"SQL compilation error:\nObject 'THINGS' does not exist or not authorized.",
+		"mssql: Invalid object name 'things'.",
+		"ORA-00942: table or view does not exist",
+	} {
+		assert.True(t, isTableNotFoundErr(errors.New(msg)), msg)
+	}
+	for _, msg := range []string{
+		"sql: database is closed",
+		`pq: database "things" does not exist`,
+		"dial tcp 127.0.0.1:5432: connect: connection refused",
+		`pq: permission denied for table things`,
+	} {
+		assert.False(t, isTableNotFoundErr(errors.New(msg)), msg)
+	}
+}
+
+func TestSQLAutoSchemaQueryError(t *testing.T) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%v/foo.db", t.TempDir()))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	a := &sqlAutoSchema{
+		driver: "sqlite",
+		table:  "things",
+		logger: service.MockResources().Logger(),
+	}
+	err = a.ensure(context.Background(), db, []string{"id"}, [][]any{{"a"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to query columns of table things")
+	assert.Nil(t, a.known)
+}
+
+func TestSQLInsertOutputAutoSchemaUpsertSQLite(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dsn := fmt.Sprintf("file:%v/foo.db?_pragma=journal_mode(WAL)", t.TempDir())
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	conf, err := sqlInsertOutputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: things
+upsert:
+  conflict_columns: [ id ]
+auto_schema:
+  enabled: true
+`, dsn), nil)
+	require.NoError(t, err)
+
+	out, err := newSQLInsertOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(ctx))
+	t.Cleanup(func() {
+		_ = out.Close(context.Background())
+	})
+
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","count":1}`)),
+		service.NewMessage([]byte(`{"id":"b","count":2}`)),
+	}))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"b","count":20,"name":"bar"}`)),
+		service.NewMessage([]byte(`{"id":"c","count":3}`)),
+	}))
+	require.Error(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"count":4}`)),
+	}))
+
+	rows, err := db.Query(`SELECT id, count, name FROM things ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var id string
+		var count int
+		var name sql.NullString
+		require.NoError(t, rows.Scan(&id, &count, &name))
+		results = append(results, fmt.Sprintf("%v %v %v", id, count, name.String))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{
+		"a 1 ",
+		"b 20 bar",
+		"c 3 ",
+	}, results)
+}
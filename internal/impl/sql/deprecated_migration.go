@@ -0,0 +1,49 @@
+package sql
+
+// sqlInsertMigration is a Bloblang snippet shared by the migration rules of the
+// deprecated sql components that converts a query consisting of a single plain
+// insert, where each value is a placeholder in the order of the arguments, into
+// the fields of the sql_insert components.
+const sqlInsertMigration = `
+let insert = this.query.or("").re_find_object("""(?is)^\s*INSERT\s+INTO\s+(?P<table>[\w.]+)\s*\((?P<columns>[^()]*)\)\s*VALUES\s*\((?P<values>[^()]*)\)\s*;?\s*$""")
+let _ = if $insert.table.or("") == "" { throw("the query is not a plain insert") }
+let columns = $insert.columns.split(",").map_each(c -> c.trim())
+let values = $insert.values.split(",").map_each(v -> v.trim())
+let _ = if !$columns.all(c -> c.re_match("^[a-zA-Z_][a-zA-Z0-9_]*$")) { throw("the columns of the query are not plain column names") }
+let _ = if $columns.length() != $values.length() || !$values.enumerated().all(v -> [ "?", "$" + (v.index + 1).string(), ":" + (v.index + 1).string() ].contains(v.value)) {
+  throw("the values of the query are not placeholders in the order of the arguments")
+}
+let _ = if this.args_mapping.or("") == "" { throw("the query has no args_mapping") }
+
+root.driver = this.driver
+root.dsn = this.data_source_name
+root.table = $insert.table
+root.columns = $columns
+root.args_mapping = this.args_mapping
+`
+
+// sqlOutputInsertMigration migrates a sql output config with a plain insert
+// query to sql_insert.
+const sqlOutputInsertMigration = sqlInsertMigration + `
+root.max_in_flight = this.max_in_flight | deleted()
+root.batching = this.batching | deleted()
+`
+
+// sqlOutputRawMigration migrates any other sql output config to sql_raw.
+const sqlOutputRawMigration = `
+root = this.without("data_source_name")
+root.dsn = this.data_source_name
+`
+
+// sqlProcessorInsertMigration migrates a sql processor config with a plain
+// insert query and unused results to sql_insert.
+const sqlProcessorInsertMigration = `
+let _ = if this.result_codec.or("none") != "none" { throw("the results of the query are used") }
+` + sqlInsertMigration
+
+// sqlProcessorRawMigration migrates any other sql processor config to sql_raw.
+const sqlProcessorRawMigration = `
+root = this.without("data_source_name", "result_codec")
+root.dsn = this.data_source_name
+root.exec_only = if this.result_codec.or("none") == "none" { true }
+`
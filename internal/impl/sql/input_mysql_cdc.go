@@ -0,0 +1,1071 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gmysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-sql-driver/mysql"
+	"github.com/siddontang/go-log/log"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func mysqlCDCInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Streams row changes from a MySQL or MariaDB database by reading the binary log.").
+		Description(`
+Connects to a server as a replica and streams row changes from the binary log, which must be enabled with `+"`binlog_format = ROW`"+` and `+"`binlog_row_image = FULL`"+`. The user must have the `+"`REPLICATION SLAVE`, `REPLICATION CLIENT` and `SELECT`"+` privileges, and the `+"`RELOAD`"+` privilege is also required in order to take a snapshot.
+
+Column names and types are obtained from the `+"`information_schema`"+` of the server when a table is first seen and after each schema change. Since the current schema is used to decode events, resuming from a position prior to a schema change of a table can fail to decode the events of that table.
+
+### Delivery Guarantees
+
+The binlog position of the last transaction where all messages, along with the messages of all prior transactions, have been acknowledged is stored within a [cache resource](/docs/components/caches/about), and is used in order to resume from the same position when the input is restarted. Transactions that were not fully acknowledged are therefore read again after a restart, and messages may be delivered more than once.
+
+When `+"`use_gtid`"+` is enabled the GTID set of the last acknowledged transaction is stored along with the binlog position and is used to resume instead, which allows the input to resume from a different server of the same replication topology.
+
+### Snapshots
+
+When `+"`stream_snapshot`"+` is enabled and no checkpoint exists within the cache the existing rows of each table that matches the table filters are emitted with the operation `+"`read`"+` before any changes are streamed. The snapshot is consistent with the binlog position that streaming begins from, and tables are briefly locked with `+"`FLUSH TABLES WITH READ LOCK`"+` while the position is obtained. The snapshot is repeated when the input is restarted before a change has been acknowledged.
+
+### Message Contents
+
+Each message is a JSON object of the form `+"`{\"before\":{...},\"after\":{...}}`"+`, where `+"`before`"+` is the state of the row before an update or delete and `+"`after`"+` is the state of the row after an insert or update.
+
+Integer, float and JSON columns are converted to their JSON equivalents, `+"`ENUM` and `SET`"+` columns are converted to their string values, and decimal, date and time columns are represented as strings. Timestamps are represented in UTC.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- mysql_operation
+- mysql_schema
+- mysql_table
+- mysql_binlog_file
+- mysql_binlog_position
+- mysql_event_timestamp
+`+"```"+`
+
+Where `+"`mysql_operation`"+` is one of `+"`insert`, `update`, `delete` or `read`"+`. The binlog file, position and event timestamp are not set for snapshot messages.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Field(service.NewStringField("dsn").
+			Description("A Data Source Name for the server of the form `[username[:password]@][protocol[(address)]]/dbname[?param1=value1&...&paramN=valueN]`. The user, password and address are also used in order to read the binary log.").
+			Example("foouser:foopassword@tcp(localhost:3306)/foodb")).
+		Field(service.NewStringEnumField("flavor", gmysql.MySQLFlavor, gmysql.MariaDBFlavor).
+			Description("The flavor of the server.").
+			Default(gmysql.MySQLFlavor).
+			Advanced()).
+		Field(service.NewIntField("server_id").
+			Description("A server ID to register as a replica with, which must be unique amongst the servers and replicas of the replication topology.").
+			Default(1000).
+			Advanced()).
+		Field(service.NewStringListField("include_tables").
+			Description("A list of regular expressions matched against the schema qualified name of each table, e.g. `foodb.users`, where only tables that match at least one expression are consumed. When empty all tables are consumed.").
+			Default([]any{}).
+			Example([]any{`^foodb\.(users|orders)$`})).
+		Field(service.NewStringListField("exclude_tables").
+			Description("A list of regular expressions matched against the schema qualified name of each table, where tables that match any expression are not consumed.").
+			Default([]any{}).
+			Example([]any{`^foodb\.tmp_`})).
+		Field(service.NewBoolField("stream_snapshot").
+			Description("Whether to emit the existing rows of each table before streaming changes when no checkpoint exists within the cache.").
+			Default(false)).
+		Field(service.NewBoolField("use_gtid").
+			Description("Whether to resume from the GTID set of the last acknowledged transaction rather than its binlog position, which requires GTIDs to be enabled on the server.").
+			Default(false).
+			Advanced()).
+		Field(service.NewStringField("checkpoint_cache").
+			Description("A [cache resource](/docs/components/caches/about) to store the binlog position of the last acknowledged transaction in.")).
+		Field(service.NewStringField("checkpoint_key").
+			Description("The key to store the binlog position of the last acknowledged transaction under.").
+			Default("mysql_cdc_position").
+			Advanced()).
+		Example("Stream Changes", `
+Stream changes to the tables `+"`users` and `orders`"+`, including the rows that already exist, and write them to Kafka topics named after each table:`,
+			`
+input:
+  mysql_cdc:
+    dsn: foouser:foopassword@tcp(localhost:3306)/foodb
+    include_tables: [ '^foodb\.(users|orders)$' ]
+    stream_snapshot: true
+    checkpoint_cache: checkpoints
+
+cache_resources:
+  - label: checkpoints
+    redis:
+      url: redis://localhost:6379
+
+output:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topic: 'cdc.${! @mysql_table }'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"mysql_cdc", mysqlCDCInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newMySQLCDCInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// mysqlBinlogPosition is the position of a transaction within the binary log,
+// which is stored as a checkpoint.
+type mysqlBinlogPosition struct {
+	File     string `json:"file"`
+	Position uint32 `json:"position"`
+	GTIDSet  string `json:"gtid_set,omitempty"`
+
+	seq uint64
+}
+
+type mysqlCDCMessage struct {
+	msg   *service.Message
+	ackFn service.AckFunc
+}
+
+type mysqlCDCInput struct {
+	dsn            string
+	connConf       *mysql.Config
+	flavor         string
+	serverID       uint32
+	includeTables  []*regexp.Regexp
+	excludeTables  []*regexp.Regexp
+	streamSnapshot bool
+	useGTID        bool
+	cacheName      string
+	cacheKey       string
+
+	connMut sync.Mutex
+	db      *sql.DB
+	msgChan chan mysqlCDCMessage
+	schemas map[string]*mysqlTableSchema
+
+	cpMut       sync.Mutex
+	checkpoints *checkpoint.Uncapped[mysqlBinlogPosition]
+
+	storeMut  sync.Mutex
+	storedSeq uint64
+
+	mgr     *service.Resources
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newMySQLCDCInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*mysqlCDCInput, error) {
+	m := &mysqlCDCInput{
+		mgr:     mgr,
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if m.dsn, err = conf.FieldString("dsn"); err != nil {
+		return nil, err
+	}
+	if m.connConf, err = mysql.ParseDSN(m.dsn); err != nil {
+		return nil, fmt.Errorf("failed to parse dsn: %w", err)
+	}
+	if m.flavor, err = conf.FieldString("flavor"); err != nil {
+		return nil, err
+	}
+
+	serverID, err := conf.FieldInt("server_id")
+	if err != nil {
+		return nil, err
+	}
+	if serverID <= 0 {
+		return nil, errors.New("server_id must be greater than zero")
+	}
+	m.serverID = uint32(serverID)
+
+	if m.includeTables, err = regexpListFromParsed(conf, "include_tables"); err != nil {
+		return nil, err
+	}
+	if m.excludeTables, err = regexpListFromParsed(conf, "exclude_tables"); err != nil {
+		return nil, err
+	}
+	if m.streamSnapshot, err = conf.FieldBool("stream_snapshot"); err != nil {
+		return nil, err
+	}
+	if m.useGTID, err = conf.FieldBool("use_gtid"); err != nil {
+		return nil, err
+	}
+	if m.cacheName, err = conf.FieldString("checkpoint_cache"); err != nil {
+		return nil, err
+	}
+	if !mgr.HasCache(m.cacheName) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", m.cacheName)
+	}
+	if m.cacheKey, err = conf.FieldString("checkpoint_key"); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func regexpListFromParsed(conf *service.ParsedConfig, field string) ([]*regexp.Regexp, error) {
+	patterns, err := conf.FieldStringList(field)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %v pattern '%v': %w", field, p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func (m *mysqlCDCInput) tableIncluded(schema, table string) bool {
+	name := schema + "." + table
+	for _, re := range m.excludeTables {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(m.includeTables) == 0 {
+		return true
+	}
+	for _, re := range m.includeTables {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mysqlCDCInput) Connect(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.msgChan != nil {
+		return nil
+	}
+
+	if m.db == nil {
+		db, err := sqlOpenWithReworks(m.log, "mysql", m.dsn)
+		if err != nil {
+			return err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			_ = db.Close()
+			return err
+		}
+		m.db = db
+	}
+
+	pos, exists, err := m.loadCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	var snapshotConn *sql.Conn
+	if !exists {
+		if m.streamSnapshot {
+			if snapshotConn, pos, err = m.beginSnapshot(ctx); err != nil {
+				return err
+			}
+		} else if pos, err = m.currentPosition(ctx, m.db); err != nil {
+			return err
+		}
+	}
+
+	m.cpMut.Lock()
+	m.checkpoints = checkpoint.NewUncapped[mysqlBinlogPosition]()
+	m.cpMut.Unlock()
+
+	// Sequence numbers of new checkpoints must follow those already stored.
+	m.storeMut.Lock()
+	pos.seq = m.storedSeq
+	m.storeMut.Unlock()
+
+	m.schemas = map[string]*mysqlTableSchema{}
+	syncer := replication.NewBinlogSyncer(m.syncerConfig())
+
+	msgChan := make(chan mysqlCDCMessage)
+	go m.loop(snapshotConn, pos, syncer, msgChan)
+
+	m.msgChan = msgChan
+	return nil
+}
+
+func (m *mysqlCDCInput) syncerConfig() replication.BinlogSyncerConfig {
+	host, portStr, err := net.SplitHostPort(m.connConf.Addr)
+	if err != nil {
+		host, portStr = m.connConf.Addr, "3306"
+	}
+	port, _ := strconv.ParseUint(portStr, 10, 16)
+
+	return replication.BinlogSyncerConfig{
+		ServerID:                m.serverID,
+		Flavor:                  m.flavor,
+		Host:                    host,
+		Port:                    uint16(port),
+		User:                    m.connConf.User,
+		Password:                m.connConf.Passwd,
+		TimestampStringLocation: time.UTC,
+		HeartbeatPeriod:         time.Second * 30,
+		ReadTimeout:             time.Minute,
+		Logger:                  log.NewDefault(&binlogLogHandler{log: m.log}),
+	}
+}
+
+// binlogLogHandler forwards the logs of the binlog syncer at the debug level.
+type binlogLogHandler struct {
+	log *service.Logger
+}
+
+func (b *binlogLogHandler) Write(p []byte) (int, error) {
+	b.log.Debugf("%s", strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (b *binlogLogHandler) Close() error {
+	return nil
+}
+
+// mysqlQueryer is implemented by both database handles and connections.
+type mysqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// currentPosition returns the current position of the binary log of the
+// server.
+func (m *mysqlCDCInput) currentPosition(ctx context.Context, q mysqlQueryer) (pos mysqlBinlogPosition, err error) {
+	rows, err := q.QueryContext(ctx, "SHOW MASTER STATUS")
+	if err != nil {
+		return pos, fmt.Errorf("failed to obtain binlog position: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return pos, err
+	}
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = errors.New("binary logging is not enabled on the server")
+		}
+		return pos, fmt.Errorf("failed to obtain binlog position: %w", err)
+	}
+
+	values := make([]sql.NullString, len(cols))
+	dests := make([]any, len(cols))
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	if err = rows.Scan(dests...); err != nil {
+		return pos, err
+	}
+
+	pos.File = values[0].String
+	position, err := strconv.ParseUint(values[1].String, 10, 32)
+	if err != nil {
+		return pos, fmt.Errorf("failed to parse binlog position: %w", err)
+	}
+	pos.Position = uint32(position)
+	if err = rows.Close(); err != nil {
+		return pos, err
+	}
+
+	if m.useGTID {
+		query := "SELECT @@GLOBAL.gtid_executed"
+		if m.flavor == gmysql.MariaDBFlavor {
+			query = "SELECT @@GLOBAL.gtid_current_pos"
+		}
+		gtidRows, err := q.QueryContext(ctx, query)
+		if err != nil {
+			return pos, fmt.Errorf("failed to obtain GTID set: %w", err)
+		}
+		defer gtidRows.Close()
+		if gtidRows.Next() {
+			if err = gtidRows.Scan(&pos.GTIDSet); err != nil {
+				return pos, err
+			}
+		}
+		if err = gtidRows.Err(); err != nil {
+			return pos, err
+		}
+	}
+	return pos, nil
+}
+
+// beginSnapshot opens a connection with a transaction that has a consistent
+// snapshot of the database, and returns the binlog position of the snapshot.
+func (m *mysqlCDCInput) beginSnapshot(ctx context.Context) (*sql.Conn, mysqlBinlogPosition, error) {
+	var pos mysqlBinlogPosition
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, pos, err
+	}
+
+	if err = func() error {
+		for _, stmt := range []string{
+			"SET time_zone = '+00:00'",
+			"FLUSH TABLES WITH READ LOCK",
+			"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+			"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+		} {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to begin snapshot: %w", err)
+			}
+		}
+		pos, err = m.currentPosition(ctx, conn)
+		_, unlockErr := conn.ExecContext(ctx, "UNLOCK TABLES")
+		if err != nil {
+			return err
+		}
+		return unlockErr
+	}(); err != nil {
+		_ = conn.Close()
+		return nil, pos, err
+	}
+	return conn, pos, nil
+}
+
+// loop streams the snapshot, if any, followed by the changes of the binary log
+// until the input is closed or an error occurs.
+func (m *mysqlCDCInput) loop(snapshotConn *sql.Conn, pos mysqlBinlogPosition, syncer *replication.BinlogSyncer, msgChan chan mysqlCDCMessage) {
+	ctx, done := m.shutSig.CloseNowCtx(context.Background())
+	defer func() {
+		done()
+		syncer.Close()
+
+		m.connMut.Lock()
+		if m.msgChan == msgChan {
+			m.msgChan = nil
+		}
+		close(msgChan)
+		m.connMut.Unlock()
+	}()
+
+	if snapshotConn != nil {
+		err := m.streamSnapshotRows(ctx, snapshotConn, msgChan)
+		_ = snapshotConn.Close()
+		if err != nil {
+			if ctx.Err() == nil {
+				m.log.Errorf("Failed to stream snapshot: %v", err)
+			}
+			return
+		}
+	}
+
+	var streamer *replication.BinlogStreamer
+	var err error
+	if m.useGTID && pos.GTIDSet != "" {
+		var gset gmysql.GTIDSet
+		if gset, err = gmysql.ParseGTIDSet(m.flavor, pos.GTIDSet); err != nil {
+			m.log.Errorf("Failed to parse GTID set: %v", err)
+			return
+		}
+		streamer, err = syncer.StartSyncGTID(gset)
+	} else {
+		streamer, err = syncer.StartSync(gmysql.Position{Name: pos.File, Pos: pos.Position})
+	}
+	if err != nil {
+		m.log.Errorf("Failed to start binlog sync: %v", err)
+		return
+	}
+
+	if err := m.streamChanges(ctx, streamer, pos, msgChan); err != nil && ctx.Err() == nil {
+		m.log.Errorf("Binlog stream failed: %v", err)
+	}
+}
+
+func (m *mysqlCDCInput) streamSnapshotRows(ctx context.Context, conn *sql.Conn, msgChan chan mysqlCDCMessage) error {
+	tableRows, err := conn.QueryContext(ctx, `SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES
+WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+ORDER BY TABLE_SCHEMA, TABLE_NAME`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	var tables [][2]string
+	for tableRows.Next() {
+		var schema, table string
+		if err := tableRows.Scan(&schema, &table); err != nil {
+			_ = tableRows.Close()
+			return err
+		}
+		if m.tableIncluded(schema, table) {
+			tables = append(tables, [2]string{schema, table})
+		}
+	}
+	if err := tableRows.Err(); err != nil {
+		return err
+	}
+	_ = tableRows.Close()
+
+	for _, table := range tables {
+		m.log.Debugf("Streaming snapshot of table %v.%v", table[0], table[1])
+
+		tableSchema, err := m.getTableSchema(ctx, conn, table[0], table[1])
+		if err != nil {
+			return err
+		}
+		if err := m.streamSnapshotTable(ctx, conn, tableSchema, table[0], table[1], msgChan); err != nil {
+			return err
+		}
+	}
+
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+func (m *mysqlCDCInput) streamSnapshotTable(ctx context.Context, conn *sql.Conn, tableSchema *mysqlTableSchema, schema, table string, msgChan chan mysqlCDCMessage) error {
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %v.%v", quoteMySQLIdentifier(schema), quoteMySQLIdentifier(table)))
+	if err != nil {
+		return fmt.Errorf("failed to read table %v.%v: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	colNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]sql.RawBytes, len(colNames))
+	dests := make([]any, len(colNames))
+	for i := range values {
+		dests[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dests...); err != nil {
+			return err
+		}
+
+		after := make(map[string]any, len(colNames))
+		for i, name := range colNames {
+			col := tableSchema.column(name)
+			if after[name], err = col.textValue(values[i]); err != nil {
+				return fmt.Errorf("failed to decode column %v: %w", name, err)
+			}
+		}
+
+		msg := mysqlChangeMessage("read", schema, table, nil, after)
+		select {
+		case msgChan <- mysqlCDCMessage{
+			msg: msg,
+			ackFn: func(ctx context.Context, err error) error {
+				return nil
+			},
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
+
+func (m *mysqlCDCInput) streamChanges(ctx context.Context, streamer *replication.BinlogStreamer, pos mysqlBinlogPosition, msgChan chan mysqlCDCMessage) error {
+	lastCommit := pos
+	currentFile := pos.File
+
+	for {
+		ev, err := streamer.GetEvent(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch t := ev.Event.(type) {
+		case *replication.RotateEvent:
+			currentFile = string(t.NextLogName)
+		case *replication.XIDEvent:
+			lastCommit = m.commit(currentFile, ev.Header.LogPos, t.GSet, lastCommit)
+		case *replication.QueryEvent:
+			if string(t.Query) == "BEGIN" {
+				continue
+			}
+			// Any other statement may have changed the schema of a table.
+			m.schemas = map[string]*mysqlTableSchema{}
+			lastCommit = m.commit(currentFile, ev.Header.LogPos, t.GSet, lastCommit)
+		case *replication.RowsEvent:
+			schema, table := string(t.Table.Schema), string(t.Table.Table)
+			if !m.tableIncluded(schema, table) {
+				continue
+			}
+
+			tableSchema, err := m.getTableSchema(ctx, m.db, schema, table)
+			if err != nil {
+				return err
+			}
+
+			events, err := rowsEventToChanges(ev.Header.EventType, tableSchema, t.Rows)
+			if err != nil {
+				return fmt.Errorf("failed to decode rows of table %v.%v: %w", schema, table, err)
+			}
+
+			for _, change := range events {
+				msg := mysqlChangeMessage(change.operation, schema, table, change.before, change.after)
+				msg.MetaSetMut("mysql_binlog_file", currentFile)
+				msg.MetaSetMut("mysql_binlog_position", strconv.FormatUint(uint64(ev.Header.LogPos), 10))
+				msg.MetaSetMut("mysql_event_timestamp", time.Unix(int64(ev.Header.Timestamp), 0).UTC().Format(time.RFC3339))
+
+				m.cpMut.Lock()
+				resolveFn := m.checkpoints.Track(lastCommit, 1)
+				m.cpMut.Unlock()
+
+				select {
+				case msgChan <- mysqlCDCMessage{
+					msg: msg,
+					ackFn: func(ctx context.Context, err error) error {
+						m.cpMut.Lock()
+						highest := resolveFn()
+						m.cpMut.Unlock()
+						if highest == nil {
+							return nil
+						}
+						return m.storeCheckpoint(ctx, *highest)
+					},
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// commit tracks the position of a committed transaction, which becomes the
+// checkpoint once all prior messages have been acknowledged.
+func (m *mysqlCDCInput) commit(file string, logPos uint32, gset gmysql.GTIDSet, prev mysqlBinlogPosition) mysqlBinlogPosition {
+	pos := mysqlBinlogPosition{
+		File:     file,
+		Position: logPos,
+		seq:      prev.seq + 1,
+	}
+	if gset != nil {
+		pos.GTIDSet = gset.String()
+	}
+
+	m.cpMut.Lock()
+	_ = m.checkpoints.Track(pos, 1)()
+	m.cpMut.Unlock()
+	return pos
+}
+
+func (m *mysqlCDCInput) loadCheckpoint(ctx context.Context) (pos mysqlBinlogPosition, exists bool, err error) {
+	var cached []byte
+	var getErr error
+	if err = m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		cached, getErr = c.Get(ctx, m.cacheKey)
+	}); err != nil {
+		err = fmt.Errorf("failed to access checkpoint cache: %w", err)
+		return
+	}
+	if getErr != nil {
+		if !errors.Is(getErr, service.ErrKeyNotFound) {
+			err = fmt.Errorf("failed to get checkpoint: %w", getErr)
+		}
+		return
+	}
+	if err = json.Unmarshal(cached, &pos); err != nil {
+		err = fmt.Errorf("failed to parse checkpoint: %w", err)
+		return
+	}
+	exists = true
+	return
+}
+
+func (m *mysqlCDCInput) storeCheckpoint(ctx context.Context, pos mysqlBinlogPosition) error {
+	m.storeMut.Lock()
+	defer m.storeMut.Unlock()
+
+	// Acknowledgements can arrive out of order, and therefore a checkpoint
+	// older than the one already stored is ignored.
+	if pos.seq <= m.storedSeq {
+		return nil
+	}
+
+	posBytes, err := json.Marshal(pos)
+	if err != nil {
+		return fmt.Errorf("failed to serialise checkpoint: %w", err)
+	}
+
+	var setErr error
+	if err := m.mgr.AccessCache(ctx, m.cacheName, func(c service.Cache) {
+		setErr = c.Set(ctx, m.cacheKey, posBytes, nil)
+	}); err != nil {
+		return fmt.Errorf("failed to access checkpoint cache: %w", err)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", setErr)
+	}
+	m.storedSeq = pos.seq
+	return nil
+}
+
+func (m *mysqlCDCInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	m.connMut.Lock()
+	msgChan := m.msgChan
+	m.connMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case msg, open := <-msgChan:
+		if !open {
+			return nil, nil, service.ErrNotConnected
+		}
+		return msg.msg, msg.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (m *mysqlCDCInput) Close(ctx context.Context) error {
+	m.shutSig.CloseNow()
+
+	m.connMut.Lock()
+	msgChan := m.msgChan
+	m.connMut.Unlock()
+
+	if msgChan != nil {
+		// Wait for the stream loop to exit, which closes the channel.
+	drainLoop:
+		for {
+			select {
+			case _, open := <-msgChan:
+				if !open {
+					break drainLoop
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+	if m.db != nil {
+		err := m.db.Close()
+		m.db = nil
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type mysqlChange struct {
+	operation string
+	before    map[string]any
+	after     map[string]any
+}
+
+func mysqlChangeMessage(operation, schema, table string, before, after map[string]any) *service.Message {
+	var beforeV, afterV any
+	if before != nil {
+		beforeV = before
+	}
+	if after != nil {
+		afterV = after
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructuredMut(map[string]any{
+		"before": beforeV,
+		"after":  afterV,
+	})
+	msg.MetaSetMut("mysql_operation", operation)
+	msg.MetaSetMut("mysql_schema", schema)
+	msg.MetaSetMut("mysql_table", table)
+	return msg
+}
+
+// rowsEventToChanges converts the rows of a binlog rows event into changes,
+// where the rows of update events are pairs of before and after images.
+func rowsEventToChanges(eventType replication.EventType, tableSchema *mysqlTableSchema, rows [][]any) ([]mysqlChange, error) {
+	var changes []mysqlChange
+	switch eventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		for _, row := range rows {
+			after, err := tableSchema.rowToMap(row)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, mysqlChange{operation: "insert", after: after})
+		}
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		for i := 0; i+1 < len(rows); i += 2 {
+			before, err := tableSchema.rowToMap(rows[i])
+			if err != nil {
+				return nil, err
+			}
+			after, err := tableSchema.rowToMap(rows[i+1])
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, mysqlChange{operation: "update", before: before, after: after})
+		}
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		for _, row := range rows {
+			before, err := tableSchema.rowToMap(row)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, mysqlChange{operation: "delete", before: before})
+		}
+	}
+	return changes, nil
+}
+
+//------------------------------------------------------------------------------
+
+type mysqlColumn struct {
+	name     string
+	dataType string
+	unsigned bool
+	values   []string
+}
+
+type mysqlTableSchema struct {
+	columns []*mysqlColumn
+}
+
+func (t *mysqlTableSchema) column(name string) *mysqlColumn {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c
+		}
+	}
+	return &mysqlColumn{name: name}
+}
+
+func (t *mysqlTableSchema) rowToMap(row []any) (map[string]any, error) {
+	if len(row) != len(t.columns) {
+		return nil, fmt.Errorf("row has %v columns, expected %v", len(row), len(t.columns))
+	}
+	values := make(map[string]any, len(row))
+	for i, v := range row {
+		values[t.columns[i].name] = t.columns[i].binlogValue(v)
+	}
+	return values, nil
+}
+
+func (m *mysqlCDCInput) getTableSchema(ctx context.Context, q mysqlQueryer, schema, table string) (*mysqlTableSchema, error) {
+	key := schema + "." + table
+	if s, exists := m.schemas[key]; exists {
+		return s, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain schema of table %v: %w", key, err)
+	}
+	defer rows.Close()
+
+	s := &mysqlTableSchema{}
+	for rows.Next() {
+		var name, dataType, columnType string
+		if err := rows.Scan(&name, &dataType, &columnType); err != nil {
+			return nil, err
+		}
+		s.columns = append(s.columns, newMySQLColumn(name, dataType, columnType))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	m.schemas[key] = s
+	return s, nil
+}
+
+func newMySQLColumn(name, dataType, columnType string) *mysqlColumn {
+	c := &mysqlColumn{
+		name:     name,
+		dataType: strings.ToLower(dataType),
+		unsigned: strings.Contains(strings.ToLower(columnType), "unsigned"),
+	}
+	if c.dataType == "enum" || c.dataType == "set" {
+		c.values = parseEnumValues(columnType)
+	}
+	return c
+}
+
+// parseEnumValues extracts the values of an ENUM or SET column type of the
+// form enum('a','b').
+func parseEnumValues(columnType string) []string {
+	start, end := strings.Index(columnType, "("), strings.LastIndex(columnType, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var values []string
+	var current strings.Builder
+	inQuote := false
+	list := columnType[start+1 : end]
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(list) && list[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case c == '\'':
+			if inQuote {
+				values = append(values, current.String())
+				current.Reset()
+			}
+			inQuote = !inQuote
+		case inQuote:
+			current.WriteByte(c)
+		}
+	}
+	return values
+}
+
+func (c *mysqlColumn) isBinary() bool {
+	switch c.dataType {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "geometry":
+		return true
+	}
+	return false
+}
+
+func (c *mysqlColumn) isText() bool {
+	switch c.dataType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return true
+	}
+	return false
+}
+
+// binlogValue converts a value decoded from a binlog rows event, which does not
+// carry the signedness of integers or the names of enum and set values.
+func (c *mysqlColumn) binlogValue(v any) any {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case int8:
+		if c.unsigned {
+			return uint64(uint8(t))
+		}
+		return int64(t)
+	case int16:
+		if c.unsigned {
+			return uint64(uint16(t))
+		}
+		return int64(t)
+	case int32:
+		if c.unsigned {
+			if c.dataType == "mediumint" {
+				return uint64(uint32(t) & 0xFFFFFF)
+			}
+			return uint64(uint32(t))
+		}
+		return int64(t)
+	case int64:
+		switch c.dataType {
+		case "enum":
+			if t > 0 && int(t) <= len(c.values) {
+				return c.values[t-1]
+			}
+			return ""
+		case "set":
+			var members []string
+			for i, value := range c.values {
+				if t&(1<<uint(i)) != 0 {
+					members = append(members, value)
+				}
+			}
+			return strings.Join(members, ",")
+		}
+		if c.unsigned {
+			return uint64(t)
+		}
+		return t
+	case float32:
+		return float64(t)
+	case string:
+		if c.isBinary() {
+			return []byte(t)
+		}
+		return t
+	case []byte:
+		if c.dataType == "json" {
+			var jv any
+			if err := json.Unmarshal(t, &jv); err == nil {
+				return jv
+			}
+			return string(t)
+		}
+		if c.isText() {
+			return string(t)
+		}
+		return t
+	}
+	return v
+}
+
+// textValue converts a value read in the text format of the server, as is the
+// case for snapshots.
+func (c *mysqlColumn) textValue(b []byte) (any, error) {
+	if b == nil {
+		return nil, nil
+	}
+	switch c.dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		if c.unsigned {
+			return strconv.ParseUint(string(b), 10, 64)
+		}
+		return strconv.ParseInt(string(b), 10, 64)
+	case "float", "double", "real":
+		return strconv.ParseFloat(string(b), 64)
+	case "json":
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case "bit":
+		var v int64
+		for _, c := range b {
+			v = v<<8 | int64(c)
+		}
+		return v, nil
+	}
+	if c.isBinary() {
+		return append([]byte(nil), b...), nil
+	}
+	return string(b), nil
+}
+
+func quoteMySQLIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
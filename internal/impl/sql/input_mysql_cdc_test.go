@@ -0,0 +1,191 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestMySQLCDCParseEnumValues(t *testing.T) {
+	assert.Equal(t, []string{"a", "b c", "d'e"}, parseEnumValues(`enum('a','b c','d''e')`))
+	assert.Equal(t, []string{"x", "y"}, parseEnumValues(`set('x','y')`))
+	assert.Nil(t, parseEnumValues(`int`))
+}
+
+func TestMySQLCDCBinlogValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataType   string
+		columnType string
+		input      any
+		output     any
+	}{
+		{name: "signed tinyint", dataType: "tinyint", columnType: "tinyint(4)", input: int8(-1), output: int64(-1)},
+		{name: "unsigned tinyint", dataType: "tinyint", columnType: "tinyint(3) unsigned", input: int8(-1), output: uint64(255)},
+		{name: "unsigned mediumint", dataType: "mediumint", columnType: "mediumint unsigned", input: int32(-1), output: uint64(16777215)},
+		{name: "unsigned int", dataType: "int", columnType: "int unsigned", input: int32(-1), output: uint64(4294967295)},
+		{name: "bigint", dataType: "bigint", columnType: "bigint", input: int64(-5), output: int64(-5)},
+		{name: "float", dataType: "float", columnType: "float", input: float32(1.5), output: 1.5},
+		{name: "enum", dataType: "enum", columnType: "enum('a','b')", input: int64(2), output: "b"},
+		{name: "empty enum", dataType: "enum", columnType: "enum('a','b')", input: int64(0), output: ""},
+		{name: "set", dataType: "set", columnType: "set('a','b','c')", input: int64(5), output: "a,c"},
+		{name: "json", dataType: "json", columnType: "json", input: []byte(`{"a":[1]}`), output: map[string]any{"a": []any{float64(1)}}},
+		{name: "text", dataType: "text", columnType: "text", input: []byte("hello"), output: "hello"},
+		{name: "blob", dataType: "blob", columnType: "blob", input: []byte("hello"), output: []byte("hello")},
+		{name: "varbinary", dataType: "varbinary", columnType: "varbinary(10)", input: "hello", output: []byte("hello")},
+		{name: "null", dataType: "int", columnType: "int", input: nil, output: nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			col := newMySQLColumn("foo", test.dataType, test.columnType)
+			assert.Equal(t, test.output, col.binlogValue(test.input))
+		})
+	}
+}
+
+func TestMySQLCDCTextValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataType   string
+		columnType string
+		input      []byte
+		output     any
+	}{
+		{name: "int", dataType: "int", columnType: "int", input: []byte("-5"), output: int64(-5)},
+		{name: "unsigned bigint", dataType: "bigint", columnType: "bigint unsigned", input: []byte("18446744073709551615"), output: uint64(18446744073709551615)},
+		{name: "double", dataType: "double", columnType: "double", input: []byte("1.5"), output: 1.5},
+		{name: "decimal", dataType: "decimal", columnType: "decimal(10,2)", input: []byte("1.50"), output: "1.50"},
+		{name: "json", dataType: "json", columnType: "json", input: []byte(`{"a":"b"}`), output: map[string]any{"a": "b"}},
+		{name: "bit", dataType: "bit", columnType: "bit(16)", input: []byte{0x01, 0x02}, output: int64(258)},
+		{name: "blob", dataType: "blob", columnType: "blob", input: []byte("hello"), output: []byte("hello")},
+		{name: "enum", dataType: "enum", columnType: "enum('a','b')", input: []byte("b"), output: "b"},
+		{name: "null", dataType: "varchar", columnType: "varchar(10)", input: nil, output: nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			col := newMySQLColumn("foo", test.dataType, test.columnType)
+			v, err := col.textValue(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.output, v)
+		})
+	}
+}
+
+func TestMySQLCDCRowsEventToChanges(t *testing.T) {
+	schema := &mysqlTableSchema{columns: []*mysqlColumn{
+		newMySQLColumn("id", "int", "int"),
+		newMySQLColumn("name", "varchar", "varchar(50)"),
+	}}
+
+	changes, err := rowsEventToChanges(replication.WRITE_ROWS_EVENTv2, schema, [][]any{
+		{int32(1), "foo"},
+		{int32(2), "bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []mysqlChange{
+		{operation: "insert", after: map[string]any{"id": int64(1), "name": "foo"}},
+		{operation: "insert", after: map[string]any{"id": int64(2), "name": "bar"}},
+	}, changes)
+
+	changes, err = rowsEventToChanges(replication.UPDATE_ROWS_EVENTv2, schema, [][]any{
+		{int32(1), "foo"},
+		{int32(1), "baz"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []mysqlChange{
+		{
+			operation: "update",
+			before:    map[string]any{"id": int64(1), "name": "foo"},
+			after:     map[string]any{"id": int64(1), "name": "baz"},
+		},
+	}, changes)
+
+	changes, err = rowsEventToChanges(replication.DELETE_ROWS_EVENTv2, schema, [][]any{
+		{int32(2), "bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []mysqlChange{
+		{operation: "delete", before: map[string]any{"id": int64(2), "name": "bar"}},
+	}, changes)
+
+	_, err = rowsEventToChanges(replication.WRITE_ROWS_EVENTv2, schema, [][]any{{int32(1)}})
+	require.Error(t, err)
+
+	msg := mysqlChangeMessage("delete", "foodb", "users", changes[0].before, changes[0].after)
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"after":null,"before":{"id":2,"name":"bar"}}`, string(b))
+
+	v, _ := msg.MetaGet("mysql_operation")
+	assert.Equal(t, "delete", v)
+	v, _ = msg.MetaGet("mysql_schema")
+	assert.Equal(t, "foodb", v)
+	v, _ = msg.MetaGet("mysql_table")
+	assert.Equal(t, "users", v)
+}
+
+func testMySQLCDCInput(t *testing.T, conf string) *mysqlCDCInput {
+	t.Helper()
+
+	pConf, err := mysqlCDCInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	m, err := newMySQLCDCInputFromConfig(pConf, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+	require.NoError(t, err)
+	return m
+}
+
+func TestMySQLCDCTableFilters(t *testing.T) {
+	m := testMySQLCDCInput(t, `
+dsn: foouser:foopass@tcp(localhost:3306)/foodb
+checkpoint_cache: foocache
+include_tables: [ '^foodb\.' ]
+exclude_tables: [ '^foodb\.tmp_' ]
+`)
+
+	assert.True(t, m.tableIncluded("foodb", "users"))
+	assert.False(t, m.tableIncluded("foodb", "tmp_users"))
+	assert.False(t, m.tableIncluded("bardb", "users"))
+
+	assert.Equal(t, "localhost", m.syncerConfig().Host)
+	assert.Equal(t, uint16(3306), m.syncerConfig().Port)
+	assert.Equal(t, "foouser", m.syncerConfig().User)
+}
+
+func TestMySQLCDCCheckpoints(t *testing.T) {
+	m := testMySQLCDCInput(t, `
+dsn: foouser:foopass@tcp(localhost:3306)/foodb
+checkpoint_cache: foocache
+`)
+	ctx := context.Background()
+
+	_, exists, err := m.loadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, m.storeCheckpoint(ctx, mysqlBinlogPosition{File: "binlog.000002", Position: 20, seq: 2}))
+	require.NoError(t, m.storeCheckpoint(ctx, mysqlBinlogPosition{File: "binlog.000001", Position: 10, seq: 1}))
+
+	pos, exists, err := m.loadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, mysqlBinlogPosition{File: "binlog.000002", Position: 20}, pos)
+
+	var stored map[string]any
+	require.NoError(t, m.mgr.AccessCache(ctx, "foocache", func(c service.Cache) {
+		b, err := c.Get(ctx, "mysql_cdc_position")
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, &stored))
+	}))
+	assert.Equal(t, map[string]any{"file": "binlog.000002", "position": float64(20)}, stored)
+}
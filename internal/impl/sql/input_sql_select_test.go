@@ -2,9 +2,15 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
 
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -29,3 +35,132 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, selectInput.Close(context.Background()))
 }
+
+func TestSQLSelectInputPolling(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dsn := fmt.Sprintf("file:%v/foo.db?_pragma=journal_mode(WAL)", t.TempDir())
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`CREATE TABLE things (id integer not null primary key, name varchar(50) not null)`)
+	require.NoError(t, err)
+
+	insertRows := func(ids ...int) {
+		t.Helper()
+		for _, id := range ids {
+			_, err := db.Exec(`INSERT INTO things (id, name) VALUES (?, ?)`, id, fmt.Sprintf("name%v", id))
+			require.NoError(t, err)
+		}
+	}
+	insertRows(1, 2, 3)
+
+	mgr := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+
+	newInput := func() *sqlSelectInput {
+		t.Helper()
+
+		conf, err := sqlSelectInputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: things
+columns: [ id, name ]
+where: name != ?
+args_mapping: 'root = [ "name2" ]'
+polling:
+  enabled: true
+  tracking_column: id
+  interval: 10ms
+  cache: foocache
+`, dsn), nil)
+		require.NoError(t, err)
+
+		i, err := newSQLSelectInputFromConfig(conf, mgr)
+		require.NoError(t, err)
+		require.NoError(t, i.Connect(tCtx))
+		return i
+	}
+
+	readRows := func(i *sqlSelectInput, n int) (rows []string, ackFns []service.AckFunc) {
+		t.Helper()
+		for len(rows) < n {
+			msg, ackFn, err := i.Read(tCtx)
+			require.NoError(t, err)
+
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+
+			rows = append(rows, string(b))
+			ackFns = append(ackFns, ackFn)
+		}
+		return
+	}
+
+	getCheckpoint := func() string {
+		t.Helper()
+
+		var b []byte
+		var getErr error
+		require.NoError(t, mgr.AccessCache(tCtx, "foocache", func(c service.Cache) {
+			b, getErr = c.Get(tCtx, "sql_select_things_id")
+		}))
+		if errors.Is(getErr, service.ErrKeyNotFound) {
+			return ""
+		}
+		require.NoError(t, getErr)
+		return string(b)
+	}
+
+	input := newInput()
+
+	rows, ackFns := readRows(input, 2)
+	assert.Equal(t, []string{`{"id":1,"name":"name1"}`, `{"id":3,"name":"name3"}`}, rows)
+
+	insertRows(4, 5)
+
+	moreRows, moreAckFns := readRows(input, 2)
+	assert.Equal(t, []string{`{"id":4,"name":"name4"}`, `{"id":5,"name":"name5"}`}, moreRows)
+
+	// The checkpoint only advances once all prior rows are acknowledged.
+	require.NoError(t, ackFns[1](tCtx, nil))
+	require.NoError(t, moreAckFns[0](tCtx, nil))
+	assert.Equal(t, "", getCheckpoint())
+
+	require.NoError(t, ackFns[0](tCtx, nil))
+	assert.Equal(t, "4", getCheckpoint())
+
+	require.NoError(t, input.Close(tCtx))
+
+	// A new input resumes from the checkpoint.
+	input = newInput()
+	t.Cleanup(func() {
+		_ = input.Close(context.Background())
+	})
+
+	rows, _ = readRows(input, 1)
+	assert.Equal(t, []string{`{"id":5,"name":"name5"}`}, rows)
+}
+
+func TestSQLSelectCheckpointFromBytes(t *testing.T) {
+	ts, err := time.Parse(time.RFC3339Nano, "2023-01-02T03:04:05.123456Z")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		input  string
+		output any
+	}{
+		{input: `5`, output: int64(5)},
+		{input: `5.5`, output: 5.5},
+		{input: `"foo"`, output: "foo"},
+		{input: `foo bar`, output: "foo bar"},
+		{input: `"2023-01-02T03:04:05.123456Z"`, output: ts},
+		{input: `2023-01-02T03:04:05.123456Z`, output: ts},
+	} {
+		assert.Equal(t, test.output, checkpointFromBytes([]byte(test.input)), test.input)
+	}
+}
@@ -780,6 +780,134 @@ func TestIntegrationMySQL(t *testing.T) {
 	testSuite(t, "mysql", dsn, createTable)
 }
 
+func TestIntegrationMySQLCDC(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+	pool.MaxWait = 3 * time.Minute
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   "mysql",
+		Tag:          "8.0",
+		ExposedPorts: []string{"3306/tcp"},
+		Cmd: []string{
+			"--binlog-format=ROW",
+			"--gtid-mode=ON",
+			"--enforce-gtid-consistency=ON",
+		},
+		Env: []string{
+			"MYSQL_ROOT_PASSWORD=testpass",
+			"MYSQL_DATABASE=testdb",
+		},
+	})
+	require.NoError(t, err)
+
+	var db *sql.DB
+	t.Cleanup(func() {
+		if err = pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %s", err)
+		}
+		if db != nil {
+			db.Close()
+		}
+	})
+
+	dsn := fmt.Sprintf("root:testpass@tcp(localhost:%s)/testdb", resource.GetPort("3306/tcp"))
+	require.NoError(t, pool.Retry(func() error {
+		if db, err = sql.Open("mysql", dsn); err != nil {
+			return err
+		}
+		if err = db.Ping(); err != nil {
+			db.Close()
+			db = nil
+			return err
+		}
+		return nil
+	}))
+
+	_, err = db.Exec("CREATE TABLE users (id INT UNSIGNED PRIMARY KEY, name VARCHAR(50), role ENUM('admin','user'), attrs JSON)")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE ignored (id INT PRIMARY KEY)")
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO users VALUES (1, 'foo', 'admin', '{"a":1}')`)
+	require.NoError(t, err)
+
+	runStream := func(t *testing.T) (<-chan string, func()) {
+		t.Helper()
+
+		streamBuilder := service.NewStreamBuilder()
+		require.NoError(t, streamBuilder.SetLoggerYAML(`level: OFF`))
+		require.NoError(t, streamBuilder.AddCacheYAML(`
+label: checkpoints
+memory: {}
+`))
+		require.NoError(t, streamBuilder.AddInputYAML(fmt.Sprintf(`
+mysql_cdc:
+  dsn: %v
+  include_tables: [ '^testdb\.users$' ]
+  stream_snapshot: true
+  use_gtid: true
+  checkpoint_cache: checkpoints
+`, dsn)))
+
+		msgChan := make(chan string)
+		require.NoError(t, streamBuilder.AddConsumerFunc(func(ctx context.Context, msg *service.Message) error {
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			op, _ := msg.MetaGet("mysql_operation")
+			select {
+			case msgChan <- op + " " + string(b):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		}))
+
+		stream, err := streamBuilder.Build()
+		require.NoError(t, err)
+
+		go func() {
+			_ = stream.Run(context.Background())
+		}()
+		return msgChan, func() {
+			require.NoError(t, stream.StopWithin(15*time.Second))
+		}
+	}
+
+	readMsg := func(t *testing.T, msgChan <-chan string) string {
+		t.Helper()
+		select {
+		case m := <-msgChan:
+			return m
+		case <-time.After(time.Minute):
+			t.Fatal("timed out waiting for message")
+		}
+		return ""
+	}
+
+	msgChan, stop := runStream(t)
+	defer stop()
+
+	assert.Equal(t, `read {"after":{"attrs":{"a":1},"id":1,"name":"foo","role":"admin"},"before":null}`, readMsg(t, msgChan))
+
+	_, err = db.Exec("INSERT INTO ignored VALUES (1)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users VALUES (4294967295, 'bar', 'user', NULL)")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE users SET name = 'baz' WHERE id = 1")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users WHERE id = 4294967295")
+	require.NoError(t, err)
+
+	assert.Equal(t, `insert {"after":{"attrs":null,"id":4294967295,"name":"bar","role":"user"},"before":null}`, readMsg(t, msgChan))
+	assert.Equal(t, `update {"after":{"attrs":{"a":1},"id":1,"name":"baz","role":"admin"},"before":{"attrs":{"a":1},"id":1,"name":"foo","role":"admin"}}`, readMsg(t, msgChan))
+	assert.Equal(t, `delete {"after":null,"before":{"attrs":null,"id":4294967295,"name":"bar","role":"user"}}`, readMsg(t, msgChan))
+}
+
 func TestIntegrationMSSQL(t *testing.T) {
 	integration.CheckSkip(t)
 	t.Parallel()
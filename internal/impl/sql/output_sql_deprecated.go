@@ -6,7 +6,7 @@ import (
 )
 
 func sqlDeprecatedOutputConfig() *service.ConfigSpec {
-	return service.NewConfigSpec().
+	spec := service.NewConfigSpec().
 		Deprecated().
 		Categories("Services").
 		Summary("Executes an arbitrary SQL query for each message.").
@@ -28,6 +28,12 @@ For basic inserts use the ` + "[`sql_insert`](/docs/components/outputs/sql)" + `
 			Default(64)).
 		Field(service.NewBatchPolicyField("batching")).
 		Version("3.65.0")
+
+	// Configs with plain insert queries are migrated to sql_insert, and all
+	// others to sql_raw.
+	return spec.
+		MigrationRule("sql_insert", sqlOutputInsertMigration).
+		MigrationRule("sql_raw", sqlOutputRawMigration)
 }
 
 func init() {
@@ -2,9 +2,14 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
 
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -28,3 +33,105 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, insertOutput.Close(context.Background()))
 }
+
+func TestSQLInsertOutputUpsertSQLite(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dsn := fmt.Sprintf("file:%v/foo.db?_pragma=journal_mode(WAL)", t.TempDir())
+
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = db.Exec(`CREATE TABLE things (id integer not null primary key, name varchar(50) not null, count integer not null)`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO things (id, name, count) VALUES (1, 'first', 1), (2, 'second', 2)`)
+	require.NoError(t, err)
+
+	conf, err := sqlInsertOutputConfig().ParseYAML(fmt.Sprintf(`
+driver: sqlite
+dsn: %v
+table: things
+columns: [ id, name, count ]
+args_mapping: 'root = [ this.id, this.name, this.count ]'
+upsert:
+  conflict_columns: [ id ]
+  update_columns: [ count ]
+`, dsn), nil)
+	require.NoError(t, err)
+
+	out, err := newSQLInsertOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(ctx))
+	t.Cleanup(func() {
+		_ = out.Close(context.Background())
+	})
+
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":2,"name":"changed","count":20}`)),
+		service.NewMessage([]byte(`{"id":3,"name":"third","count":3}`)),
+		service.NewMessage([]byte(`{"id":3,"name":"third","count":30}`)),
+	}))
+
+	rows, err := db.Query(`SELECT id, name, count FROM things ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var results []string
+	for rows.Next() {
+		var id, count int
+		var name string
+		require.NoError(t, rows.Scan(&id, &name, &count))
+		results = append(results, fmt.Sprintf("%v %v %v", id, name, count))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{
+		"1 first 1",
+		"2 second 20",
+		"3 third 30",
+	}, results)
+}
+
+func TestSQLInsertOutputUpsertConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+	}{
+		{
+			name: "with suffix",
+			conf: `
+suffix: ON CONFLICT (foo) DO NOTHING
+upsert:
+  conflict_columns: [ foo ]
+`,
+		},
+		{
+			name: "unknown conflict column",
+			conf: `
+upsert:
+  conflict_columns: [ bar ]
+`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := sqlInsertOutputConfig().ParseYAML(`
+driver: postgres
+dsn: woof
+table: quack
+columns: [ foo ]
+args_mapping: 'root = [ this.id ]'
+`+test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newSQLInsertOutputFromConfig(conf, service.MockResources())
+			require.Error(t, err)
+		})
+	}
+}
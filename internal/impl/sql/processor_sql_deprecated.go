@@ -7,7 +7,7 @@ import (
 
 // DeprecatedProcessorConfig returns a config spec for an sql processor.
 func DeprecatedProcessorConfig() *service.ConfigSpec {
-	return service.NewConfigSpec().
+	spec := service.NewConfigSpec().
 		Deprecated().
 		Categories("Integration").
 		Summary("Runs an arbitrary SQL query against a database and (optionally) returns the result as an array of objects, one for each row returned.").
@@ -35,6 +35,12 @@ For basic inserts or select queries use use either the ` + "[`sql_insert`](/docs
 			Default("none")).
 		Version("3.65.0")
 	// TODO: Add example
+
+	// Configs with plain insert queries and unused results are migrated to
+	// sql_insert, and all others to sql_raw.
+	return spec.
+		MigrationRule("sql_insert", sqlProcessorInsertMigration).
+		MigrationRule("sql_raw", sqlProcessorRawMigration)
 }
 
 func init() {
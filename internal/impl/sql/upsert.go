@@ -0,0 +1,266 @@
+package sql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// sqlUpsert describes how rows that conflict with existing rows of a table are
+// written, and produces the statements for each driver.
+type sqlUpsert struct {
+	driver          string
+	table           string
+	columns         []string
+	conflictColumns []string
+	updateColumns   []string
+
+	conflictIndexes []int
+}
+
+// sqlUpsertConfig holds the configured columns of an upsert, from which the
+// statements are built once the inserted columns are known.
+type sqlUpsertConfig struct {
+	driver          string
+	table           string
+	conflictColumns []string
+	updateColumns   []string
+}
+
+// upsertConfigFromParsed returns nil when no conflict columns are configured,
+// as parsed optional objects are populated even when absent from a config.
+func upsertConfigFromParsed(conf *service.ParsedConfig, driver, table string) (*sqlUpsertConfig, error) {
+	conflictColumns, err := conf.FieldStringList("conflict_columns")
+	if err != nil {
+		return nil, err
+	}
+	updateColumns, err := conf.FieldStringList("update_columns")
+	if err != nil {
+		return nil, err
+	}
+	if len(conflictColumns) == 0 {
+		if len(updateColumns) > 0 {
+			return nil, errors.New("update columns were specified without any conflict columns")
+		}
+		return nil, nil
+	}
+	return &sqlUpsertConfig{
+		driver:          driver,
+		table:           table,
+		conflictColumns: conflictColumns,
+		updateColumns:   updateColumns,
+	}, nil
+}
+
+// forColumns returns an upsert of rows consisting of the given columns, which
+// must include the conflict columns and any update columns.
+func (c *sqlUpsertConfig) forColumns(columns []string) (*sqlUpsert, error) {
+	return newSQLUpsert(c.driver, c.table, columns, c.conflictColumns, c.updateColumns)
+}
+
+func newSQLUpsert(driver, table string, columns, conflictColumns, updateColumns []string) (*sqlUpsert, error) {
+	if len(conflictColumns) == 0 {
+		return nil, errors.New("at least one conflict column must be specified")
+	}
+
+	u := &sqlUpsert{
+		driver:          driver,
+		table:           table,
+		columns:         columns,
+		conflictColumns: conflictColumns,
+	}
+
+	colIndexes := make(map[string]int, len(columns))
+	for i, c := range columns {
+		colIndexes[c] = i
+	}
+
+	isConflictCol := map[string]bool{}
+	for _, c := range conflictColumns {
+		i, exists := colIndexes[c]
+		if !exists {
+			return nil, fmt.Errorf("conflict column %v is not one of the inserted columns", c)
+		}
+		isConflictCol[c] = true
+		u.conflictIndexes = append(u.conflictIndexes, i)
+	}
+
+	if len(updateColumns) == 0 {
+		for _, c := range columns {
+			if !isConflictCol[c] {
+				u.updateColumns = append(u.updateColumns, c)
+			}
+		}
+	} else {
+		for _, c := range updateColumns {
+			if _, exists := colIndexes[c]; !exists {
+				return nil, fmt.Errorf("update column %v is not one of the inserted columns", c)
+			}
+			if isConflictCol[c] {
+				return nil, fmt.Errorf("update column %v cannot also be a conflict column", c)
+			}
+		}
+		u.updateColumns = updateColumns
+	}
+	return u, nil
+}
+
+// usesMerge returns true if the driver expresses upserts with a MERGE
+// statement rather than a suffix to an INSERT statement.
+func (u *sqlUpsert) usesMerge() bool {
+	switch u.driver {
+	case "mssql", "oracle", "snowflake":
+		return true
+	}
+	return false
+}
+
+// insertSuffix returns the suffix to add to INSERT statements for drivers that
+// support upserts within INSERT statements.
+func (u *sqlUpsert) insertSuffix() string {
+	switch u.driver {
+	case "postgres", "sqlite":
+		if len(u.updateColumns) == 0 {
+			return fmt.Sprintf("ON CONFLICT (%v) DO NOTHING", strings.Join(u.conflictColumns, ", "))
+		}
+		sets := make([]string, 0, len(u.updateColumns))
+		for _, c := range u.updateColumns {
+			sets = append(sets, fmt.Sprintf("%v = EXCLUDED.%v", c, c))
+		}
+		return fmt.Sprintf("ON CONFLICT (%v) DO UPDATE SET %v", strings.Join(u.conflictColumns, ", "), strings.Join(sets, ", "))
+	case "mysql":
+		// Assigning a conflict column to itself leaves the existing row
+		// unchanged without ignoring other errors as INSERT IGNORE would.
+		if len(u.updateColumns) == 0 {
+			return fmt.Sprintf("ON DUPLICATE KEY UPDATE %v = %v", u.conflictColumns[0], u.conflictColumns[0])
+		}
+		sets := make([]string, 0, len(u.updateColumns))
+		for _, c := range u.updateColumns {
+			sets = append(sets, fmt.Sprintf("%v = VALUES(%v)", c, c))
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+	// ClickHouse tables using the ReplacingMergeTree engine deduplicate rows
+	// by their sorting key in the background, and therefore rows are inserted
+	// as they are.
+	return ""
+}
+
+// mergeSQL returns a MERGE statement for the given number of rows, using
+// question mark placeholders for each column of each row.
+func (u *sqlUpsert) mergeSQL(rows int) string {
+	var source string
+	switch u.driver {
+	case "oracle":
+		selects := make([]string, 0, rows)
+		for r := 0; r < rows; r++ {
+			cols := make([]string, 0, len(u.columns))
+			for _, c := range u.columns {
+				cols = append(cols, "? AS "+c)
+			}
+			selects = append(selects, "SELECT "+strings.Join(cols, ", ")+" FROM dual")
+		}
+		source = "(" + strings.Join(selects, " UNION ALL ") + ") source"
+	case "snowflake":
+		cols := make([]string, 0, len(u.columns))
+		for i, c := range u.columns {
+			cols = append(cols, fmt.Sprintf("column%v AS %v", i+1, c))
+		}
+		source = fmt.Sprintf("(SELECT %v FROM VALUES %v) AS source", strings.Join(cols, ", "), u.valuesList(rows))
+	default:
+		source = fmt.Sprintf("(VALUES %v) AS source (%v)", u.valuesList(rows), strings.Join(u.columns, ", "))
+	}
+
+	targetAlias := "AS target"
+	if u.driver == "oracle" {
+		// Oracle does not support AS for table aliases.
+		targetAlias = "target"
+	}
+
+	conds := make([]string, 0, len(u.conflictColumns))
+	for _, c := range u.conflictColumns {
+		conds = append(conds, fmt.Sprintf("target.%v = source.%v", c, c))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "MERGE INTO %v %v USING %v ON (%v)", u.table, targetAlias, source, strings.Join(conds, " AND "))
+	if len(u.updateColumns) > 0 {
+		sets := make([]string, 0, len(u.updateColumns))
+		for _, c := range u.updateColumns {
+			sets = append(sets, fmt.Sprintf("target.%v = source.%v", c, c))
+		}
+		fmt.Fprintf(&b, " WHEN MATCHED THEN UPDATE SET %v", strings.Join(sets, ", "))
+	}
+
+	sourceCols := make([]string, 0, len(u.columns))
+	for _, c := range u.columns {
+		sourceCols = append(sourceCols, "source."+c)
+	}
+	fmt.Fprintf(&b, " WHEN NOT MATCHED THEN INSERT (%v) VALUES (%v)", strings.Join(u.columns, ", "), strings.Join(sourceCols, ", "))
+	if u.driver == "mssql" {
+		// SQL Server requires MERGE statements to be terminated.
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+func (u *sqlUpsert) valuesList(rows int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(u.columns)), ", ") + ")"
+	rowList := make([]string, rows)
+	for i := range rowList {
+		rowList[i] = row
+	}
+	return strings.Join(rowList, ", ")
+}
+
+// mergeQuery returns a MERGE statement and its arguments for a batch of rows.
+func (u *sqlUpsert) mergeQuery(placeholder squirrel.PlaceholderFormat, rows [][]any) (string, []any, error) {
+	args := make([]any, 0, len(rows)*len(u.columns))
+	for _, row := range rows {
+		args = append(args, row...)
+	}
+	query, err := placeholder.ReplacePlaceholders(u.mergeSQL(len(rows)))
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+// dedupe removes rows that share conflict column values with a later row of
+// the same batch, since most databases reject statements that would modify the
+// same row more than once. The order of the remaining rows is preserved.
+func (u *sqlUpsert) dedupe(rows [][]any) ([][]any, error) {
+	keys := make([]string, len(rows))
+	lastIndex := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if len(row) != len(u.columns) {
+			return nil, fmt.Errorf("mapping returned %v values, expected %v", len(row), len(u.columns))
+		}
+		keyValues := make([]any, 0, len(u.conflictIndexes))
+		for _, ci := range u.conflictIndexes {
+			keyValues = append(keyValues, row[ci])
+		}
+		keyBytes, err := json.Marshal(keyValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialise conflict column values: %w", err)
+		}
+		keys[i] = string(keyBytes)
+		lastIndex[keys[i]] = i
+	}
+	if len(lastIndex) == len(rows) {
+		return rows, nil
+	}
+
+	deduped := make([][]any, 0, len(lastIndex))
+	for i, row := range rows {
+		if lastIndex[keys[i]] == i {
+			deduped = append(deduped, row)
+		}
+	}
+	return deduped, nil
+}
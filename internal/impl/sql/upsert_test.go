@@ -0,0 +1,174 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLUpsertValidation(t *testing.T) {
+	columns := []string{"id", "name", "age"}
+
+	_, err := newSQLUpsert("postgres", "people", columns, nil, nil)
+	require.Error(t, err)
+
+	_, err = newSQLUpsert("postgres", "people", columns, []string{"nope"}, nil)
+	require.Error(t, err)
+
+	_, err = newSQLUpsert("postgres", "people", columns, []string{"id"}, []string{"nope"})
+	require.Error(t, err)
+
+	_, err = newSQLUpsert("postgres", "people", columns, []string{"id"}, []string{"id"})
+	require.Error(t, err)
+
+	u, err := newSQLUpsert("postgres", "people", columns, []string{"id"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "age"}, u.updateColumns)
+}
+
+func TestSQLUpsertInsertSuffix(t *testing.T) {
+	tests := []struct {
+		name            string
+		driver          string
+		conflictColumns []string
+		updateColumns   []string
+		expected        string
+	}{
+		{
+			name:            "postgres",
+			driver:          "postgres",
+			conflictColumns: []string{"id"},
+			expected:        "ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, age = EXCLUDED.age",
+		},
+		{
+			name:            "sqlite update columns",
+			driver:          "sqlite",
+			conflictColumns: []string{"id"},
+			updateColumns:   []string{"age"},
+			expected:        "ON CONFLICT (id) DO UPDATE SET age = EXCLUDED.age",
+		},
+		{
+			name:            "postgres nothing to update",
+			driver:          "postgres",
+			conflictColumns: []string{"id", "name", "age"},
+			expected:        "ON CONFLICT (id, name, age) DO NOTHING",
+		},
+		{
+			name:            "mysql",
+			driver:          "mysql",
+			conflictColumns: []string{"id"},
+			expected:        "ON DUPLICATE KEY UPDATE name = VALUES(name), age = VALUES(age)",
+		},
+		{
+			name:            "mysql nothing to update",
+			driver:          "mysql",
+			conflictColumns: []string{"id", "name", "age"},
+			expected:        "ON DUPLICATE KEY UPDATE id = id",
+		},
+		{
+			name:            "clickhouse",
+			driver:          "clickhouse",
+			conflictColumns: []string{"id"},
+			expected:        "",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			u, err := newSQLUpsert(test.driver, "people", []string{"id", "name", "age"}, test.conflictColumns, test.updateColumns)
+			require.NoError(t, err)
+			assert.False(t, u.usesMerge())
+			assert.Equal(t, test.expected, u.insertSuffix())
+		})
+	}
+}
+
+func TestSQLUpsertMergeQuery(t *testing.T) {
+	tests := []struct {
+		name            string
+		driver          string
+		placeholder     squirrel.PlaceholderFormat
+		conflictColumns []string
+		rows            int
+		expected        string
+	}{
+		{
+			name:            "mssql",
+			driver:          "mssql",
+			placeholder:     squirrel.Question,
+			conflictColumns: []string{"id"},
+			rows:            2,
+			expected:        "MERGE INTO people AS target USING (VALUES (?, ?), (?, ?)) AS source (id, name) ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET target.name = source.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name);",
+		},
+		{
+			name:            "snowflake",
+			driver:          "snowflake",
+			placeholder:     squirrel.Question,
+			conflictColumns: []string{"id"},
+			rows:            2,
+			expected:        "MERGE INTO people AS target USING (SELECT column1 AS id, column2 AS name FROM VALUES (?, ?), (?, ?)) AS source ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET target.name = source.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name)",
+		},
+		{
+			name:            "oracle",
+			driver:          "oracle",
+			placeholder:     squirrel.Colon,
+			conflictColumns: []string{"id"},
+			rows:            1,
+			expected:        "MERGE INTO people target USING (SELECT :1 AS id, :2 AS name FROM dual) source ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET target.name = source.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name)",
+		},
+		{
+			name:            "oracle nothing to update",
+			driver:          "oracle",
+			placeholder:     squirrel.Colon,
+			conflictColumns: []string{"id", "name"},
+			rows:            1,
+			expected:        "MERGE INTO people target USING (SELECT :1 AS id, :2 AS name FROM dual) source ON (target.id = source.id AND target.name = source.name) WHEN NOT MATCHED THEN INSERT (id, name) VALUES (source.id, source.name)",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			u, err := newSQLUpsert(test.driver, "people", []string{"id", "name"}, test.conflictColumns, nil)
+			require.NoError(t, err)
+			assert.True(t, u.usesMerge())
+
+			rows := make([][]any, test.rows)
+			var expectedArgs []any
+			for i := range rows {
+				rows[i] = []any{i, "foo"}
+				expectedArgs = append(expectedArgs, i, "foo")
+			}
+
+			query, args, err := u.mergeQuery(test.placeholder, rows)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, query)
+			assert.Equal(t, expectedArgs, args)
+		})
+	}
+}
+
+func TestSQLUpsertDedupe(t *testing.T) {
+	u, err := newSQLUpsert("postgres", "people", []string{"id", "region", "name"}, []string{"id", "region"}, nil)
+	require.NoError(t, err)
+
+	rows, err := u.dedupe([][]any{
+		{1, "eu", "a"},
+		{2, "eu", "b"},
+		{1, "us", "c"},
+		{1, "eu", "d"},
+		{2, "eu", "e"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{
+		{1, "us", "c"},
+		{1, "eu", "d"},
+		{2, "eu", "e"},
+	}, rows)
+
+	_, err = u.dedupe([][]any{{1, "eu"}})
+	require.Error(t, err)
+}
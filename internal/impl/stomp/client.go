@@ -0,0 +1,183 @@
+package stomp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/go-stomp/stomp/v3"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func connectionFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField("address").
+			Description("The address of the STOMP server to connect to.").
+			Example("localhost:61613"),
+		service.NewObjectField("auth",
+			service.NewStringField("login").
+				Description("A login to authenticate with, leave empty in order to connect without authentication.").
+				Default(""),
+			service.NewStringField("passcode").
+				Description("A passcode to authenticate with.").
+				Default("").
+				Secret(),
+		).
+			Description("Optional credentials to provide when connecting to the server.").
+			Advanced(),
+		service.NewStringField("host").
+			Description("The virtual host to connect to, when empty the host of the address is used.").
+			Default("").
+			Advanced(),
+		service.NewObjectField("heartbeat",
+			service.NewDurationField("send").
+				Description("The interval at which heartbeats are sent to the server, set to `0s` in order to disable outgoing heartbeats.").
+				Default("1m"),
+			service.NewDurationField("receive").
+				Description("The interval at which heartbeats are expected from the server, set to `0s` in order to disable incoming heartbeats.").
+				Default("1m"),
+		).
+			Description("Heartbeats negotiated with the server, which are used in order to detect broken connections.").
+			Advanced(),
+		service.NewTLSToggledField("tls"),
+	}
+}
+
+type stompConnDetails struct {
+	address string
+	tlsConf *tls.Config
+	opts    []func(*stomp.Conn) error
+}
+
+func connDetailsFromParsed(conf *service.ParsedConfig, log *service.Logger) (*stompConnDetails, error) {
+	d := &stompConnDetails{}
+
+	var err error
+	if d.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+
+	var tlsEnabled bool
+	if d.tlsConf, tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+	if !tlsEnabled {
+		d.tlsConf = nil
+	}
+
+	d.opts = append(d.opts, stomp.ConnOpt.Logger(&stompLogger{log: log}))
+
+	login, err := conf.FieldString("auth", "login")
+	if err != nil {
+		return nil, err
+	}
+	passcode, err := conf.FieldString("auth", "passcode")
+	if err != nil {
+		return nil, err
+	}
+	if login != "" {
+		d.opts = append(d.opts, stomp.ConnOpt.Login(login, passcode))
+	}
+
+	host, err := conf.FieldString("host")
+	if err != nil {
+		return nil, err
+	}
+	if host != "" {
+		d.opts = append(d.opts, stomp.ConnOpt.Host(host))
+	}
+
+	heartbeatSend, err := conf.FieldDuration("heartbeat", "send")
+	if err != nil {
+		return nil, err
+	}
+	heartbeatReceive, err := conf.FieldDuration("heartbeat", "receive")
+	if err != nil {
+		return nil, err
+	}
+	d.opts = append(d.opts, stomp.ConnOpt.HeartBeat(heartbeatSend, heartbeatReceive))
+	return d, nil
+}
+
+func (d *stompConnDetails) connect(ctx context.Context) (*stomp.Conn, error) {
+	var netConn net.Conn
+	var err error
+	if d.tlsConf != nil {
+		dialer := &tls.Dialer{Config: d.tlsConf}
+		netConn, err = dialer.DialContext(ctx, "tcp", d.address)
+	} else {
+		var dialer net.Dialer
+		netConn, err = dialer.DialContext(ctx, "tcp", d.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The handshake blocks until the server responds, therefore it is bound
+	// by the deadline of the context, which is cleared once connected.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := netConn.SetDeadline(deadline); err != nil {
+			_ = netConn.Close()
+			return nil, err
+		}
+	}
+
+	// The client only sets the host automatically when it dials the connection
+	// itself, therefore we replicate that behaviour here whilst allowing it to
+	// be overridden by the configured host.
+	opts := d.opts
+	if host, _, err := net.SplitHostPort(d.address); err == nil {
+		opts = append([]func(*stomp.Conn) error{stomp.ConnOpt.Host(host)}, opts...)
+	}
+
+	conn, err := stomp.Connect(netConn, opts...)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.MustDisconnect()
+		return nil, err
+	}
+	return conn, nil
+}
+
+//------------------------------------------------------------------------------
+
+type stompLogger struct {
+	log *service.Logger
+}
+
+func (s *stompLogger) Debugf(format string, value ...any) {
+	s.log.Debugf(format, value...)
+}
+
+func (s *stompLogger) Infof(format string, value ...any) {
+	s.log.Debugf(format, value...)
+}
+
+func (s *stompLogger) Warningf(format string, value ...any) {
+	s.log.Warnf(format, value...)
+}
+
+func (s *stompLogger) Errorf(format string, value ...any) {
+	s.log.Errorf(format, value...)
+}
+
+func (s *stompLogger) Debug(message string) {
+	s.log.Debug(message)
+}
+
+func (s *stompLogger) Info(message string) {
+	s.log.Debug(message)
+}
+
+func (s *stompLogger) Warning(message string) {
+	s.log.Warn(message)
+}
+
+func (s *stompLogger) Error(message string) {
+	s.log.Error(message)
+}
@@ -0,0 +1,271 @@
+package stomp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-stomp/stomp/v3"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	ackModeAuto             = "auto"
+	ackModeClient           = "client"
+	ackModeClientIndividual = "client-individual"
+)
+
+func stompInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Subscribes to a destination of a STOMP server.").
+		Description(`
+### Acknowledgements
+
+The ` + "`ack_mode`" + ` determines how messages are acknowledged with the server. With the ` + "`auto`" + ` mode messages are considered delivered as soon as they are sent by the server, and therefore messages can be lost when they fail to be processed.
+
+With the ` + "`client-individual`" + ` mode each message is acknowledged once it has been processed by the pipeline and delivered by all outputs, and a message that is rejected is negatively acknowledged and redelivered by the server.
+
+With the ` + "`client`" + ` mode acknowledgements are cumulative and therefore messages are acknowledged in the order that they were received once all prior messages have also been processed. When a message is rejected all subsequent messages that were resolved at the same time are negatively acknowledged along with it, and may therefore be delivered more than once.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- All headers of the message
+` + "```" + `
+
+Headers include ` + "`destination`, `message-id`, `subscription` and `content-type`" + ` along with any custom headers set by the producer.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Fields(connectionFields()...).
+		Field(service.NewStringField("destination").
+			Description("The destination to subscribe to.").
+			Example("/queue/benthos").
+			Example("/topic/foo")).
+		Field(service.NewStringAnnotatedEnumField("ack_mode", map[string]string{
+			ackModeAuto:             "Messages are considered delivered by the server once they have been sent.",
+			ackModeClient:           "Messages are acknowledged cumulatively in the order in which they were received.",
+			ackModeClientIndividual: "Each message is acknowledged individually once it has been processed.",
+		}).
+			Description("The acknowledgement mode of the subscription.").
+			Default(ackModeClientIndividual))
+}
+
+func init() {
+	err := service.RegisterInput(
+		"stomp", stompInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newStompReaderFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type stompReader struct {
+	details     *stompConnDetails
+	destination string
+	ackMode     stomp.AckMode
+	cumulative  bool
+
+	connMut sync.Mutex
+	conn    *stomp.Conn
+	sub     *stomp.Subscription
+	tracker *cumulativeAcks
+
+	log *service.Logger
+}
+
+func newStompReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*stompReader, error) {
+	r := &stompReader{log: log}
+
+	var err error
+	if r.details, err = connDetailsFromParsed(conf, log); err != nil {
+		return nil, err
+	}
+	if r.destination, err = conf.FieldString("destination"); err != nil {
+		return nil, err
+	}
+
+	ackMode, err := conf.FieldString("ack_mode")
+	if err != nil {
+		return nil, err
+	}
+	switch ackMode {
+	case ackModeAuto:
+		r.ackMode = stomp.AckAuto
+	case ackModeClient:
+		r.ackMode = stomp.AckClient
+		r.cumulative = true
+	case ackModeClientIndividual:
+		r.ackMode = stomp.AckClientIndividual
+	default:
+		return nil, fmt.Errorf("ack mode %v not recognised", ackMode)
+	}
+	return r, nil
+}
+
+func (r *stompReader) Connect(ctx context.Context) error {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.conn != nil {
+		return nil
+	}
+
+	conn, err := r.details.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	sub, err := conn.Subscribe(r.destination, r.ackMode)
+	if err != nil {
+		_ = conn.Disconnect()
+		return err
+	}
+
+	r.conn = conn
+	r.sub = sub
+	if r.cumulative {
+		r.tracker = &cumulativeAcks{ack: conn.Ack, nack: conn.Nack}
+	}
+	r.log.Infof("Receiving STOMP messages from destination: %v", r.destination)
+	return nil
+}
+
+func (r *stompReader) disconnect() {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.conn == nil {
+		return
+	}
+	if err := r.conn.Disconnect(); err != nil {
+		r.log.Debugf("Failed to disconnect cleanly: %v", err)
+	}
+	r.conn = nil
+	r.sub = nil
+	r.tracker = nil
+}
+
+func (r *stompReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	r.connMut.Lock()
+	conn, sub, tracker := r.conn, r.sub, r.tracker
+	r.connMut.Unlock()
+
+	if sub == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var sMsg *stomp.Message
+	var open bool
+	select {
+	case sMsg, open = <-sub.C:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	if !open {
+		r.disconnect()
+		return nil, nil, service.ErrNotConnected
+	}
+	if sMsg.Err != nil {
+		r.log.Errorf("Subscription error: %v", sMsg.Err)
+		r.disconnect()
+		return nil, nil, service.ErrNotConnected
+	}
+
+	msg := service.NewMessage(sMsg.Body)
+	if sMsg.Header != nil {
+		for i := 0; i < sMsg.Header.Len(); i++ {
+			k, v := sMsg.Header.GetAt(i)
+			msg.MetaSetMut(k, v)
+		}
+	}
+
+	if !sMsg.ShouldAck() {
+		return msg, func(ctx context.Context, res error) error {
+			return nil
+		}, nil
+	}
+
+	if tracker != nil {
+		entry := tracker.add(sMsg)
+		return msg, func(ctx context.Context, res error) error {
+			return tracker.resolve(entry, res)
+		}, nil
+	}
+
+	return msg, func(ctx context.Context, res error) error {
+		if res != nil {
+			return conn.Nack(sMsg)
+		}
+		return conn.Ack(sMsg)
+	}, nil
+}
+
+func (r *stompReader) Close(ctx context.Context) error {
+	r.disconnect()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type pendingAck struct {
+	msg      *stomp.Message
+	resolved bool
+	err      error
+}
+
+// cumulativeAcks tracks messages of a subscription with cumulative
+// acknowledgements, where acknowledging a message also acknowledges all prior
+// messages, and therefore acknowledgements are only sent once all prior
+// messages have been resolved.
+type cumulativeAcks struct {
+	ack  func(*stomp.Message) error
+	nack func(*stomp.Message) error
+
+	mut     sync.Mutex
+	pending []*pendingAck
+}
+
+func (c *cumulativeAcks) add(msg *stomp.Message) *pendingAck {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	p := &pendingAck{msg: msg}
+	c.pending = append(c.pending, p)
+	return p
+}
+
+func (c *cumulativeAcks) resolve(p *pendingAck, res error) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	p.resolved = true
+	p.err = res
+
+	var ackUpTo, nackUpTo *stomp.Message
+	i := 0
+	for ; i < len(c.pending) && c.pending[i].resolved; i++ {
+		if c.pending[i].err != nil || nackUpTo != nil {
+			nackUpTo = c.pending[i].msg
+		} else {
+			ackUpTo = c.pending[i].msg
+		}
+	}
+	c.pending = c.pending[i:]
+
+	if ackUpTo != nil {
+		if err := c.ack(ackUpTo); err != nil {
+			return err
+		}
+	}
+	if nackUpTo != nil {
+		return c.nack(nackUpTo)
+	}
+	return nil
+}
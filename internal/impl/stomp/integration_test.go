@@ -0,0 +1,84 @@
+package stomp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-stomp/stomp/v3"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationStomp(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 60
+	resource, err := pool.Run("rmohr/activemq", "5.15.9", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		conn, err := stomp.Dial("tcp", "localhost:"+resource.GetPort("61613/tcp"))
+		if err != nil {
+			return err
+		}
+		return conn.Disconnect()
+	}))
+
+	template := `
+output:
+  stomp:
+    address: localhost:$PORT
+    destination: /queue/queue-$ID
+    metadata:
+      include_patterns: [ ".*" ]
+    receipts: true
+    max_in_flight: $MAX_IN_FLIGHT
+
+input:
+  stomp:
+    address: localhost:$PORT
+    destination: /queue/queue-$ID
+    ack_mode: $VAR1
+`
+	suite := integration.StreamTests(
+		integration.StreamTestOpenClose(),
+		integration.StreamTestMetadata(),
+		integration.StreamTestSendBatch(10),
+		integration.StreamTestStreamSequential(100),
+		integration.StreamTestStreamParallel(100),
+		integration.StreamTestAtLeastOnceDelivery(),
+	)
+	suite.Run(
+		t, template,
+		integration.StreamTestOptPort(resource.GetPort("61613/tcp")),
+		integration.StreamTestOptVarOne("client-individual"),
+	)
+	t.Run("with client acks", func(t *testing.T) {
+		t.Parallel()
+		suite.Run(
+			t, template,
+			integration.StreamTestOptPort(resource.GetPort("61613/tcp")),
+			integration.StreamTestOptVarOne("client"),
+		)
+	})
+	t.Run("with max in flight", func(t *testing.T) {
+		t.Parallel()
+		suite.Run(
+			t, template,
+			integration.StreamTestOptPort(resource.GetPort("61613/tcp")),
+			integration.StreamTestOptVarOne("client-individual"),
+			integration.StreamTestOptMaxInFlight(10),
+		)
+	})
+}
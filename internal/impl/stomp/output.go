@@ -0,0 +1,192 @@
+package stomp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-stomp/stomp/v3"
+	"github.com/go-stomp/stomp/v3/frame"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// Headers that are either set explicitly when sending messages or are
+// reserved by the server, and are therefore never set from metadata.
+var reservedHeaders = map[string]struct{}{
+	frame.ContentLength: {},
+	frame.ContentType:   {},
+	frame.Destination:   {},
+	frame.Receipt:       {},
+	frame.Transaction:   {},
+	frame.MessageId:     {},
+	frame.Subscription:  {},
+	frame.Ack:           {},
+}
+
+func stompOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Version("4.14.0").
+		Summary("Sends messages to a destination of a STOMP server.").
+		Description(`
+Metadata values can be added to messages as headers by specifying them with the ` + "`metadata`" + ` field, headers that are managed by the protocol such as ` + "`destination` and `content-length`" + ` are never set from metadata.
+
+When ` + "`receipts`" + ` are enabled each message is only considered delivered once the server has confirmed that it has been received.`).
+		Fields(connectionFields()...).
+		Field(service.NewInterpolatedStringField("destination").
+			Description("The destination to send messages to.").
+			Example("/queue/benthos").
+			Example(`/topic/${! meta("topic") }`)).
+		Field(service.NewInterpolatedStringField("content_type").
+			Description("The content type of messages, when empty the header is omitted.").
+			Default("").
+			Example("application/json")).
+		Field(service.NewMetadataFilterField("metadata").
+			Description("Determine which (if any) metadata values should be added to messages as headers.").
+			Optional()).
+		Field(service.NewBoolField("receipts").
+			Description("Whether to request a receipt from the server for each message sent, and wait for it before acknowledging the message.").
+			Default(false).
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
+			Default(64))
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"stomp", stompOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt("max_in_flight")
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newStompWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type stompWriter struct {
+	details     *stompConnDetails
+	destination *service.InterpolatedString
+	contentType *service.InterpolatedString
+	metaFilter  *service.MetadataFilter
+	receipts    bool
+
+	connMut sync.Mutex
+	conn    *stomp.Conn
+
+	log *service.Logger
+}
+
+func newStompWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*stompWriter, error) {
+	w := &stompWriter{log: log}
+
+	var err error
+	if w.details, err = connDetailsFromParsed(conf, log); err != nil {
+		return nil, err
+	}
+	if w.destination, err = conf.FieldInterpolatedString("destination"); err != nil {
+		return nil, err
+	}
+	if w.contentType, err = conf.FieldInterpolatedString("content_type"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("metadata") {
+		if w.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
+			return nil, err
+		}
+	}
+	if w.receipts, err = conf.FieldBool("receipts"); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *stompWriter) Connect(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.conn != nil {
+		return nil
+	}
+
+	conn, err := w.details.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.conn = conn
+	w.log.Infof("Sending STOMP messages to address: %v", w.details.address)
+	return nil
+}
+
+func (w *stompWriter) Write(ctx context.Context, msg *service.Message) error {
+	w.connMut.Lock()
+	conn := w.conn
+	w.connMut.Unlock()
+
+	if conn == nil {
+		return service.ErrNotConnected
+	}
+
+	destination, err := w.destination.TryString(msg)
+	if err != nil {
+		return err
+	}
+	contentType, err := w.contentType.TryString(msg)
+	if err != nil {
+		return err
+	}
+	msgBytes, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	var opts []func(*frame.Frame) error
+	_ = w.metaFilter.Walk(msg, func(key, value string) error {
+		if _, exists := reservedHeaders[key]; !exists {
+			opts = append(opts, stomp.SendOpt.Header(key, value))
+		}
+		return nil
+	})
+	if w.receipts {
+		opts = append(opts, stomp.SendOpt.Receipt)
+	}
+
+	if err = conn.Send(destination, contentType, msgBytes, opts...); err != nil {
+		if errors.Is(err, stomp.ErrClosedUnexpectedly) || errors.Is(err, stomp.ErrAlreadyClosed) {
+			w.disconnect(conn)
+			return service.ErrNotConnected
+		}
+	}
+	return err
+}
+
+func (w *stompWriter) disconnect(conn *stomp.Conn) {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.conn != conn {
+		return
+	}
+	if err := w.conn.Disconnect(); err != nil {
+		w.log.Debugf("Failed to disconnect cleanly: %v", err)
+	}
+	w.conn = nil
+}
+
+func (w *stompWriter) Close(context.Context) error {
+	w.connMut.Lock()
+	conn := w.conn
+	w.connMut.Unlock()
+
+	if conn != nil {
+		w.disconnect(conn)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package stomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-stomp/stomp/v3"
+	"github.com/go-stomp/stomp/v3/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	go func() {
+		_ = server.Serve(l)
+	}()
+	return l.Addr().String()
+}
+
+func testReader(t *testing.T, addr string) *stompReader {
+	t.Helper()
+
+	conf, err := stompInputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+destination: /queue/foo
+ack_mode: auto
+`, addr), nil)
+	require.NoError(t, err)
+
+	r, err := newStompReaderFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, r.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = r.Close(context.Background())
+	})
+	return r
+}
+
+func testWriter(t *testing.T, addr string) *stompWriter {
+	t.Helper()
+
+	conf, err := stompOutputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+destination: /queue/foo
+content_type: text/plain
+metadata:
+  include_prefixes: [ "x-" ]
+receipts: true
+`, addr), nil)
+	require.NoError(t, err)
+
+	w, err := newStompWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+	return w
+}
+
+func writeMessages(t *testing.T, w *stompWriter, contents ...string) {
+	t.Helper()
+
+	for _, c := range contents {
+		msg := service.NewMessage([]byte(c))
+		msg.MetaSetMut("x-foo", "bar")
+		msg.MetaSetMut("destination", "/queue/nope")
+		msg.MetaSetMut("baz", "buz")
+		require.NoError(t, w.Write(context.Background(), msg))
+	}
+}
+
+func readMessage(t *testing.T, r *stompReader) (*service.Message, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := r.Read(ctx)
+	require.NoError(t, err)
+	return msg, ackFn
+}
+
+func assertMessage(t *testing.T, msg *service.Message, content string) {
+	t.Helper()
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(b))
+
+	v, _ := msg.MetaGet("x-foo")
+	assert.Equal(t, "bar", v)
+
+	v, _ = msg.MetaGet("destination")
+	assert.Equal(t, "/queue/foo", v)
+
+	v, _ = msg.MetaGet("content-type")
+	assert.Equal(t, "text/plain", v)
+
+	_, exists := msg.MetaGet("baz")
+	assert.False(t, exists)
+}
+
+// The embedded server does not support acknowledgements with STOMP 1.2, and
+// therefore client acknowledgement modes are covered by integration tests.
+func TestStompAuto(t *testing.T) {
+	addr := startServer(t)
+
+	w := testWriter(t, addr)
+	writeMessages(t, w, "hello", "world")
+
+	r := testReader(t, addr)
+
+	msg, ackFn := readMessage(t, r)
+	assertMessage(t, msg, "hello")
+	require.NoError(t, ackFn(context.Background(), errors.New("nope")))
+
+	msg, _ = readMessage(t, r)
+	assertMessage(t, msg, "world")
+}
+
+func TestCumulativeAcks(t *testing.T) {
+	var acked, nacked []string
+	c := &cumulativeAcks{
+		ack: func(m *stomp.Message) error {
+			acked = append(acked, string(m.Body))
+			return nil
+		},
+		nack: func(m *stomp.Message) error {
+			nacked = append(nacked, string(m.Body))
+			return nil
+		},
+	}
+
+	var pending []*pendingAck
+	for _, b := range []string{"a", "b", "c", "d", "e"} {
+		pending = append(pending, c.add(&stomp.Message{Body: []byte(b)}))
+	}
+
+	require.NoError(t, c.resolve(pending[3], errors.New("nope")))
+	require.NoError(t, c.resolve(pending[1], nil))
+	require.NoError(t, c.resolve(pending[2], nil))
+	assert.Empty(t, acked)
+	assert.Empty(t, nacked)
+	assert.Len(t, c.pending, 5)
+
+	require.NoError(t, c.resolve(pending[0], nil))
+	assert.Equal(t, []string{"c"}, acked)
+	assert.Equal(t, []string{"d"}, nacked)
+	assert.Len(t, c.pending, 1)
+
+	require.NoError(t, c.resolve(pending[4], nil))
+	assert.Equal(t, []string{"c", "e"}, acked)
+	assert.Equal(t, []string{"d"}, nacked)
+	assert.Empty(t, c.pending)
+}
+
+func TestConnectContextDeadline(t *testing.T) {
+	// A server that accepts connections but never completes the handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+		}
+	}()
+
+	conf, err := stompOutputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+destination: /queue/foo
+`, l.Addr().String()), nil)
+	require.NoError(t, err)
+
+	w, err := newStompWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer done()
+
+	start := time.Now()
+	require.Error(t, w.Connect(ctx))
+	assert.Less(t, time.Since(start), time.Second*5)
+}
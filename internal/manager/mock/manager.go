@@ -31,6 +31,7 @@ type Manager struct {
 	Outputs    map[string]OutputWriter
 	Processors map[string]Processor
 	Pipes      map[string]<-chan message.Transaction
+	Generics   sync.Map
 	lock       sync.Mutex
 
 	// OnRegisterEndpoint can be set in order to intercept endpoints registered
@@ -350,3 +351,14 @@ func (m *Manager) SetPipe(name string, t <-chan message.Transaction) {
 func (m *Manager) UnsetPipe(name string, t <-chan message.Transaction) {
 	delete(m.Pipes, name)
 }
+
+// GetGeneric attempts to obtain and return a generic value stored under a key.
+func (m *Manager) GetGeneric(key any) (any, bool) {
+	return m.Generics.Load(key)
+}
+
+// GetOrSetGeneric returns the existing generic value stored under a key if
+// present, otherwise the provided value is stored and returned.
+func (m *Manager) GetOrSetGeneric(key, value any) (any, bool) {
+	return m.Generics.LoadOrStore(key, value)
+}
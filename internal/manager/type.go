@@ -87,6 +87,9 @@ type Type struct {
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
+
+	// Arbitrary values shared between the components of a stream.
+	genericValues *sync.Map
 }
 
 // OptFunc is an opt setting for a manager type.
@@ -192,6 +195,8 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 
 		pipes:    map[string]<-chan message.Transaction{},
 		pipeLock: &sync.RWMutex{},
+
+		genericValues: &sync.Map{},
 	}
 
 	for _, opt := range opts {
@@ -293,6 +298,7 @@ func (t *Type) ForStream(id string) bundle.NewManagement {
 func (t *Type) forStream(id string) *Type {
 	newT := *t
 	newT.stream = id
+	newT.genericValues = &sync.Map{}
 	newT.logger = t.logger.WithFields(map[string]string{
 		"stream": id,
 	})
@@ -396,6 +402,18 @@ func (t *Type) UnsetPipe(name string, tran <-chan message.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// GetGeneric attempts to obtain and return a generic value stored under a key.
+func (t *Type) GetGeneric(key any) (any, bool) {
+	return t.genericValues.Load(key)
+}
+
+// GetOrSetGeneric returns the existing generic value stored under a key if
+// present, otherwise the provided value is stored and returned. The loaded
+// result is true if the value was loaded, and false if it was stored.
+func (t *Type) GetOrSetGeneric(key, value any) (any, bool) {
+	return t.genericValues.LoadOrStore(key, value)
+}
+
 //------------------------------------------------------------------------------
 
 // WithMetricsMapping returns a manager with the stored metrics exporter wrapped
@@ -536,3 +536,33 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerGenericValues(t *testing.T) {
+	type testKey struct{}
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	_, exists := mgr.GetGeneric(testKey{})
+	assert.False(t, exists)
+
+	v, loaded := mgr.GetOrSetGeneric(testKey{}, "foo")
+	assert.False(t, loaded)
+	assert.Equal(t, "foo", v)
+
+	v, loaded = mgr.IntoPath("input").GetOrSetGeneric(testKey{}, "bar")
+	assert.True(t, loaded)
+	assert.Equal(t, "foo", v)
+
+	// Each stream has its own values.
+	streamMgr := mgr.ForStream("baz")
+	_, exists = streamMgr.GetGeneric(testKey{})
+	assert.False(t, exists)
+
+	v, loaded = streamMgr.GetOrSetGeneric(testKey{}, "bar")
+	assert.False(t, loaded)
+	assert.Equal(t, "bar", v)
+
+	v, _ = mgr.GetGeneric(testKey{})
+	assert.Equal(t, "foo", v)
+}
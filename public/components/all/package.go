@@ -12,6 +12,7 @@ import (
 	_ "github.com/benthosdev/benthos/v4/public/components/azure"
 	_ "github.com/benthosdev/benthos/v4/public/components/beanstalkd"
 	_ "github.com/benthosdev/benthos/v4/public/components/cassandra"
+	_ "github.com/benthosdev/benthos/v4/public/components/clickhouse"
 	_ "github.com/benthosdev/benthos/v4/public/components/confluent"
 	_ "github.com/benthosdev/benthos/v4/public/components/couchbase"
 	_ "github.com/benthosdev/benthos/v4/public/components/crypto"
@@ -30,7 +31,9 @@ import (
 	_ "github.com/benthosdev/benthos/v4/public/components/nanomsg"
 	_ "github.com/benthosdev/benthos/v4/public/components/nats"
 	_ "github.com/benthosdev/benthos/v4/public/components/nsq"
+	_ "github.com/benthosdev/benthos/v4/public/components/opensearch"
 	_ "github.com/benthosdev/benthos/v4/public/components/otlp"
+	_ "github.com/benthosdev/benthos/v4/public/components/postgresql"
 	_ "github.com/benthosdev/benthos/v4/public/components/prometheus"
 	_ "github.com/benthosdev/benthos/v4/public/components/pure"
 	_ "github.com/benthosdev/benthos/v4/public/components/pure/extended"
@@ -40,5 +43,6 @@ import (
 	_ "github.com/benthosdev/benthos/v4/public/components/snowflake"
 	_ "github.com/benthosdev/benthos/v4/public/components/sql"
 	_ "github.com/benthosdev/benthos/v4/public/components/statsd"
+	_ "github.com/benthosdev/benthos/v4/public/components/stomp"
 	_ "github.com/benthosdev/benthos/v4/public/components/wasm"
 )
@@ -457,6 +457,26 @@ func (c *ConfigSpec) LintRule(blobl string) *ConfigSpec {
 	return c
 }
 
+// MigrationRule adds a rule to the ConfigSpec for automatically rewriting
+// configs of the component into configs of an alternative component, which is
+// used by the `benthos lint --fix` subcommand. This is usually paired with
+// Deprecated in order to provide users with an easy upgrade path.
+//
+// The rule is a bloblang mapping that is provided the value of the fields
+// within the ConfigSpec as the context `this`, and should assign to `root` the
+// equivalent config of the component named by `to`. If a config cannot be
+// migrated automatically the mapping should throw an error describing why.
+//
+// Multiple rules can be added, in which case they are attempted in the order
+// that they were added and the first that does not throw an error is applied.
+func (c *ConfigSpec) MigrationRule(to, blobl string) *ConfigSpec {
+	c.component.Migrations = append(c.component.Migrations, docs.ComponentMigration{
+		To:      to,
+		Mapping: blobl,
+	})
+	return c
+}
+
 //------------------------------------------------------------------------------
 
 // ConfigView is a struct returned by a Benthos service environment when walking
@@ -233,6 +233,24 @@ func (r *Resources) HasRateLimit(name string) bool {
 	return r.mgr.ProbeRateLimit(name)
 }
 
+// GetGeneric attempts to obtain and return a generic value that was stored
+// under a key with GetOrSetGeneric.
+func (r *Resources) GetGeneric(key any) (any, bool) {
+	return r.mgr.GetGeneric(key)
+}
+
+// GetOrSetGeneric returns the existing value stored under a key if present,
+// otherwise the provided value is stored and returned. The loaded result is
+// true if the value was loaded, and false if it was stored.
+//
+// Values are shared by the components of a stream, including its resources,
+// which allows plugins to coordinate with one another without resorting to
+// process-wide state. Keys should be of an unexported type in order to avoid
+// collisions, similar to context keys.
+func (r *Resources) GetOrSetGeneric(key, value any) (any, bool) {
+	return r.mgr.GetOrSetGeneric(key, value)
+}
+
 //------------------------------------------------------------------------------
 
 type resourcesUnwrapper struct {